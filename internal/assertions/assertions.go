@@ -0,0 +1,477 @@
+package assertions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/schemas"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// References are the stored documents some assertions check against, loaded by the caller:
+// the collection's JSON Schemas for json_schema and the request examples, by id, for example assertions.
+type References struct {
+	Schemas  []models.CollectionSchema
+	Examples map[int]models.RequestExample
+}
+
+// EvaluateAll checks every enabled assertion against an execution result, in order.
+func EvaluateAll(assertions []models.RequestAssertion, result *models.ExecutionResult, refs References) []models.TestOutcome {
+	outcomes := []models.TestOutcome{}
+
+	for _, a := range assertions {
+		if !a.Enabled {
+			continue
+		}
+
+		switch a.Type {
+		case models.AssertionTypeJSONSchema:
+			outcomes = append(outcomes, evaluateSchema(a, result, refs.Schemas)...)
+		case models.AssertionTypeExample:
+			outcomes = append(outcomes, evaluateExample(a, result, refs.Examples))
+		default:
+			outcomes = append(outcomes, Evaluate(a, result))
+		}
+	}
+
+	return outcomes
+}
+
+// NeedsSchemas reports whether any enabled assertion validates against a JSON Schema.
+func NeedsSchemas(assertions []models.RequestAssertion) bool {
+	for _, a := range assertions {
+		if a.Enabled && a.Type == models.AssertionTypeJSONSchema {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateSchema validates the response body and reports every violation as its own outcome,
+// named after the JSON pointer of the offending value.
+func evaluateSchema(a models.RequestAssertion, result *models.ExecutionResult, collection []models.CollectionSchema) []models.TestOutcome {
+	name := Describe(a)
+
+	if result.Error != "" && result.StatusCode == 0 {
+		return []models.TestOutcome{{Name: name, Source: models.TestSourceAssertion, Message: "no response: " + result.Error}}
+	}
+
+	if a.Operator != models.AssertionOperatorValid {
+		return []models.TestOutcome{{Name: name, Source: models.TestSourceAssertion, Message: unsupportedOperator(a).Error()}}
+	}
+
+	violations, err := schemas.Validate(result.ResponseBody, a.Expected, a.Target, collection)
+	if err != nil {
+		return []models.TestOutcome{{Name: name, Source: models.TestSourceAssertion, Message: err.Error()}}
+	}
+
+	if len(violations) == 0 {
+		return []models.TestOutcome{{Name: name, Source: models.TestSourceAssertion, Passed: true}}
+	}
+
+	outcomes := make([]models.TestOutcome, 0, len(violations))
+	for _, v := range violations {
+		pointer := v.Pointer
+		if pointer == "" {
+			pointer = "/"
+		}
+
+		outcomes = append(outcomes, models.TestOutcome{
+			Name:    name + " at " + pointer,
+			Source:  models.TestSourceAssertion,
+			Message: v.Message,
+		})
+	}
+
+	return outcomes
+}
+
+// Evaluate checks a single assertion. Responses that never arrived fail every assertion.
+func Evaluate(a models.RequestAssertion, result *models.ExecutionResult) models.TestOutcome {
+	outcome := models.TestOutcome{Name: Describe(a), Source: models.TestSourceAssertion}
+
+	if result.Error != "" && result.StatusCode == 0 {
+		outcome.Message = "no response: " + result.Error
+		return outcome
+	}
+
+	var err error
+	switch a.Type {
+	case models.AssertionTypeStatus:
+		err = checkStatus(a, result.StatusCode)
+	case models.AssertionTypeHeader:
+		err = checkHeader(a, http.Header(result.ResponseHeaders))
+	case models.AssertionTypeJSONPath:
+		err = checkJSONPath(a, result.ResponseBody)
+	case models.AssertionTypeXPath:
+		err = checkXPath(a, result.ResponseBody)
+	case models.AssertionTypeBody:
+		err = checkText(a, result.ResponseBody, true)
+	case models.AssertionTypeResponseTime:
+		err = checkNumber(a, float64(result.ResponseTime), "ms")
+	case models.AssertionTypeBodySize:
+		err = checkNumber(a, float64(result.DataVolume), "bytes")
+	default:
+		err = fmt.Errorf("unknown assertion type %q", a.Type)
+	}
+
+	if err != nil {
+		outcome.Message = err.Error()
+		return outcome
+	}
+
+	outcome.Passed = true
+	return outcome
+}
+
+// Describe returns the assertion's name, or a readable description when it has none.
+func Describe(a models.RequestAssertion) string {
+	if a.Name != "" {
+		return a.Name
+	}
+
+	if a.Type == models.AssertionTypeExample {
+		return "response equals example " + a.Target
+	}
+
+	parts := []string{strings.ReplaceAll(a.Type, "_", " ")}
+	if a.Target != "" {
+		parts = append(parts, a.Target)
+	}
+	parts = append(parts, strings.ReplaceAll(a.Operator, "_", " "))
+	if a.Expected != "" && a.Type != models.AssertionTypeJSONSchema {
+		parts = append(parts, a.Expected)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func checkStatus(a models.RequestAssertion, status int) error {
+	switch a.Operator {
+	case models.AssertionOperatorEquals:
+		expected, err := strconv.Atoi(strings.TrimSpace(a.Expected))
+		if err != nil {
+			return fmt.Errorf("expected status %q is not a number", a.Expected)
+		}
+		if status != expected {
+			return fmt.Errorf("expected status %d, got %d", expected, status)
+		}
+	case models.AssertionOperatorIn:
+		for _, part := range strings.Split(a.Expected, ",") {
+			if strings.TrimSpace(part) == strconv.Itoa(status) {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected status in [%s], got %d", a.Expected, status)
+	case models.AssertionOperatorInRange:
+		low, high, err := parseRange(a.Expected)
+		if err != nil {
+			return err
+		}
+		if float64(status) < low || float64(status) > high {
+			return fmt.Errorf("expected status in range %s, got %d", a.Expected, status)
+		}
+	default:
+		return unsupportedOperator(a)
+	}
+
+	return nil
+}
+
+func checkHeader(a models.RequestAssertion, headers http.Header) error {
+	values, ok := headers[http.CanonicalHeaderKey(a.Target)]
+	if !ok {
+		// Response headers are canonicalized by net/http, but results loaded from elsewhere may not be.
+		for key, v := range headers {
+			if strings.EqualFold(key, a.Target) {
+				values, ok = v, true
+				break
+			}
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf("header %q is missing", a.Target)
+	}
+
+	if a.Operator == models.AssertionOperatorExists {
+		return nil
+	}
+
+	return checkText(a, strings.Join(values, ", "), false)
+}
+
+func checkJSONPath(a models.RequestAssertion, body string) error {
+	var doc any
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("response is not valid JSON: %v", err)
+	}
+
+	values, err := EvaluateJSONPath(doc, a.Target)
+	if err != nil {
+		return err
+	}
+
+	if len(values) == 0 {
+		return fmt.Errorf("%s matched nothing", a.Target)
+	}
+
+	value := values[0]
+
+	switch a.Operator {
+	case models.AssertionOperatorExists:
+		return nil
+	case models.AssertionOperatorEquals:
+		if !jsonEquals(value, a.Expected) {
+			return fmt.Errorf("expected %s, got %s", a.Expected, renderJSON(value))
+		}
+	case models.AssertionOperatorContains:
+		if !jsonContains(value, a.Expected) {
+			return fmt.Errorf("%s does not contain %s", renderJSON(value), a.Expected)
+		}
+	case models.AssertionOperatorType:
+		if got := JSONType(value); got != strings.TrimSpace(a.Expected) {
+			return fmt.Errorf("expected type %s, got %s", a.Expected, got)
+		}
+	case models.AssertionOperatorMatches:
+		return checkText(a, textOf(value), false)
+	default:
+		return unsupportedOperator(a)
+	}
+
+	return nil
+}
+
+func checkXPath(a models.RequestAssertion, body string) error {
+	doc, err := xmlquery.Parse(strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("response is not valid XML: %v", err)
+	}
+
+	expr, err := xpath.Compile(a.Target)
+	if err != nil {
+		return fmt.Errorf("invalid XPath %q: %v", a.Target, err)
+	}
+
+	var value string
+	found := false
+
+	switch v := expr.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		if v.MoveNext() {
+			value, found = v.Current().Value(), true
+		}
+	case string:
+		value, found = v, true
+	case float64:
+		value, found = strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		value, found = strconv.FormatBool(v), true
+	}
+
+	if !found {
+		return fmt.Errorf("%s matched nothing", a.Target)
+	}
+
+	if a.Operator == models.AssertionOperatorExists {
+		return nil
+	}
+
+	return checkText(a, value, false)
+}
+
+// checkText applies equals, contains and matches to plain text.
+func checkText(a models.RequestAssertion, text string, truncate bool) error {
+	shown := text
+	if truncate && len(shown) > 200 {
+		shown = shown[:200] + "..."
+	}
+
+	switch a.Operator {
+	case models.AssertionOperatorEquals:
+		if text != a.Expected {
+			return fmt.Errorf("expected %q, got %q", a.Expected, shown)
+		}
+	case models.AssertionOperatorContains:
+		if !strings.Contains(text, a.Expected) {
+			return fmt.Errorf("%q does not contain %q", shown, a.Expected)
+		}
+	case models.AssertionOperatorMatches:
+		re, err := regexp.Compile(a.Expected)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %v", a.Expected, err)
+		}
+		if !re.MatchString(text) {
+			return fmt.Errorf("%q does not match %s", shown, a.Expected)
+		}
+	default:
+		return unsupportedOperator(a)
+	}
+
+	return nil
+}
+
+func checkNumber(a models.RequestAssertion, got float64, unit string) error {
+	switch a.Operator {
+	case models.AssertionOperatorBelow, models.AssertionOperatorAbove:
+		limit, err := strconv.ParseFloat(strings.TrimSpace(a.Expected), 64)
+		if err != nil {
+			return fmt.Errorf("expected value %q is not a number", a.Expected)
+		}
+		if a.Operator == models.AssertionOperatorBelow && got >= limit {
+			return fmt.Errorf("expected below %g %s, got %g %s", limit, unit, got, unit)
+		}
+		if a.Operator == models.AssertionOperatorAbove && got <= limit {
+			return fmt.Errorf("expected above %g %s, got %g %s", limit, unit, got, unit)
+		}
+	case models.AssertionOperatorInRange:
+		low, high, err := parseRange(a.Expected)
+		if err != nil {
+			return err
+		}
+		if got < low || got > high {
+			return fmt.Errorf("expected %s %s, got %g %s", a.Expected, unit, got, unit)
+		}
+	default:
+		return unsupportedOperator(a)
+	}
+
+	return nil
+}
+
+// parseRange parses an inclusive "low-high" range such as "200-299".
+func parseRange(s string) (float64, float64, error) {
+	low, high, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("range %q must look like low-high", s)
+	}
+
+	l, err := strconv.ParseFloat(strings.TrimSpace(low), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("range %q must look like low-high", s)
+	}
+
+	h, err := strconv.ParseFloat(strings.TrimSpace(high), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("range %q must look like low-high", s)
+	}
+
+	return l, h, nil
+}
+
+// JSONType names the JSON type of a decoded value: string, number, boolean, object, array or null.
+func JSONType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number, float64, int:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+// jsonEquals compares a decoded value with the expected text, read as JSON when possible.
+// Bare words are compared against strings, so `ok` and `"ok"` both match the string ok.
+func jsonEquals(value any, expected string) bool {
+	if s, ok := value.(string); ok && s == expected {
+		return true
+	}
+
+	want, ok := decodeJSON(expected)
+	if !ok {
+		return false
+	}
+
+	return reflect.DeepEqual(normalize(value), normalize(want))
+}
+
+// jsonContains checks substrings of strings, elements of arrays and keys of objects.
+func jsonContains(value any, expected string) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.Contains(v, expected)
+	case []any:
+		for _, item := range v {
+			if jsonEquals(item, expected) {
+				return true
+			}
+		}
+	case map[string]any:
+		_, ok := v[expected]
+		return ok
+	}
+
+	return false
+}
+
+func decodeJSON(s string) (any, bool) {
+	var v any
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// normalize turns json.Number into float64 so 1 and 1.0 compare equal.
+func normalize(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for key, value := range t {
+			out[key] = normalize(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, value := range t {
+			out[i] = normalize(value)
+		}
+		return out
+	}
+	return v
+}
+
+func textOf(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return renderJSON(v)
+}
+
+func renderJSON(v any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func unsupportedOperator(a models.RequestAssertion) error {
+	return fmt.Errorf("operator %q is not supported for %s assertions", a.Operator, a.Type)
+}
@@ -0,0 +1,376 @@
+package assertions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// VOLATILE_HEADERS change between otherwise identical responses and are never compared with an example.
+var VOLATILE_HEADERS = []string{"Date", "Age", "Expires", "Last-Modified", "Etag", "Set-Cookie", "Content-Length"}
+
+const MASK string = "<ignored>"
+
+// ExampleIDs returns the ids of the request examples referenced by enabled example assertions.
+func ExampleIDs(assertions []models.RequestAssertion) []int {
+	ids := []int{}
+	for _, a := range assertions {
+		if !a.Enabled || a.Type != models.AssertionTypeExample {
+			continue
+		}
+		if id, err := strconv.Atoi(strings.TrimSpace(a.Target)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// evaluateExample compares the response with a saved example and attaches the differences to the outcome.
+func evaluateExample(a models.RequestAssertion, result *models.ExecutionResult, examples map[int]models.RequestExample) models.TestOutcome {
+	outcome := models.TestOutcome{Name: Describe(a), Source: models.TestSourceAssertion}
+
+	if result.Error != "" && result.StatusCode == 0 {
+		outcome.Message = "no response: " + result.Error
+		return outcome
+	}
+
+	if a.Operator != models.AssertionOperatorEquals {
+		outcome.Message = unsupportedOperator(a).Error()
+		return outcome
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(a.Target))
+	if err != nil {
+		outcome.Message = fmt.Sprintf("example id %q is not a number", a.Target)
+		return outcome
+	}
+
+	example, ok := examples[id]
+	if !ok {
+		outcome.Message = fmt.Sprintf("example %d not found", id)
+		return outcome
+	}
+
+	var rules models.ExampleIgnoreRules
+	if strings.TrimSpace(a.Expected) != "" {
+		if err := json.Unmarshal([]byte(a.Expected), &rules); err != nil {
+			outcome.Message = fmt.Sprintf("invalid ignore rules: %v", err)
+			return outcome
+		}
+	}
+
+	diff, err := CompareWithExample(example, result, rules)
+	if err != nil {
+		outcome.Message = err.Error()
+		return outcome
+	}
+
+	if len(diff) > 0 {
+		outcome.Message = fmt.Sprintf("%d difference(s) from example %d", len(diff), id)
+		outcome.Diff = diff
+		return outcome
+	}
+
+	outcome.Passed = true
+	return outcome
+}
+
+// CompareWithExample diffs the status, headers and body of a response against an example.
+// JSON bodies are compared structurally, anything else line by line.
+func CompareWithExample(example models.RequestExample, result *models.ExecutionResult, rules models.ExampleIgnoreRules) ([]models.DiffEntry, error) {
+	c, err := newComparison(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if example.StatusCode != result.StatusCode {
+		c.add("status", models.DiffKindChanged, strconv.Itoa(example.StatusCode), strconv.Itoa(result.StatusCode))
+	}
+
+	// Examples saved without headers only have their status and body compared.
+	if strings.TrimSpace(example.ResponseHeaders) != "" {
+		expected, err := parseHeaders(example.ResponseHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("example headers are not valid JSON: %v", err)
+		}
+		c.headers(expected, http.Header(result.ResponseHeaders))
+	}
+
+	expected, expectedJSON := decodeJSON(example.Response)
+	actual, actualJSON := decodeJSON(result.ResponseBody)
+	if expectedJSON && actualJSON {
+		c.json(nil, expected, actual)
+	} else {
+		c.text(example.Response, result.ResponseBody)
+	}
+
+	return c.diff, nil
+}
+
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+type comparison struct {
+	paths          [][]jsonPathStep
+	ignoredHeaders map[string]bool // canonicalized
+	patterns       []*regexp.Regexp
+	diff           []models.DiffEntry
+}
+
+func newComparison(rules models.ExampleIgnoreRules) (*comparison, error) {
+	c := &comparison{ignoredHeaders: map[string]bool{}, diff: []models.DiffEntry{}}
+
+	for _, path := range rules.Paths {
+		steps, err := compileJSONPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignored path %q: %v", path, err)
+		}
+		c.paths = append(c.paths, steps)
+	}
+
+	for _, name := range append(append([]string{}, VOLATILE_HEADERS...), rules.Headers...) {
+		c.ignoredHeaders[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+
+	for _, pattern := range rules.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignored pattern %q: %v", pattern, err)
+		}
+		c.patterns = append(c.patterns, re)
+	}
+
+	return c, nil
+}
+
+func (c *comparison) add(path, kind, expected, actual string) {
+	c.diff = append(c.diff, models.DiffEntry{Path: path, Kind: kind, Expected: expected, Actual: actual})
+}
+
+// mask replaces every ignored pattern so timestamps, ids and the like compare equal.
+func (c *comparison) mask(s string) string {
+	for _, re := range c.patterns {
+		s = re.ReplaceAllString(s, MASK)
+	}
+	return s
+}
+
+func (c *comparison) headers(expected, actual http.Header) {
+	names := map[string]bool{}
+	for name := range expected {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+	for name := range actual {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		if !c.ignoredHeaders[name] {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		want, wantOK := expected[name]
+		got, gotOK := actual[name]
+		path := "headers." + name
+
+		switch {
+		case !gotOK:
+			c.add(path, models.DiffKindRemoved, strings.Join(want, ", "), "")
+		case !wantOK:
+			c.add(path, models.DiffKindAdded, "", strings.Join(got, ", "))
+		case c.mask(strings.Join(want, ", ")) != c.mask(strings.Join(got, ", ")):
+			c.add(path, models.DiffKindChanged, strings.Join(want, ", "), strings.Join(got, ", "))
+		}
+	}
+}
+
+func (c *comparison) json(path []pathSegment, expected, actual any) {
+	if c.ignored(path) {
+		return
+	}
+
+	location := renderPath(path)
+
+	if JSONType(expected) != JSONType(actual) {
+		c.add(location, models.DiffKindChanged, renderJSON(expected), renderJSON(actual))
+		return
+	}
+
+	switch want := expected.(type) {
+	case map[string]any:
+		got := actual.(map[string]any)
+
+		keys := make([]string, 0, len(want)+len(got))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		for key := range got {
+			if _, ok := want[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := append(append([]pathSegment{}, path...), pathSegment{key: key})
+			w, wantOK := want[key]
+			g, gotOK := got[key]
+
+			switch {
+			case c.ignored(child):
+			case !gotOK:
+				c.add(renderPath(child), models.DiffKindRemoved, renderJSON(w), "")
+			case !wantOK:
+				c.add(renderPath(child), models.DiffKindAdded, "", renderJSON(g))
+			default:
+				c.json(child, w, g)
+			}
+		}
+	case []any:
+		got := actual.([]any)
+
+		for i := 0; i < len(want) || i < len(got); i++ {
+			child := append(append([]pathSegment{}, path...), pathSegment{index: i, isIndex: true})
+
+			switch {
+			case c.ignored(child):
+			case i >= len(got):
+				c.add(renderPath(child), models.DiffKindRemoved, renderJSON(want[i]), "")
+			case i >= len(want):
+				c.add(renderPath(child), models.DiffKindAdded, "", renderJSON(got[i]))
+			default:
+				c.json(child, want[i], got[i])
+			}
+		}
+	case string:
+		if c.mask(want) != c.mask(actual.(string)) {
+			c.add(location, models.DiffKindChanged, renderJSON(want), renderJSON(actual))
+		}
+	default:
+		if !reflect.DeepEqual(normalize(expected), normalize(actual)) {
+			c.add(location, models.DiffKindChanged, renderJSON(expected), renderJSON(actual))
+		}
+	}
+}
+
+// text reports the first differing line of two non-JSON bodies.
+func (c *comparison) text(expected, actual string) {
+	if c.mask(expected) == c.mask(actual) {
+		return
+	}
+
+	want := strings.Split(expected, "\n")
+	got := strings.Split(actual, "\n")
+
+	for i := 0; i < len(want) || i < len(got); i++ {
+		path := fmt.Sprintf("body line %d", i+1)
+
+		switch {
+		case i >= len(got):
+			c.add(path, models.DiffKindRemoved, want[i], "")
+			return
+		case i >= len(want):
+			c.add(path, models.DiffKindAdded, "", got[i])
+			return
+		case c.mask(want[i]) != c.mask(got[i]):
+			c.add(path, models.DiffKindChanged, want[i], got[i])
+			return
+		}
+	}
+}
+
+// ignored reports whether a location is at or below one of the ignored paths.
+func (c *comparison) ignored(path []pathSegment) bool {
+	for _, steps := range c.paths {
+		if matchPath(steps, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPath(steps []jsonPathStep, path []pathSegment) bool {
+	if len(steps) == 0 {
+		return true
+	}
+	if len(path) == 0 {
+		return false
+	}
+
+	step := steps[0]
+	if matchSegment(step, path[0]) && matchPath(steps[1:], path[1:]) {
+		return true
+	}
+
+	// A recursive step may skip any number of levels before matching.
+	return step.recursive && matchPath(steps, path[1:])
+}
+
+func matchSegment(step jsonPathStep, segment pathSegment) bool {
+	switch {
+	case step.wildcard:
+		return true
+	case step.index != nil:
+		return segment.isIndex && *step.index == segment.index
+	default:
+		return !segment.isIndex && step.name == segment.key
+	}
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// renderPath formats a location as a JSONPath, e.g. $.items[0]['content-type'].
+func renderPath(path []pathSegment) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+
+	for _, segment := range path {
+		switch {
+		case segment.isIndex:
+			sb.WriteString("[" + strconv.Itoa(segment.index) + "]")
+		case identifier.MatchString(segment.key):
+			sb.WriteString("." + segment.key)
+		default:
+			sb.WriteString("['" + strings.ReplaceAll(segment.key, "'", "\\'") + "']")
+		}
+	}
+
+	return sb.String()
+}
+
+// parseHeaders reads headers stored as {"Name": ["value"]} or {"Name": "value"}.
+func parseHeaders(s string) (http.Header, error) {
+	multi := map[string][]string{}
+	if err := json.Unmarshal([]byte(s), &multi); err == nil {
+		headers := http.Header{}
+		for name, values := range multi {
+			headers[http.CanonicalHeaderKey(name)] = values
+		}
+		return headers, nil
+	}
+
+	single := map[string]string{}
+	if err := json.Unmarshal([]byte(s), &single); err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	for name, value := range single {
+		headers.Set(name, value)
+	}
+	return headers, nil
+}
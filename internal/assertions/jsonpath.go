@@ -0,0 +1,183 @@
+package assertions
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EvaluateJSONPath returns every value of doc selected by path.
+// Supported syntax: $, .name, ['name'], [index] (negative counts from the end), [*], .* and ..name.
+func EvaluateJSONPath(doc any, path string) ([]any, error) {
+	steps, err := compileJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := []any{doc}
+	for _, step := range steps {
+		next := []any{}
+		for _, node := range current {
+			next = append(next, step.apply(node)...)
+		}
+		current = next
+	}
+
+	return current, nil
+}
+
+// compileJSONPath parses a path into steps; "" and "$" select the root and yield no steps.
+func compileJSONPath(path string) ([]jsonPathStep, error) {
+	path = strings.TrimSpace(path)
+	if path == "" || path == "$" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(path, "$") {
+		path = "$." + path
+	}
+
+	return parseJSONPath(path[1:])
+}
+
+type jsonPathStep struct {
+	recursive bool
+	wildcard  bool
+	name      string
+	index     *int
+}
+
+func (s jsonPathStep) apply(node any) []any {
+	if !s.recursive {
+		return s.select1(node)
+	}
+
+	out := []any{}
+	var walk func(n any)
+	walk = func(n any) {
+		out = append(out, s.select1(n)...)
+		switch v := n.(type) {
+		case map[string]any:
+			for _, key := range sortedKeys(v) {
+				walk(v[key])
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(node)
+
+	return out
+}
+
+// select1 applies the step to a single node without descending further.
+func (s jsonPathStep) select1(node any) []any {
+	switch v := node.(type) {
+	case map[string]any:
+		if s.wildcard {
+			out := make([]any, 0, len(v))
+			for _, key := range sortedKeys(v) {
+				out = append(out, v[key])
+			}
+			return out
+		}
+		if s.index == nil {
+			if value, ok := v[s.name]; ok {
+				return []any{value}
+			}
+		}
+	case []any:
+		if s.wildcard {
+			return append([]any{}, v...)
+		}
+		if s.index != nil {
+			i := *s.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				return []any{v[i]}
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	steps := []jsonPathStep{}
+
+	for len(path) > 0 {
+		recursive := false
+
+		switch {
+		case strings.HasPrefix(path, ".."):
+			recursive = true
+			path = path[2:]
+		case path[0] == '.':
+			path = path[1:]
+		}
+
+		if len(path) > 0 && path[0] == '[' {
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in JSONPath")
+			}
+
+			step, err := parseBracket(path[1:end])
+			if err != nil {
+				return nil, err
+			}
+			step.recursive = recursive
+			steps = append(steps, step)
+			path = path[end+1:]
+			continue
+		}
+
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			end = len(path)
+		}
+
+		name := path[:end]
+		if name == "" {
+			return nil, fmt.Errorf("empty name in JSONPath")
+		}
+
+		steps = append(steps, jsonPathStep{recursive: recursive, wildcard: name == "*", name: name})
+		path = path[end:]
+	}
+
+	return steps, nil
+}
+
+func parseBracket(content string) (jsonPathStep, error) {
+	content = strings.TrimSpace(content)
+
+	if content == "*" {
+		return jsonPathStep{wildcard: true}, nil
+	}
+
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return jsonPathStep{name: content[1 : len(content)-1]}, nil
+	}
+
+	i, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathStep{}, fmt.Errorf("unsupported JSONPath selector [%s]", content)
+	}
+
+	return jsonPathStep{index: &i}, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	EXIT_OK      int = 0
+	EXIT_FAILURE int = 1 // a request or test failed, or the run was stopped
+	EXIT_ERROR   int = 2 // bad usage, or the database could not be read
+)
+
+const usage = `Usage: tapa <command> [arguments]
+
+Without a command TAPA starts the desktop app.
+
+Commands:
+  run <collection>    run a collection (or one of its folders) and report the results
+  list [collection]   list collections, or the folders and requests of one collection
+  env [environment]   list environments, or the variables of one environment
+  runs <collection>   list the past runs of a collection
+  compare <a> <b>     show what changed between two runs, by run id
+  load <collection>   load test a request, folder or collection and report latency and errors
+  monitor [collection] run saved monitors, or a collection every --every minutes, until interrupted
+  workflow [workflow]  list workflows, or run one and show the values passed between its requests
+  import <file>       create collections from a Postman, Insomnia or Hoppscotch export, OpenAPI/Swagger document, HAR file
+                      or .http files
+  export <collection> write a collection as Postman JSON, an .http file or an inferred OpenAPI document, an environment with --env,
+                      or a run (--run) or the request history (--history) as HAR
+  curl <collection>   print a request as a curl command, or add one from a curl command with --add
+  snippet <collection> print a request as code in another language, e.g. --lang python
+  help                show this help
+
+Collections, folders and environments are given by id or by name.
+Run "tapa <command> -h" for the options of a command.
+`
+
+type command struct {
+	name string
+	run  func(c *cli, args []string) int
+}
+
+var commands = []command{
+	{name: "run", run: (*cli).run},
+	{name: "list", run: (*cli).list},
+	{name: "env", run: (*cli).env},
+	{name: "runs", run: (*cli).runs},
+	{name: "compare", run: (*cli).compare},
+	{name: "load", run: (*cli).load},
+	{name: "monitor", run: (*cli).monitor},
+	{name: "workflow", run: (*cli).workflow},
+	{name: "import", run: (*cli).importFile},
+	{name: "export", run: (*cli).export},
+	{name: "curl", run: (*cli).curl},
+	{name: "snippet", run: (*cli).snippet},
+}
+
+// cli holds what every command shares: the schema to open the database with and the output streams.
+type cli struct {
+	schema embed.FS
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// IsCommand reports whether the first argument of the process selects the CLI instead of the desktop app.
+func IsCommand(arg string) bool {
+	switch arg {
+	case "help", "-h", "--help":
+		return true
+	}
+
+	for _, c := range commands {
+		if c.name == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes a CLI command against the app's database and returns the process exit code.
+func Run(args []string, schema embed.FS, stdout, stderr io.Writer) int {
+	c := &cli{schema: schema, stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return EXIT_ERROR
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return EXIT_OK
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+	return EXIT_ERROR
+}
+
+// globalFlags are accepted by every command.
+type globalFlags struct {
+	dbPath  string
+	verbose bool
+}
+
+func (g *globalFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.dbPath, "db", "", "path of the TAPA database (default: the desktop app's database)")
+	fs.BoolVar(&g.verbose, "verbose", false, "print database and internal logs")
+}
+
+// newFlagSet creates the flag set of a command, printing its usage line on -h.
+func (c *cli) newFlagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: tapa %s %s\n\nOptions:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse accepts flags before and after positional arguments, e.g. "tapa run API --env staging".
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// open opens the database the desktop app uses, or the one given with --db.
+func (c *cli) open(g globalFlags) (*sqlx.DB, error) {
+	if !g.verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	if g.dbPath != "" {
+		return database.InitializeDBAt(c.schema, g.dbPath)
+	}
+
+	return database.InitializeDB(c.schema)
+}
+
+func (c *cli) fail(format string, args ...any) int {
+	fmt.Fprintf(c.stderr, "tapa: "+format+"\n", args...)
+	return EXIT_ERROR
+}
+
+// match finds an item by id or, failing that, by name; exact names win over case-insensitive ones.
+func match[T any](items []T, ref string, id func(T) int, name func(T) string) (T, bool) {
+	var zero T
+
+	if n, err := strconv.Atoi(ref); err == nil {
+		for _, item := range items {
+			if id(item) == n {
+				return item, true
+			}
+		}
+	}
+
+	for _, item := range items {
+		if name(item) == ref {
+			return item, true
+		}
+	}
+
+	found, count := zero, 0
+	for _, item := range items {
+		if strings.EqualFold(name(item), ref) {
+			found = item
+			count++
+		}
+	}
+
+	return found, count == 1
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+)
+
+// curl prints a stored request as a curl command, or with --add stores the request a curl command sends.
+func (c *cli) curl(args []string) int {
+	var (
+		g      globalFlags
+		env    string
+		add    string
+		folder string
+		name   string
+	)
+
+	fs := c.newFlagSet("curl", "<collection> <request> [options] | <collection> --add <file> [options]")
+	g.register(fs)
+	fs.StringVar(&env, "env", "", "environment whose variables are resolved (default: the environment selected in the app)")
+	fs.StringVar(&add, "add", "", "add the request of the curl command in this file, - for stdin, to the collection")
+	fs.StringVar(&folder, "folder", "", "with --add, the folder to add the request to")
+	fs.StringVar(&name, "name", "", "with --add, the name of the request (default: method and path)")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if (add == "" && len(positional) != 2) || (add != "" && len(positional) != 1) {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	list, err := services.NewDashboardService(db).GetFullRequestList()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	collection, ok := findCollection(list.Collections, positional[0])
+	if !ok {
+		return c.fail("collection %q not found", positional[0])
+	}
+
+	if add != "" {
+		var command []byte
+		if add == "-" {
+			command, err = io.ReadAll(os.Stdin)
+		} else {
+			command, err = os.ReadFile(add)
+		}
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		var folderID *int
+		if folder != "" {
+			f, ok := match(collection.Folders, folder,
+				func(f models.PopulatedFolder) int { return f.Folder.ID },
+				func(f models.PopulatedFolder) string { return f.Folder.Name })
+			if !ok {
+				return c.fail("folder %q not found in %s", folder, collection.Collection.Name)
+			}
+			folderID = &f.Folder.ID
+		}
+
+		report, err := services.NewCollectionsService(db).ImportCurl(string(command), collection.Collection.ID, folderID, name)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		fmt.Fprintf(c.stdout, "Added %q to %s\n", report.Name, collection.Collection.Name)
+		for _, w := range report.Warnings {
+			fmt.Fprintf(c.stdout, "  %s: %s\n", w.Item, w.Message)
+		}
+		return EXIT_OK
+	}
+
+	plan, err := runner.Plan(list, collection.Collection.ID, nil)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	r, ok := match(plan, positional[1],
+		func(r models.RequestBasic) int { return r.ID },
+		func(r models.RequestBasic) string { return r.Name })
+	if !ok {
+		return c.fail("request %q not found in %s", positional[1], collection.Collection.Name)
+	}
+
+	var environmentID *int
+	if env != "" {
+		environments, err := repository.NewEnvironmentsRepository(db).GetEnvironments()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		e, ok := findEnvironment(environments, env)
+		if !ok {
+			return c.fail("environment %q not found", env)
+		}
+		environmentID = &e.ID
+	}
+
+	command, err := services.NewRequestsService(db).CurlCommand(r.ID, environmentID)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	fmt.Fprintln(c.stdout, command)
+	return EXIT_OK
+}
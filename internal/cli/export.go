@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/openapi"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+)
+
+// export writes a collection, or with --env an environment, to stdout or to the file given with --out.
+// With --run or --history it writes the requests of a run or the request history as a HAR file instead.
+func (c *cli) export(args []string) int {
+	var (
+		g       globalFlags
+		format  string
+		out     string
+		env     string
+		folder  string
+		redact  bool
+		run     int
+		history bool
+	)
+
+	fs := c.newFlagSet("export", "<collection> [options] | --env <environment> [options] | --run <id> | --history")
+	g.register(fs)
+	fs.StringVar(&format, "format", "postman", "format to write: postman, tapa, openapi, openapi-yaml, http (environments: postman only; runs and history: har)")
+	fs.StringVar(&out, "out", "", "file to write (default: stdout)")
+	fs.StringVar(&env, "env", "", "export this environment instead of a collection")
+	fs.StringVar(&folder, "folder", "", "export only this folder of the collection (http)")
+	fs.BoolVar(&redact, "redact-secrets", false, "leave out the values of environment variables that look like credentials")
+	fs.IntVar(&run, "run", 0, "export the requests of this collection run as HAR")
+	fs.BoolVar(&history, "history", false, "export the request history as HAR")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	modes := len(positional)
+	for _, set := range []bool{env != "", run != 0, history} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 || len(positional) > 1 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	collections := services.NewCollectionsService(db)
+
+	var data string
+	switch {
+	case run != 0 || history:
+		if format != "postman" && format != "har" {
+			return c.fail("runs and history can only be exported as har")
+		}
+
+		if run != 0 {
+			data, err = services.NewRunnerService(db).ExportRunHAR(run)
+		} else {
+			data, err = services.NewRequestsService(db).ExportHistoryHAR(nil)
+		}
+		if err != nil {
+			return c.fail("%v", err)
+		}
+	case env != "":
+		if format != "postman" {
+			return c.fail("environments can only be exported as postman")
+		}
+
+		environments, err := repository.NewEnvironmentsRepository(db).GetEnvironments()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		e, ok := findEnvironment(environments, env)
+		if !ok {
+			return c.fail("environment %q not found", env)
+		}
+
+		if data, err = collections.ExportPostmanEnvironment(e.ID, redact); err != nil {
+			return c.fail("%v", err)
+		}
+	default:
+		list, err := services.NewDashboardService(db).GetFullRequestList()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		collection, ok := findCollection(list.Collections, positional[0])
+		if !ok {
+			return c.fail("collection %q not found", positional[0])
+		}
+
+		var folderID *int
+		if folder != "" {
+			if format != "http" {
+				return c.fail("only http exports can be limited to a folder")
+			}
+
+			f, ok := match(collection.Folders, folder,
+				func(f models.PopulatedFolder) int { return f.Folder.ID },
+				func(f models.PopulatedFolder) string { return f.Folder.Name })
+			if !ok {
+				return c.fail("folder %q not found in %s", folder, collection.Collection.Name)
+			}
+			folderID = &f.Folder.ID
+		}
+
+		switch format {
+		case "postman":
+			data, err = collections.ExportPostmanCollection(collection.Collection.ID)
+		case "tapa":
+			data, err = exportJSON(collections, collection.Collection.ID)
+		case "openapi":
+			data, err = collections.GenerateOpenAPI(collection.Collection.ID, openapi.FORMAT_JSON)
+		case "openapi-yaml":
+			data, err = collections.GenerateOpenAPI(collection.Collection.ID, openapi.FORMAT_YAML)
+		case "http":
+			var export models.TextExport
+			if export, err = collections.ExportHTTPFile(collection.Collection.ID, folderID); err == nil {
+				data = export.Content
+				for _, w := range export.Warnings {
+					fmt.Fprintf(c.stderr, "warning: %s: %s\n", w.Item, w.Message)
+				}
+			}
+		default:
+			return c.fail("unknown format %q", format)
+		}
+		if err != nil {
+			return c.fail("%v", err)
+		}
+	}
+
+	if out == "" {
+		fmt.Fprintln(c.stdout, data)
+		return EXIT_OK
+	}
+
+	if err := os.WriteFile(out, []byte(data+"\n"), 0o644); err != nil {
+		return c.fail("%v", err)
+	}
+
+	fmt.Fprintf(c.stderr, "Wrote %s\n", out)
+	return EXIT_OK
+}
+
+func exportJSON(collections *services.CollectionsService, collectionID int) (string, error) {
+	export, err := collections.ExportCollection(collectionID)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(export, "", "\t")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/har"
+	"github.com/Amir-Zouerami/TAPA/internal/hoppscotch"
+	"github.com/Amir-Zouerami/TAPA/internal/httpfile"
+	"github.com/Amir-Zouerami/TAPA/internal/insomnia"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/openapi"
+	"github.com/Amir-Zouerami/TAPA/internal/postman"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+)
+
+// importer creates or, when it can merge, updates a collection from a file written by another tool.
+type importer struct {
+	format      string
+	merges      bool // whether the format can be imported into an existing collection
+	directories bool // whether a directory of files can be imported at once
+	detect      func(data []byte) bool
+	run         func(collections *services.CollectionsService, path string, opts importOptions) (models.ImportReport, error)
+}
+
+// importOptions are the import flags some formats use.
+type importOptions struct {
+	into     int  // collection to merge into, 0 for a new collection
+	examples bool // save captured responses as examples
+}
+
+// importers are tried in order when the format is not given.
+var importers = []importer{
+	{
+		format: postman.SOURCE,
+		detect: postman.Detect,
+		run: func(collections *services.CollectionsService, path string, _ importOptions) (models.ImportReport, error) {
+			return collections.ImportPostmanCollection(path)
+		},
+	},
+	{
+		format: openapi.SOURCE,
+		merges: true,
+		detect: openapi.Detect,
+		run: func(collections *services.CollectionsService, path string, opts importOptions) (models.ImportReport, error) {
+			return collections.ImportOpenAPI(path, opts.into)
+		},
+	},
+	{
+		format: har.SOURCE,
+		detect: har.Detect,
+		run: func(collections *services.CollectionsService, path string, opts importOptions) (models.ImportReport, error) {
+			return collections.ImportHAR(path, opts.examples)
+		},
+	},
+	{
+		format: insomnia.SOURCE,
+		detect: insomnia.Detect,
+		run: func(collections *services.CollectionsService, path string, _ importOptions) (models.ImportReport, error) {
+			return collections.ImportInsomnia(path)
+		},
+	},
+	{
+		format: hoppscotch.SOURCE,
+		detect: hoppscotch.Detect,
+		run: func(collections *services.CollectionsService, path string, _ importOptions) (models.ImportReport, error) {
+			return collections.ImportHoppscotch(path)
+		},
+	},
+	{
+		format:      httpfile.SOURCE,
+		directories: true,
+		detect:      httpfile.Detect,
+		run: func(collections *services.CollectionsService, path string, _ importOptions) (models.ImportReport, error) {
+			return collections.ImportHTTPFile(path)
+		},
+	},
+}
+
+// importFile creates a collection from a file written by another tool, or merges one into a collection,
+// and prints what could not be brought over.
+func (c *cli) importFile(args []string) int {
+	var (
+		g          globalFlags
+		format     string
+		into       string
+		noExamples bool
+	)
+
+	formats := make([]string, len(importers))
+	for i, imp := range importers {
+		formats[i] = imp.format
+	}
+
+	fs := c.newFlagSet("import", "<file | directory> [options]")
+	g.register(fs)
+	fs.StringVar(&format, "format", "auto", "format of the file: auto, "+strings.Join(formats, ", "))
+	fs.StringVar(&into, "into", "", "merge into this collection, keeping requests edited since the last import (openapi)")
+	fs.BoolVar(&noExamples, "no-examples", false, "do not save the captured responses as request examples (har)")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+	path := positional[0]
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	var data []byte
+	if !info.IsDir() {
+		if data, err = os.ReadFile(path); err != nil {
+			return c.fail("%v", err)
+		}
+	}
+
+	var chosen *importer
+	for i, imp := range importers {
+		if info.IsDir() && !imp.directories {
+			continue
+		}
+		if imp.format == format || (format == "auto" && (info.IsDir() || imp.detect(data))) {
+			chosen = &importers[i]
+			break
+		}
+	}
+
+	if chosen == nil {
+		if info.IsDir() {
+			return c.fail("%s is a directory, only .http and .rest files can be imported from one", path)
+		}
+		if format == "auto" {
+			return c.fail("%s: format not recognized, pass --format", path)
+		}
+		return c.fail("unknown format %q", format)
+	}
+
+	if into != "" && !chosen.merges {
+		return c.fail("%s files cannot be merged into a collection, import them without --into", chosen.format)
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	collectionID := 0
+	if into != "" {
+		list, err := services.NewDashboardService(db).GetFullRequestList()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		collection, ok := findCollection(list.Collections, into)
+		if !ok {
+			return c.fail("collection %q not found", into)
+		}
+		collectionID = collection.Collection.ID
+	}
+
+	report, err := chosen.run(services.NewCollectionsService(db), path, importOptions{into: collectionID, examples: !noExamples})
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	c.importSummary(report, collectionID != 0)
+	return EXIT_OK
+}
+
+func (c *cli) importSummary(report models.ImportReport, merged bool) {
+	out := c.stdout
+
+	if merged {
+		fmt.Fprintf(out, "Merged %s into %q (collection #%d)\n", report.Source, report.Name, report.CollectionID)
+		fmt.Fprintf(out, "  %d requests added, %d updated, %d unchanged, %d kept because they were edited, %d variables added\n",
+			report.Requests, report.Updated, report.Unchanged, report.Kept, report.Variables)
+	} else {
+		switch {
+		case len(report.CollectionIDs) > 1:
+			ids := make([]string, len(report.CollectionIDs))
+			for i, id := range report.CollectionIDs {
+				ids[i] = fmt.Sprintf("#%d", id)
+			}
+			fmt.Fprintf(out, "Imported %q from %s as collections %s\n", report.Name, report.Source, strings.Join(ids, ", "))
+		case report.CollectionID != 0:
+			fmt.Fprintf(out, "Imported %q from %s as collection #%d\n", report.Name, report.Source, report.CollectionID)
+		default:
+			// Environment exports create no collection.
+			fmt.Fprintf(out, "Imported %d environments from %s\n", report.Environments, report.Source)
+		}
+		if report.CollectionID != 0 {
+			fmt.Fprintf(out, "  %d folders, %d requests, %d examples, %d variables, %d scripts\n",
+				report.Folders, report.Requests, report.Examples, report.Variables, report.Scripts)
+			if report.Environments > 0 {
+				fmt.Fprintf(out, "  %d environments created or merged into existing ones\n", report.Environments)
+			}
+		}
+	}
+
+	if len(report.Warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "\n%d warnings:\n", len(report.Warnings))
+	for _, w := range report.Warnings {
+		item := w.Item
+		if item == "" {
+			item = "collection"
+		}
+		fmt.Fprintf(out, "  %s: %s\n", item, w.Message)
+	}
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+)
+
+// list prints the collections, or the run order of one collection.
+func (c *cli) list(args []string) int {
+	var g globalFlags
+	fs := c.newFlagSet("list", "[collection]")
+	g.register(fs)
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	list, err := services.NewDashboardService(db).GetFullRequestList()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	collections := list.Collections
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Collection.Position < collections[j].Collection.Position })
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	if len(positional) == 0 {
+		fmt.Fprintln(w, "ID\tCOLLECTION\tFOLDERS\tREQUESTS")
+		for _, col := range collections {
+			count := len(col.Requests)
+			for _, folder := range col.Folders {
+				count += len(folder.Requests)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\n", col.Collection.ID, col.Collection.Name, len(col.Folders), count)
+		}
+		return EXIT_OK
+	}
+
+	collection, ok := findCollection(collections, positional[0])
+	if !ok {
+		return c.fail("collection %q not found", positional[0])
+	}
+
+	plan, err := runner.Plan(list, collection.Collection.ID, nil)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	folders := make(map[int]string, len(collection.Folders))
+	for _, folder := range collection.Folders {
+		folders[folder.Folder.ID] = folder.Folder.Name
+	}
+
+	fmt.Fprintln(w, "ID\tMETHOD\tREQUEST\tFOLDER")
+	for _, request := range plan {
+		folder := "-"
+		if request.FolderID != nil {
+			folder = fmt.Sprintf("%s (%d)", folders[*request.FolderID], *request.FolderID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", request.ID, request.Method, request.Name, folder)
+	}
+
+	return EXIT_OK
+}
+
+// env prints the environments, or the variables of one environment.
+func (c *cli) env(args []string) int {
+	var g globalFlags
+	fs := c.newFlagSet("env", "[environment]")
+	g.register(fs)
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewEnvironmentsRepository(db)
+	environments, err := repo.GetEnvironments()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	if len(positional) == 0 {
+		selected, err := repo.GetSelectedEnvironmentID()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		fmt.Fprintln(w, "ID\tENVIRONMENT\tSELECTED")
+		for _, env := range environments {
+			mark := ""
+			if selected != nil && *selected == env.ID {
+				mark = "*"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", env.ID, env.Name, mark)
+		}
+		return EXIT_OK
+	}
+
+	env, ok := findEnvironment(environments, positional[0])
+	if !ok {
+		return c.fail("environment %q not found", positional[0])
+	}
+
+	vars, err := repo.GetEnvironmentVariables(env.ID)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Key < vars[j].Key })
+
+	fmt.Fprintln(w, "KEY\tVALUE")
+	for _, v := range vars {
+		fmt.Fprintf(w, "%s\t%s\n", v.Key, v.Value)
+	}
+
+	return EXIT_OK
+}
+
+func findCollection(collections []models.PopulatedCollection, ref string) (models.PopulatedCollection, bool) {
+	return match(collections, ref,
+		func(c models.PopulatedCollection) int { return c.Collection.ID },
+		func(c models.PopulatedCollection) string { return c.Collection.Name })
+}
+
+func findEnvironment(environments []models.Environment, ref string) (models.Environment, bool) {
+	return match(environments, ref,
+		func(e models.Environment) int { return e.ID },
+		func(e models.Environment) string { return e.Name })
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/loadtest"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+)
+
+// load drives a request, folder or collection with virtual users or at a target rate and prints the latency,
+// throughput and errors. It exits non-zero when any request failed.
+func (c *cli) load(args []string) int {
+	var (
+		g        globalFlags
+		request  string
+		folder   string
+		env      string
+		users    int
+		rate     float64
+		duration time.Duration
+		compare  int
+	)
+
+	fs := c.newFlagSet("load", "<collection> [options]")
+	g.register(fs)
+	fs.StringVar(&request, "request", "", "load test only this request of the collection")
+	fs.StringVar(&folder, "folder", "", "load test only this folder of the collection, its requests in order")
+	fs.StringVar(&env, "env", "", "environment to use (default: the environment selected in the app)")
+	fs.IntVar(&users, "users", 0, "virtual users sending back to back, or the cap on requests in flight with --rps")
+	fs.Float64Var(&rate, "rps", 0, "requests started per second, instead of back-to-back virtual users")
+	fs.DurationVar(&duration, "duration", 10*time.Second, "how long to send requests")
+	fs.IntVar(&compare, "compare", 0, "id of an earlier load test to compare with")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	if users == 0 && rate == 0 {
+		users = 1
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	list, err := services.NewDashboardService(db).GetFullRequestList()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	collection, ok := findCollection(list.Collections, positional[0])
+	if !ok {
+		return c.fail("collection %q not found", positional[0])
+	}
+
+	options := models.LoadTestOptions{
+		CollectionID:  collection.Collection.ID,
+		VirtualUsers:  users,
+		RatePerSecond: rate,
+		DurationMs:    int(duration.Milliseconds()),
+	}
+
+	switch {
+	case request != "":
+		plan, err := runner.Plan(list, collection.Collection.ID, nil)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		r, ok := match(plan, request,
+			func(r models.RequestBasic) int { return r.ID },
+			func(r models.RequestBasic) string { return r.Name })
+		if !ok {
+			return c.fail("request %q not found in %s", request, collection.Collection.Name)
+		}
+		options.RequestID = &r.ID
+	case folder != "":
+		f, ok := match(collection.Folders, folder,
+			func(f models.PopulatedFolder) int { return f.Folder.ID },
+			func(f models.PopulatedFolder) string { return f.Folder.Name })
+		if !ok {
+			return c.fail("folder %q not found in %s", folder, collection.Collection.Name)
+		}
+		options.FolderID = &f.Folder.ID
+	}
+
+	if env != "" {
+		environments, err := repository.NewEnvironmentsRepository(db).GetEnvironments()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		e, ok := findEnvironment(environments, env)
+		if !ok {
+			return c.fail("environment %q not found", env)
+		}
+		options.EnvironmentID = &e.ID
+	}
+
+	targets, name, err := loadtest.Targets(list, options)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	tests := repository.NewLoadTestsRepository(db)
+
+	var base models.LoadTestReport
+	if compare != 0 {
+		if base, err = tests.GetLoadTest(compare); err != nil {
+			return c.fail("load test %d: %v", compare, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	test, err := loadtest.NewTester(executor.NewExecutor(db), tests).Start(ctx, name, targets, options, c.loadProgress)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	mode := fmt.Sprintf("%d virtual users", options.VirtualUsers)
+	if rate > 0 {
+		mode = fmt.Sprintf("%g requests/s", rate)
+	}
+	fmt.Fprintf(c.stdout, "%s: %s for %s\n", name, mode, duration)
+
+	report := test.Wait()
+	c.loadSummary(report)
+
+	if compare != 0 {
+		c.loadComparison(loadtest.Compare(base, report))
+	}
+
+	if report.Status != models.RunStatusCompleted || report.Errors > 0 {
+		return EXIT_FAILURE
+	}
+
+	return EXIT_OK
+}
+
+func (c *cli) loadProgress(event models.LoadTestEvent) {
+	if event.Type != models.LoadTestEventProgress {
+		return
+	}
+
+	s := event.Second
+	fmt.Fprintf(c.stdout, "  %4ds  %6d req/s  %5d errors  mean %8.2f ms  p90 %8.2f ms\n",
+		s.Second+1, s.Requests, s.Errors, s.MeanLatency, s.P90Latency)
+}
+
+func (c *cli) loadSummary(report models.LoadTestReport) {
+	out := c.stdout
+
+	fmt.Fprintln(out)
+	if report.Status == models.RunStatusStopped {
+		fmt.Fprintln(out, "Load test stopped.")
+	}
+
+	fmt.Fprintf(out, "Load test: #%d\n", report.ID)
+	fmt.Fprintf(out, "Requests:  %d, %d errors", report.Requests, report.Errors)
+	if report.Dropped > 0 {
+		fmt.Fprintf(out, ", %d dropped while every virtual user was busy", report.Dropped)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Rate:      %.2f requests/s\n", report.Throughput)
+
+	l := report.Latency
+	fmt.Fprintf(out, "Latency:   min %.2f  mean %.2f  p50 %.2f  p90 %.2f  p99 %.2f  max %.2f ms\n", l.Min, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	fmt.Fprintf(out, "Data:      %s sent, %s received\n", formatBytes(report.BytesSent), formatBytes(report.BytesReceived))
+
+	for _, e := range report.ErrorBreakdown {
+		fmt.Fprintf(out, "  %6d  %-9s  %s\n", e.Count, e.Kind, oneLine(e.Error))
+	}
+
+	fmt.Fprintf(out, "Duration:  %s\n", (time.Duration(report.Duration) * time.Millisecond).String())
+}
+
+func (c *cli) loadComparison(comparison models.LoadTestComparison) {
+	out := c.stdout
+	d := comparison.LatencyDelta
+
+	fmt.Fprintf(out, "\nCompared with #%d:\n", comparison.Base.ID)
+	fmt.Fprintf(out, "Rate:      %+.2f requests/s\n", comparison.ThroughputDelta)
+	fmt.Fprintf(out, "Errors:    %.2f%% -> %.2f%%\n", comparison.BaseErrorRate*100, comparison.TargetErrorRate*100)
+	fmt.Fprintf(out, "Latency:   mean %+.2f  p50 %+.2f  p90 %+.2f  p99 %+.2f ms\n", d.Mean, d.P50, d.P90, d.P99)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/monitor"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+)
+
+// monitor runs the enabled monitors saved in the app, or the collection given on the command line,
+// on their schedule until interrupted. Runs are stored in the run history either way.
+func (c *cli) monitor(args []string) int {
+	var (
+		g      globalFlags
+		folder string
+		env    string
+		every  time.Duration
+	)
+
+	fs := c.newFlagSet("monitor", "[collection] [options]")
+	g.register(fs)
+	fs.StringVar(&folder, "folder", "", "monitor only this folder of the collection")
+	fs.StringVar(&env, "env", "", "environment to use (default: the environment selected in the app)")
+	fs.DurationVar(&every, "every", 5*time.Minute, "how often to run the collection, in whole minutes")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) > 1 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	var store monitor.Store = repository.NewMonitorsRepository(db)
+
+	if len(positional) == 1 {
+		m, err := c.adHocMonitor(services.NewDashboardService(db), positional[0], folder, every)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		if env != "" {
+			environments, err := repository.NewEnvironmentsRepository(db).GetEnvironments()
+			if err != nil {
+				return c.fail("%v", err)
+			}
+
+			e, ok := findEnvironment(environments, env)
+			if !ok {
+				return c.fail("environment %q not found", env)
+			}
+			m.EnvironmentID = &e.ID
+		}
+
+		store = monitor.NewMemoryStore(m)
+	}
+
+	monitors, err := store.GetMonitors()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	enabled := 0
+	for _, m := range monitors {
+		if m.Enabled {
+			enabled++
+			fmt.Fprintf(c.stdout, "Monitoring %s every %d min\n", m.Name, m.IntervalMinutes)
+		}
+	}
+	if enabled == 0 {
+		return c.fail("no enabled monitors; create one in the app or name a collection to monitor")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var mu sync.Mutex
+	printer := func(event models.MonitorEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		c.monitorEvent(event)
+	}
+
+	r := runner.NewRunner(executor.NewExecutor(db), repository.NewCollectionRunsRepository(db))
+	monitor.NewScheduler(r, services.NewRunnerService(db), store, printer).Run(ctx)
+
+	return EXIT_OK
+}
+
+// adHocMonitor builds an unsaved monitor for a collection, or one of its folders, named on the command line.
+func (c *cli) adHocMonitor(dashboard *services.DashboardService, ref, folder string, every time.Duration) (models.Monitor, error) {
+	list, err := dashboard.GetFullRequestList()
+	if err != nil {
+		return models.Monitor{}, err
+	}
+
+	collection, ok := findCollection(list.Collections, ref)
+	if !ok {
+		return models.Monitor{}, fmt.Errorf("collection %q not found", ref)
+	}
+
+	m := models.Monitor{
+		ID:              1,
+		CollectionID:    collection.Collection.ID,
+		Name:            collection.Collection.Name,
+		IntervalMinutes: int(math.Ceil(every.Minutes())),
+		Enabled:         true,
+	}
+
+	if folder != "" {
+		f, ok := match(collection.Folders, folder,
+			func(f models.PopulatedFolder) int { return f.Folder.ID },
+			func(f models.PopulatedFolder) string { return f.Folder.Name })
+		if !ok {
+			return models.Monitor{}, fmt.Errorf("folder %q not found in %s", folder, collection.Collection.Name)
+		}
+		m.FolderID = &f.Folder.ID
+		m.Name += " / " + f.Folder.Name
+	}
+
+	return m, monitor.Validate(m)
+}
+
+func (c *cli) monitorEvent(event models.MonitorEvent) {
+	at := event.At.Local().Format("2006-01-02 15:04:05")
+
+	switch event.Type {
+	case models.MonitorEventRun:
+		status := "PASS"
+		if event.Status == models.MonitorStatusFailing {
+			status = "FAIL"
+		}
+
+		line := fmt.Sprintf("%s  [%s] %s", at, status, event.Name)
+		if event.RunID != 0 {
+			line += fmt.Sprintf("  run #%d: %d passed, %d failed", event.RunID, event.Passed, event.Failed)
+		}
+		if event.Error != "" {
+			line += "  (" + oneLine(event.Error) + ")"
+		}
+		fmt.Fprintln(c.stdout, line)
+	case models.MonitorEventFailing:
+		fmt.Fprintf(c.stdout, "%s  %s is failing\n", at, event.Name)
+	case models.MonitorEventRecovered:
+		fmt.Fprintf(c.stdout, "%s  %s recovered\n", at, event.Name)
+	}
+
+	if event.Type == models.MonitorEventRun && event.ConsecutiveFailures > 1 {
+		fmt.Fprintf(c.stdout, "%s  %s has failed %d times in a row\n", at, event.Name, event.ConsecutiveFailures)
+	}
+}
@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/reports"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+	"github.com/jmoiron/sqlx"
+)
+
+// REPORT_NAME is the file name, without extension, of the reports --reporter writes.
+const REPORT_NAME string = "tapa-report"
+
+// run sends a collection, or one of its folders, and exits non-zero when anything failed.
+func (c *cli) run(args []string) int {
+	var (
+		g          globalFlags
+		folder     string
+		env        string
+		data       string
+		iterations int
+		bail       bool
+		delay      int
+		maxSteps   int
+		parallel   int
+		perHost    int
+		rate       float64
+		reporters  string
+		reportDir  string
+	)
+
+	fs := c.newFlagSet("run", "<collection> [options]")
+	g.register(fs)
+	fs.StringVar(&folder, "folder", "", "run only this folder of the collection")
+	fs.StringVar(&env, "env", "", "environment to use (default: the environment selected in the app)")
+	fs.StringVar(&data, "data", "", "CSV or JSON data file, a path or the name of a data file stored with the collection")
+	fs.IntVar(&iterations, "iterations", 1, "number of iterations when no data file is given")
+	fs.BoolVar(&bail, "bail", false, "stop at the first failed request")
+	fs.IntVar(&delay, "delay", 0, "delay between requests in milliseconds")
+	fs.IntVar(&maxSteps, "max-steps", runner.DEFAULT_MAX_STEPS, "requests one iteration may go through before its flow is considered a loop")
+	fs.IntVar(&parallel, "parallel", 1, "requests sent at once; results are still reported in collection order")
+	fs.IntVar(&perHost, "per-host", 0, "requests sent at once to the same host, 0 for no cap")
+	fs.Float64Var(&rate, "rate", 0, "requests started per second, 0 for no limit")
+	fs.StringVar(&reporters, "reporter", "", "comma-separated report formats to write: "+strings.Join(reports.FORMATS, ", "))
+	fs.StringVar(&reportDir, "report-dir", ".", "directory reports are written to, as "+REPORT_NAME+".<ext>")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	formats, err := reportFormats(reporters)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	list, err := services.NewDashboardService(db).GetFullRequestList()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	collection, ok := findCollection(list.Collections, positional[0])
+	if !ok {
+		return c.fail("collection %q not found", positional[0])
+	}
+
+	options := models.RunOptions{
+		CollectionID:  collection.Collection.ID,
+		Iterations:    iterations,
+		StopOnFailure: bail,
+		DelayMs:       delay,
+		MaxSteps:      maxSteps,
+		Concurrency:   parallel,
+		MaxPerHost:    perHost,
+		RatePerSecond: rate,
+	}
+
+	if folder != "" {
+		f, ok := match(collection.Folders, folder,
+			func(f models.PopulatedFolder) int { return f.Folder.ID },
+			func(f models.PopulatedFolder) string { return f.Folder.Name })
+		if !ok {
+			return c.fail("folder %q not found in %s", folder, collection.Collection.Name)
+		}
+		options.FolderID = &f.Folder.ID
+	}
+
+	if env != "" {
+		environments, err := repository.NewEnvironmentsRepository(db).GetEnvironments()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		e, ok := findEnvironment(environments, env)
+		if !ok {
+			return c.fail("environment %q not found", env)
+		}
+		options.EnvironmentID = &e.ID
+	}
+
+	rows, err := c.loadData(db, &options, data)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	plan, err := runner.Plan(list, options.CollectionID, options.FolderID)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	printer := &progress{cli: c, plan: len(plan)}
+	fmt.Fprintf(c.stdout, "%s\n", collection.Collection.Name)
+
+	run, err := runner.NewRunner(executor.NewExecutor(db), repository.NewCollectionRunsRepository(db)).Start(ctx, plan, rows, options, printer.event)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	report := run.Wait()
+	printer.summary(report)
+
+	if err := c.writeReports(db, collection.Collection.Name, report, formats, reportDir); err != nil {
+		return c.fail("%v", err)
+	}
+
+	if report.Status != models.RunStatusCompleted || report.Failed > 0 || report.Error != "" {
+		return EXIT_FAILURE
+	}
+
+	return EXIT_OK
+}
+
+// reportFormats parses --reporter.
+func reportFormats(value string) ([]string, error) {
+	formats := []string{}
+	for _, format := range strings.Split(value, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "" {
+			continue
+		}
+		if !reports.IsFormat(format) {
+			return nil, fmt.Errorf("unknown reporter %q, expected one of %s", format, strings.Join(reports.FORMATS, ", "))
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// writeReports writes one report per format into dir.
+func (c *cli) writeReports(db *sqlx.DB, title string, run models.RunReport, formats []string, dir string) error {
+	if len(formats) == 0 {
+		return nil
+	}
+
+	report, err := reports.NewBuilder(db).Build(title, run)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, format := range formats {
+		path := filepath.Join(dir, REPORT_NAME+reports.Extension(format))
+
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		err = reports.Write(file, format, report)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		fmt.Fprintf(c.stdout, "Report:   %s\n", path)
+	}
+
+	return nil
+}
+
+// loadData reads --data from disk when it names a file, otherwise from the collection's stored data files.
+func (c *cli) loadData(db *sqlx.DB, options *models.RunOptions, data string) ([]map[string]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	if content, err := os.ReadFile(data); err == nil {
+		rows, err := runner.ParseDataFile(runner.DataFileFormat(data, string(content)), string(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", data, err)
+		}
+		return rows, nil
+	}
+
+	files, err := repository.NewCollectionDataFilesRepository(db).GetCollectionDataFiles(options.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, ok := match(files, data,
+		func(f models.CollectionDataFile) int { return f.ID },
+		func(f models.CollectionDataFile) string { return f.Name })
+	if !ok {
+		return nil, fmt.Errorf("data file %q is neither a file nor stored with the collection", data)
+	}
+
+	rows, err := runner.ParseDataFile(file.Format, file.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file.Name, err)
+	}
+
+	options.DataFileID = &file.ID
+	return rows, nil
+}
+
+// progress prints run events as they arrive.
+type progress struct {
+	cli  *cli
+	plan int
+}
+
+func (p *progress) event(event models.RunEvent) {
+	out := p.cli.stdout
+
+	switch event.Type {
+	case models.RunEventIterationStarted:
+		if event.Data != nil || event.Iteration > 0 {
+			fmt.Fprintf(out, "\nIteration %d%s\n", event.Iteration+1, formatRow(event.Data))
+		}
+	case models.RunEventRequestSkipped:
+		fmt.Fprintf(out, "  [SKIP] %-7s %s  (%s)\n", event.Request.Method, event.Request.Name, oneLine(event.Step.Detail))
+	case models.RunEventRequestFinished:
+		result := event.Result
+
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+
+		detail := fmt.Sprintf("%d %d ms", result.StatusCode, result.ResponseTime)
+		if result.Error != "" {
+			detail = "error: " + oneLine(result.Error)
+		}
+
+		fmt.Fprintf(out, "  [%s] %-7s %s  (%s)\n", status, result.Method, result.Name, detail)
+
+		for _, test := range result.Tests {
+			mark := "ok"
+			if !test.Passed {
+				mark = "x "
+			}
+
+			line := fmt.Sprintf("      %s %s", mark, test.Name)
+			if !test.Passed && test.Message != "" {
+				line += ": " + oneLine(test.Message)
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+}
+
+func (p *progress) summary(report models.RunReport) {
+	out := p.cli.stdout
+
+	fmt.Fprintln(out)
+	if report.Error != "" {
+		fmt.Fprintf(out, "Run aborted: %s\n", report.Error)
+	} else if report.Status == models.RunStatusStopped {
+		fmt.Fprintln(out, "Run stopped.")
+	}
+
+	fmt.Fprintf(out, "Requests: %d passed, %d failed, %d planned\n", report.Passed, report.Failed, report.Total)
+	fmt.Fprintf(out, "Tests:    %d passed, %d failed\n", report.TestsPassed, report.TestsFailed)
+	if report.Skipped > 0 {
+		fmt.Fprintf(out, "Skipped:  %d\n", report.Skipped)
+	}
+
+	if report.TotalIterations > 1 {
+		fmt.Fprintf(out, "Iterations: %d of %d", len(report.Iterations), report.TotalIterations)
+		if len(report.FailedIterations) > 0 {
+			failed := make([]string, len(report.FailedIterations))
+			for i, index := range report.FailedIterations {
+				failed[i] = fmt.Sprint(index + 1)
+			}
+			fmt.Fprintf(out, ", failed: %s", strings.Join(failed, ", "))
+		}
+		fmt.Fprintln(out)
+	}
+
+	fmt.Fprintf(out, "Duration: %s\n", (time.Duration(report.Duration) * time.Millisecond).String())
+}
+
+func formatRow(row map[string]string) string {
+	if len(row) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(row))
+	for key := range row {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + row[key]
+	}
+
+	return "  " + oneLine(strings.Join(parts, " "))
+}
+
+func oneLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 160 {
+		s = s[:160] + "..."
+	}
+	return s
+}
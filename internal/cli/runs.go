@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+)
+
+// runs prints the past runs of a collection, newest first.
+func (c *cli) runs(args []string) int {
+	var (
+		g     globalFlags
+		limit int
+	)
+
+	fs := c.newFlagSet("runs", "<collection> [options]")
+	g.register(fs)
+	fs.IntVar(&limit, "limit", 20, "number of runs to show, 0 for all")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	list, err := services.NewDashboardService(db).GetFullRequestList()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	collection, ok := findCollection(list.Collections, positional[0])
+	if !ok {
+		return c.fail("collection %q not found", positional[0])
+	}
+
+	runs, err := repository.NewCollectionRunsRepository(db).GetCollectionRuns(collection.Collection.ID)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "ID\tSTARTED\tSTATUS\tREQUESTS\tFAILED\tTESTS FAILED\tDURATION")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%s\n", run.ID, run.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			run.Status, run.Passed+run.Failed, run.Failed, run.TestsFailed,
+			(time.Duration(run.Duration) * time.Millisecond).String())
+	}
+
+	return EXIT_OK
+}
+
+// compare prints what changed between two runs and exits non-zero when the target run has new failures.
+func (c *cli) compare(args []string) int {
+	var (
+		g   globalFlags
+		all bool
+	)
+
+	fs := c.newFlagSet("compare", "<base run> <target run> [options]")
+	g.register(fs)
+	fs.BoolVar(&all, "all", false, "also list requests whose outcome did not change")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) != 2 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	ids := make([]int, 2)
+	for i, ref := range positional {
+		id, err := strconv.Atoi(ref)
+		if err != nil {
+			return c.fail("run id %q is not a number", ref)
+		}
+		ids[i] = id
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewCollectionRunsRepository(db)
+
+	base, err := repo.GetCollectionRun(ids[0])
+	if err != nil {
+		return c.fail("run %d: %v", ids[0], err)
+	}
+
+	target, err := repo.GetCollectionRun(ids[1])
+	if err != nil {
+		return c.fail("run %d: %v", ids[1], err)
+	}
+
+	comparison := runner.Compare(base, target)
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "CHANGE\tREQUEST\tSTATUS\tTIME\tTESTS")
+	for _, r := range comparison.Requests {
+		if !all && !changed(r) {
+			continue
+		}
+
+		name := r.Method + " " + r.Name
+		if base.TotalIterations > 1 || target.TotalIterations > 1 {
+			name = fmt.Sprintf("#%d %s", r.Iteration+1, name)
+		}
+
+		status := fmt.Sprint(r.TargetStatus)
+		if r.StatusChanged {
+			status = fmt.Sprintf("%d -> %d", r.BaseStatus, r.TargetStatus)
+		}
+
+		tests := ""
+		if len(r.FailedTests) > 0 {
+			tests += fmt.Sprintf("%d failing", len(r.FailedTests))
+		}
+		if len(r.FixedTests) > 0 {
+			if tests != "" {
+				tests += ", "
+			}
+			tests += fmt.Sprintf("%d fixed", len(r.FixedTests))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%+d ms\t%s\n", r.Change, name, status, r.ResponseTimeDelta, tests)
+	}
+	w.Flush()
+
+	fmt.Fprintf(c.stdout, "\nRun %d -> %d: %d new failures, %d fixed, %d status changes, %d tests failing, %d tests fixed\n",
+		base.ID, target.ID, comparison.NewFailures, comparison.Fixed, comparison.StatusChanges,
+		comparison.FailedTests, comparison.FixedTests)
+
+	if comparison.NewFailures > 0 || comparison.FailedTests > 0 {
+		return EXIT_FAILURE
+	}
+
+	return EXIT_OK
+}
+
+// changed reports whether a request is worth listing without --all.
+func changed(r models.RequestComparison) bool {
+	return (r.Change != models.RunChangePassing && r.Change != models.RunChangeStillFailing) ||
+		r.StatusChanged || len(r.FailedTests) > 0 || len(r.FixedTests) > 0
+}
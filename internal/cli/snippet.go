@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/services"
+	"github.com/Amir-Zouerami/TAPA/internal/snippets"
+)
+
+// snippet prints code that sends a stored request, in one of the languages snippets can be generated for.
+func (c *cli) snippet(args []string) int {
+	var (
+		g      globalFlags
+		env    string
+		target string
+	)
+
+	ids := []string{}
+	for _, t := range snippets.Targets() {
+		ids = append(ids, t.ID)
+	}
+
+	fs := c.newFlagSet("snippet", "<collection> <request> [options]")
+	g.register(fs)
+	fs.StringVar(&env, "env", "", "environment whose variables are resolved (default: the environment selected in the app)")
+	fs.StringVar(&target, "lang", "curl", "language and library of the code: "+strings.Join(ids, ", "))
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) != 2 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	list, err := services.NewDashboardService(db).GetFullRequestList()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	collection, ok := findCollection(list.Collections, positional[0])
+	if !ok {
+		return c.fail("collection %q not found", positional[0])
+	}
+
+	plan, err := runner.Plan(list, collection.Collection.ID, nil)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	r, ok := match(plan, positional[1],
+		func(r models.RequestBasic) int { return r.ID },
+		func(r models.RequestBasic) string { return r.Name })
+	if !ok {
+		return c.fail("request %q not found in %s", positional[1], collection.Collection.Name)
+	}
+
+	var environmentID *int
+	if env != "" {
+		environments, err := repository.NewEnvironmentsRepository(db).GetEnvironments()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		e, ok := findEnvironment(environments, env)
+		if !ok {
+			return c.fail("environment %q not found", env)
+		}
+		environmentID = &e.ID
+	}
+
+	code, err := services.NewSnippetsService(db).GenerateSnippet(r.ID, environmentID, target)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	fmt.Fprint(c.stdout, strings.TrimSuffix(code, "\n")+"\n")
+	return EXIT_OK
+}
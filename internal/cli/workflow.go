@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/workflow"
+)
+
+// workflow lists the saved workflows, or runs one and prints every node with the values mapped into it.
+// It exits non-zero when a node failed or was skipped.
+func (c *cli) workflow(args []string) int {
+	var (
+		g   globalFlags
+		env string
+	)
+
+	fs := c.newFlagSet("workflow", "[workflow] [options]")
+	g.register(fs)
+	fs.StringVar(&env, "env", "", "environment to use (default: the workflow's, or the environment selected in the app)")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return EXIT_ERROR
+	}
+
+	if len(positional) > 1 {
+		fs.Usage()
+		return EXIT_ERROR
+	}
+
+	db, err := c.open(g)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+	defer db.Close()
+
+	workflows := repository.NewWorkflowsRepository(db)
+
+	list, err := workflows.GetWorkflows()
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	if len(positional) == 0 {
+		w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintln(w, "ID\tNAME\tDESCRIPTION")
+		for _, wf := range list {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", wf.ID, wf.Name, oneLine(wf.Description))
+		}
+		return EXIT_OK
+	}
+
+	found, ok := match(list, positional[0],
+		func(w models.Workflow) int { return w.ID },
+		func(w models.Workflow) string { return w.Name })
+	if !ok {
+		return c.fail("workflow %q not found", positional[0])
+	}
+
+	wf, err := workflows.GetWorkflow(found.ID)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	var environmentID *int
+	if env != "" {
+		environments, err := repository.NewEnvironmentsRepository(db).GetEnvironments()
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		e, ok := findEnvironment(environments, env)
+		if !ok {
+			return c.fail("environment %q not found", env)
+		}
+		environmentID = &e.ID
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var mu sync.Mutex
+	printer := func(event models.WorkflowEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		c.workflowEvent(event)
+	}
+
+	run, err := workflow.NewRunner(executor.NewExecutor(db), workflows).Start(ctx, wf, environmentID, printer)
+	if err != nil {
+		return c.fail("%v", err)
+	}
+
+	report := run.Wait()
+	c.workflowSummary(report)
+
+	if report.Status != models.RunStatusCompleted || report.Failed > 0 || report.Skipped > 0 {
+		return EXIT_FAILURE
+	}
+
+	return EXIT_OK
+}
+
+func (c *cli) workflowEvent(event models.WorkflowEvent) {
+	out := c.stdout
+
+	switch event.Type {
+	case models.WorkflowEventStarted:
+		fmt.Fprintf(out, "Workflow run #%d\n", event.RunID)
+	case models.WorkflowEventNodeFinished:
+		node := event.Node
+
+		if node.Status == models.WorkflowNodeSkipped {
+			fmt.Fprintf(out, "  [SKIP] %s  (%s)\n", node.Node, oneLine(node.Error))
+			return
+		}
+
+		status := "PASS"
+		if node.Status == models.WorkflowNodeFailed {
+			status = "FAIL"
+		}
+
+		detail := fmt.Sprintf("%d %d ms", node.StatusCode, node.ResponseTime)
+		if node.Error != "" {
+			detail = "error: " + oneLine(node.Error)
+		}
+
+		fmt.Fprintf(out, "  [%s] %s  %s %s  (%s)\n", status, node.Node, node.Method, node.URL, detail)
+
+		for _, input := range node.Inputs {
+			value := oneLine(input.Value)
+			if input.Error != "" {
+				value = "error: " + oneLine(input.Error)
+			}
+			fmt.Fprintf(out, "      %s.%s -> %s = %s\n", input.From, input.Source, input.Target, value)
+		}
+
+		for _, test := range node.Tests {
+			mark := "ok"
+			if !test.Passed {
+				mark = "x "
+			}
+
+			line := fmt.Sprintf("      %s %s", mark, test.Name)
+			if !test.Passed && test.Message != "" {
+				line += ": " + oneLine(test.Message)
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+}
+
+func (c *cli) workflowSummary(report models.WorkflowRunReport) {
+	out := c.stdout
+
+	fmt.Fprintln(out)
+	if report.Status == models.RunStatusStopped {
+		fmt.Fprintln(out, "Workflow stopped.")
+	}
+
+	fmt.Fprintf(out, "Nodes:    %d passed, %d failed, %d skipped of %d\n", report.Passed, report.Failed, report.Skipped, len(report.Nodes))
+	fmt.Fprintf(out, "Duration: %s\n", (time.Duration(report.Duration) * time.Millisecond).String())
+}
@@ -40,7 +40,10 @@ func GetAppConfig(assets fs.FS, app *App, serviceContainer *services.Services) (
 		Width:            APP_WIDTH,
 		Height:           APP_HEIGHT,
 		WindowStartState: options.Maximised,
-		OnStartup:        app.startup,
+		OnStartup: func(ctx context.Context) {
+			app.startup(ctx)
+			serviceContainer.Startup(ctx)
+		},
 		Linux: &linux.Options{
 			Icon: icon,
 		},
@@ -53,6 +56,13 @@ func GetAppConfig(assets fs.FS, app *App, serviceContainer *services.Services) (
 		Bind: []any{
 			app,
 			serviceContainer.Dashboard,
+			serviceContainer.Requests,
+			serviceContainer.Collections,
+			serviceContainer.Runner,
+			serviceContainer.LoadTests,
+			serviceContainer.Monitors,
+			serviceContainer.Workflows,
+			serviceContainer.Snippets,
 		},
 	}, nil
 }
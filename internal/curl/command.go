@@ -0,0 +1,129 @@
+package curl
+
+import (
+	"encoding/json"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Command writes a request as a curl command, one option per line. The request should have its variables
+// resolved already; the URL, query and Content-Type are written the way the executor sends them.
+func Command(req models.RequestWithDetail) string {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	first := "curl"
+	switch {
+	case method == "HEAD":
+		first += " -I"
+	case method != "GET" || req.Body != "":
+		first += " -X " + method
+	}
+	lines := []string{first + " " + Quote(URL(req))}
+
+	hasContentType := false
+	for _, h := range req.Headers {
+		if h.Key == "" {
+			continue
+		}
+		if strings.EqualFold(h.Key, "content-type") {
+			// Multipart bodies need the boundary curl picks.
+			if req.BodyFormat == "form-data" {
+				continue
+			}
+			hasContentType = true
+		}
+
+		if h.Value == "" {
+			lines = append(lines, "-H "+Quote(h.Key+";"))
+		} else {
+			lines = append(lines, "-H "+Quote(h.Key+": "+h.Value))
+		}
+	}
+
+	if req.Body != "" && !hasContentType {
+		switch req.BodyFormat {
+		case "JSON":
+			lines = append(lines, "-H "+Quote("Content-Type: application/json"))
+		case "XML":
+			lines = append(lines, "-H "+Quote("Content-Type: application/xml"))
+		}
+	}
+
+	if len(req.Cookies) > 0 {
+		pairs := make([]string, 0, len(req.Cookies))
+		for _, c := range req.Cookies {
+			pairs = append(pairs, c.Key+"="+c.Value)
+		}
+		lines = append(lines, "-b "+Quote(strings.Join(pairs, "; ")))
+	}
+
+	if req.Body != "" {
+		fields := map[string]string{}
+		if req.BodyFormat == "form-data" && json.Unmarshal([]byte(req.Body), &fields) == nil {
+			keys := make([]string, 0, len(fields))
+			for key := range fields {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				// -F would read values starting with @ or < from files.
+				option := "-F"
+				if strings.HasPrefix(fields[key], "@") || strings.HasPrefix(fields[key], "<") || strings.Contains(fields[key], ";") {
+					option = "--form-string"
+				}
+				lines = append(lines, option+" "+Quote(key+"="+fields[key]))
+			}
+		} else {
+			lines = append(lines, "--data-raw "+Quote(req.Body))
+		}
+	}
+
+	if req.Timeout > 0 && req.Timeout != importing.DEFAULT_TIMEOUT_MS {
+		lines = append(lines, "-m "+strconv.FormatFloat(float64(req.Timeout)/1000, 'f', -1, 64))
+	}
+	if !req.SSLVerification {
+		lines = append(lines, "-k")
+	}
+	if req.AllowRedirects {
+		lines = append(lines, "-L")
+	}
+
+	return strings.Join(lines, " \\\n  ")
+}
+
+// URL returns the URL a request is sent to: its URL with the query parameters, encoded when the request
+// encodes its URL.
+func URL(req models.RequestWithDetail) string {
+	rawURL := strings.TrimSpace(req.URL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	if len(req.QueryParams) == 0 {
+		return rawURL
+	}
+
+	params := make([]string, 0, len(req.QueryParams))
+	for _, p := range req.QueryParams {
+		key, value := p.Key, p.Value
+		if req.EncodeURL {
+			key, value = neturl.QueryEscape(key), neturl.QueryEscape(value)
+		}
+		params = append(params, key+"="+value)
+	}
+
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + strings.Join(params, "&")
+}
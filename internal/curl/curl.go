@@ -0,0 +1,203 @@
+// Package curl turns curl command lines into TAPA requests and requests back into curl commands.
+package curl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const SOURCE string = "curl"
+
+// split splits a command line into words the way a POSIX shell does for the quoting curl commands use:
+// single, double and ANSI-C ($'...') quotes, backslash escapes and backslash line continuations.
+func split(command string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+	)
+
+	r := []rune(command)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+
+		switch {
+		case c == '\\':
+			switch {
+			case i+1 < len(r) && r[i+1] == '\n':
+				i++
+			case i+2 < len(r) && r[i+1] == '\r' && r[i+2] == '\n':
+				i += 2
+			case i+1 < len(r):
+				word.WriteRune(r[i+1])
+				inWord = true
+				i++
+			}
+		case c == '\'':
+			end := indexRune(r, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(string(r[i+1 : end]))
+			inWord = true
+			i = end
+		case c == '$' && i+1 < len(r) && r[i+1] == '\'':
+			end, err := ansiC(r, i+2, &word)
+			if err != nil {
+				return nil, err
+			}
+			inWord = true
+			i = end
+		case c == '"':
+			end, err := doubleQuoted(r, i+1, &word)
+			if err != nil {
+				return nil, err
+			}
+			inWord = true
+			i = end
+		case unicode.IsSpace(c):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// doubleQuoted reads a double quoted string starting after its opening quote and returns the index of the
+// closing quote. Backslashes only escape the characters the shell lets them escape there.
+func doubleQuoted(r []rune, start int, word *strings.Builder) (int, error) {
+	for i := start; i < len(r); i++ {
+		switch {
+		case r[i] == '"':
+			return i, nil
+		case r[i] == '\\' && i+1 < len(r) && strings.ContainsRune("\"\\$`\n", r[i+1]):
+			if r[i+1] != '\n' {
+				word.WriteRune(r[i+1])
+			}
+			i++
+		default:
+			word.WriteRune(r[i])
+		}
+	}
+	return 0, fmt.Errorf("unterminated double quote")
+}
+
+// ansiC reads a $'...' string starting after its opening quote and returns the index of the closing quote.
+// Browsers quote bodies with special characters this way.
+func ansiC(r []rune, start int, word *strings.Builder) (int, error) {
+	simple := map[rune]rune{'n': '\n', 't': '\t', 'r': '\r', 'a': '\a', 'b': '\b', 'f': '\f', 'v': '\v', 'e': 0x1b,
+		'\\': '\\', '\'': '\'', '"': '"', '?': '?'}
+
+	for i := start; i < len(r); i++ {
+		if r[i] == '\'' {
+			return i, nil
+		}
+		if r[i] != '\\' || i+1 >= len(r) {
+			word.WriteRune(r[i])
+			continue
+		}
+
+		i++
+		if s, ok := simple[r[i]]; ok {
+			word.WriteRune(s)
+			continue
+		}
+
+		digits, base, max := "", 16, 0
+		switch r[i] {
+		case 'x':
+			max = 2
+		case 'u':
+			max = 4
+		case 'U':
+			max = 8
+		default:
+			if r[i] >= '0' && r[i] <= '7' {
+				base, max = 8, 3
+				i--
+			}
+		}
+		if max == 0 {
+			word.WriteRune('\\')
+			word.WriteRune(r[i])
+			continue
+		}
+
+		for len(digits) < max && i+1 < len(r) && isDigit(r[i+1], base) {
+			digits += string(r[i+1])
+			i++
+		}
+		code, err := strconv.ParseUint(digits, base, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid escape in $'...' string")
+		}
+		if max == 2 || base == 8 {
+			word.WriteByte(byte(code))
+		} else {
+			word.WriteRune(rune(code))
+		}
+	}
+	return 0, fmt.Errorf("unterminated $'...' string")
+}
+
+func isDigit(c rune, base int) bool {
+	if base == 8 {
+		return c >= '0' && c <= '7'
+	}
+	return unicode.Is(unicode.ASCII_Hex_Digit, c)
+}
+
+func indexRune(r []rune, start int, c rune) int {
+	for i := start; i < len(r); i++ {
+		if r[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// Quote quotes a word for a POSIX shell, leaving words that need no quoting as they are. Words with control
+// characters other than newlines and tabs use ANSI-C quoting, so they survive being copied.
+func Quote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return s
+	}
+
+	if strings.IndexFunc(s, func(r rune) bool { return unicode.IsControl(r) && r != '\n' && r != '\t' }) >= 0 {
+		var b strings.Builder
+		b.WriteString("$'")
+		for _, r := range s {
+			switch {
+			case r == '\\' || r == '\'':
+				b.WriteRune('\\')
+				b.WriteRune(r)
+			case r == '\n':
+				b.WriteString(`\n`)
+			case r == '\r':
+				b.WriteString(`\r`)
+			case r == '\t':
+				b.WriteString(`\t`)
+			case unicode.IsControl(r) && r < 0x80:
+				fmt.Fprintf(&b, `\x%02x`, r)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		b.WriteString("'")
+		return b.String()
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,243 @@
+package curl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// headers lists a request's headers as "Key: Value".
+func headers(req models.RequestWithDetail) []string {
+	list := []string{}
+	for _, h := range req.Headers {
+		list = append(list, h.Key+": "+h.Value)
+	}
+	return list
+}
+
+// query lists a request's query parameters as "key=value".
+func query(req models.RequestWithDetail) []string {
+	list := []string{}
+	for _, p := range req.QueryParams {
+		list = append(list, p.Key+"="+p.Value)
+	}
+	return list
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		method     string
+		url        string
+		query      []string
+		headers    []string
+		body       string
+		bodyFormat string
+		warnings   int
+	}{
+		{
+			name:       "get",
+			command:    `curl https://api.example.com/users`,
+			method:     "GET",
+			url:        "https://api.example.com/users",
+			query:      []string{},
+			headers:    []string{},
+			bodyFormat: "raw",
+		},
+		{
+			name:       "prompt and query",
+			command:    `$ curl 'https://api.example.com/search?q=two%20words&page=2'`,
+			method:     "GET",
+			url:        "https://api.example.com/search",
+			query:      []string{"q=two words", "page=2"},
+			headers:    []string{},
+			bodyFormat: "raw",
+		},
+		{
+			name:       "json body",
+			command:    `curl -X POST https://api.example.com/users -H 'Content-Type: application/json' -d '{"name":"Ada"}'`,
+			method:     "POST",
+			url:        "https://api.example.com/users",
+			query:      []string{},
+			headers:    []string{},
+			body:       `{"name":"Ada"}`,
+			bodyFormat: "JSON",
+		},
+		{
+			name:       "data is a form",
+			command:    `curl https://api.example.com/login -d user=ada -d 'pass=s3cret'`,
+			method:     "POST",
+			url:        "https://api.example.com/login",
+			query:      []string{},
+			headers:    []string{"Content-Type: application/x-www-form-urlencoded"},
+			body:       "user=ada&pass=s3cret",
+			bodyFormat: "raw",
+		},
+		{
+			name:       "body from a file",
+			command:    `curl --data-binary @body.json https://api.example.com/upload`,
+			method:     "POST",
+			url:        "https://api.example.com/upload",
+			query:      []string{},
+			headers:    []string{},
+			bodyFormat: "raw",
+			warnings:   1,
+		},
+		{
+			name:       "json from a file",
+			command:    `curl --json @body.json https://api.example.com/upload`,
+			method:     "POST",
+			url:        "https://api.example.com/upload",
+			query:      []string{},
+			headers:    []string{},
+			bodyFormat: "raw",
+			warnings:   1,
+		},
+		{
+			name:       "form field from a file",
+			command:    `curl -F file=@photo.png https://api.example.com/upload`,
+			method:     "POST",
+			url:        "https://api.example.com/upload",
+			query:      []string{},
+			headers:    []string{},
+			bodyFormat: "raw",
+			warnings:   1,
+		},
+		{
+			name:       "get with data",
+			command:    `curl -G -d q=go https://api.example.com/search`,
+			method:     "GET",
+			url:        "https://api.example.com/search",
+			query:      []string{"q=go"},
+			headers:    []string{},
+			bodyFormat: "raw",
+		},
+		{
+			name:       "head",
+			command:    `curl -I https://api.example.com`,
+			method:     "HEAD",
+			url:        "https://api.example.com",
+			query:      []string{},
+			headers:    []string{},
+			bodyFormat: "raw",
+		},
+		{
+			name:       "grouped options",
+			command:    `curl -sSL -u ada:s3cret https://api.example.com/me`,
+			method:     "GET",
+			url:        "https://api.example.com/me",
+			query:      []string{},
+			headers:    []string{"Authorization: Basic YWRhOnMzY3JldA=="},
+			bodyFormat: "raw",
+		},
+		{
+			name:       "unsupported option",
+			command:    `curl --tcp-nodelay https://api.example.com`,
+			method:     "GET",
+			url:        "https://api.example.com",
+			query:      []string{},
+			headers:    []string{},
+			bodyFormat: "raw",
+			warnings:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, report, err := Parse(tt.command)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if req.Method != tt.method || req.URL != tt.url {
+				t.Errorf("request = %s %s, want %s %s", req.Method, req.URL, tt.method, tt.url)
+			}
+			if got := query(req); !reflect.DeepEqual(got, tt.query) {
+				t.Errorf("query = %q, want %q", got, tt.query)
+			}
+			if got := headers(req); !reflect.DeepEqual(got, tt.headers) {
+				t.Errorf("headers = %q, want %q", got, tt.headers)
+			}
+			if req.Body != tt.body || req.BodyFormat != tt.bodyFormat {
+				t.Errorf("body = %q (%s), want %q (%s)", req.Body, req.BodyFormat, tt.body, tt.bodyFormat)
+			}
+			if len(report.Warnings) != tt.warnings {
+				t.Errorf("warnings = %+v, want %d", report.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, command := range []string{``, `wget https://api.example.com`, `curl -s`, `curl -H`, `curl 'https://api.example.com`} {
+		if _, _, err := Parse(command); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", command)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      models.RequestWithDetail
+		contains []string // parts of the command
+	}{
+		{
+			name: "get with query",
+			req: models.RequestWithDetail{
+				Request:     models.Request{Method: "GET", URL: "https://api.example.com/search", EncodeURL: true, SSLVerification: true},
+				QueryParams: []models.RequestQueryParam{{Key: "q", Value: "two words"}},
+			},
+			contains: []string{"curl 'https://api.example.com/search?q=two+words'"},
+		},
+		{
+			name: "json body",
+			req: models.RequestWithDetail{
+				Request: models.Request{Method: "POST", URL: "https://api.example.com/users", Body: `{"name":"Ada"}`, BodyFormat: "JSON", SSLVerification: true},
+			},
+			contains: []string{"-X POST", "-H 'Content-Type: application/json'", `--data-raw '{"name":"Ada"}'`},
+		},
+		{
+			name: "form fields that look like files",
+			req: models.RequestWithDetail{
+				Request: models.Request{Method: "POST", URL: "https://api.example.com/upload", Body: `{"handle":"@ada","name":"Ada"}`, BodyFormat: "form-data", SSLVerification: true},
+				Headers: []models.RequestHeader{{Key: "Content-Type", Value: "multipart/form-data; boundary=x"}},
+			},
+			contains: []string{"--form-string handle=@ada", "-F name=Ada"},
+		},
+		{
+			name: "head, insecure, redirects and timeout",
+			req: models.RequestWithDetail{
+				Request: models.Request{Method: "HEAD", URL: "api.example.com", Timeout: 1500, AllowRedirects: true},
+			},
+			contains: []string{"curl -I http://api.example.com", "-m 1.5", "-k", "-L"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := Command(tt.req)
+			for _, part := range tt.contains {
+				if !strings.Contains(command, part) {
+					t.Errorf("command does not contain %q:\n%s", part, command)
+				}
+			}
+
+			// The command reads back as the request it was written from.
+			back, _, err := Parse(command)
+			if err != nil {
+				t.Fatalf("parsing the command: %v", err)
+			}
+
+			if back.Method != tt.req.Method || back.Body != tt.req.Body {
+				t.Errorf("parsed back = %s %q, want %s %q", back.Method, back.Body, tt.req.Method, tt.req.Body)
+			}
+			if back.AllowRedirects != tt.req.AllowRedirects || back.SSLVerification != tt.req.SSLVerification {
+				t.Errorf("parsed back redirects = %v, ssl = %v; want %v, %v", back.AllowRedirects, back.SSLVerification, tt.req.AllowRedirects, tt.req.SSLVerification)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package curl
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// shortOptions maps curl's single letter options to their long names.
+var shortOptions = map[byte]string{
+	'X': "request", 'H': "header", 'd': "data", 'F': "form", 'u': "user", 'b': "cookie", 'A': "user-agent",
+	'e': "referer", 'm': "max-time", 'o': "output", 'w': "write-out", 'x': "proxy", 'E': "cert", 'T': "upload-file",
+	'c': "cookie-jar", 'k': "insecure", 'L': "location", 'G': "get", 'I': "head", 's': "silent", 'S': "show-error",
+	'v': "verbose", 'i': "include", 'f': "fail", 'g': "globoff", 'N': "no-buffer", 'O': "remote-name",
+}
+
+// withArgument are the long options that take an argument.
+var withArgument = map[string]bool{
+	"request": true, "header": true, "data": true, "data-raw": true, "data-binary": true, "data-ascii": true,
+	"data-urlencode": true, "json": true, "form": true, "form-string": true, "user": true, "cookie": true,
+	"user-agent": true, "referer": true, "url": true, "max-time": true, "oauth2-bearer": true, "output": true,
+	"write-out": true, "connect-timeout": true, "max-redirs": true, "retry": true, "proxy": true, "cacert": true,
+	"cert": true, "key": true, "resolve": true, "upload-file": true, "cookie-jar": true, "limit-rate": true,
+}
+
+// ignored are options that change how curl runs or prints, not the request it sends. --compressed is among them
+// because requests are always sent accepting compressed responses.
+var ignored = map[string]bool{
+	"silent": true, "show-error": true, "verbose": true, "include": true, "fail": true, "globoff": true,
+	"no-buffer": true, "output": true, "remote-name": true, "write-out": true, "connect-timeout": true,
+	"max-redirs": true, "retry": true, "compressed": true, "http1.0": true, "http1.1": true, "http2": true,
+	"http2-prior-knowledge": true, "http3": true, "path-as-is": true, "location-trusted": true,
+}
+
+type parser struct {
+	report   *models.ImportReport
+	method   string
+	urls     []string
+	headers  []models.RequestHeader
+	cookies  []models.RequestCookie
+	data     []string
+	form     map[string]string
+	fromFile bool // a body or form field was read from a file; the request is still sent with one
+	json     bool
+	get      bool
+	head     bool
+	insecure bool
+	location bool
+	timeout  int
+}
+
+// Parse turns a curl command line, as browsers and documentation write them, into a request. Options that change
+// what is sent but cannot be stored, e.g. bodies read from files, are listed in the report's warnings.
+// Without -L the request does not follow redirects, as curl does not.
+func Parse(command string) (models.RequestWithDetail, models.ImportReport, error) {
+	report := models.ImportReport{Source: SOURCE, Warnings: []models.ImportWarning{}}
+
+	words, err := split(strings.TrimSpace(command))
+	if err != nil {
+		return models.RequestWithDetail{}, report, errors.Wrap(errors.ErrInvalidImport, err)
+	}
+
+	// Commands copied from a terminal may keep the prompt.
+	if len(words) > 0 && words[0] == "$" {
+		words = words[1:]
+	}
+	if len(words) == 0 || path.Base(words[0]) != "curl" {
+		return models.RequestWithDetail{}, report, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("not a curl command"))
+	}
+
+	p := &parser{report: &report, form: map[string]string{}}
+	if err := p.options(words[1:]); err != nil {
+		return models.RequestWithDetail{}, report, errors.Wrap(errors.ErrInvalidImport, err)
+	}
+
+	if len(p.urls) == 0 {
+		return models.RequestWithDetail{}, report, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("the curl command has no URL"))
+	}
+	if len(p.urls) > 1 {
+		p.warn("", "the command requests %d URLs, only the first was imported", len(p.urls))
+	}
+
+	req := p.request()
+	report.Name = req.Name
+	report.Requests = 1
+	return req, report, nil
+}
+
+func (p *parser) warn(option, format string, args ...any) {
+	p.report.Warnings = append(p.report.Warnings, models.ImportWarning{Item: option, Message: fmt.Sprintf(format, args...)})
+}
+
+// options reads the words after "curl". Short options may be grouped, e.g. -sSL, and take their argument either
+// attached, e.g. -XPOST, or as the next word.
+func (p *parser) options(words []string) error {
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+
+		next := func(name string) (string, error) {
+			if i+1 >= len(words) {
+				return "", fmt.Errorf("option %s needs an argument", name)
+			}
+			i++
+			return words[i], nil
+		}
+
+		switch {
+		case word == "--":
+			p.urls = append(p.urls, words[i+1:]...)
+			return nil
+		case strings.HasPrefix(word, "--"):
+			name := word[2:]
+			value := ""
+			if withArgument[name] {
+				v, err := next(word)
+				if err != nil {
+					return err
+				}
+				value = v
+			}
+			p.option(name, value)
+		case strings.HasPrefix(word, "-") && len(word) > 1:
+			for j := 1; j < len(word); j++ {
+				name, ok := shortOptions[word[j]]
+				if !ok {
+					p.warn("-"+string(word[j]), "option -%c is not supported and was ignored", word[j])
+					continue
+				}
+				if !withArgument[name] {
+					p.option(name, "")
+					continue
+				}
+
+				value := word[j+1:]
+				if value == "" {
+					v, err := next("-" + string(word[j]))
+					if err != nil {
+						return err
+					}
+					value = v
+				}
+				p.option(name, value)
+				break
+			}
+		default:
+			p.urls = append(p.urls, word)
+		}
+	}
+	return nil
+}
+
+func (p *parser) option(name, value string) {
+	switch name {
+	case "request":
+		p.method = strings.ToUpper(value)
+	case "header":
+		p.header(value)
+	case "data", "data-ascii", "data-binary":
+		if strings.HasPrefix(value, "@") {
+			p.warn("--"+name, "the body is read from %s, which is not imported", value[1:])
+			p.fromFile = true
+			return
+		}
+		p.data = append(p.data, value)
+	case "data-raw":
+		p.data = append(p.data, value)
+	case "data-urlencode":
+		p.data = append(p.data, urlencode(value))
+	case "json":
+		p.json = true
+		if strings.HasPrefix(value, "@") {
+			p.warn("--json", "the body is read from %s, which is not imported", value[1:])
+			p.fromFile = true
+			return
+		}
+		p.data = append(p.data, value)
+	case "form", "form-string":
+		key, field, _ := strings.Cut(value, "=")
+		if name == "form" && (strings.HasPrefix(field, "@") || strings.HasPrefix(field, "<")) {
+			p.warn("--form", "form field %s is read from a file, which is not supported, it was skipped", key)
+			p.fromFile = true
+			return
+		}
+		if name == "form" {
+			field, _, _ = strings.Cut(field, ";type=")
+		}
+		p.form[key] = field
+	case "user":
+		if !strings.Contains(value, ":") {
+			p.warn("--user", "curl asks for the password of %s, it was left empty", value)
+			value += ":"
+		}
+		p.headers = append(p.headers, models.RequestHeader{Key: "Authorization", Value: "Basic " + base64.StdEncoding.EncodeToString([]byte(value))})
+	case "oauth2-bearer":
+		p.headers = append(p.headers, models.RequestHeader{Key: "Authorization", Value: "Bearer " + value})
+	case "cookie":
+		if !strings.Contains(value, "=") {
+			p.warn("--cookie", "cookies are read from the file %s, which is not imported", value)
+			return
+		}
+		p.cookie(value)
+	case "user-agent":
+		p.headers = append(p.headers, models.RequestHeader{Key: "User-Agent", Value: value})
+	case "referer":
+		p.headers = append(p.headers, models.RequestHeader{Key: "Referer", Value: value})
+	case "url":
+		p.urls = append(p.urls, value)
+	case "max-time":
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			p.timeout = int(seconds * 1000)
+		}
+	case "insecure":
+		p.insecure = true
+	case "location":
+		p.location = true
+	case "get":
+		p.get = true
+	case "head":
+		p.head = true
+	default:
+		if !ignored[name] {
+			p.warn("--"+name, "option --%s is not supported and was ignored", name)
+		}
+	}
+}
+
+// header adds a -H header. "Name;" sends the header empty, "Name:" removes a header curl would send, and
+// Cookie headers become cookies.
+func (p *parser) header(value string) {
+	key, v, found := strings.Cut(value, ":")
+	if !found {
+		if strings.HasSuffix(key, ";") {
+			p.headers = append(p.headers, models.RequestHeader{Key: strings.TrimSpace(strings.TrimSuffix(key, ";"))})
+		}
+		return
+	}
+
+	key, v = strings.TrimSpace(key), strings.TrimSpace(v)
+	if v == "" {
+		return
+	}
+
+	if strings.EqualFold(key, "cookie") {
+		p.cookie(v)
+		return
+	}
+	p.headers = append(p.headers, models.RequestHeader{Key: key, Value: v})
+}
+
+func (p *parser) cookie(value string) {
+	for _, pair := range strings.Split(value, ";") {
+		key, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if key != "" {
+			p.cookies = append(p.cookies, models.RequestCookie{Key: key, Value: v})
+		}
+	}
+}
+
+// request builds the request the options describe, with curl's defaults for method and content type.
+func (p *parser) request() models.RequestWithDetail {
+	req := models.RequestWithDetail{}
+	req.Timeout = importing.DEFAULT_TIMEOUT_MS
+	if p.timeout > 0 {
+		req.Timeout = p.timeout
+	}
+	req.AllowRedirects = p.location
+	req.SSLVerification = !p.insecure
+	req.EncodeURL = true
+	req.BodyFormat = "raw"
+	req.Headers = []models.RequestHeader{}
+	req.QueryParams = []models.RequestQueryParam{}
+	req.Cookies = p.cookies
+	if req.Cookies == nil {
+		req.Cookies = []models.RequestCookie{}
+	}
+	req.Scripts = []models.RequestScript{}
+	req.Assertions = []models.RequestAssertion{}
+	req.SkipConditions = []models.SkipCondition{}
+
+	rawURL, _, _ := strings.Cut(p.urls[0], "#")
+	rawURL, rawQuery, _ := strings.Cut(rawURL, "?")
+
+	body := strings.Join(p.data, "&")
+	if p.get && body != "" {
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += body
+		body = ""
+	}
+
+	if rawQuery != "" {
+		for _, pair := range strings.Split(rawQuery, "&") {
+			if pair == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(pair, "=")
+			req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: importing.Unescape(key), Value: importing.Unescape(value)})
+		}
+	}
+	req.URL = rawURL
+
+	switch {
+	case p.method != "":
+		req.Method = p.method
+	case p.head:
+		req.Method = "HEAD"
+	case p.get:
+		req.Method = "GET"
+	case body != "" || len(p.form) > 0 || p.fromFile:
+		req.Method = "POST"
+	default:
+		req.Method = "GET"
+	}
+
+	req.Headers = append(req.Headers, p.headers...)
+	contentType := ""
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Key, "content-type") {
+			contentType = h.Value
+		}
+	}
+
+	switch {
+	case len(p.form) > 0:
+		encoded, _ := json.Marshal(p.form)
+		req.Body = string(encoded)
+		req.BodyFormat = "form-data"
+		if body != "" {
+			p.warn("--data", "curl cannot send --data and --form together, the data was skipped")
+		}
+		// The boundary is chosen when the request is sent.
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	case body == "":
+	case p.json:
+		req.Body = body
+		req.BodyFormat = "JSON"
+		if contentType == "" {
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Accept", Value: "application/json"})
+		}
+	default:
+		req.Body = body
+		base := importing.MediaType(contentType)
+		switch {
+		case base == "":
+			// curl sends --data as a form unless told otherwise.
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+		case base == "application/json" || strings.HasSuffix(base, "+json"):
+			req.BodyFormat = "JSON"
+		case base == "application/xml" || base == "text/xml" || strings.HasSuffix(base, "+xml"):
+			req.BodyFormat = "XML"
+		}
+	}
+
+	// JSON and XML bodies get their Content-Type from the body format.
+	if req.BodyFormat == "JSON" && strings.EqualFold(contentType, "application/json") {
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	}
+	if req.BodyFormat == "XML" && strings.EqualFold(contentType, "application/xml") {
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	}
+
+	req.Name = req.Method + " " + importing.RequestName(rawURL)
+	return req
+}
+
+// urlencode encodes a --data-urlencode argument: "name=value" and "=value" encode the value, anything else whole.
+func urlencode(value string) string {
+	if name, content, ok := strings.Cut(value, "="); ok {
+		if name == "" {
+			return neturl.QueryEscape(content)
+		}
+		return name + "=" + neturl.QueryEscape(content)
+	}
+	return neturl.QueryEscape(value)
+}
@@ -28,6 +28,42 @@ CREATE TABLE IF NOT EXISTS collection_variables (
     UNIQUE (collection_id, key)
 );
 
+CREATE TABLE IF NOT EXISTS collection_script_modules (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection_id               INTEGER NOT NULL,
+    name                        TEXT NOT NULL,
+    script                      TEXT NOT NULL,
+    version                     INTEGER NOT NULL DEFAULT 1,
+    created_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    UNIQUE (collection_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS collection_schemas (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection_id               INTEGER NOT NULL,
+    name                        TEXT NOT NULL,
+    schema                      TEXT NOT NULL, -- JSON Schema (draft 2020-12)
+    source                      TEXT CHECK(source IN ('manual', 'file', 'openapi')) DEFAULT 'manual',
+    created_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    UNIQUE (collection_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS collection_data_files (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection_id               INTEGER NOT NULL,
+    name                        TEXT NOT NULL,
+    format                      TEXT CHECK(format IN ('csv', 'json')) NOT NULL,
+    content                     TEXT NOT NULL, -- Iteration data for the collection runner, one iteration per row
+    created_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    UNIQUE (collection_id, name)
+);
+
 CREATE TABLE IF NOT EXISTS requests (
     id                          INTEGER PRIMARY KEY AUTOINCREMENT,
     collection_id               INTEGER, -- Nullable to allow standalone requests
@@ -93,7 +129,8 @@ CREATE TABLE IF NOT EXISTS environment_variables (
 
 CREATE TABLE IF NOT EXISTS request_history (
     id                          INTEGER PRIMARY KEY AUTOINCREMENT,
-    request_id                  INTEGER NOT NULL,
+    request_id                  INTEGER, -- Nullable for ad-hoc requests sent from scripts
+    parent_id                   INTEGER, -- Set for requests sent from another request's scripts
     timestamp                   DATETIME DEFAULT CURRENT_TIMESTAMP,
     method                      TEXT NOT NULL,
     url                         TEXT NOT NULL,
@@ -103,18 +140,23 @@ CREATE TABLE IF NOT EXISTS request_history (
     status_code                 INTEGER,
     response_time               INTEGER,
     data_volume                 INTEGER,
-    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
+    response_snippet            TEXT, -- Beginning of the response body, for reports
+    error                       TEXT, -- Transport or script error, when the request failed
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id)     REFERENCES request_history(id) ON DELETE SET NULL
 );
 
 -- Trigger to enforce a maximum of 300 history records
-CREATE TRIGGER IF NOT EXISTS limit_request_history
+-- (a negative LIMIT means "no limit" in SQLite, hence the MAX; dropped first so existing databases get the fix)
+DROP TRIGGER IF EXISTS limit_request_history;
+CREATE TRIGGER limit_request_history
 AFTER INSERT ON request_history
 BEGIN
     DELETE FROM request_history
     WHERE id IN (
         SELECT id FROM request_history
-        ORDER BY timestamp ASC
-        LIMIT (SELECT COUNT(*) - 300 FROM request_history)
+        ORDER BY timestamp ASC, id ASC
+        LIMIT MAX(0, (SELECT COUNT(*) - 300 FROM request_history))
     );
 END;
 
@@ -139,19 +181,133 @@ CREATE TABLE IF NOT EXISTS request_examples (
 CREATE TABLE IF NOT EXISTS request_scripts (
     id                          INTEGER PRIMARY KEY AUTOINCREMENT,
     request_id                  INTEGER NOT NULL,
+    phase                       TEXT CHECK(phase IN ('pre-request', 'post-response')) DEFAULT 'pre-request',
     script                      TEXT NOT NULL,
     FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS request_assertions (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    request_id                  INTEGER NOT NULL,
+    position                    INTEGER NOT NULL,
+    name                        TEXT, -- Optional, generated from the assertion when empty
+    type                        TEXT CHECK(type IN ('status', 'header', 'jsonpath', 'xpath', 'body', 'response_time', 'body_size', 'json_schema', 'example')) NOT NULL,
+    target                      TEXT, -- Header name, JSONPath or XPath expression, name of a collection schema or id of a request example
+    operator                    TEXT CHECK(operator IN ('equals', 'in', 'in_range', 'exists', 'matches', 'contains', 'type', 'below', 'above', 'valid')) NOT NULL,
+    expected                    TEXT, -- Inline JSON Schema for json_schema assertions, ignore rules (JSON) for example assertions
+    enabled                     BOOLEAN DEFAULT TRUE,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS request_skip_conditions (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    request_id                  INTEGER NOT NULL,
+    position                    INTEGER NOT NULL,
+    variable                    TEXT NOT NULL, -- Name of the variable the condition looks at
+    operator                    TEXT CHECK(operator IN ('equals', 'not_equals', 'exists', 'not_exists', 'contains', 'matches')) NOT NULL,
+    value                       TEXT, -- May reference other {{variables}}
+    enabled                     BOOLEAN DEFAULT TRUE,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
+);
+
 CREATE TABLE IF NOT EXISTS test_results (
     id                          INTEGER PRIMARY KEY AUTOINCREMENT,
     request_id                  INTEGER NOT NULL,
+    history_id                  INTEGER, -- The execution that produced the result
+    source                      TEXT CHECK(source IN ('script', 'assertion')) DEFAULT 'script',
     test_name                   TEXT NOT NULL,
-    result                      TEXT,
+    passed                      BOOLEAN NOT NULL DEFAULT FALSE,
+    result                      TEXT, -- Failure message, empty when passed
+    diff                        TEXT, -- JSON list of differences for example assertions
     created_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE,
+    FOREIGN KEY (history_id)    REFERENCES request_history(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS collection_runs (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection_id               INTEGER NOT NULL,
+    folder_id                   INTEGER, -- Set when only a folder was run
+    environment_id              INTEGER, -- The environment the run used, if any
+    data_file_id                INTEGER,
+    status                      TEXT CHECK(status IN ('running', 'paused', 'stopped', 'completed')) NOT NULL,
+    total                       INTEGER NOT NULL DEFAULT 0, -- Requests planned over all iterations
+    total_iterations            INTEGER NOT NULL DEFAULT 1,
+    passed                      INTEGER NOT NULL DEFAULT 0,
+    failed                      INTEGER NOT NULL DEFAULT 0,
+    tests_passed                INTEGER NOT NULL DEFAULT 0,
+    tests_failed                INTEGER NOT NULL DEFAULT 0,
+    skipped                     INTEGER NOT NULL DEFAULT 0,
+    error                       TEXT, -- Why the run ended early, e.g. a flow that loops
+    iteration_data              TEXT, -- JSON list of the data row of every iteration
+    steps                       TEXT, -- JSON list of every step taken, in order
+    duration                    INTEGER NOT NULL DEFAULT 0, -- Milliseconds
+    started_at                  DATETIME NOT NULL,
+    finished_at                 DATETIME,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (folder_id)     REFERENCES folders(id) ON DELETE SET NULL,
+    FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE SET NULL,
+    FOREIGN KEY (data_file_id)  REFERENCES collection_data_files(id) ON DELETE SET NULL
 );
 
+CREATE TABLE IF NOT EXISTS collection_run_results (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id                      INTEGER NOT NULL,
+    iteration                   INTEGER NOT NULL,
+    position                    INTEGER NOT NULL, -- Order within the iteration
+    request_id                  INTEGER NOT NULL, -- Not a foreign key, past runs keep deleted requests
+    history_id                  INTEGER, -- Not a foreign key either, history is pruned
+    name                        TEXT NOT NULL,
+    method                      TEXT NOT NULL,
+    url                         TEXT,
+    status_code                 INTEGER,
+    response_time               INTEGER,
+    data_volume                 INTEGER,
+    passed                      BOOLEAN NOT NULL DEFAULT FALSE,
+    error                       TEXT,
+    tests                       TEXT, -- JSON list of test and assertion outcomes
+    FOREIGN KEY (run_id)        REFERENCES collection_runs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_runs_collection ON collection_runs(collection_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_collection_run_results_run ON collection_run_results(run_id, iteration, position);
+
+CREATE TABLE IF NOT EXISTS load_tests (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection_id               INTEGER NOT NULL,
+    folder_id                   INTEGER, -- Set when a folder was tested
+    request_id                  INTEGER, -- Set when a single request was tested
+    environment_id              INTEGER,
+    name                        TEXT NOT NULL, -- The request or folder under test, as named when it ran
+    virtual_users               INTEGER NOT NULL,
+    rate_per_second             REAL NOT NULL DEFAULT 0, -- 0 when virtual users sent back to back
+    duration_ms                 INTEGER NOT NULL, -- As requested
+    status                      TEXT CHECK(status IN ('running', 'stopped', 'completed')) NOT NULL,
+    requests                    INTEGER NOT NULL DEFAULT 0,
+    errors                      INTEGER NOT NULL DEFAULT 0,
+    dropped                     INTEGER NOT NULL DEFAULT 0,
+    bytes_sent                  INTEGER NOT NULL DEFAULT 0,
+    bytes_received              INTEGER NOT NULL DEFAULT 0,
+    throughput                  REAL NOT NULL DEFAULT 0, -- Completed requests per second
+    latency_min                 REAL NOT NULL DEFAULT 0, -- Latencies in milliseconds
+    latency_mean                REAL NOT NULL DEFAULT 0,
+    latency_p50                 REAL NOT NULL DEFAULT 0,
+    latency_p90                 REAL NOT NULL DEFAULT 0,
+    latency_p99                 REAL NOT NULL DEFAULT 0,
+    latency_max                 REAL NOT NULL DEFAULT 0,
+    timeline                    TEXT, -- JSON list of per-second requests, errors, bytes and latency
+    error_breakdown             TEXT, -- JSON list of failures by status or transport error
+    duration                    INTEGER NOT NULL DEFAULT 0, -- Milliseconds, as it ran
+    started_at                  DATETIME NOT NULL,
+    finished_at                 DATETIME,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (folder_id)     REFERENCES folders(id) ON DELETE SET NULL,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE SET NULL,
+    FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_load_tests_collection ON load_tests(collection_id, started_at);
+
 CREATE TABLE IF NOT EXISTS sync_metadata (
     id                          INTEGER PRIMARY KEY AUTOINCREMENT,
     entity_type                 TEXT CHECK(entity_type IN ('requests', 'collections', 'variables', 'history')),
@@ -160,6 +316,94 @@ CREATE TABLE IF NOT EXISTS sync_metadata (
     is_dirty                    BOOLEAN DEFAULT FALSE
 );
 
+CREATE TABLE IF NOT EXISTS monitors (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection_id               INTEGER NOT NULL,
+    folder_id                   INTEGER, -- Set when only a folder is monitored
+    environment_id              INTEGER, -- NULL uses the environment selected when the monitor runs
+    name                        TEXT NOT NULL,
+    interval_minutes            INTEGER NOT NULL CHECK(interval_minutes > 0),
+    enabled                     BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures        INTEGER NOT NULL DEFAULT 0,
+    last_status                 TEXT CHECK(last_status IN ('passing', 'failing')),
+    last_run_id                 INTEGER, -- Not a foreign key, run history can be deleted
+    last_run_at                 DATETIME,
+    created_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (folder_id)     REFERENCES folders(id) ON DELETE CASCADE,
+    FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS workflows (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name                        TEXT NOT NULL,
+    description                 TEXT,
+    environment_id              INTEGER, -- NULL uses the environment selected when the workflow runs
+    created_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS workflow_nodes (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    workflow_id                 INTEGER NOT NULL,
+    request_id                  INTEGER, -- NULL once the request is deleted; the node keeps its edges and fails when run
+    name                        TEXT NOT NULL, -- How edges and mappings refer to the node
+    position_x                  REAL DEFAULT 0,
+    position_y                  REAL DEFAULT 0,
+    FOREIGN KEY (workflow_id)   REFERENCES workflows(id) ON DELETE CASCADE,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE SET NULL,
+    UNIQUE (workflow_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS workflow_edges (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    workflow_id                 INTEGER NOT NULL,
+    from_node_id                INTEGER NOT NULL,
+    to_node_id                  INTEGER NOT NULL CHECK(to_node_id <> from_node_id),
+    FOREIGN KEY (workflow_id)   REFERENCES workflows(id) ON DELETE CASCADE,
+    FOREIGN KEY (from_node_id)  REFERENCES workflow_nodes(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_node_id)    REFERENCES workflow_nodes(id) ON DELETE CASCADE,
+    UNIQUE (from_node_id, to_node_id)
+);
+
+CREATE TABLE IF NOT EXISTS workflow_mappings (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    edge_id                     INTEGER NOT NULL,
+    position                    INTEGER NOT NULL,
+    source                      TEXT NOT NULL, -- Value of the edge's source response, e.g. "body.token", "header.Location", "status"
+    target                      TEXT NOT NULL, -- Where it goes in the target request, e.g. "header.Authorization", "variable.token"
+    FOREIGN KEY (edge_id)       REFERENCES workflow_edges(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    workflow_id                 INTEGER NOT NULL,
+    environment_id              INTEGER, -- The environment the run used, if any
+    status                      TEXT CHECK(status IN ('running', 'stopped', 'completed')) NOT NULL,
+    passed                      INTEGER DEFAULT 0,
+    failed                      INTEGER DEFAULT 0,
+    skipped                     INTEGER DEFAULT 0,
+    nodes                       TEXT, -- JSON list of node results with their inputs and outputs
+    duration                    INTEGER DEFAULT 0, -- milliseconds
+    started_at                  DATETIME NOT NULL,
+    finished_at                 DATETIME,
+    FOREIGN KEY (workflow_id)   REFERENCES workflows(id) ON DELETE CASCADE,
+    FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS request_sources (
+    request_id                  INTEGER PRIMARY KEY,
+    collection_id               INTEGER NOT NULL,
+    source                      TEXT NOT NULL, -- The format the request was generated from, e.g. 'openapi'
+    operation                   TEXT NOT NULL, -- e.g. 'GET /users/{id}'
+    fingerprint                 TEXT NOT NULL, -- Hash of the request as generated, to tell whether it was edited since
+    imported_at                 DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE,
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    UNIQUE (collection_id, source, operation)
+);
+
 CREATE TABLE IF NOT EXISTS keyboard_shortcuts (
     id                          INTEGER PRIMARY KEY AUTOINCREMENT,
     action                      TEXT NOT NULL UNIQUE,
@@ -1,7 +1,8 @@
 package database
 
 import (
-	"embed"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
@@ -18,36 +19,62 @@ import (
 
 // InitializeDB creates the database file and applies its schema from db-schema.sql.
 // The database is created at user config directory
-func InitializeDB(schemaEmbed embed.FS) (*sqlx.DB, error) {
+func InitializeDB(schemaEmbed fs.ReadFileFS) (*sqlx.DB, error) {
+	dbPath, err := DefaultDBPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return InitializeDBAt(schemaEmbed, dbPath)
+}
+
+// DefaultDBPath returns the location of the app's database in the user config directory, creating the directory.
+func DefaultDBPath() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrGetUserConfigDirectory, err)
+		return "", errors.Wrap(errors.ErrGetUserConfigDirectory, err)
 	}
 
 	appDir := filepath.Join(configDir, strings.ToLower(config.APP_NAME), "db")
 	if err := os.MkdirAll(appDir, 0755); err != nil {
-		return nil, errors.Wrap(errors.ErrCreateAppDirectory, err)
+		return "", errors.Wrap(errors.ErrCreateAppDirectory, err)
 	}
 
-	var dbPath string
-
 	if common.IsInDevelopmentMode() {
-		dbPath = filepath.Join(appDir, strings.ToLower(config.APP_NAME)+"_DEV"+".sqlite")
-	} else {
-		dbPath = filepath.Join(appDir, strings.ToLower(config.APP_NAME)+".sqlite")
+		return filepath.Join(appDir, strings.ToLower(config.APP_NAME)+"_DEV"+".sqlite"), nil
 	}
 
-	db, err := sqlx.Open("sqlite", dbPath)
+	return filepath.Join(appDir, strings.ToLower(config.APP_NAME)+".sqlite"), nil
+}
+
+// BUSY_TIMEOUT_MS is how long a connection waits for another one's write to finish before failing with SQLITE_BUSY,
+// e.g. while a parallel collection run records several requests at once.
+const BUSY_TIMEOUT_MS int = 5000
+
+// InitializeDBAt opens, or creates, the database at dbPath, migrates it and applies its schema.
+// With ":memory:" the database lives in a single connection, e.g. for tests.
+func InitializeDBAt(schemaEmbed fs.ReadFileFS, dbPath string) (*sqlx.DB, error) {
+	// The pragma goes into the DSN so every pooled connection gets it, not just the one a PRAGMA statement runs on.
+	db, err := sqlx.Open("sqlite", fmt.Sprintf("%s?_pragma=busy_timeout(%d)", dbPath, BUSY_TIMEOUT_MS))
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrOpeningDatabaseFile, err)
 	}
 
+	// Every connection to :memory: opens a database of its own, so the pool must not open a second one.
+	if dbPath == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
 	if err := db.Ping(); err != nil {
 		return nil, errors.Wrap(errors.ErrConnectingDatabase, err)
 	}
 
 	setJournalMode(db)
 
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
 	if err := applySchema(schemaEmbed, db); err != nil {
 		return nil, err
 	}
@@ -68,7 +95,7 @@ func setJournalMode(db *sqlx.DB) {
 }
 
 // applies the database schema from the embedded db-schema.sql
-func applySchema(schemaEmbed embed.FS, db *sqlx.DB) error {
+func applySchema(schemaEmbed fs.ReadFileFS, db *sqlx.DB) error {
 	log.Println("Applying database schema...")
 
 	rawDBSchema, err := schemaEmbed.ReadFile(config.TAPA_DB_SCHEMA_FILE_PATH)
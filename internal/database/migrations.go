@@ -0,0 +1,175 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+)
+
+// migration upgrades the tables of an existing database by one schema version.
+type migration func(tx *sqlx.Tx) error
+
+// migrations add what db-schema.sql changed in tables that already exist, since CREATE TABLE IF NOT EXISTS
+// leaves those as they are. A database's user_version is the number of migrations it went through:
+// append new ones, never change or reorder released ones.
+var migrations = []migration{
+	migrateRequestHistory, // 1: ad-hoc and nested requests in the history, post-response scripts
+	migrateTestResults,    // 2: assertion results linked to the execution that produced them
+}
+
+// migrate brings an existing database up to the latest schema version. It runs before the schema is applied,
+// so the schema recreates the triggers of tables a migration rebuilt. A new database starts at the latest version.
+func migrate(db *sqlx.DB) error {
+	var version int
+	if err := db.Get(&version, `PRAGMA user_version`); err != nil {
+		return errors.Wrap(errors.ErrSchemaMigration, err)
+	}
+
+	if version >= len(migrations) {
+		return nil
+	}
+
+	var tables int
+	if err := db.Get(&tables, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'requests'`); err != nil {
+		return errors.Wrap(errors.ErrSchemaMigration, err)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return errors.Wrap(errors.ErrSchemaMigration, err)
+	}
+	defer tx.Rollback()
+
+	if tables > 0 {
+		for i := version; i < len(migrations); i++ {
+			log.Printf("Migrating database to version %d...", i+1)
+			if err := migrations[i](tx); err != nil {
+				return errors.Wrap(errors.ErrSchemaMigration, fmt.Errorf("version %d: %w", i+1, err))
+			}
+		}
+	}
+
+	// PRAGMA takes no bound parameters.
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(migrations))); err != nil {
+		return errors.Wrap(errors.ErrSchemaMigration, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(errors.ErrSchemaMigration, err)
+	}
+
+	return nil
+}
+
+// migrateRequestHistory makes request_history.request_id nullable, which takes a rebuild of the table, adds the
+// history's parent, response snippet and error, and adds the phase of request scripts.
+func migrateRequestHistory(tx *sqlx.Tx) error {
+	if err := addColumn(tx, "request_scripts", "phase",
+		`TEXT CHECK(phase IN ('pre-request', 'post-response')) DEFAULT 'pre-request'`); err != nil {
+		return err
+	}
+
+	return rebuildTable(tx, "request_history", `
+		CREATE TABLE request_history_new (
+			id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+			request_id                  INTEGER,
+			parent_id                   INTEGER,
+			timestamp                   DATETIME DEFAULT CURRENT_TIMESTAMP,
+			method                      TEXT NOT NULL,
+			url                         TEXT NOT NULL,
+			headers                     TEXT,
+			query_params                TEXT,
+			body                        TEXT,
+			status_code                 INTEGER,
+			response_time               INTEGER,
+			data_volume                 INTEGER,
+			response_snippet            TEXT,
+			error                       TEXT,
+			FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE,
+			FOREIGN KEY (parent_id)     REFERENCES request_history(id) ON DELETE SET NULL
+		)`)
+}
+
+// migrateTestResults adds the execution, source, outcome and example diff of test results.
+func migrateTestResults(tx *sqlx.Tx) error {
+	for _, column := range [][2]string{
+		{"history_id", `INTEGER REFERENCES request_history(id) ON DELETE SET NULL`},
+		{"source", `TEXT CHECK(source IN ('script', 'assertion')) DEFAULT 'script'`},
+		{"passed", `BOOLEAN NOT NULL DEFAULT FALSE`},
+		{"diff", `TEXT`},
+	} {
+		if err := addColumn(tx, "test_results", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// columns returns the names of a table's columns.
+func columns(tx *sqlx.Tx, table string) ([]string, error) {
+	var names []string
+	if err := tx.Select(&names, `SELECT name FROM pragma_table_info(?)`, table); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// addColumn adds a column to a table unless the table already has it.
+func addColumn(tx *sqlx.Tx, table, column, definition string) error {
+	existing, err := columns(tx, table)
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(existing, column) {
+		return nil
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
+// rebuildTable replaces a table with the one create makes as "<table>_new", for changes ALTER TABLE cannot make,
+// e.g. dropping a NOT NULL. The rows keep the columns both tables have. Triggers and indexes of the table are dropped.
+func rebuildTable(tx *sqlx.Tx, table, create string) error {
+	if _, err := tx.Exec(create); err != nil {
+		return err
+	}
+
+	old, err := columns(tx, table)
+	if err != nil {
+		return err
+	}
+
+	rebuilt, err := columns(tx, table+"_new")
+	if err != nil {
+		return err
+	}
+
+	kept := []string{}
+	for _, column := range rebuilt {
+		if slices.Contains(old, column) {
+			kept = append(kept, column)
+		}
+	}
+	list := strings.Join(kept, ", ")
+
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO %s_new (%s) SELECT %s FROM %s`, table, list, list, table),
+		fmt.Sprintf(`DROP TABLE %s`, table),
+		fmt.Sprintf(`ALTER TABLE %s_new RENAME TO %s`, table, table),
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
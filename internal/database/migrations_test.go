@@ -0,0 +1,131 @@
+package database
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+// baseline is the part of the schema the migrations change, as the first release of the app created it.
+const baseline = `
+CREATE TABLE requests (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name                        TEXT NOT NULL
+);
+
+CREATE TABLE request_history (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    request_id                  INTEGER NOT NULL,
+    timestamp                   DATETIME DEFAULT CURRENT_TIMESTAMP,
+    method                      TEXT NOT NULL,
+    url                         TEXT NOT NULL,
+    headers                     TEXT,
+    query_params                TEXT,
+    body                        TEXT,
+    status_code                 INTEGER,
+    response_time               INTEGER,
+    data_volume                 INTEGER,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
+);
+
+CREATE TABLE request_scripts (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    request_id                  INTEGER NOT NULL,
+    script                      TEXT NOT NULL,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
+);
+
+CREATE TABLE test_results (
+    id                          INTEGER PRIMARY KEY AUTOINCREMENT,
+    request_id                  INTEGER NOT NULL,
+    test_name                   TEXT NOT NULL,
+    result                      TEXT,
+    created_at                  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (request_id)    REFERENCES requests(id) ON DELETE CASCADE
+);
+
+INSERT INTO requests (id, name) VALUES (1, 'Ping');
+INSERT INTO request_history (id, request_id, method, url, status_code) VALUES (7, 1, 'GET', 'https://example.com', 200);
+INSERT INTO request_scripts (request_id, script) VALUES (1, 'tapa.test("ok", () => {})');
+INSERT INTO test_results (request_id, test_name, result) VALUES (1, 'ok', 'passed');
+`
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tapa.sqlite")
+
+	old, err := sqlx.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("opening the old database: %v", err)
+	}
+	if _, err := old.Exec(baseline); err != nil {
+		t.Fatalf("creating the old database: %v", err)
+	}
+	old.Close()
+
+	db, err := InitializeDBAt(os.DirFS("../..").(fs.ReadFileFS), path)
+	if err != nil {
+		t.Fatalf("initializing the old database: %v", err)
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.Get(&version, `PRAGMA user_version`); err != nil || version != len(migrations) {
+		t.Errorf("user_version = %d (%v), want %d", version, err, len(migrations))
+	}
+
+	var url string
+	if err := db.Get(&url, `SELECT url FROM request_history WHERE id = 7 AND parent_id IS NULL AND error IS NULL`); err != nil {
+		t.Errorf("reading the migrated history: %v", err)
+	} else if url != "https://example.com" {
+		t.Errorf("history url = %q, want the old one", url)
+	}
+
+	if _, err := db.Exec(`INSERT INTO request_history (request_id, parent_id, method, url, response_snippet) VALUES (NULL, 7, 'GET', 'https://example.com/ad-hoc', '{}')`); err != nil {
+		t.Errorf("recording an ad-hoc request: %v", err)
+	}
+
+	var triggers int
+	if err := db.Get(&triggers, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'limit_request_history'`); err != nil || triggers != 1 {
+		t.Errorf("history limit triggers = %d (%v), want 1", triggers, err)
+	}
+
+	var phase string
+	if err := db.Get(&phase, `SELECT phase FROM request_scripts WHERE request_id = 1`); err != nil || phase != "pre-request" {
+		t.Errorf("script phase = %q (%v), want pre-request", phase, err)
+	}
+
+	var source string
+	var passed bool
+	if err := db.QueryRow(`SELECT source, passed FROM test_results WHERE request_id = 1`).Scan(&source, &passed); err != nil || source != "script" || passed {
+		t.Errorf("test result source = %q, passed = %v (%v); want script and false", source, passed, err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO test_results (request_id, history_id, source, test_name, passed, diff) VALUES (1, 7, 'assertion', 'status', TRUE, '[]')`); err != nil {
+		t.Errorf("recording an assertion result: %v", err)
+	}
+
+	// Opening it again has nothing left to migrate.
+	db.Close()
+	db, err = InitializeDBAt(os.DirFS("../..").(fs.ReadFileFS), path)
+	if err != nil {
+		t.Fatalf("initializing the migrated database: %v", err)
+	}
+	db.Close()
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	db, err := InitializeDBAt(os.DirFS("../..").(fs.ReadFileFS), ":memory:")
+	if err != nil {
+		t.Fatalf("initializing the database: %v", err)
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.Get(&version, `PRAGMA user_version`); err != nil || version != len(migrations) {
+		t.Errorf("user_version = %d (%v), want %d", version, err, len(migrations))
+	}
+}
@@ -195,16 +195,16 @@ func seedRequestHistory(db *sqlx.DB, requestID int, post PlaceholderPost) error
 // The id field is omitted so that autoincrement works.
 func seedRequestScripts(db *sqlx.DB, requestID int, post PlaceholderPost) error {
 	_, err := db.Exec(`
-		INSERT INTO request_scripts (request_id, script) VALUES 
-		(?, ?);
+		INSERT INTO request_scripts (request_id, phase, script) VALUES 
+		(?, 'pre-request', ?);
 	`, requestID, fmt.Sprintf("console.log('Pre-request script for post %d');", post.ID))
 	if err != nil {
 		return fmt.Errorf("failed to insert pre-request script for request '%s': %w", post.Title, err)
 	}
 
 	_, err = db.Exec(`
-		INSERT INTO request_scripts (request_id, script) VALUES 
-		(?, ?);
+		INSERT INTO request_scripts (request_id, phase, script) VALUES 
+		(?, 'post-response', ?);
 	`, requestID, fmt.Sprintf("console.log('Test script for post %d');", post.ID))
 	if err != nil {
 		return fmt.Errorf("failed to insert test script for request '%s': %w", post.Title, err)
@@ -288,9 +288,14 @@ func FlushDB(db *sqlx.DB) error {
 	log.Println("Flushing database...")
 
 	tables := []string{
-		"collections", "folders", "requests", "request_headers", "request_query_params",
-		"request_cookies", "environments", "environment_variables", "collection_variables",
-		"request_history", "request_scripts", "sync_metadata", "keyboard_shortcuts", "user_settings", "app_state",
+		"collections", "folders", "collection_script_modules", "collection_schemas", "collection_data_files",
+		"requests", "request_headers", "request_query_params", "request_cookies", "environments", "environment_variables",
+		"collection_variables",
+		"request_history", "request_scripts", "request_assertions", "request_skip_conditions", "test_results",
+		"collection_runs", "collection_run_results", "load_tests",
+		"sync_metadata", "monitors", "workflows", "workflow_nodes", "workflow_edges", "workflow_mappings", "workflow_runs",
+		"request_sources",
+		"keyboard_shortcuts", "user_settings", "app_state",
 	}
 
 	_, _ = db.Exec("PRAGMA foreign_keys = OFF;")
@@ -15,4 +15,5 @@ var (
 	ErrSchemaCreation      = &TapaError{Code: 2002, Message: "Database schema creation error \n"}
 	ErrOpeningDatabaseFile = &TapaError{Code: 2003, Message: "Opening database file failed \n"}
 	ErrConnectingDatabase  = &TapaError{Code: 2004, Message: "Connecting to database failed \n"}
+	ErrSchemaMigration     = &TapaError{Code: 2005, Message: "Database schema migration error \n"}
 )
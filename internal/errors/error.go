@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 )
 
@@ -24,6 +25,12 @@ func (e *TapaError) Unwrap() error {
 	return e.cause
 }
 
+// Is matches errors by code, so a wrapped error matches the error it was wrapped from.
+func (e *TapaError) Is(target error) bool {
+	t, ok := target.(*TapaError)
+	return ok && t.Code == e.Code
+}
+
 func Wrap(err *TapaError, cause error) error {
 	return &TapaError{
 		Code:    err.Code,
@@ -31,3 +38,8 @@ func Wrap(err *TapaError, cause error) error {
 		cause:   cause,
 	}
 }
+
+// Is reports whether err, or an error it wraps, has the code of target.
+func Is(err error, target *TapaError) bool {
+	return stderrors.Is(err, target)
+}
@@ -0,0 +1,62 @@
+package errors
+
+// ------------- REQUEST EXECUTION ERRORS (4000)
+var (
+	ErrRequestBuild      = &TapaError{Code: 4000, Message: "Building the HTTP request failed \n"}
+	ErrRequestSend       = &TapaError{Code: 4001, Message: "Sending the HTTP request failed \n"}
+	ErrResponseRead      = &TapaError{Code: 4002, Message: "Reading the HTTP response failed \n"}
+	ErrInvalidSubRequest = &TapaError{Code: 4003, Message: "Invalid sub-request \n"}
+)
+
+// ------------- COLLECTION RUNNER ERRORS (4100)
+var (
+	ErrRunTargetNotFound = &TapaError{Code: 4100, Message: "Collection or folder to run not found \n"}
+	ErrRunNotFound       = &TapaError{Code: 4101, Message: "Run not found \n"}
+	ErrReportFormat      = &TapaError{Code: 4102, Message: "Unsupported run report format \n"}
+	ErrReportWrite       = &TapaError{Code: 4103, Message: "Failed writing the run report \n"}
+	ErrRunInProgress     = &TapaError{Code: 4104, Message: "The run is still in progress \n"}
+)
+
+// ------------- LOAD TESTING ERRORS (4200)
+var (
+	ErrLoadTestOptions    = &TapaError{Code: 4200, Message: "Invalid load test options \n"}
+	ErrLoadTestNotFound   = &TapaError{Code: 4201, Message: "Load test not found \n"}
+	ErrLoadTestInProgress = &TapaError{Code: 4202, Message: "The load test is still in progress \n"}
+	ErrLoadTestInsertion  = &TapaError{Code: 4203, Message: "Failed saving load test \n"}
+	ErrLoadTestsRetrieval = &TapaError{Code: 4204, Message: "Failed fetching load tests \n"}
+	ErrLoadTestDeletion   = &TapaError{Code: 4205, Message: "Failed deleting load test \n"}
+)
+
+// ------------- MONITOR ERRORS (4300)
+var (
+	ErrMonitorsRetrieval = &TapaError{Code: 4300, Message: "Failed fetching monitors \n"}
+	ErrMonitorNotFound   = &TapaError{Code: 4301, Message: "Monitor not found \n"}
+	ErrMonitorSave       = &TapaError{Code: 4302, Message: "Failed saving monitor \n"}
+	ErrMonitorDeletion   = &TapaError{Code: 4303, Message: "Failed deleting monitor \n"}
+	ErrInvalidMonitor    = &TapaError{Code: 4304, Message: "Invalid monitor \n"}
+)
+
+// ------------- WORKFLOW ERRORS (4400)
+var (
+	ErrWorkflowsRetrieval     = &TapaError{Code: 4400, Message: "Failed fetching workflows \n"}
+	ErrWorkflowNotFound       = &TapaError{Code: 4401, Message: "Workflow not found \n"}
+	ErrWorkflowSave           = &TapaError{Code: 4402, Message: "Failed saving workflow \n"}
+	ErrWorkflowDeletion       = &TapaError{Code: 4403, Message: "Failed deleting workflow \n"}
+	ErrInvalidWorkflow        = &TapaError{Code: 4404, Message: "Invalid workflow \n"}
+	ErrWorkflowMapping        = &TapaError{Code: 4405, Message: "Workflow mapping failed \n"}
+	ErrWorkflowRunNotFound    = &TapaError{Code: 4406, Message: "Workflow run not found \n"}
+	ErrWorkflowRunInsertion   = &TapaError{Code: 4407, Message: "Failed saving workflow run \n"}
+	ErrWorkflowRunsRetrieval  = &TapaError{Code: 4408, Message: "Failed fetching workflow runs \n"}
+	ErrWorkflowRequestDeleted = &TapaError{Code: 4409, Message: "The request of the workflow node was deleted \n"}
+)
+
+// ------------- SNIPPET ERRORS (4500)
+var (
+	ErrSnippetTarget = &TapaError{Code: 4500, Message: "Unsupported code snippet target \n"}
+)
+
+// ------------- SCRIPT ERRORS (5000)
+var (
+	ErrScriptExecution = &TapaError{Code: 5000, Message: "Script execution failed \n"}
+	ErrScriptTimeout   = &TapaError{Code: 5001, Message: "Script timed out \n"}
+)
@@ -2,7 +2,90 @@ package errors
 
 // ------------- Collection Repository
 var (
-	ErrCollectionsRetrieval      = &TapaError{Code: 3000, Message: "Failed fetching all collections \n"}
-	ErrFoldersRetrieval          = &TapaError{Code: 3001, Message: "Failed fetching all folders \n"}
-	ErrRequestSummariesRetrieval = &TapaError{Code: 3002, Message: "Failed fetching all request summaries \n"}
+	ErrCollectionsRetrieval         = &TapaError{Code: 3000, Message: "Failed fetching all collections \n"}
+	ErrFoldersRetrieval             = &TapaError{Code: 3001, Message: "Failed fetching all folders \n"}
+	ErrRequestSummariesRetrieval    = &TapaError{Code: 3002, Message: "Failed fetching all request summaries \n"}
+	ErrCollectionVariablesRetrieval = &TapaError{Code: 3003, Message: "Failed fetching collection variables \n"}
+)
+
+// ------------- Request Repository
+var (
+	ErrRequestRetrieval           = &TapaError{Code: 3100, Message: "Failed fetching request \n"}
+	ErrRequestNotFound            = &TapaError{Code: 3101, Message: "Request not found \n"}
+	ErrRequestDetailsRetrieval    = &TapaError{Code: 3102, Message: "Failed fetching request headers, params, cookies or scripts \n"}
+	ErrRequestExamplesRetrieval   = &TapaError{Code: 3103, Message: "Failed fetching request examples \n"}
+	ErrRequestAssertionsRetrieval = &TapaError{Code: 3104, Message: "Failed fetching request assertions \n"}
+	ErrRequestAssertionsSave      = &TapaError{Code: 3105, Message: "Failed saving request assertions \n"}
+	ErrRequestExampleNotFound     = &TapaError{Code: 3106, Message: "Request example not found \n"}
+	ErrRequestExampleSave         = &TapaError{Code: 3107, Message: "Failed saving request example \n"}
+	ErrSkipConditionsRetrieval    = &TapaError{Code: 3108, Message: "Failed fetching request skip conditions \n"}
+	ErrSkipConditionsSave         = &TapaError{Code: 3109, Message: "Failed saving request skip conditions \n"}
+	ErrInvalidSkipCondition       = &TapaError{Code: 3110, Message: "Invalid skip condition \n"}
+)
+
+// ------------- Environment Repository
+var (
+	ErrEnvironmentVariablesRetrieval = &TapaError{Code: 3200, Message: "Failed fetching environment variables \n"}
+	ErrSelectedEnvironmentRetrieval  = &TapaError{Code: 3201, Message: "Failed fetching the selected environment \n"}
+	ErrEnvironmentsRetrieval         = &TapaError{Code: 3202, Message: "Failed fetching environments \n"}
+	ErrEnvironmentNotFound           = &TapaError{Code: 3203, Message: "Environment not found \n"}
+)
+
+// ------------- History Repository
+var (
+	ErrHistoryInsertion = &TapaError{Code: 3300, Message: "Failed saving request history \n"}
+	ErrHistoryLinking   = &TapaError{Code: 3301, Message: "Failed linking sub-request history to its parent \n"}
+	ErrHistoryRetrieval = &TapaError{Code: 3302, Message: "Failed fetching request history \n"}
+)
+
+// ------------- Script Module Repository
+var (
+	ErrScriptModulesRetrieval = &TapaError{Code: 3400, Message: "Failed fetching script modules \n"}
+	ErrScriptModuleNotFound   = &TapaError{Code: 3401, Message: "Script module not found \n"}
+	ErrScriptModuleSave       = &TapaError{Code: 3402, Message: "Failed saving script module \n"}
+	ErrScriptModuleDeletion   = &TapaError{Code: 3403, Message: "Failed deleting script module \n"}
+)
+
+// ------------- Collection Import / Export
+var (
+	ErrCollectionExport        = &TapaError{Code: 3500, Message: "Failed exporting collection \n"}
+	ErrCollectionImport        = &TapaError{Code: 3501, Message: "Failed importing collection \n"}
+	ErrUnsupportedExportFormat = &TapaError{Code: 3502, Message: "Unsupported collection export version \n"}
+	ErrImportFileRead          = &TapaError{Code: 3503, Message: "Failed reading import file \n"}
+	ErrInvalidImport           = &TapaError{Code: 3504, Message: "Unrecognized or invalid import file \n"}
+	ErrImportTargetNotFound    = &TapaError{Code: 3505, Message: "Collection to merge the import into not found \n"}
+	ErrHARExport               = &TapaError{Code: 3506, Message: "Failed exporting HAR file \n"}
+)
+
+// ------------- Test Results Repository
+var (
+	ErrTestResultsInsertion = &TapaError{Code: 3600, Message: "Failed saving test results \n"}
+	ErrTestResultsRetrieval = &TapaError{Code: 3601, Message: "Failed fetching test results \n"}
+)
+
+// ------------- Collection Schema Repository
+var (
+	ErrCollectionSchemasRetrieval = &TapaError{Code: 3700, Message: "Failed fetching collection schemas \n"}
+	ErrCollectionSchemaSave       = &TapaError{Code: 3701, Message: "Failed saving collection schema \n"}
+	ErrCollectionSchemaDeletion   = &TapaError{Code: 3702, Message: "Failed deleting collection schema \n"}
+	ErrSchemaFileRead             = &TapaError{Code: 3703, Message: "Failed reading schema file \n"}
+	ErrInvalidSchema              = &TapaError{Code: 3704, Message: "Invalid JSON Schema \n"}
+)
+
+// ------------- Collection Data File Repository
+var (
+	ErrCollectionDataFilesRetrieval = &TapaError{Code: 3800, Message: "Failed fetching collection data files \n"}
+	ErrCollectionDataFileNotFound   = &TapaError{Code: 3801, Message: "Collection data file not found \n"}
+	ErrCollectionDataFileSave       = &TapaError{Code: 3802, Message: "Failed saving collection data file \n"}
+	ErrCollectionDataFileDeletion   = &TapaError{Code: 3803, Message: "Failed deleting collection data file \n"}
+	ErrDataFileRead                 = &TapaError{Code: 3804, Message: "Failed reading data file \n"}
+	ErrInvalidDataFile              = &TapaError{Code: 3805, Message: "Invalid data file \n"}
+)
+
+// ------------- Collection Run Repository
+var (
+	ErrCollectionRunInsertion  = &TapaError{Code: 3900, Message: "Failed saving collection run \n"}
+	ErrCollectionRunsRetrieval = &TapaError{Code: 3901, Message: "Failed fetching collection runs \n"}
+	ErrCollectionRunNotFound   = &TapaError{Code: 3902, Message: "Collection run not found \n"}
+	ErrCollectionRunDeletion   = &TapaError{Code: 3903, Message: "Failed deleting collection run \n"}
 )
@@ -0,0 +1,501 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/assertions"
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/scripting"
+	"github.com/jmoiron/sqlx"
+)
+
+type RequestsRepository interface {
+	GetRequestWithDetail(id int) (models.RequestWithDetail, error)
+	GetRequestExamplesByIDs(ids []int) ([]models.RequestExample, error)
+	FindRequestIDByName(name string, collectionID *int) (int, error)
+	GetRequestSkipConditions(requestID int) ([]models.SkipCondition, error)
+}
+
+type CollectionsRepository interface {
+	GetCollectionVariables(collectionID int) ([]models.CollectionVariable, error)
+}
+
+type EnvironmentsRepository interface {
+	GetEnvironmentVariables(environmentID int) ([]models.EnvironmentVariable, error)
+	GetSelectedEnvironmentID() (*int, error)
+}
+
+type CollectionSchemasRepository interface {
+	GetCollectionSchemas(collectionID int) ([]models.CollectionSchema, error)
+}
+
+type ScriptModulesRepository interface {
+	GetScriptModule(collectionID int, name string) (models.ScriptModule, error)
+}
+
+type HistoryRepository interface {
+	InsertHistory(h models.RequestHistory) (int, error)
+	LinkHistoryToParent(parentID int, childIDs []int) error
+}
+
+type TestResultsRepository interface {
+	InsertTestResults(results []models.TestResult) error
+}
+
+// Executor sends stored and ad-hoc requests, runs their scripts and records them in the history.
+type Executor struct {
+	requests     RequestsRepository
+	collections  CollectionsRepository
+	environments EnvironmentsRepository
+	modules      ScriptModulesRepository
+	schemas      CollectionSchemasRepository
+	history      HistoryRepository
+	testResults  TestResultsRepository
+	transports   *transports
+}
+
+// Session carries the state shared by consecutive executions: variables and cookies.
+// A single send from the UI uses its own session, a collection run shares one between requests.
+type Session struct {
+	EnvironmentID *int
+	Variables     *Variables
+	jar           http.CookieJar
+	hosts         *hostLimiter // nil without a per-host cap
+}
+
+// NewSession creates a session for the given environment.
+// When environmentID is nil the environment currently selected in the UI is used, if any.
+func (e *Executor) NewSession(environmentID *int) (*Session, error) {
+	if environmentID == nil {
+		selected, err := e.environments.GetSelectedEnvironmentID()
+		if err != nil {
+			return nil, err
+		}
+		environmentID = selected
+	}
+
+	var envVars []models.EnvironmentVariable
+	if environmentID != nil {
+		vars, err := e.environments.GetEnvironmentVariables(*environmentID)
+		if err != nil {
+			return nil, err
+		}
+		envVars = vars
+	}
+
+	jar, _ := cookiejar.New(nil)
+
+	return &Session{
+		EnvironmentID: environmentID,
+		Variables:     NewVariables(envVars),
+		jar:           jar,
+	}, nil
+}
+
+// Fork returns a session with a copy of the variables that shares the cookies and the per-host cap.
+// Variables set in one fork are not seen by the others, e.g. in parallel branches of a workflow.
+func (s *Session) Fork() *Session {
+	return &Session{
+		EnvironmentID: s.EnvironmentID,
+		Variables:     s.Variables.clone(),
+		jar:           s.jar,
+		hosts:         s.hosts,
+	}
+}
+
+// Execute runs a stored request: pre-request scripts, the HTTP call, history, post-response scripts and assertions.
+// The returned error is only set when the request could not be loaded or recorded;
+// transport and script failures end up in the result's Error field next to the console output.
+func (e *Executor) Execute(ctx context.Context, session *Session, requestID int) (*models.ExecutionResult, error) {
+	return e.ExecuteWith(ctx, session, requestID, Overrides{})
+}
+
+// ExecuteWith runs a stored request like Execute, with parts of it replaced by overrides before its scripts run.
+func (e *Executor) ExecuteWith(ctx context.Context, session *Session, requestID int, overrides Overrides) (*models.ExecutionResult, error) {
+	detail, err := e.requests.GetRequestWithDetail(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := e.scopeFor(session, detail.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	exec := &execution{
+		executor:     e,
+		session:      session,
+		vars:         vars,
+		collectionID: detail.CollectionID,
+		console:      []models.ConsoleEntry{},
+		subRequests:  []models.ExecutionResult{},
+		tests:        []models.TestOutcome{},
+	}
+
+	out := outgoingFromRequest(detail)
+	if err := overrides.apply(out); err != nil {
+		return exec.finish(&models.ExecutionResult{
+			RequestID:      &detail.ID,
+			Name:           detail.Name,
+			Method:         out.method,
+			URL:            vars.resolve(out.url),
+			RequestHeaders: map[string]string{},
+			Error:          err.Error(),
+			StartedAt:      time.Now(),
+		}), nil
+	}
+
+	scriptRequest := &scripting.ScriptRequest{
+		Method:  out.method,
+		URL:     out.url,
+		Body:    out.body,
+		Headers: out.headers,
+	}
+
+	for _, script := range detail.Scripts {
+		if script.Phase != models.ScriptPhasePreRequest {
+			continue
+		}
+
+		if err := scripting.Run(ctx, script.Script, exec, &scripting.Scope{Request: scriptRequest}); err != nil {
+			exec.Log(models.ConsoleLevelError, err.Error())
+			result := &models.ExecutionResult{
+				RequestID:      &detail.ID,
+				Name:           detail.Name,
+				Method:         out.method,
+				URL:            vars.resolve(out.url),
+				RequestHeaders: map[string]string{},
+				Error:          err.Error(),
+				StartedAt:      time.Now(),
+			}
+
+			// Sub-requests sent by earlier scripts need a parent, so the failed execution is recorded for them.
+			if len(exec.childHistoryIDs) > 0 {
+				if err := exec.recordParent(result); err != nil {
+					return nil, err
+				}
+			}
+
+			return exec.finish(result), nil
+		}
+	}
+
+	out.method = scriptRequest.Method
+	out.url = scriptRequest.URL
+	out.body = scriptRequest.Body
+	out.headers = scriptRequest.Headers
+
+	result := e.send(ctx, session, vars, out)
+
+	if err := exec.recordParent(result); err != nil {
+		return nil, err
+	}
+	historyID := result.HistoryID
+
+	if result.Error == "" {
+		for _, script := range detail.Scripts {
+			if script.Phase != models.ScriptPhasePostResponse {
+				continue
+			}
+
+			if err := scripting.Run(ctx, script.Script, exec, &scripting.Scope{Response: result}); err != nil {
+				exec.Log(models.ConsoleLevelError, err.Error())
+				result.Error = err.Error()
+				break
+			}
+		}
+	}
+
+	refs, err := e.assertionReferences(detail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assertions may reference variables, e.g. the columns of a data-driven run.
+	resolved := make([]models.RequestAssertion, len(detail.Assertions))
+	for i, a := range detail.Assertions {
+		a.Target = vars.resolve(a.Target)
+		a.Expected = vars.resolve(a.Expected)
+		resolved[i] = a
+	}
+
+	exec.tests = append(exec.tests, assertions.EvaluateAll(resolved, result, refs)...)
+	if err := e.recordTests(detail.ID, historyID, exec.tests); err != nil {
+		return nil, err
+	}
+
+	return exec.finish(result), nil
+}
+
+// assertionReferences loads the schemas and examples the request's assertions check against, if any.
+func (e *Executor) assertionReferences(detail models.RequestWithDetail) (assertions.References, error) {
+	refs := assertions.References{Examples: map[int]models.RequestExample{}}
+
+	if detail.CollectionID != nil && assertions.NeedsSchemas(detail.Assertions) {
+		schemas, err := e.schemas.GetCollectionSchemas(*detail.CollectionID)
+		if err != nil {
+			return refs, err
+		}
+		refs.Schemas = schemas
+	}
+
+	if ids := assertions.ExampleIDs(detail.Assertions); len(ids) > 0 {
+		examples, err := e.requests.GetRequestExamplesByIDs(ids)
+		if err != nil {
+			return refs, err
+		}
+		for _, example := range examples {
+			refs.Examples[example.ID] = example
+		}
+	}
+
+	return refs, nil
+}
+
+// recordTests stores the script tests and assertion outcomes of an execution in test_results.
+func (e *Executor) recordTests(requestID, historyID int, tests []models.TestOutcome) error {
+	results := make([]models.TestResult, 0, len(tests))
+	for _, t := range tests {
+		results = append(results, models.TestResult{
+			RequestID: requestID,
+			HistoryID: &historyID,
+			Source:    t.Source,
+			TestName:  t.Name,
+			Passed:    t.Passed,
+			Result:    t.Message,
+			Diff:      diffJSON(t.Diff),
+		})
+	}
+
+	return e.testResults.InsertTestResults(results)
+}
+
+// diffJSON encodes the differences of an example assertion for test_results, empty when there are none.
+func diffJSON(diff []models.DiffEntry) string {
+	if len(diff) == 0 {
+		return ""
+	}
+	return toJSON(diff)
+}
+
+// scopeFor layers the collection's variables, if any, underneath the session variables.
+func (e *Executor) scopeFor(session *Session, collectionID *int) (*variableScope, error) {
+	var collectionVars []models.CollectionVariable
+	if collectionID != nil {
+		vars, err := e.collections.GetCollectionVariables(*collectionID)
+		if err != nil {
+			return nil, err
+		}
+		collectionVars = vars
+	}
+
+	return session.Variables.scope(collectionVars), nil
+}
+
+// record stores a sent request in the history.
+func (e *Executor) record(result *models.ExecutionResult, parentID *int) (int, error) {
+	return e.history.InsertHistory(models.RequestHistory{
+		RequestID:       result.RequestID,
+		ParentID:        parentID,
+		Timestamp:       result.StartedAt,
+		Method:          result.Method,
+		URL:             result.URL,
+		Headers:         toJSON(result.RequestHeaders),
+		QueryParams:     toJSON(queryParamsOf(result.URL)),
+		Body:            result.RequestBody,
+		StatusCode:      result.StatusCode,
+		ResponseTime:    result.ResponseTime,
+		DataVolume:      result.DataVolume,
+		ResponseSnippet: snippet(result.ResponseBody),
+		Error:           result.Error,
+	})
+}
+
+// ExampleFromResult turns a received response into a request example, stored the same way as the history.
+func ExampleFromResult(result *models.ExecutionResult) models.RequestExample {
+	example := models.RequestExample{
+		Timestamp:       result.StartedAt,
+		Method:          result.Method,
+		URL:             result.URL,
+		Headers:         toJSON(result.RequestHeaders),
+		QueryParams:     toJSON(queryParamsOf(result.URL)),
+		Body:            result.RequestBody,
+		StatusCode:      result.StatusCode,
+		Response:        result.ResponseBody,
+		ResponseHeaders: toJSON(result.ResponseHeaders),
+		ResponseTime:    result.ResponseTime,
+		DataVolume:      result.DataVolume,
+	}
+
+	if result.RequestID != nil {
+		example.RequestID = *result.RequestID
+	}
+
+	return example
+}
+
+// execution is the scripting host for a single Execute call.
+type execution struct {
+	executor        *Executor
+	session         *Session
+	vars            *variableScope
+	collectionID    *int
+	console         []models.ConsoleEntry
+	subRequests     []models.ExecutionResult
+	tests           []models.TestOutcome
+	childHistoryIDs []int
+	parentHistoryID int // set once the main request is recorded
+	next            *models.NextRequest
+}
+
+func (x *execution) Variable(key string) (string, bool) {
+	return x.vars.get(key)
+}
+
+func (x *execution) SetVariable(key, value string) {
+	x.session.Variables.Set(key, value)
+}
+
+func (x *execution) UnsetVariable(key string) {
+	x.session.Variables.Unset(key)
+}
+
+func (x *execution) Log(level, message string) {
+	x.console = append(x.console, models.ConsoleEntry{
+		Level:     level,
+		Message:   message,
+		Timestamp: time.Now(),
+	})
+}
+
+func (x *execution) RecordTest(name string, passed bool, message string) {
+	x.tests = append(x.tests, models.TestOutcome{
+		Name:    name,
+		Source:  models.TestSourceScript,
+		Passed:  passed,
+		Message: message,
+	})
+}
+
+// SetNextRequest keeps the last tapa.setNextRequest call of the execution's scripts.
+func (x *execution) SetNextRequest(next models.NextRequest) {
+	x.next = &next
+}
+
+// Module looks up a script module of the request's collection for require("name").
+func (x *execution) Module(name string) (models.ScriptModule, error) {
+	if x.collectionID == nil {
+		return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModuleNotFound, fmt.Errorf("%q: request does not belong to a collection", name))
+	}
+
+	return x.executor.modules.GetScriptModule(*x.collectionID, name)
+}
+
+// SendRequest sends a sub-request on behalf of a script.
+// Stored requests are sent as they are saved; their own scripts do not run, so sub-requests cannot recurse.
+// Sub-requests share the session's variables and cookies and are cancelled together with the script.
+func (x *execution) SendRequest(ctx context.Context, sub scripting.SubRequest) (*models.ExecutionResult, error) {
+	var out *outgoing
+	vars := x.vars
+
+	if sub.ID != nil || sub.Name != "" {
+		id := 0
+		if sub.ID != nil {
+			id = *sub.ID
+		} else {
+			found, err := x.executor.requests.FindRequestIDByName(sub.Name, x.collectionID)
+			if err != nil {
+				return nil, err
+			}
+			id = found
+		}
+
+		detail, err := x.executor.requests.GetRequestWithDetail(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if detail.CollectionID != nil && (x.collectionID == nil || *detail.CollectionID != *x.collectionID) {
+			if vars, err = x.executor.scopeFor(x.session, detail.CollectionID); err != nil {
+				return nil, err
+			}
+		}
+
+		out = outgoingFromRequest(detail)
+	} else {
+		out = adHocOutgoing(sub)
+	}
+
+	result := x.executor.send(ctx, x.session, vars, out)
+
+	var parentID *int
+	if x.parentHistoryID != 0 {
+		parentID = &x.parentHistoryID
+	}
+
+	historyID, err := x.executor.record(result, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.HistoryID = historyID
+	x.childHistoryIDs = append(x.childHistoryIDs, historyID)
+	x.subRequests = append(x.subRequests, *result)
+
+	if result.Error != "" {
+		x.Log(models.ConsoleLevelRequest, fmt.Sprintf("%s %s -> %s", result.Method, result.URL, result.Error))
+	} else {
+		x.Log(models.ConsoleLevelRequest, fmt.Sprintf("%s %s -> %s (%d ms)", result.Method, result.URL, result.Status, result.ResponseTime))
+	}
+
+	return result, nil
+}
+
+// recordParent stores the execution's own request in the history and links the sub-requests sent so far to it.
+// Sub-requests sent afterwards, e.g. from post-response scripts, are recorded with the parent directly.
+func (x *execution) recordParent(result *models.ExecutionResult) error {
+	historyID, err := x.executor.record(result, nil)
+	if err != nil {
+		return err
+	}
+
+	result.HistoryID = historyID
+	x.parentHistoryID = historyID
+
+	return x.executor.history.LinkHistoryToParent(historyID, x.childHistoryIDs)
+}
+
+// finish attaches the console and sub-requests to the result of the execution.
+func (x *execution) finish(result *models.ExecutionResult) *models.ExecutionResult {
+	result.Console = x.console
+	result.SubRequests = x.subRequests
+	result.Tests = x.tests
+	result.NextRequest = x.next
+
+	if result.HistoryID != 0 {
+		for i := range result.SubRequests {
+			result.SubRequests[i].ParentHistoryID = &result.HistoryID
+		}
+	}
+
+	return result
+}
+
+func NewExecutor(db *sqlx.DB) *Executor {
+	return &Executor{
+		requests:     repository.NewRequestsRepository(db),
+		collections:  repository.NewCollectionsRepository(db),
+		environments: repository.NewEnvironmentsRepository(db),
+		modules:      repository.NewScriptModulesRepository(db),
+		schemas:      repository.NewCollectionSchemasRepository(db),
+		history:      repository.NewHistoryRepository(db),
+		testResults:  repository.NewTestResultsRepository(db),
+		transports:   newTransports(),
+	}
+}
@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"context"
+	"sync"
+)
+
+// hostLimiter caps how many requests of a session are in flight to the same host at once.
+type hostLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// LimitPerHost caps the requests of the session that are in flight to one host at once,
+// for runs that send several requests concurrently. A limit below 1 removes the cap.
+func (s *Session) LimitPerHost(limit int) {
+	if limit < 1 {
+		s.hosts = nil
+		return
+	}
+
+	s.hosts = &hostLimiter{limit: limit, slots: map[string]chan struct{}{}}
+}
+
+// acquire waits for a free slot for host and returns the function releasing it.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	slots, ok := l.slots[host]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[host] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,334 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/scripting"
+)
+
+const (
+	DEFAULT_TIMEOUT_MS    int = 30000
+	MAX_REDIRECTS         int = 10
+	HISTORY_SNIPPET_BYTES int = 4096 // response body kept in the history for reports
+
+	// MAX_IDLE_CONNS_PER_HOST lets parallel runs and load tests reuse their connections instead of opening new ones.
+	MAX_IDLE_CONNS_PER_HOST int = 100
+)
+
+// outgoing is a request after scripts ran and before its variables are resolved.
+type outgoing struct {
+	requestID               *int
+	name                    string
+	method                  string
+	url                     string
+	body                    string
+	bodyFormat              string
+	headers                 map[string]string
+	queryParams             []models.RequestQueryParam
+	cookies                 []models.RequestCookie
+	timeout                 int
+	allowRedirects          bool
+	sslVerification         bool
+	removeRefererOnRedirect bool
+	encodeURL               bool
+}
+
+func outgoingFromRequest(d models.RequestWithDetail) *outgoing {
+	headers := make(map[string]string, len(d.Headers))
+	for _, h := range d.Headers {
+		headers[h.Key] = h.Value
+	}
+
+	id := d.ID
+	return &outgoing{
+		requestID:               &id,
+		name:                    d.Name,
+		method:                  d.Method,
+		url:                     d.URL,
+		body:                    d.Body,
+		bodyFormat:              d.BodyFormat,
+		headers:                 headers,
+		queryParams:             d.QueryParams,
+		cookies:                 d.Cookies,
+		timeout:                 d.Timeout,
+		allowRedirects:          d.AllowRedirects,
+		sslVerification:         d.SSLVerification,
+		removeRefererOnRedirect: d.RemoveRefererOnRedirect,
+		encodeURL:               d.EncodeURL,
+	}
+}
+
+// adHocOutgoing builds a request from a script's tapa.sendRequest call using the schema defaults.
+func adHocOutgoing(sub scripting.SubRequest) *outgoing {
+	method := sub.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	headers := sub.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	return &outgoing{
+		name:            sub.URL,
+		method:          method,
+		url:             sub.URL,
+		body:            sub.Body,
+		bodyFormat:      "raw",
+		headers:         headers,
+		timeout:         DEFAULT_TIMEOUT_MS,
+		allowRedirects:  true,
+		sslVerification: true,
+		encodeURL:       true,
+	}
+}
+
+// send resolves variables, performs the HTTP call and reads the whole response.
+// Failures are reported through the result's Error field.
+func (e *Executor) send(ctx context.Context, session *Session, vars *variableScope, out *outgoing) *models.ExecutionResult {
+	result := &models.ExecutionResult{
+		RequestID:       out.requestID,
+		Name:            out.name,
+		Method:          out.method,
+		RequestHeaders:  map[string]string{},
+		ResponseHeaders: map[string][]string{},
+		Console:         []models.ConsoleEntry{},
+		Tests:           []models.TestOutcome{},
+		SubRequests:     []models.ExecutionResult{},
+		StartedAt:       time.Now(),
+	}
+
+	req, err := buildHTTPRequest(ctx, vars, out)
+	if err != nil {
+		result.URL = vars.resolve(out.url)
+		result.Error = errors.Wrap(errors.ErrRequestBuild, err).Error()
+		return result
+	}
+
+	result.URL = req.URL.String()
+	result.RequestBody = vars.resolve(out.body)
+	for key := range req.Header {
+		result.RequestHeaders[key] = req.Header.Get(key)
+	}
+
+	timeout := out.timeout
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT_MS
+	}
+
+	client := &http.Client{
+		Transport: e.transports.get(out.sslVerification),
+		Jar:       session.jar,
+		Timeout:   time.Duration(timeout) * time.Millisecond,
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			if !out.allowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= MAX_REDIRECTS {
+				return http.ErrUseLastResponse
+			}
+			if out.removeRefererOnRedirect {
+				r.Header.Del("Referer")
+			}
+			return nil
+		},
+	}
+
+	release, err := session.hosts.acquire(ctx, req.URL.Host)
+	if err != nil {
+		result.Error = errors.Wrap(errors.ErrRequestSend, err).Error()
+		return result
+	}
+	defer release()
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.ResponseTime = int(time.Since(start).Milliseconds())
+		result.Error = errors.Wrap(errors.ErrRequestSend, err).Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	result.ResponseTime = int(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Error = errors.Wrap(errors.ErrResponseRead, err).Error()
+		return result
+	}
+
+	result.StatusCode = resp.StatusCode
+	result.Status = resp.Status
+	result.ResponseHeaders = resp.Header
+	result.ResponseBody = string(body)
+	result.DataVolume = len(body)
+
+	return result
+}
+
+// buildHTTPRequest turns an outgoing request into an *http.Request with every variable resolved.
+func buildHTTPRequest(ctx context.Context, vars *variableScope, out *outgoing) (*http.Request, error) {
+	rawURL := strings.TrimSpace(vars.resolve(out.url))
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	if len(out.queryParams) > 0 {
+		params := make([]string, 0, len(out.queryParams))
+		for _, p := range out.queryParams {
+			key, value := vars.resolve(p.Key), vars.resolve(p.Value)
+			if out.encodeURL {
+				key, value = url.QueryEscape(key), url.QueryEscape(value)
+			}
+			params = append(params, key+"="+value)
+		}
+
+		separator := "?"
+		if strings.Contains(rawURL, "?") {
+			separator = "&"
+		}
+		rawURL += separator + strings.Join(params, "&")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	for key, value := range out.headers {
+		headers.Set(vars.resolve(key), vars.resolve(value))
+	}
+
+	body, contentType, err := encodeBody(vars.resolve(out.body), out.bodyFormat)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(out.method), u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+
+	for _, c := range out.cookies {
+		req.AddCookie(&http.Cookie{Name: vars.resolve(c.Key), Value: vars.resolve(c.Value)})
+	}
+
+	return req, nil
+}
+
+// encodeBody returns the request body and the content type implied by its format.
+// form-data bodies are stored as a JSON object of field names to values.
+func encodeBody(body, format string) ([]byte, string, error) {
+	if body == "" {
+		return nil, "", nil
+	}
+
+	switch format {
+	case "JSON":
+		return []byte(body), "application/json", nil
+	case "XML":
+		return []byte(body), "application/xml", nil
+	case "form-data":
+		var fields map[string]string
+		if err := json.Unmarshal([]byte(body), &fields); err != nil {
+			return []byte(body), "", nil
+		}
+
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		for key, value := range fields {
+			if err := writer.WriteField(key, value); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return nil, "", err
+		}
+
+		return buf.Bytes(), writer.FormDataContentType(), nil
+	default:
+		return []byte(body), "", nil
+	}
+}
+
+// transports keeps one pooled transport for verified and one for unverified TLS connections.
+type transports struct {
+	verified   *http.Transport
+	unverified *http.Transport
+}
+
+func newTransports() *transports {
+	verified := http.DefaultTransport.(*http.Transport).Clone()
+	verified.Proxy = http.ProxyFromEnvironment
+	verified.MaxIdleConnsPerHost = MAX_IDLE_CONNS_PER_HOST
+
+	unverified := verified.Clone()
+	unverified.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+	return &transports{verified: verified, unverified: unverified}
+}
+
+func (t *transports) get(sslVerification bool) *http.Transport {
+	if sslVerification {
+		return t.verified
+	}
+	return t.unverified
+}
+
+// snippet cuts a response body down to HISTORY_SNIPPET_BYTES, on a rune boundary.
+func snippet(body string) string {
+	if len(body) <= HISTORY_SNIPPET_BYTES {
+		return body
+	}
+
+	cut := HISTORY_SNIPPET_BYTES
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return body[:cut]
+}
+
+func toJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func queryParamsOf(rawURL string) map[string]string {
+	params := map[string]string{}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return params
+	}
+
+	for key, values := range u.Query() {
+		params[key] = strings.Join(values, ",")
+	}
+	return params
+}
@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Overrides replace parts of a stored request before its pre-request scripts run,
+// e.g. the values a workflow carries over from earlier requests.
+type Overrides struct {
+	Headers     map[string]string // replace headers of the same name, case-insensitively, or add them
+	QueryParams map[string]string // replace query parameters of the same key or add them
+	BodyFields  map[string]any    // dotted paths into a JSON object body, e.g. "customer.id"
+}
+
+func (o Overrides) apply(out *outgoing) error {
+	for name, value := range o.Headers {
+		for key := range out.headers {
+			if strings.EqualFold(key, name) {
+				delete(out.headers, key)
+			}
+		}
+		out.headers[name] = value
+	}
+
+	if len(o.QueryParams) > 0 {
+		params := make([]models.RequestQueryParam, 0, len(out.queryParams)+len(o.QueryParams))
+		replaced := map[string]bool{}
+		for _, p := range out.queryParams {
+			value, ok := o.QueryParams[p.Key]
+			if !ok {
+				params = append(params, p)
+				continue
+			}
+			if !replaced[p.Key] {
+				p.Value = value
+				params = append(params, p)
+				replaced[p.Key] = true
+			}
+		}
+
+		for _, key := range sortedKeys(o.QueryParams) {
+			if !replaced[key] {
+				params = append(params, models.RequestQueryParam{Key: key, Value: o.QueryParams[key]})
+			}
+		}
+		out.queryParams = params
+	}
+
+	if len(o.BodyFields) > 0 {
+		body := map[string]any{}
+		if strings.TrimSpace(out.body) != "" {
+			// Numbers stay as written, so ids beyond float64's precision are sent unchanged.
+			decoder := json.NewDecoder(strings.NewReader(out.body))
+			decoder.UseNumber()
+			err := decoder.Decode(&body)
+			if err == nil && decoder.More() {
+				err = fmt.Errorf("more than one JSON value")
+			}
+
+			switch {
+			case err != nil && variablePattern.MatchString(out.body):
+				// Variables are resolved after the overrides, so {{var}} outside a string is not JSON yet.
+				return errors.Wrap(errors.ErrRequestBuild, fmt.Errorf("the body of %s uses {{variables}} outside JSON strings, so fields cannot be mapped into it, map into a variable instead: %w", out.name, err))
+			case err != nil:
+				return errors.Wrap(errors.ErrRequestBuild, fmt.Errorf("the body of %s is not a JSON object, map into a variable instead: %w", out.name, err))
+			}
+		}
+
+		for _, path := range sortedKeys(o.BodyFields) {
+			if err := setField(body, strings.Split(path, "."), o.BodyFields[path]); err != nil {
+				return errors.Wrap(errors.ErrRequestBuild, fmt.Errorf("body field %q: %w", path, err))
+			}
+		}
+
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(errors.ErrRequestBuild, err)
+		}
+		out.body = string(encoded)
+	}
+
+	return nil
+}
+
+// setField sets the value at path, creating the objects along the way.
+func setField(object map[string]any, path []string, value any) error {
+	key := path[0]
+	if key == "" {
+		return fmt.Errorf("empty field name")
+	}
+
+	if len(path) == 1 {
+		object[key] = value
+		return nil
+	}
+
+	child, ok := object[key].(map[string]any)
+	if !ok {
+		if _, exists := object[key]; exists {
+			return fmt.Errorf("%q is not an object", key)
+		}
+		child = map[string]any{}
+		object[key] = child
+	}
+
+	return setField(child, path[1:], value)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Probe is a stored request loaded once to be sent many times, as load tests do.
+// Sends skip scripts, assertions and the history; variables are resolved from the session on every send.
+type Probe struct {
+	executor *Executor
+	session  *Session
+	vars     *variableScope
+	out      *outgoing
+}
+
+// NewProbe loads a stored request for repeated sends within session.
+func (e *Executor) NewProbe(session *Session, requestID int) (*Probe, error) {
+	detail, err := e.requests.GetRequestWithDetail(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := e.scopeFor(session, detail.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Probe{executor: e, session: session, vars: vars, out: outgoingFromRequest(detail)}, nil
+}
+
+// Send performs the HTTP call. Failures are reported through the result's Error field.
+func (p *Probe) Send(ctx context.Context) *models.ExecutionResult {
+	return p.executor.send(ctx, p.session, p.vars, p.out)
+}
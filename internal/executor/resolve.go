@@ -0,0 +1,31 @@
+package executor
+
+import "github.com/Amir-Zouerami/TAPA/internal/models"
+
+// Resolve returns a stored request with the variables of the session and its collection resolved, as it would be
+// sent if its pre-request scripts set nothing. References to unknown variables are left as they are.
+func (e *Executor) Resolve(session *Session, requestID int) (models.RequestWithDetail, error) {
+	detail, err := e.requests.GetRequestWithDetail(requestID)
+	if err != nil {
+		return models.RequestWithDetail{}, err
+	}
+
+	vars, err := e.scopeFor(session, detail.CollectionID)
+	if err != nil {
+		return models.RequestWithDetail{}, err
+	}
+
+	detail.URL = vars.resolve(detail.URL)
+	detail.Body = vars.resolve(detail.Body)
+	for i, h := range detail.Headers {
+		detail.Headers[i].Key, detail.Headers[i].Value = vars.resolve(h.Key), vars.resolve(h.Value)
+	}
+	for i, p := range detail.QueryParams {
+		detail.QueryParams[i].Key, detail.QueryParams[i].Value = vars.resolve(p.Key), vars.resolve(p.Value)
+	}
+	for i, c := range detail.Cookies {
+		detail.Cookies[i].Key, detail.Cookies[i].Value = vars.resolve(c.Key), vars.resolve(c.Value)
+	}
+
+	return detail, nil
+}
@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// SkipReason evaluates the skip conditions of a request against the session's variables
+// and returns why the request is skipped, or "" when it should be sent.
+func (e *Executor) SkipReason(session *Session, request models.RequestBasic) (string, error) {
+	conditions, err := e.requests.GetRequestSkipConditions(request.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	vars, err := e.scopeFor(session, request.CollectionID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range conditions {
+		if !c.Enabled {
+			continue
+		}
+
+		holds, err := skipConditionHolds(c, vars)
+		if err != nil {
+			return "", err
+		}
+		if holds {
+			return DescribeSkipCondition(c), nil
+		}
+	}
+
+	return "", nil
+}
+
+func skipConditionHolds(c models.SkipCondition, vars *variableScope) (bool, error) {
+	value, exists := vars.get(strings.TrimSpace(c.Variable))
+	expected := vars.resolve(c.Value)
+
+	switch c.Operator {
+	case models.SkipOperatorEquals:
+		return exists && value == expected, nil
+	case models.SkipOperatorNotEquals:
+		return !exists || value != expected, nil
+	case models.SkipOperatorExists:
+		return exists, nil
+	case models.SkipOperatorNotExists:
+		return !exists, nil
+	case models.SkipOperatorContains:
+		return exists && strings.Contains(value, expected), nil
+	case models.SkipOperatorMatches:
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return false, errors.Wrap(errors.ErrInvalidSkipCondition, err)
+		}
+		return exists && re.MatchString(value), nil
+	}
+
+	return false, errors.Wrap(errors.ErrInvalidSkipCondition, fmt.Errorf("unknown operator %q", c.Operator))
+}
+
+// ValidateSkipCondition reports conditions that can never be evaluated: no variable, an unknown operator
+// or a pattern that does not compile. Patterns with {{variables}} are only checked when they run.
+func ValidateSkipCondition(c models.SkipCondition) error {
+	if strings.TrimSpace(c.Variable) == "" {
+		return errors.Wrap(errors.ErrInvalidSkipCondition, fmt.Errorf("the condition needs a variable"))
+	}
+
+	switch c.Operator {
+	case models.SkipOperatorEquals, models.SkipOperatorNotEquals, models.SkipOperatorExists,
+		models.SkipOperatorNotExists, models.SkipOperatorContains:
+		return nil
+	case models.SkipOperatorMatches:
+		if variablePattern.MatchString(c.Value) {
+			return nil
+		}
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return errors.Wrap(errors.ErrInvalidSkipCondition, err)
+		}
+		return nil
+	}
+
+	return errors.Wrap(errors.ErrInvalidSkipCondition, fmt.Errorf("unknown operator %q", c.Operator))
+}
+
+// DescribeSkipCondition renders a condition for run logs, e.g. `env equals "prod"`.
+func DescribeSkipCondition(c models.SkipCondition) string {
+	switch c.Operator {
+	case models.SkipOperatorExists, models.SkipOperatorNotExists:
+		return fmt.Sprintf("%s %s", c.Variable, strings.ReplaceAll(c.Operator, "_", " "))
+	}
+	return fmt.Sprintf("%s %s %q", c.Variable, strings.ReplaceAll(c.Operator, "_", " "), c.Value)
+}
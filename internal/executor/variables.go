@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"maps"
+	"regexp"
+	"sync"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// Variables holds the values available to {{variable}} references during a session.
+// Values set by scripts shadow the current data row of a collection run, which shadows environment values,
+// which shadow collection values.
+type Variables struct {
+	mu          sync.RWMutex
+	runtime     map[string]string
+	data        map[string]string
+	environment map[string]string
+}
+
+// NewVariables creates a variable set on top of the given environment variables.
+func NewVariables(environment []models.EnvironmentVariable) *Variables {
+	v := &Variables{
+		runtime:     map[string]string{},
+		data:        map[string]string{},
+		environment: make(map[string]string, len(environment)),
+	}
+
+	for _, ev := range environment {
+		v.environment[ev.Key] = ev.Value
+	}
+
+	return v
+}
+
+// Get returns a runtime, data or environment variable.
+func (v *Variables) Get(key string) (string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if value, ok := v.runtime[key]; ok {
+		return value, true
+	}
+
+	if value, ok := v.data[key]; ok {
+		return value, true
+	}
+
+	value, ok := v.environment[key]
+	return value, ok
+}
+
+// Set stores a runtime variable for the rest of the session.
+func (v *Variables) Set(key, value string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.runtime[key] = value
+}
+
+// Unset removes a runtime variable, uncovering the data or environment value if there is one.
+func (v *Variables) Unset(key string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.runtime, key)
+}
+
+// SetData replaces the data row of the current iteration. Runtime values left over from
+// the previous iteration under the same names are dropped so every iteration sees its own row.
+func (v *Variables) SetData(row map[string]string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.data = make(map[string]string, len(row))
+	for key, value := range row {
+		v.data[key] = value
+		delete(v.runtime, key)
+	}
+}
+
+func (v *Variables) clone() *Variables {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return &Variables{runtime: maps.Clone(v.runtime), data: maps.Clone(v.data), environment: maps.Clone(v.environment)}
+}
+
+// scope adds a collection's variables underneath the session variables.
+func (v *Variables) scope(collection []models.CollectionVariable) *variableScope {
+	s := &variableScope{vars: v, collection: make(map[string]string, len(collection))}
+	for _, cv := range collection {
+		s.collection[cv.Key] = cv.Value
+	}
+	return s
+}
+
+type variableScope struct {
+	vars       *Variables
+	collection map[string]string
+}
+
+func (s *variableScope) get(key string) (string, bool) {
+	if value, ok := s.vars.Get(key); ok {
+		return value, true
+	}
+
+	value, ok := s.collection[key]
+	return value, ok
+}
+
+// resolve replaces every known {{variable}} in text. Unknown references are left untouched.
+func (s *variableScope) resolve(text string) string {
+	return variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		key := variablePattern.FindStringSubmatch(match)[1]
+		if value, ok := s.get(key); ok {
+			return value
+		}
+		return match
+	})
+}
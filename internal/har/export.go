@@ -0,0 +1,168 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// TIME_FORMAT is how exported files write times: ISO 8601 with milliseconds, as browsers do.
+const TIME_FORMAT string = "2006-01-02T15:04:05.000Z07:00"
+
+// Page is a group of history records exported together, e.g. one iteration of a collection run.
+type Page struct {
+	Title   string // empty for records that belong to no page
+	History []models.RequestHistory
+}
+
+// Export writes history records as a HAR 1.2 file, one HAR page per titled page. TAPA's history keeps the beginning
+// of response bodies and no response headers, so responses are exported as far as they were kept; a comment on
+// the content says when the body was cut.
+func Export(comment string, pages []Page) ([]byte, error) {
+	doc := document{Log: archive{
+		Version: VERSION,
+		Creator: creator{Name: CREATOR_NAME, Version: CREATOR_VERSION},
+		Pages:   []page{},
+		Entries: []entry{},
+		Comment: comment,
+	}}
+
+	for _, p := range pages {
+		pageref := ""
+		if p.Title != "" && len(p.History) > 0 {
+			pageref = fmt.Sprintf("page_%d", len(doc.Log.Pages)+1)
+
+			started := p.History[0].Timestamp
+			for _, h := range p.History {
+				if h.Timestamp.Before(started) {
+					started = h.Timestamp
+				}
+			}
+
+			doc.Log.Pages = append(doc.Log.Pages, page{
+				StartedDateTime: started.Format(TIME_FORMAT),
+				ID:              pageref,
+				Title:           p.Title,
+				PageTimings:     pageTimings{OnContentLoad: -1, OnLoad: -1},
+			})
+		}
+
+		for _, h := range p.History {
+			e := exportEntry(h)
+			e.Pageref = pageref
+			doc.Log.Entries = append(doc.Log.Entries, e)
+		}
+	}
+
+	data, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrHARExport, err)
+	}
+	return data, nil
+}
+
+func exportEntry(h models.RequestHistory) entry {
+	sent := map[string]string{}
+	_ = json.Unmarshal([]byte(h.Headers), &sent)
+
+	req := request{
+		Method:      h.Method,
+		URL:         h.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []cookie{},
+		Headers:     []nameValue{},
+		QueryString: []nameValue{},
+		HeadersSize: -1,
+		BodySize:    len(h.Body),
+	}
+
+	contentType := ""
+	for _, name := range importing.SortedKeys(sent) {
+		req.Headers = append(req.Headers, nameValue{Name: name, Value: sent[name]})
+		switch strings.ToLower(name) {
+		case "content-type":
+			contentType = sent[name]
+		case "cookie":
+			for _, pair := range strings.Split(sent[name], ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if key != "" {
+					req.Cookies = append(req.Cookies, cookie{Name: key, Value: value})
+				}
+			}
+		}
+	}
+
+	if _, rawQuery, ok := strings.Cut(h.URL, "?"); ok {
+		rawQuery, _, _ = strings.Cut(rawQuery, "#")
+		for _, p := range queryParams(rawQuery) {
+			req.QueryString = append(req.QueryString, nameValue{Name: p.Key, Value: p.Value})
+		}
+	}
+
+	if h.Body != "" {
+		req.PostData = &postData{MimeType: contentType, Text: h.Body}
+		switch importing.MediaType(contentType) {
+		case "application/x-www-form-urlencoded":
+			for _, p := range queryParams(h.Body) {
+				req.PostData.Params = append(req.PostData.Params, param{Name: p.Key, Value: p.Value})
+			}
+		case "multipart/form-data":
+			// The history keeps form-data bodies as their fields, not as the multipart body that was sent.
+			fields := map[string]string{}
+			if json.Unmarshal([]byte(h.Body), &fields) == nil {
+				req.PostData.Text = ""
+				for _, name := range importing.SortedKeys(fields) {
+					req.PostData.Params = append(req.PostData.Params, param{Name: name, Value: fields[name]})
+				}
+			}
+		}
+	}
+
+	resp := response{
+		Status:      h.StatusCode,
+		StatusText:  http.StatusText(h.StatusCode),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []cookie{},
+		Headers:     []nameValue{},
+		Content: content{
+			Size:     h.DataVolume,
+			MimeType: guessMimeType(h.ResponseSnippet),
+			Text:     h.ResponseSnippet,
+		},
+		HeadersSize: -1,
+		BodySize:    h.DataVolume,
+	}
+	if h.StatusCode == 0 {
+		resp.HTTPVersion = ""
+		resp.BodySize = -1
+	}
+	if len(h.ResponseSnippet) < h.DataVolume {
+		resp.Content.Comment = fmt.Sprintf("only the first %d of %d bytes were kept", len(h.ResponseSnippet), h.DataVolume)
+	}
+
+	return entry{
+		StartedDateTime: h.Timestamp.Format(TIME_FORMAT),
+		Time:            float64(h.ResponseTime),
+		Request:         req,
+		Response:        resp,
+		Timings:         timings{Blocked: -1, DNS: -1, Connect: -1, Send: 0, Wait: float64(h.ResponseTime), Receive: 0, SSL: -1},
+		Error:           h.Error,
+	}
+}
+
+// guessMimeType guesses the media type of a response body from its beginning, as the history keeps no headers.
+func guessMimeType(body string) string {
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case trimmed == "":
+		return ""
+	case strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		return "application/json"
+	}
+	return http.DetectContentType([]byte(body))
+}
@@ -0,0 +1,136 @@
+// Package har converts HTTP Archive (HAR) 1.2 files captured by browsers into TAPA collections, and the request
+// history TAPA records into HAR files other tools can open.
+package har
+
+import "encoding/json"
+
+const SOURCE string = "har"
+
+// VERSION is the HAR version exported files declare.
+const VERSION string = "1.2"
+
+// CREATOR_NAME and CREATOR_VERSION identify TAPA as the creator of exported files.
+const (
+	CREATOR_NAME    string = "TAPA"
+	CREATOR_VERSION string = "1.0.0"
+)
+
+type document struct {
+	Log archive `json:"log"`
+}
+
+type archive struct {
+	Version string  `json:"version"`
+	Creator creator `json:"creator"`
+	Pages   []page  `json:"pages,omitempty"`
+	Entries []entry `json:"entries"`
+	Comment string  `json:"comment,omitempty"`
+}
+
+type creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type page struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	ID              string      `json:"id"`
+	Title           string      `json:"title"`
+	PageTimings     pageTimings `json:"pageTimings"`
+}
+
+type pageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+type entry struct {
+	Pageref         string   `json:"pageref,omitempty"`
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         request  `json:"request"`
+	Response        response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         timings  `json:"timings"`
+	Comment         string   `json:"comment,omitempty"`
+
+	// Custom fields, which HAR prefixes with an underscore.
+	ResourceType string `json:"_resourceType,omitempty"` // set by Chromium, e.g. "xhr" or "image"
+	Error        string `json:"_error,omitempty"`        // why no response arrived
+}
+
+type request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []cookie    `json:"cookies"`
+	Headers     []nameValue `json:"headers"`
+	QueryString []nameValue `json:"queryString"`
+	PostData    *postData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []cookie    `json:"cookies"`
+	Headers     []nameValue `json:"headers"`
+	Content     content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type nameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type postData struct {
+	MimeType string  `json:"mimeType"`
+	Params   []param `json:"params,omitempty"`
+	Text     string  `json:"text"`
+}
+
+type param struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"` // "base64" for binary bodies
+	Comment  string `json:"comment,omitempty"`
+}
+
+// timings are in milliseconds, -1 for phases that do not apply or were not measured.
+type timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Detect reports whether data looks like a HAR file.
+func Detect(data []byte) bool {
+	var probe struct {
+		Log *struct {
+			Entries *json.RawMessage `json:"entries"`
+		} `json:"log"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Log != nil && probe.Log.Entries != nil
+}
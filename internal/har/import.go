@@ -0,0 +1,333 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// skippedHeaders are set by whoever sends the request, or describe the browser's connection rather than the request.
+// Accept-Encoding is left to the executor too, which only decompresses responses when it asked for compression.
+var skippedHeaders = map[string]bool{"host": true, "content-length": true, "connection": true, "keep-alive": true,
+	"transfer-encoding": true, "upgrade": true, "te": true, "accept-encoding": true}
+
+// Import converts the entries of a HAR file into a collection export for CollectionsRepository.ImportCollection:
+// one request per entry, in one folder per host when the entries go to more than one. With examples, the response
+// of every entry is saved as an example of its request. Entries that cannot be sent again are skipped and reported.
+func Import(data []byte, examples bool) (models.CollectionExport, models.ImportReport, error) {
+	if !Detect(data) {
+		return models.CollectionExport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("not a HAR file"))
+	}
+
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return models.CollectionExport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+	}
+
+	name := "HAR import"
+	if len(doc.Log.Pages) > 0 && strings.TrimSpace(doc.Log.Pages[0].Title) != "" {
+		name = strings.TrimSpace(doc.Log.Pages[0].Title)
+	}
+
+	im := &importer{
+		examples: examples,
+		report:   models.ImportReport{Name: name, Source: SOURCE, Warnings: []models.ImportWarning{}},
+		skipped:  map[string]int{},
+	}
+
+	type converted struct {
+		host    string
+		request models.RequestExport
+	}
+	var requests []converted
+	hosts := map[string]bool{}
+
+	for _, e := range doc.Log.Entries {
+		host, req, ok := im.request(e)
+		if !ok {
+			continue
+		}
+		requests = append(requests, converted{host: host, request: req})
+		hosts[host] = true
+	}
+
+	export := models.CollectionExport{
+		Version:    models.COLLECTION_EXPORT_VERSION,
+		Collection: models.Collection{Name: name, Description: doc.Log.Comment},
+		Variables:  []models.CollectionVariable{},
+		Modules:    []models.ScriptModule{},
+		Schemas:    []models.CollectionSchema{},
+		DataFiles:  []models.CollectionDataFile{},
+		Folders:    []models.FolderExport{},
+		Requests:   []models.RequestExport{},
+	}
+
+	folders := map[string]int{}
+	for _, r := range requests {
+		if len(hosts) == 1 {
+			export.Requests = append(export.Requests, r.request)
+			continue
+		}
+
+		i, ok := folders[r.host]
+		if !ok {
+			i = len(export.Folders)
+			folders[r.host] = i
+			export.Folders = append(export.Folders, models.FolderExport{
+				Folder:   models.Folder{Name: r.host},
+				Requests: []models.RequestExport{},
+			})
+		}
+		export.Folders[i].Requests = append(export.Folders[i].Requests, r.request)
+	}
+
+	reasons := make([]string, 0, len(im.skipped))
+	for reason := range im.skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		im.warn("", "%s (%d)", reason, im.skipped[reason])
+	}
+
+	im.report.Folders = len(export.Folders)
+	return export, im.report, nil
+}
+
+type importer struct {
+	examples bool
+	report   models.ImportReport
+	skipped  map[string]int // entries skipped or changed in bulk, counted by what happened, e.g. "skipped entries with data: URLs"
+}
+
+func (im *importer) warn(where string, format string, args ...any) {
+	im.report.Warnings = append(im.report.Warnings, models.ImportWarning{Item: where, Message: fmt.Sprintf(format, args...)})
+}
+
+// request converts an entry into a request and returns the host it was sent to.
+func (im *importer) request(e entry) (string, models.RequestExport, bool) {
+	rawURL, _, _ := strings.Cut(e.Request.URL, "#")
+	u, err := neturl.Parse(rawURL)
+	switch {
+	case err != nil:
+		im.skipped["skipped entries with invalid URLs"]++
+		return "", models.RequestExport{}, false
+	case u.Scheme != "http" && u.Scheme != "https":
+		im.skipped[fmt.Sprintf("skipped entries with %s: URLs", u.Scheme)]++
+		return "", models.RequestExport{}, false
+	case u.Host == "":
+		im.skipped["skipped entries with invalid URLs"]++
+		return "", models.RequestExport{}, false
+	}
+
+	method := strings.ToUpper(e.Request.Method)
+	where := method + " " + rawURL
+	if !importing.IsMethod(method) {
+		im.warn(where, "method %s is not supported, the entry was skipped", method)
+		return "", models.RequestExport{}, false
+	}
+
+	req := models.RequestExport{Examples: []models.RequestExample{}}
+	req.Method = method
+	req.Name = method + " " + requestName(u)
+	req.BodyFormat = "raw"
+	req.Timeout = importing.DEFAULT_TIMEOUT_MS
+	req.AllowRedirects = true
+	req.SSLVerification = true
+	req.EncodeURL = true
+	req.Headers = []models.RequestHeader{}
+	req.QueryParams = []models.RequestQueryParam{}
+	req.Cookies = []models.RequestCookie{}
+	req.Scripts = []models.RequestScript{}
+	req.Assertions = []models.RequestAssertion{}
+	req.SkipConditions = []models.SkipCondition{}
+
+	base, rawQuery, _ := strings.Cut(rawURL, "?")
+	req.URL = base
+	req.QueryParams = queryParams(rawQuery)
+
+	contentType := ""
+	cookieHeader := ""
+	for _, h := range e.Request.Headers {
+		key := strings.TrimSpace(h.Name)
+		lower := strings.ToLower(key)
+		switch {
+		// HTTP/2 pseudo-headers, e.g. :authority.
+		case key == "" || strings.HasPrefix(key, ":") || skippedHeaders[lower]:
+			continue
+		case lower == "cookie":
+			cookieHeader = h.Value
+			continue
+		case lower == "content-type":
+			contentType = h.Value
+		}
+		req.Headers = append(req.Headers, models.RequestHeader{Key: key, Value: h.Value})
+	}
+
+	if len(e.Request.Cookies) > 0 {
+		for _, c := range e.Request.Cookies {
+			req.Cookies = append(req.Cookies, models.RequestCookie{Key: c.Name, Value: c.Value})
+		}
+	} else if cookieHeader != "" {
+		for _, pair := range strings.Split(cookieHeader, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if key != "" {
+				req.Cookies = append(req.Cookies, models.RequestCookie{Key: key, Value: value})
+			}
+		}
+	}
+
+	if e.Request.PostData != nil {
+		im.body(where, e.Request.PostData, contentType, &req.RequestWithDetail)
+	}
+
+	if im.examples && e.Response.Status > 0 {
+		req.Examples = append(req.Examples, im.example(e, rawURL))
+		im.report.Examples++
+	}
+
+	im.report.Requests++
+	return u.Host, req, true
+}
+
+// body sets the body of req from the posted data of an entry.
+func (im *importer) body(where string, data *postData, contentType string, req *models.RequestWithDetail) {
+	if contentType == "" {
+		contentType = data.MimeType
+	}
+
+	switch mt := importing.MediaType(contentType); {
+	case mt == "multipart/form-data" && len(data.Params) > 0:
+		fields := map[string]string{}
+		for _, p := range data.Params {
+			if p.FileName != "" {
+				im.warn(where, "file field %s was skipped, files cannot be attached to requests", p.Name)
+				continue
+			}
+			fields[p.Name] = p.Value
+		}
+		encoded, _ := json.Marshal(fields)
+		req.Body = string(encoded)
+		req.BodyFormat = "form-data"
+		// The boundary is chosen when the request is sent.
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		req.Body = data.Text
+		req.BodyFormat = "JSON"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		req.Body = data.Text
+		req.BodyFormat = "XML"
+	default:
+		req.Body = data.Text
+		if contentType != "" && !importing.HasHeader(req.Headers, "Content-Type") {
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: contentType})
+		}
+	}
+
+	// JSON and XML bodies get their Content-Type from the body format.
+	if (req.BodyFormat == "JSON" && importing.MediaType(contentType) == "application/json") ||
+		(req.BodyFormat == "XML" && importing.MediaType(contentType) == "application/xml") {
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	}
+}
+
+// example turns the response of an entry into a request example, with the request as the browser sent it.
+func (im *importer) example(e entry, rawURL string) models.RequestExample {
+	timestamp, err := time.Parse(time.RFC3339Nano, e.StartedDateTime)
+	if err != nil {
+		timestamp = time.Now()
+	}
+
+	headers := map[string]string{}
+	for _, h := range e.Request.Headers {
+		if !strings.HasPrefix(h.Name, ":") {
+			headers[h.Name] = h.Value
+		}
+	}
+
+	query := map[string]string{}
+	_, rawQuery, _ := strings.Cut(rawURL, "?")
+	for _, p := range queryParams(rawQuery) {
+		query[p.Key] = p.Value
+	}
+
+	responseHeaders := map[string][]string{}
+	for _, h := range e.Response.Headers {
+		if !strings.HasPrefix(h.Name, ":") {
+			responseHeaders[h.Name] = append(responseHeaders[h.Name], h.Value)
+		}
+	}
+
+	body := e.Response.Content.Text
+	if e.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err == nil && utf8.Valid(decoded) {
+			body = string(decoded)
+		} else {
+			body = ""
+			im.skipped["binary response bodies were not saved with their examples"]++
+		}
+	}
+
+	size := e.Response.Content.Size
+	if size < 0 {
+		size = len(body)
+	}
+
+	example := models.RequestExample{
+		Timestamp:       timestamp,
+		Method:          strings.ToUpper(e.Request.Method),
+		URL:             rawURL,
+		Headers:         importing.ToJSON(headers),
+		QueryParams:     importing.ToJSON(query),
+		StatusCode:      e.Response.Status,
+		Response:        body,
+		ResponseHeaders: importing.ToJSON(responseHeaders),
+		ResponseTime:    int(math.Round(e.Time)),
+		DataVolume:      size,
+	}
+	if e.Request.PostData != nil {
+		example.Body = e.Request.PostData.Text
+	}
+
+	if len(e.Response.Cookies) > 0 {
+		cookies := map[string]string{}
+		for _, c := range e.Response.Cookies {
+			cookies[c.Name] = c.Value
+		}
+		example.ResponseCookies = importing.ToJSON(cookies)
+	}
+
+	return example
+}
+
+// queryParams splits a raw query into decoded parameters, in order.
+func queryParams(rawQuery string) []models.RequestQueryParam {
+	params := []models.RequestQueryParam{}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		params = append(params, models.RequestQueryParam{Key: importing.Unescape(key), Value: importing.Unescape(value)})
+	}
+	return params
+}
+
+// requestName names a request after its URL's path, or its host when the path is empty.
+func requestName(u *neturl.URL) string {
+	if strings.Trim(u.Path, "/") == "" {
+		return u.Host
+	}
+	return u.Path
+}
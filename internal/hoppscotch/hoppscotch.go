@@ -0,0 +1,147 @@
+// Package hoppscotch converts Hoppscotch collection and environment exports into TAPA collections and environments.
+package hoppscotch
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const SOURCE string = "hoppscotch"
+
+// FOLDER_SEPARATOR joins the names of nested Hoppscotch folders into the name of one TAPA folder.
+const FOLDER_SEPARATOR string = " / "
+
+var (
+	// variableRef matches Hoppscotch variables, <<name>>.
+	variableRef = regexp.MustCompile(`<<\s*([A-Za-z0-9_\-.]+)\s*>>`)
+
+	hoppscotchAPI = regexp.MustCompile(`\b(?:pw|hopp)\.[A-Za-z]`)
+)
+
+// collection is a collection or, nested in one, a folder.
+type collection struct {
+	Name      string       `json:"name"`
+	Folders   []collection `json:"folders"`
+	Requests  []request    `json:"requests"`
+	Auth      *auth        `json:"auth"`
+	Headers   []pair       `json:"headers"`
+	Variables []variable   `json:"variables"`
+}
+
+type request struct {
+	Name             string              `json:"name"`
+	Method           string              `json:"method"`
+	Endpoint         string              `json:"endpoint"`
+	Params           []pair              `json:"params"`
+	Headers          []pair              `json:"headers"`
+	Auth             *auth               `json:"auth"`
+	Body             body                `json:"body"`
+	PreRequestScript string              `json:"preRequestScript"`
+	TestScript       string              `json:"testScript"`
+	Variables        []pair              `json:"requestVariables"`
+	Responses        map[string]response `json:"responses"`
+}
+
+type pair struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Active *bool  `json:"active"` // missing in older exports, where every pair is active
+	IsFile bool   `json:"isFile"`
+}
+
+func (p pair) active() bool {
+	return p.Active == nil || *p.Active
+}
+
+type auth struct {
+	Type     string `json:"authType"` // "none", "inherit", "basic", "bearer", "api-key", "oauth-2", "digest", ...
+	Active   *bool  `json:"authActive"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	AddTo    string `json:"addTo"` // "HEADERS" or "QUERY_PARAMS"; "Headers" or "Query params" in older exports
+}
+
+// body is a request body; Body is a string, or the fields of a multipart body.
+type body struct {
+	ContentType *string         `json:"contentType"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// response is a response saved with a request.
+type response struct {
+	Name    string `json:"name"`
+	Code    int    `json:"code"`
+	Headers []pair `json:"headers"`
+	Body    string `json:"body"`
+}
+
+type environment struct {
+	Name      string     `json:"name"`
+	Variables []variable `json:"variables"`
+}
+
+// variable is an environment or collection variable. Newer exports split the value into an initial value,
+// which is shared, and a current one, which is not.
+type variable struct {
+	Key          string  `json:"key"`
+	Value        *string `json:"value"`
+	InitialValue *string `json:"initialValue"`
+	CurrentValue *string `json:"currentValue"`
+}
+
+func (v variable) value() string {
+	for _, value := range []*string{v.Value, v.InitialValue, v.CurrentValue} {
+		if value != nil && *value != "" {
+			return *value
+		}
+	}
+	return ""
+}
+
+// probe has the keys that tell collections and environments apart.
+type probe struct {
+	Name      *string            `json:"name"`
+	Folders   *json.RawMessage   `json:"folders"`
+	Requests  *json.RawMessage   `json:"requests"`
+	Variables *[]json.RawMessage `json:"variables"`
+}
+
+func (p probe) isCollection() bool {
+	return p.Name != nil && (p.Folders != nil || p.Requests != nil)
+}
+
+func (p probe) isEnvironment() bool {
+	return p.Name != nil && p.Variables != nil && p.Folders == nil && p.Requests == nil
+}
+
+// Detect reports whether data looks like a Hoppscotch export: a collection, an environment, or a list of either.
+func Detect(data []byte) bool {
+	var probes []probe
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		if json.Unmarshal(data, &probes) != nil || len(probes) == 0 {
+			return false
+		}
+	} else {
+		var p probe
+		if json.Unmarshal(data, &p) != nil {
+			return false
+		}
+		probes = []probe{p}
+	}
+
+	for _, p := range probes {
+		if !p.isCollection() && !p.isEnvironment() {
+			return false
+		}
+	}
+	return true
+}
+
+// variables rewrites Hoppscotch variable references as TAPA ones, e.g. <<base_url>> as {{base_url}}.
+func variables(s string) string {
+	return variableRef.ReplaceAllString(s, "{{$1}}")
+}
@@ -0,0 +1,434 @@
+package hoppscotch
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Import converts a Hoppscotch export, one collection, one environment or a list of either, into TAPA collections
+// and environments. Nested folders become one folder each, named after their path; auth and headers set on
+// collections and folders are copied into the requests that inherit them. Whatever cannot be mapped is listed
+// in the report's warnings.
+func Import(data []byte) (models.WorkspaceImport, models.ImportReport, error) {
+	if !Detect(data) {
+		return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("not a Hoppscotch export"))
+	}
+
+	var items []json.RawMessage
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+		}
+	} else {
+		items = []json.RawMessage{data}
+	}
+
+	im := &importer{
+		workspace: models.WorkspaceImport{Collections: []models.CollectionExport{}, Environments: []models.EnvironmentExport{}},
+		report:    models.ImportReport{Source: SOURCE, Warnings: []models.ImportWarning{}},
+	}
+
+	names := []string{}
+	for _, item := range items {
+		var p probe
+		if err := json.Unmarshal(item, &p); err != nil {
+			return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+		}
+
+		if p.isEnvironment() {
+			var env environment
+			if err := json.Unmarshal(item, &env); err != nil {
+				return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+			}
+			im.environment(env)
+			continue
+		}
+
+		var c collection
+		if err := json.Unmarshal(item, &c); err != nil {
+			return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+		}
+		names = append(names, im.collection(c))
+	}
+
+	if len(names) == 0 {
+		names = append(names, "Hoppscotch environments")
+	}
+	im.report.Name = strings.Join(names, ", ")
+
+	return im.workspace, im.report, nil
+}
+
+type importer struct {
+	workspace models.WorkspaceImport
+	report    models.ImportReport
+}
+
+// target is a collection being converted.
+type target struct {
+	export    models.CollectionExport
+	variables map[string]bool
+}
+
+// inheritance is what requests take over from the collection and folders they are in.
+type inheritance struct {
+	auth    *auth
+	headers []pair
+}
+
+func (im *importer) warn(where string, format string, args ...any) {
+	im.report.Warnings = append(im.report.Warnings, models.ImportWarning{Item: where, Message: fmt.Sprintf(format, args...)})
+}
+
+func (im *importer) environment(env environment) {
+	name := strings.TrimSpace(env.Name)
+	if name == "" {
+		name = "Hoppscotch environment"
+	}
+
+	export := models.EnvironmentExport{Environment: models.Environment{Name: name}, Variables: []models.EnvironmentVariable{}}
+	for _, v := range env.Variables {
+		if v.Key != "" {
+			export.Variables = append(export.Variables, models.EnvironmentVariable{Key: v.Key, Value: variables(v.value())})
+		}
+	}
+
+	im.workspace.Environments = append(im.workspace.Environments, export)
+	im.report.Environments++
+}
+
+// collection converts a top-level collection and returns its name.
+func (im *importer) collection(c collection) string {
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		name = "Hoppscotch collection"
+	}
+
+	t := &target{
+		export: models.CollectionExport{
+			Version:    models.COLLECTION_EXPORT_VERSION,
+			Collection: models.Collection{Name: name},
+			Variables:  []models.CollectionVariable{},
+			Modules:    []models.ScriptModule{},
+			Schemas:    []models.CollectionSchema{},
+			DataFiles:  []models.CollectionDataFile{},
+			Folders:    []models.FolderExport{},
+			Requests:   []models.RequestExport{},
+		},
+		variables: map[string]bool{},
+	}
+
+	for _, v := range c.Variables {
+		im.variable(t, v.Key, v.value())
+	}
+
+	inherited := inheritance{auth: c.Auth, headers: c.Headers}
+	for _, r := range c.Requests {
+		if req, ok := im.request(t, r, nil, inherited); ok {
+			t.export.Requests = append(t.export.Requests, req)
+		}
+	}
+	for _, f := range c.Folders {
+		im.folder(t, f, nil, inherited)
+	}
+
+	im.workspace.Collections = append(im.workspace.Collections, t.export)
+	im.report.Folders += len(t.export.Folders)
+	return name
+}
+
+// folder converts a folder and the folders in it, one TAPA folder each.
+func (im *importer) folder(t *target, f collection, path []string, inherited inheritance) {
+	name := strings.TrimSpace(f.Name)
+	if name == "" {
+		name = "Untitled folder"
+	}
+	path = append(append([]string{}, path...), name)
+
+	next := inheritance{auth: inherited.auth, headers: append(append([]pair{}, f.Headers...), inherited.headers...)}
+	if f.Auth != nil && f.Auth.Type != "inherit" {
+		next.auth = f.Auth
+	}
+
+	folder := models.FolderExport{
+		Folder:   models.Folder{Name: strings.Join(path, FOLDER_SEPARATOR)},
+		Requests: []models.RequestExport{},
+	}
+	for _, r := range f.Requests {
+		if req, ok := im.request(t, r, path, next); ok {
+			folder.Requests = append(folder.Requests, req)
+		}
+	}
+	if len(folder.Requests) > 0 {
+		t.export.Folders = append(t.export.Folders, folder)
+	}
+
+	for _, sub := range f.Folders {
+		im.folder(t, sub, path, next)
+	}
+}
+
+// variable adds a collection variable unless the collection has one of that name already.
+func (im *importer) variable(t *target, key, value string) bool {
+	if key == "" || t.variables[key] {
+		return false
+	}
+	t.variables[key] = true
+	t.export.Variables = append(t.export.Variables, models.CollectionVariable{Key: key, Value: variables(value)})
+	im.report.Variables++
+	return true
+}
+
+func (im *importer) request(t *target, r request, path []string, inherited inheritance) (models.RequestExport, bool) {
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		name = "Untitled request"
+	}
+	where := strings.Join(append(append([]string{}, path...), name), FOLDER_SEPARATOR)
+
+	method := strings.ToUpper(strings.TrimSpace(r.Method))
+	if method == "" {
+		method = "GET"
+	}
+	if !importing.IsMethod(method) {
+		im.warn(where, "method %s is not supported, the request was skipped", method)
+		return models.RequestExport{}, false
+	}
+
+	req := models.RequestExport{Examples: []models.RequestExample{}}
+	req.Name = name
+	req.Method = method
+	req.BodyFormat = "raw"
+	req.Timeout = importing.DEFAULT_TIMEOUT_MS
+	req.AllowRedirects = true
+	req.SSLVerification = true
+	req.EncodeURL = true
+	req.Headers = []models.RequestHeader{}
+	req.QueryParams = []models.RequestQueryParam{}
+	req.Cookies = []models.RequestCookie{}
+	req.Scripts = []models.RequestScript{}
+	req.Assertions = []models.RequestAssertion{}
+	req.SkipConditions = []models.SkipCondition{}
+
+	rawURL, _, _ := strings.Cut(variables(strings.TrimSpace(r.Endpoint)), "#")
+	rawURL, rawQuery, _ := strings.Cut(rawURL, "?")
+	req.URL = rawURL
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: importing.Unescape(key), Value: importing.Unescape(value)})
+	}
+	for _, p := range r.Params {
+		if p.active() && p.Key != "" {
+			req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: variables(p.Key), Value: variables(p.Value)})
+		}
+	}
+
+	// Headers of the request win over the ones of its folders and collection.
+	for _, h := range append(append([]pair{}, r.Headers...), inherited.headers...) {
+		if h.active() && strings.TrimSpace(h.Key) != "" && !importing.HasHeader(req.Headers, h.Key) {
+			req.Headers = append(req.Headers, models.RequestHeader{Key: strings.TrimSpace(h.Key), Value: variables(h.Value)})
+		}
+	}
+
+	a := r.Auth
+	if a == nil || a.Type == "inherit" {
+		a = inherited.auth
+	}
+	im.auth(where, a, &req.RequestWithDetail)
+
+	im.body(where, r.Body, &req.RequestWithDetail)
+
+	added := false
+	for _, v := range r.Variables {
+		if v.active() {
+			added = im.variable(t, v.Key, v.Value) || added
+		}
+	}
+	if added {
+		im.warn(where, "request variables were added to the collection variables")
+	}
+
+	for _, s := range []struct {
+		phase  string
+		source string
+	}{{models.ScriptPhasePreRequest, r.PreRequestScript}, {models.ScriptPhasePostResponse, r.TestScript}} {
+		source := strings.TrimSpace(s.source)
+		if source == "" {
+			continue
+		}
+		req.Scripts = append(req.Scripts, models.RequestScript{Phase: s.phase, Script: source})
+		if hoppscotchAPI.MatchString(source) {
+			im.warn(where, "the %s script uses the Hoppscotch pw API, rewrite it with tapa.* before running it", s.phase)
+		}
+	}
+
+	for _, key := range importing.SortedKeys(r.Responses) {
+		req.Examples = append(req.Examples, example(r.Responses[key], req.RequestWithDetail))
+	}
+
+	im.report.Requests++
+	im.report.Scripts += len(req.Scripts)
+	im.report.Examples += len(req.Examples)
+	return req, true
+}
+
+// auth turns supported auth into the header or query parameter it sends, unless the request sets it itself.
+func (im *importer) auth(where string, a *auth, req *models.RequestWithDetail) {
+	if a == nil || (a.Active != nil && !*a.Active) {
+		return
+	}
+
+	switch a.Type {
+	case "", "none", "inherit":
+	case "bearer":
+		if !importing.HasHeader(req.Headers, "Authorization") {
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: "Bearer " + variables(a.Token)})
+		}
+	case "basic":
+		username, password := variables(a.Username), variables(a.Password)
+		if strings.Contains(username+password, "{{") {
+			im.warn(where, "basic auth with variables cannot be encoded ahead of time, add the Authorization header by hand")
+			return
+		}
+		if !importing.HasHeader(req.Headers, "Authorization") {
+			credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: "Basic " + credentials})
+		}
+	case "api-key":
+		key, value := variables(a.Key), variables(a.Value)
+		if key == "" {
+			return
+		}
+		switch a.AddTo {
+		case "QUERY_PARAMS", "Query params":
+			req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: key, Value: value})
+		default:
+			if !importing.HasHeader(req.Headers, key) {
+				req.Headers = append(req.Headers, models.RequestHeader{Key: key, Value: value})
+			}
+		}
+	default:
+		im.warn(where, "%s auth is not supported, add the credentials by hand", a.Type)
+	}
+}
+
+// body sets the body of req from a Hoppscotch body.
+func (im *importer) body(where string, b body, req *models.RequestWithDetail) {
+	if b.ContentType == nil || *b.ContentType == "" {
+		return
+	}
+	contentType := *b.ContentType
+	mt := importing.MediaType(contentType)
+
+	if mt == "multipart/form-data" {
+		var params []pair
+		if err := json.Unmarshal(b.Body, &params); err != nil {
+			im.warn(where, "the multipart body could not be read and was skipped")
+			return
+		}
+
+		fields := map[string]string{}
+		for _, p := range params {
+			if !p.active() || p.Key == "" {
+				continue
+			}
+			if p.IsFile {
+				im.warn(where, "file field %s was skipped, files cannot be attached to requests", p.Key)
+				continue
+			}
+			fields[variables(p.Key)] = variables(p.Value)
+		}
+		encoded, _ := json.Marshal(fields)
+		req.Body = string(encoded)
+		req.BodyFormat = "form-data"
+		// The boundary is chosen when the request is sent.
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+		return
+	}
+
+	var text string
+	if len(b.Body) > 0 && json.Unmarshal(b.Body, &text) != nil {
+		im.warn(where, "%s bodies are not supported, the body was skipped", contentType)
+		return
+	}
+	text = variables(text)
+
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		// Hoppscotch keeps form fields as "key: value" lines; commented out lines are disabled fields.
+		pairs := []string{}
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, value, _ := strings.Cut(line, ":")
+			pairs = append(pairs, importing.FormEscape(strings.TrimSpace(key))+"="+importing.FormEscape(strings.TrimSpace(value)))
+		}
+		req.Body = strings.Join(pairs, "&")
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		req.Body = text
+		req.BodyFormat = "JSON"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		req.Body = text
+		req.BodyFormat = "XML"
+	default:
+		req.Body = text
+	}
+
+	switch {
+	case (req.BodyFormat == "JSON" && mt == "application/json") || (req.BodyFormat == "XML" && mt == "application/xml"):
+		// JSON and XML bodies get their Content-Type from the body format.
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	case !importing.HasHeader(req.Headers, "Content-Type"):
+		req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: contentType})
+	}
+}
+
+// example turns a saved response into a request example, with the request as it was imported.
+func example(resp response, req models.RequestWithDetail) models.RequestExample {
+	headers := map[string]string{}
+	for _, h := range req.Headers {
+		headers[h.Key] = h.Value
+	}
+
+	query := map[string]string{}
+	url := req.URL
+	for i, p := range req.QueryParams {
+		query[p.Key] = p.Value
+		separator := "&"
+		if i == 0 {
+			separator = "?"
+		}
+		url += separator + neturl.QueryEscape(p.Key) + "=" + neturl.QueryEscape(p.Value)
+	}
+
+	responseHeaders := map[string][]string{}
+	for _, h := range resp.Headers {
+		responseHeaders[h.Key] = append(responseHeaders[h.Key], h.Value)
+	}
+
+	return models.RequestExample{
+		Timestamp:       time.Now(),
+		Method:          req.Method,
+		URL:             url,
+		Headers:         importing.ToJSON(headers),
+		QueryParams:     importing.ToJSON(query),
+		Body:            req.Body,
+		StatusCode:      resp.Code,
+		Response:        resp.Body,
+		ResponseHeaders: importing.ToJSON(responseHeaders),
+		DataVolume:      len(resp.Body),
+	}
+}
@@ -0,0 +1,210 @@
+package httpfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Export writes a collection as a request file that Import reads back as it was. Collection variables become file
+// variables and notes become comments; requests in folders are marked with a # @folder comment, which other
+// clients ignore. Settings the format has no syntax for, e.g. SSL verification, assertions and examples, are not
+// written. Body lines the format would read as a separator or a response handler cannot be escaped without
+// changing the body other clients send; they are written as they are and listed in the returned warnings.
+func Export(export models.CollectionExport) (string, []models.ImportWarning) {
+	var b strings.Builder
+
+	for _, v := range export.Variables {
+		b.WriteString("@" + v.Key + " = " + v.Value + "\n")
+	}
+
+	blocks := []string{}
+	warnings := []models.ImportWarning{}
+	add := func(req models.RequestWithDetail, folder string) {
+		block, conflicts := request(req, folder)
+		blocks = append(blocks, block)
+		for _, line := range conflicts {
+			warnings = append(warnings, models.ImportWarning{
+				Item:    joinFolder(folder, req.Name),
+				Message: fmt.Sprintf("the body line %q is read back as %s, the body will not import as it was", line, conflictKind(line)),
+			})
+		}
+	}
+
+	for _, req := range export.Requests {
+		add(req.RequestWithDetail, "")
+	}
+	for _, f := range export.Folders {
+		for _, req := range f.Requests {
+			add(req.RequestWithDetail, f.Folder.Name)
+		}
+	}
+
+	for i, block := range blocks {
+		if i > 0 || len(export.Variables) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(block)
+	}
+
+	return b.String(), warnings
+}
+
+// request writes one request, from its ### separator to its response handlers, and returns the body lines that
+// would not be read back as part of the body.
+func request(req models.RequestWithDetail, folder string) (string, []string) {
+	var b strings.Builder
+
+	b.WriteString("### " + strings.Join(strings.Fields(req.Name), " ") + "\n")
+
+	if notes := strings.TrimSpace(req.Notes); notes != "" {
+		for _, line := range strings.Split(notes, "\n") {
+			// A note line starting with @ would be read as a directive; the backslash is dropped on import.
+			if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, `\`) {
+				line = `\` + line
+			}
+			b.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+		}
+	}
+	if folder != "" {
+		b.WriteString("# @folder " + folder + "\n")
+	}
+	if !req.AllowRedirects {
+		b.WriteString("# @no-redirect\n")
+	}
+	if !req.EncodeURL {
+		b.WriteString("# @no-auto-encoding\n")
+	}
+	if req.Timeout > 0 && req.Timeout != importing.DEFAULT_TIMEOUT_MS {
+		if req.Timeout%1000 == 0 {
+			b.WriteString("# @timeout " + strconv.Itoa(req.Timeout/1000) + "\n")
+		} else {
+			b.WriteString("# @timeout " + strconv.Itoa(req.Timeout) + " ms\n")
+		}
+	}
+
+	for _, s := range req.Scripts {
+		if s.Phase == models.ScriptPhasePreRequest {
+			b.WriteString("< {%\n" + strings.TrimSpace(s.Script) + "\n%}\n")
+		}
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	b.WriteString(method + " " + requestURL(req) + "\n")
+
+	fields := map[string]string{}
+	multipart := req.BodyFormat == "form-data" && req.Body != "" && json.Unmarshal([]byte(req.Body), &fields) == nil
+
+	hasContentType := false
+	for _, h := range req.Headers {
+		if h.Key == "" {
+			continue
+		}
+		if strings.EqualFold(h.Key, "Content-Type") {
+			// Multipart bodies need the boundary they are written with.
+			if multipart {
+				continue
+			}
+			hasContentType = true
+		}
+		b.WriteString(h.Key + ": " + h.Value + "\n")
+	}
+
+	if req.Body != "" && !hasContentType {
+		switch {
+		case multipart:
+			b.WriteString("Content-Type: multipart/form-data; boundary=" + BOUNDARY + "\n")
+		case req.BodyFormat == "JSON":
+			b.WriteString("Content-Type: application/json\n")
+		case req.BodyFormat == "XML":
+			b.WriteString("Content-Type: application/xml\n")
+		}
+	}
+
+	if len(req.Cookies) > 0 {
+		pairs := make([]string, 0, len(req.Cookies))
+		for _, c := range req.Cookies {
+			pairs = append(pairs, c.Key+"="+c.Value)
+		}
+		b.WriteString("Cookie: " + strings.Join(pairs, "; ") + "\n")
+	}
+
+	var body strings.Builder
+	switch {
+	case multipart:
+		body.WriteString("\n")
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			body.WriteString("--" + BOUNDARY + "\n")
+			body.WriteString(`Content-Disposition: form-data; name="` + strings.ReplaceAll(key, `"`, `\"`) + `"` + "\n\n")
+			body.WriteString(fields[key] + "\n")
+		}
+		body.WriteString("--" + BOUNDARY + "--\n")
+	case req.Body != "":
+		body.WriteString("\n" + strings.TrimRight(req.Body, "\n") + "\n")
+	}
+	b.WriteString(body.String())
+
+	var conflicts []string
+	for _, line := range strings.Split(body.String(), "\n") {
+		if conflictKind(line) != "" {
+			conflicts = append(conflicts, line)
+		}
+	}
+
+	for _, s := range req.Scripts {
+		if s.Phase == models.ScriptPhasePostResponse {
+			b.WriteString("\n> {%\n" + strings.TrimSpace(s.Script) + "\n%}\n")
+		}
+	}
+
+	return b.String(), conflicts
+}
+
+// conflictKind names what a body line is read back as by Import and other clients, empty for a plain body line.
+func conflictKind(line string) string {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "###"):
+		return "the separator of a new request"
+	case strings.HasPrefix(trimmed, "> "), strings.HasPrefix(trimmed, ">>"), strings.HasPrefix(trimmed, "<> "):
+		return "a response handler"
+	}
+	return ""
+}
+
+// requestURL writes the URL of a request with its query parameters, encoded unless the request keeps them as typed.
+func requestURL(req models.RequestWithDetail) string {
+	rawURL := strings.TrimSpace(req.URL)
+	if len(req.QueryParams) == 0 {
+		return rawURL
+	}
+
+	params := make([]string, 0, len(req.QueryParams))
+	for _, p := range req.QueryParams {
+		key, value := p.Key, p.Value
+		if req.EncodeURL {
+			key, value = importing.FormEscape(key), importing.FormEscape(value)
+		}
+		params = append(params, key+"="+value)
+	}
+
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + strings.Join(params, "&")
+}
@@ -0,0 +1,72 @@
+// Package httpfile reads and writes .http and .rest files, the plain text request format of the VS Code REST Client
+// extension and the JetBrains HTTP Client.
+package httpfile
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+)
+
+const SOURCE string = "http"
+
+// BOUNDARY separates the fields of exported multipart bodies.
+const BOUNDARY string = "WebAppBoundary"
+
+const (
+	// ENV_FILE and PRIVATE_ENV_FILE hold the JetBrains HTTP Client environments of the .http files in their directory.
+	ENV_FILE         string = "http-client.env.json"
+	PRIVATE_ENV_FILE string = "http-client.private.env.json"
+
+	// SHARED_ENV is the environment of an environment file whose variables every other environment has.
+	SHARED_ENV string = "$shared"
+)
+
+// FOLDER_SEPARATOR joins the directories of a file into the name of the folder its requests are put in.
+const FOLDER_SEPARATOR string = " / "
+
+// Extensions are the file extensions of request files.
+var Extensions = []string{".http", ".rest"}
+
+var (
+	// requestLine matches the line that starts a request, e.g. GET https://example.com/users HTTP/1.1.
+	requestLine = regexp.MustCompile(`^(?:([A-Z]+)\s+)?(\S+)(?:\s+HTTP/[0-9.]+)?\s*$`)
+
+	// httpVersion matches the HTTP version that may end the request line, or the last line of a long query.
+	httpVersion = regexp.MustCompile(`\s+HTTP/[0-9.]+$`)
+
+	// fileVariable matches file variables, e.g. @host = https://example.com.
+	fileVariable = regexp.MustCompile(`^@([A-Za-z0-9_\-.]+)\s*=\s*(.*)$`)
+
+	// directive matches comments that set an option of the next request, e.g. # @name login or // @no-redirect.
+	directive = regexp.MustCompile(`^(?:#|//)\s*@([A-Za-z][A-Za-z0-9_\-]*)\s*(.*)$`)
+
+	// variableRef matches variable references; names starting with $ are dynamic variables, e.g. {{$guid}}.
+	variableRef = regexp.MustCompile(`\{\{\s*([^{}\s]+)[^{}]*\}\}`)
+
+	// chainedRef matches references to another request, e.g. {{login.response.body.$.token}}.
+	chainedRef = regexp.MustCompile(`^[A-Za-z0-9_\-]+\.(?:request|response)\.`)
+
+	// clientAPI matches the JetBrains HTTP Client script API, e.g. client.test or request.variables.set.
+	clientAPI = regexp.MustCompile(`\bclient\.[A-Za-z]|\brequest\.variables\b`)
+)
+
+// Detect reports whether data looks like a request file: text with a request line that has a method.
+func Detect(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := requestLine.FindStringSubmatch(line); m != nil && importing.IsMethod(m[1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package httpfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// newRequest returns a request with the settings Import gives requests that set none.
+func newRequest(name, method, url string) models.RequestWithDetail {
+	return models.RequestWithDetail{
+		Request: models.Request{
+			Name:            name,
+			Method:          method,
+			URL:             url,
+			BodyFormat:      "raw",
+			Timeout:         importing.DEFAULT_TIMEOUT_MS,
+			AllowRedirects:  true,
+			SSLVerification: true,
+			EncodeURL:       true,
+		},
+		Headers:     []models.RequestHeader{},
+		QueryParams: []models.RequestQueryParam{},
+		Cookies:     []models.RequestCookie{},
+		Scripts:     []models.RequestScript{},
+	}
+}
+
+// roundTrip exports a collection with one request, in folder when set, and imports it back.
+func roundTrip(t *testing.T, req models.RequestWithDetail, folder string) (string, []models.ImportWarning, models.RequestWithDetail, string) {
+	t.Helper()
+
+	export := models.CollectionExport{Requests: []models.RequestExport{}, Folders: []models.FolderExport{}}
+	if folder == "" {
+		export.Requests = append(export.Requests, models.RequestExport{RequestWithDetail: req})
+	} else {
+		export.Folders = append(export.Folders, models.FolderExport{
+			Folder:   models.Folder{Name: folder},
+			Requests: []models.RequestExport{{RequestWithDetail: req}},
+		})
+	}
+
+	text, warnings := Export(export)
+
+	imported, _, err := Import("API", []File{{Path: "api.http", Data: []byte(text)}}, nil)
+	if err != nil {
+		t.Fatalf("importing the export: %v\n%s", err, text)
+	}
+
+	collection := imported.Collections[0]
+	switch {
+	case len(collection.Requests) == 1:
+		return text, warnings, collection.Requests[0].RequestWithDetail, ""
+	case len(collection.Folders) == 1 && len(collection.Folders[0].Requests) == 1:
+		return text, warnings, collection.Folders[0].Requests[0].RequestWithDetail, collection.Folders[0].Folder.Name
+	}
+
+	t.Fatalf("imported %d requests and %d folders, want one request:\n%s", len(collection.Requests), len(collection.Folders), text)
+	return "", nil, models.RequestWithDetail{}, ""
+}
+
+func TestExportImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func(req *models.RequestWithDetail)
+		folder string
+	}{
+		{
+			name: "plain get",
+			edit: func(req *models.RequestWithDetail) {},
+		},
+		{
+			name: "query, headers and json body",
+			edit: func(req *models.RequestWithDetail) {
+				req.Method = "POST"
+				req.QueryParams = []models.RequestQueryParam{{Key: "q", Value: "two words"}, {Key: "ref", Value: "{{ref}}"}}
+				req.Headers = []models.RequestHeader{{Key: "Authorization", Value: "Bearer {{token}}"}}
+				req.Body = "{\n  \"name\": \"Ada\"\n}"
+				req.BodyFormat = "JSON"
+			},
+		},
+		{
+			name: "settings",
+			edit: func(req *models.RequestWithDetail) {
+				req.AllowRedirects = false
+				req.EncodeURL = false
+				req.Timeout = 1500
+			},
+		},
+		{
+			name:   "folder",
+			edit:   func(req *models.RequestWithDetail) {},
+			folder: "Users",
+		},
+		{
+			name: "notes that read as directives",
+			edit: func(req *models.RequestWithDetail) {
+				req.Notes = "@folder is not a folder\n  @no-redirect either\n\\ starts with a backslash\nplain"
+			},
+		},
+		{
+			name: "notes that read as separators",
+			edit: func(req *models.RequestWithDetail) {
+				req.Notes = "### not a request\n> not a handler"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest("List users", "GET", "https://api.example.com/users")
+			tt.edit(&req)
+
+			text, warnings, back, folder := roundTrip(t, req, tt.folder)
+			if len(warnings) != 0 {
+				t.Errorf("export warnings = %+v, want none", warnings)
+			}
+
+			if folder != tt.folder {
+				t.Errorf("folder = %q, want %q", folder, tt.folder)
+			}
+			if back.Name != req.Name || back.Method != req.Method || back.URL != req.URL {
+				t.Errorf("request = %q %s %s, want %q %s %s\n%s", back.Name, back.Method, back.URL, req.Name, req.Method, req.URL, text)
+			}
+			if !reflect.DeepEqual(back.QueryParams, req.QueryParams) {
+				t.Errorf("query = %+v, want %+v\n%s", back.QueryParams, req.QueryParams, text)
+			}
+			if !reflect.DeepEqual(back.Headers, req.Headers) {
+				t.Errorf("headers = %+v, want %+v\n%s", back.Headers, req.Headers, text)
+			}
+			if back.Body != req.Body || back.BodyFormat != req.BodyFormat {
+				t.Errorf("body = %q (%s), want %q (%s)\n%s", back.Body, back.BodyFormat, req.Body, req.BodyFormat, text)
+			}
+			if back.Notes != req.Notes {
+				t.Errorf("notes = %q, want %q\n%s", back.Notes, req.Notes, text)
+			}
+			if back.AllowRedirects != req.AllowRedirects || back.EncodeURL != req.EncodeURL || back.Timeout != req.Timeout {
+				t.Errorf("settings = redirects %v, encode %v, timeout %d; want %v, %v, %d\n%s", back.AllowRedirects, back.EncodeURL,
+					back.Timeout, req.AllowRedirects, req.EncodeURL, req.Timeout, text)
+			}
+		})
+	}
+}
+
+func TestExportBodyConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		kind string // what the conflicting line is read back as, empty for none
+	}{
+		{name: "plain", body: "line one\n  ## heading\n-> arrow"},
+		{name: "separator", body: "before\n### after", kind: "separator"},
+		{name: "indented separator", body: "before\n  ### after"},
+		{name: "response handler", body: "before\n> handler.js", kind: "response handler"},
+		{name: "response redirect", body: "before\n>> out.json", kind: "response handler"},
+		{name: "comparison", body: "before\n<> previous.json", kind: "response handler"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest("Send", "POST", "https://api.example.com/messages")
+			req.Body = tt.body
+
+			text, warnings, back, _ := roundTrip(t, req, "")
+
+			if tt.kind == "" {
+				if len(warnings) != 0 {
+					t.Errorf("warnings = %+v, want none", warnings)
+				}
+				if back.Body != tt.body {
+					t.Errorf("body = %q, want %q\n%s", back.Body, tt.body, text)
+				}
+				return
+			}
+
+			if len(warnings) != 1 || !strings.Contains(warnings[0].Message, tt.kind) {
+				t.Errorf("warnings = %+v, want one about a %s", warnings, tt.kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,625 @@
+package httpfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// File is a request or environment file to import.
+type File struct {
+	Path   string // as shown in errors
+	Folder string // folder the requests of the file go in, empty for the collection itself
+	Data   []byte
+}
+
+// Import converts request files into one collection, and JetBrains environment files into environments named after
+// it; values of a private environment file win over the ones of the shared file. File variables become collection
+// variables, ### separators start requests and comments before a request become its notes. Pre-request scripts
+// (< {% %}) and response handlers (> {% %}) become scripts. Whatever cannot be mapped, e.g. bodies read from files
+// or dynamic variables, is listed in the report's warnings.
+func Import(name string, files []File, environments []File) (models.WorkspaceImport, models.ImportReport, error) {
+	im := &importer{
+		report: models.ImportReport{Name: name, Source: SOURCE, Warnings: []models.ImportWarning{}},
+		export: models.CollectionExport{
+			Version:    models.COLLECTION_EXPORT_VERSION,
+			Collection: models.Collection{Name: name},
+			Variables:  []models.CollectionVariable{},
+			Modules:    []models.ScriptModule{},
+			Schemas:    []models.CollectionSchema{},
+			DataFiles:  []models.CollectionDataFile{},
+			Folders:    []models.FolderExport{},
+			Requests:   []models.RequestExport{},
+		},
+		folders:   map[string]int{},
+		variables: map[string]int{},
+	}
+
+	for _, f := range files {
+		im.file(f)
+	}
+
+	envs, err := im.environments(name, environments)
+	if err != nil {
+		return models.WorkspaceImport{}, models.ImportReport{}, err
+	}
+
+	if im.report.Requests == 0 && len(envs) == 0 {
+		return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("no requests found"))
+	}
+
+	im.report.Folders = len(im.export.Folders)
+	im.report.Environments = len(envs)
+	return models.WorkspaceImport{Collections: []models.CollectionExport{im.export}, Environments: envs}, im.report, nil
+}
+
+type importer struct {
+	report    models.ImportReport
+	export    models.CollectionExport
+	folders   map[string]int // folder name to index in export.Folders
+	variables map[string]int // variable name to index in export.Variables
+}
+
+func (im *importer) warn(where string, format string, args ...any) {
+	im.report.Warnings = append(im.report.Warnings, models.ImportWarning{Item: where, Message: fmt.Sprintf(format, args...)})
+}
+
+// file splits a file into the blocks between ### separators and converts them.
+func (im *importer) file(f File) {
+	lines := strings.Split(strings.ReplaceAll(string(f.Data), "\r\n", "\n"), "\n")
+
+	title := ""
+	start := 0
+	for i, line := range lines {
+		if strings.HasPrefix(line, "###") {
+			im.block(f, title, lines[start:i])
+			title = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			start = i + 1
+		}
+	}
+	im.block(f, title, lines[start:])
+}
+
+// block converts the lines between two separators: variables, comments and a pre-request script, then the request
+// line, the headers, a blank line, the body and response handlers.
+func (im *importer) block(f File, title string, lines []string) {
+	var (
+		notes      []string
+		directives [][2]string
+		pre        []string
+	)
+
+	where := f.Path
+	if title != "" {
+		where = f.Path + ": " + title
+	}
+
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if m := fileVariable.FindStringSubmatch(line); m != nil {
+			im.variable(where, m[1], strings.TrimSpace(m[2]))
+			continue
+		}
+		if m := directive.FindStringSubmatch(line); m != nil {
+			directives = append(directives, [2]string{m[1], strings.TrimSpace(m[2])})
+			continue
+		}
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#"):
+			notes = append(notes, comment(line, "#"))
+		case strings.HasPrefix(line, "//"):
+			notes = append(notes, comment(line, "//"))
+		case strings.HasPrefix(line, "< {%"):
+			var script string
+			script, i = readScript(lines, i)
+			pre = append(pre, script)
+		case strings.HasPrefix(line, "< "):
+			im.warn(where, "pre-request script files are not supported, %s was skipped", strings.TrimSpace(line[2:]))
+		default:
+			im.request(f, title, notes, directives, pre, lines[i:])
+			return
+		}
+	}
+}
+
+// request converts the lines from the request line to the end of a block.
+func (im *importer) request(f File, title string, notes []string, directives [][2]string, pre []string, lines []string) {
+	m := requestLine.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if m == nil {
+		im.warn(f.Path, "%q is not a request line, the lines up to the next ### were skipped", strings.TrimSpace(lines[0]))
+		return
+	}
+
+	method, rawURL := m[1], m[2]
+	if method == "" {
+		method = "GET"
+	}
+
+	// Long queries may continue on the next lines, each indented and starting with ? or &.
+	i := 1
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if lines[i] == line || (!strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&")) {
+			break
+		}
+		rawURL += httpVersion.ReplaceAllString(line, "")
+	}
+
+	req := models.RequestExport{Examples: []models.RequestExample{}}
+	req.Method = method
+	req.BodyFormat = "raw"
+	req.Timeout = importing.DEFAULT_TIMEOUT_MS
+	req.AllowRedirects = true
+	req.SSLVerification = true
+	req.EncodeURL = true
+	req.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+	req.Headers = []models.RequestHeader{}
+	req.QueryParams = []models.RequestQueryParam{}
+	req.Cookies = []models.RequestCookie{}
+	req.Scripts = []models.RequestScript{}
+	req.Assertions = []models.RequestAssertion{}
+	req.SkipConditions = []models.SkipCondition{}
+
+	name := title
+	folder := f.Folder
+	var unsupported []string
+	for _, d := range directives {
+		switch d[0] {
+		case "name":
+			// The text of the separator names the request in both clients when it has one.
+			if name == "" {
+				name = d[1]
+			}
+		case "folder":
+			folder = joinFolder(folder, d[1])
+		case "no-redirect":
+			req.AllowRedirects = false
+		case "no-auto-encoding":
+			req.EncodeURL = false
+		case "timeout":
+			if timeout, ok := parseTimeout(d[1]); ok {
+				req.Timeout = timeout
+			} else {
+				unsupported = append(unsupported, "@timeout "+d[1])
+			}
+		case "no-log", "no-cookie-jar":
+			// They change what the client keeps, not the request.
+		default:
+			unsupported = append(unsupported, "@"+d[0])
+		}
+	}
+	if name == "" {
+		name = method + " " + importing.RequestName(rawURL)
+	}
+	req.Name = name
+	where := joinFolder(folder, name)
+
+	if !importing.IsMethod(method) {
+		im.warn(where, "method %s is not supported, the request was skipped", method)
+		return
+	}
+	if len(unsupported) > 0 {
+		im.warn(where, "directives not supported: %s", strings.Join(unsupported, ", "))
+	}
+
+	host := ""
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			// The body starts without the blank line.
+			break
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch strings.ToLower(key) {
+		case "cookie":
+			for _, pair := range strings.Split(value, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if name != "" {
+					req.Cookies = append(req.Cookies, models.RequestCookie{Key: name, Value: value})
+				}
+			}
+		case "host":
+			host = value
+			req.Headers = append(req.Headers, models.RequestHeader{Key: key, Value: value})
+		default:
+			req.Headers = append(req.Headers, models.RequestHeader{Key: key, Value: value})
+		}
+	}
+
+	// Requests may give only the path, with the host in the Host header.
+	if strings.HasPrefix(rawURL, "/") && host != "" {
+		scheme := "http://"
+		if strings.HasSuffix(host, ":443") {
+			scheme = "https://"
+		}
+		rawURL = scheme + host + rawURL
+		req.Headers = importing.WithoutHeader(req.Headers, "Host")
+	}
+
+	rawURL, _, _ = strings.Cut(rawURL, "#")
+	rawURL, rawQuery, _ := strings.Cut(rawURL, "?")
+	req.URL = rawURL
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		if req.EncodeURL {
+			key, value = importing.Unescape(key), importing.Unescape(value)
+		}
+		req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: key, Value: value})
+	}
+
+	var body []string
+	var post []string
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(line, "> {%"):
+			var script string
+			script, i = readScript(lines, i)
+			post = append(post, script)
+		case strings.HasPrefix(line, "> "):
+			im.warn(where, "response handler files are not supported, %s was skipped", strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, ">>"):
+			// Where the client saves the response.
+		case strings.HasPrefix(line, "<> "):
+			// A response the client saved earlier, to compare the new one with.
+		case len(post) == 0:
+			body = append(body, lines[i])
+		}
+	}
+
+	text := strings.TrimRight(strings.Join(body, "\n"), "\n\t ")
+	if strings.HasPrefix(text, "< ") && !strings.Contains(text, "\n") {
+		im.warn(where, "bodies read from files are not supported, %s was skipped", strings.TrimSpace(text[2:]))
+		text = ""
+	}
+	im.body(where, text, &req.RequestWithDetail)
+
+	for _, script := range pre {
+		req.Scripts = append(req.Scripts, models.RequestScript{Phase: models.ScriptPhasePreRequest, Script: script})
+	}
+	for _, script := range post {
+		req.Scripts = append(req.Scripts, models.RequestScript{Phase: models.ScriptPhasePostResponse, Script: script})
+	}
+	for _, s := range req.Scripts {
+		if clientAPI.MatchString(s.Script) {
+			im.warn(where, "the %s script uses the JetBrains client API, rewrite it with tapa.* before running it", s.Phase)
+		}
+	}
+
+	im.references(where, req.RequestWithDetail)
+
+	im.report.Requests++
+	im.report.Scripts += len(req.Scripts)
+
+	if folder == "" {
+		im.export.Requests = append(im.export.Requests, req)
+		return
+	}
+
+	index, ok := im.folders[folder]
+	if !ok {
+		index = len(im.export.Folders)
+		im.folders[folder] = index
+		im.export.Folders = append(im.export.Folders, models.FolderExport{
+			Folder:   models.Folder{Name: folder},
+			Requests: []models.RequestExport{},
+		})
+	}
+	im.export.Folders[index].Requests = append(im.export.Folders[index].Requests, req)
+}
+
+// body sets the body of req, with the body format its Content-Type calls for.
+func (im *importer) body(where string, text string, req *models.RequestWithDetail) {
+	if text == "" {
+		return
+	}
+
+	contentType := ""
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			contentType = h.Value
+		}
+	}
+
+	switch mt := importing.MediaType(contentType); {
+	case mt == "multipart/form-data":
+		fields, ok := im.multipart(where, text, contentType)
+		if !ok {
+			req.Body = text
+			return
+		}
+		encoded, _ := json.Marshal(fields)
+		req.Body = string(encoded)
+		req.BodyFormat = "form-data"
+		// The boundary is chosen when the request is sent.
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+		return
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		req.Body = text
+		req.BodyFormat = "JSON"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		req.Body = text
+		req.BodyFormat = "XML"
+	default:
+		req.Body = text
+	}
+
+	// JSON and XML bodies get their Content-Type from the body format.
+	if (req.BodyFormat == "JSON" && strings.EqualFold(contentType, "application/json")) ||
+		(req.BodyFormat == "XML" && strings.EqualFold(contentType, "application/xml")) {
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	}
+}
+
+// multipart reads the fields of a multipart body written out in the file. Fields read from files are skipped.
+func (im *importer) multipart(where string, text string, contentType string) (map[string]string, bool) {
+	_, params, err := mime.ParseMediaType(contentType)
+	boundary := params["boundary"]
+	if err != nil || boundary == "" {
+		im.warn(where, "the multipart body has no boundary and was imported as text")
+		return nil, false
+	}
+
+	fields := map[string]string{}
+	var (
+		name    string
+		value   []string
+		inPart  bool
+		inValue bool
+	)
+	end := func() {
+		if inPart && name != "" {
+			joined := strings.Join(value, "\n")
+			if strings.HasPrefix(joined, "< ") {
+				im.warn(where, "file field %s was skipped, files cannot be attached to requests", name)
+			} else {
+				fields[name] = joined
+			}
+		}
+		name, value, inValue = "", nil, false
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "--"+boundary+"--":
+			end()
+			return fields, true
+		case strings.TrimSpace(line) == "--"+boundary:
+			end()
+			inPart = true
+		case !inPart:
+		case inValue:
+			value = append(value, line)
+		case strings.TrimSpace(line) == "":
+			inValue = true
+		default:
+			key, v, _ := strings.Cut(line, ":")
+			if !strings.EqualFold(strings.TrimSpace(key), "Content-Disposition") {
+				continue
+			}
+			_, disposition, err := mime.ParseMediaType(strings.TrimSpace(v))
+			if err != nil {
+				continue
+			}
+			name = disposition["name"]
+			if disposition["filename"] != "" {
+				im.warn(where, "file field %s was skipped, files cannot be attached to requests", name)
+				name = ""
+			}
+		}
+	}
+	end()
+
+	return fields, len(fields) > 0
+}
+
+// variable adds or, when the file sets it again, updates a collection variable.
+func (im *importer) variable(where, key, value string) {
+	for _, ref := range variableRef.FindAllStringSubmatch(value, -1) {
+		if strings.HasPrefix(ref[1], "$") {
+			im.warn(where, "variable %s uses the dynamic variable {{%s}}, which is not supported", key, ref[1])
+		}
+	}
+
+	if i, ok := im.variables[key]; ok {
+		im.export.Variables[i].Value = value
+		return
+	}
+	im.variables[key] = len(im.export.Variables)
+	im.export.Variables = append(im.export.Variables, models.CollectionVariable{Key: key, Value: value})
+	im.report.Variables++
+}
+
+// references warns about the dynamic variables and references to other requests of a request, which TAPA
+// does not resolve.
+func (im *importer) references(where string, req models.RequestWithDetail) {
+	texts := []string{req.URL, req.Body}
+	for _, h := range req.Headers {
+		texts = append(texts, h.Key, h.Value)
+	}
+	for _, p := range req.QueryParams {
+		texts = append(texts, p.Key, p.Value)
+	}
+	for _, c := range req.Cookies {
+		texts = append(texts, c.Value)
+	}
+
+	dynamic := map[string]bool{}
+	chained := map[string]bool{}
+	for _, text := range texts {
+		for _, ref := range variableRef.FindAllStringSubmatch(text, -1) {
+			switch {
+			case strings.HasPrefix(ref[1], "$"):
+				dynamic["{{"+ref[1]+"}}"] = true
+			case chainedRef.MatchString(ref[1]):
+				chained["{{"+ref[1]+"}}"] = true
+			}
+		}
+	}
+
+	if len(dynamic) > 0 {
+		im.warn(where, "dynamic variables are not supported: %s", strings.Join(sortedSet(dynamic), ", "))
+	}
+	if len(chained) > 0 {
+		im.warn(where, "references to other requests are not supported, set the values from a script: %s", strings.Join(sortedSet(chained), ", "))
+	}
+}
+
+// environments reads JetBrains environment files, {"dev": {"host": "..."}, ...}, into environments.
+func (im *importer) environments(name string, files []File) ([]models.EnvironmentExport, error) {
+	values := map[string]map[string]string{}
+	for _, f := range files {
+		var envs map[string]map[string]any
+		if err := json.Unmarshal(f.Data, &envs); err != nil {
+			return nil, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("%s: %w", f.Path, err))
+		}
+
+		for env, vars := range envs {
+			if values[env] == nil {
+				values[env] = map[string]string{}
+			}
+			for key, v := range vars {
+				switch v := v.(type) {
+				case string:
+					values[env][key] = v
+				case float64:
+					values[env][key] = strconv.FormatFloat(v, 'f', -1, 64)
+				case bool:
+					values[env][key] = strconv.FormatBool(v)
+				default:
+					// e.g. SSLConfiguration, which configures the client rather than setting a variable.
+					im.warn(f.Path, "%s of environment %s is not a variable and was skipped", key, env)
+				}
+			}
+		}
+	}
+
+	shared := values[SHARED_ENV]
+	delete(values, SHARED_ENV)
+
+	names := make([]string, 0, len(values))
+	for env := range values {
+		names = append(names, env)
+	}
+	sort.Strings(names)
+
+	envs := []models.EnvironmentExport{}
+	for _, env := range names {
+		for key, value := range shared {
+			if _, ok := values[env][key]; !ok {
+				values[env][key] = value
+			}
+		}
+
+		keys := make([]string, 0, len(values[env]))
+		for key := range values[env] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		export := models.EnvironmentExport{
+			Environment: models.Environment{Name: name + " - " + env},
+			Variables:   make([]models.EnvironmentVariable, 0, len(keys)),
+		}
+		for _, key := range keys {
+			export.Variables = append(export.Variables, models.EnvironmentVariable{Key: key, Value: values[env][key]})
+		}
+		envs = append(envs, export)
+	}
+
+	return envs, nil
+}
+
+// readScript reads the script between {% and %} that starts on line i, and returns it with the line it ends on.
+func readScript(lines []string, i int) (string, int) {
+	_, rest, _ := strings.Cut(lines[i], "{%")
+	if script, _, ok := strings.Cut(rest, "%}"); ok {
+		return strings.TrimSpace(script), i
+	}
+
+	script := []string{rest}
+	for i++; i < len(lines); i++ {
+		if before, _, ok := strings.Cut(lines[i], "%}"); ok {
+			script = append(script, before)
+			break
+		}
+		script = append(script, lines[i])
+	}
+	return strings.TrimSpace(strings.Join(script, "\n")), i
+}
+
+// parseTimeout reads the value of a @timeout directive: seconds, or a number with the unit ms, s or m.
+func parseTimeout(value string) (int, bool) {
+	number := strings.TrimRight(value, "abcdefghijklmnopqrstuvwxyz ")
+	unit := strings.TrimSpace(value[len(number):])
+
+	n, err := strconv.Atoi(strings.TrimSpace(number))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	switch unit {
+	case "ms":
+		return n, true
+	case "", "s":
+		return n * 1000, true
+	case "m":
+		return n * 60 * 1000, true
+	}
+	return 0, false
+}
+
+// comment returns the text of a comment line. A backslash Export put before a note line that would be read as a
+// directive, or that starts with a backslash itself, is dropped.
+func comment(line, prefix string) string {
+	text := strings.TrimPrefix(line, prefix)
+	text = strings.TrimPrefix(text, " ")
+	if rest, ok := strings.CutPrefix(text, `\`); ok {
+		if trimmed := strings.TrimLeft(rest, " \t"); strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, `\`) {
+			return rest
+		}
+	}
+	return text
+}
+
+func joinFolder(parent, name string) string {
+	switch {
+	case parent == "":
+		return name
+	case name == "":
+		return parent
+	}
+	return parent + FOLDER_SEPARATOR + name
+}
+
+func sortedSet(set map[string]bool) []string {
+	values := make([]string, 0, len(set))
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,108 @@
+// Package importing holds what the importers of other tools' formats share when they build TAPA requests.
+package importing
+
+import (
+	"encoding/json"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// DEFAULT_TIMEOUT_MS is the timeout of imported requests whose source sets none.
+const DEFAULT_TIMEOUT_MS int = 30000
+
+var (
+	methods = map[string]bool{"GET": true, "POST": true, "PUT": true, "DELETE": true, "PATCH": true, "OPTIONS": true, "HEAD": true}
+
+	variableRef = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+)
+
+// IsMethod reports whether requests can be stored with the upper case method.
+func IsMethod(method string) bool {
+	return methods[method]
+}
+
+// HasHeader reports whether headers has key, ignoring case.
+func HasHeader(headers []models.RequestHeader, key string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutHeader removes key, ignoring case, from headers in place.
+func WithoutHeader(headers []models.RequestHeader, key string) []models.RequestHeader {
+	out := headers[:0]
+	for _, h := range headers {
+		if !strings.EqualFold(h.Key, key) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// Unescape decodes a query or form component, keeping it as it is when it is not validly encoded.
+func Unescape(s string) string {
+	if decoded, err := neturl.QueryUnescape(s); err == nil {
+		return decoded
+	}
+	return s
+}
+
+// FormEscape URL-encodes a form field or query component, leaving {{variable}} references intact so they still resolve.
+func FormEscape(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range variableRef.FindAllStringIndex(s, -1) {
+		b.WriteString(neturl.QueryEscape(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(neturl.QueryEscape(s[last:]))
+
+	return b.String()
+}
+
+// MediaType returns the media type of a Content-Type value, lowercase and without parameters.
+func MediaType(contentType string) string {
+	base, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// RequestName names a request after its URL's path, or its host when the path is empty.
+func RequestName(rawURL string) string {
+	rest, _, _ := strings.Cut(rawURL, "?")
+	if _, after, ok := strings.Cut(rest, "://"); ok {
+		rest = after
+	}
+
+	host, path, _ := strings.Cut(rest, "/")
+	if strings.Trim(path, "/") == "" {
+		return host
+	}
+	return "/" + path
+}
+
+// ToJSON encodes v for the JSON columns of the database, empty when it cannot be encoded.
+func ToJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// SortedKeys returns the keys of m in order, so imports do not depend on map iteration.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,504 @@
+package insomnia
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// skippedKinds names the resources that have no TAPA counterpart, for the report.
+var skippedKinds = map[string]string{
+	"grpc_request":      "gRPC requests",
+	"websocket_request": "WebSocket requests",
+	"unit_test_suite":   "unit test suites",
+	"api_spec":          "API design documents",
+	"cookie_jar":        "cookie jars",
+	"proto_file":        "proto files",
+	"proto_directory":   "proto directories",
+	"mock_server":       "mock servers",
+}
+
+// orphans is the parent of resources whose workspace is not part of the export.
+const orphans string = ""
+
+// Import converts an Insomnia v4 export into one collection per workspace. The base environment of a workspace
+// becomes its collection variables and every sub environment a TAPA environment named after the workspace.
+// Nested folders become one folder each, named after their path; folder auth and headers are copied into the
+// requests that inherit them. Whatever cannot be mapped is listed in the report's warnings.
+func Import(data []byte) (models.WorkspaceImport, models.ImportReport, error) {
+	if !Detect(data) {
+		return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("not an Insomnia export"))
+	}
+
+	var e export
+	if err := json.Unmarshal(data, &e); err != nil {
+		return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+	}
+	if e.Format != EXPORT_FORMAT {
+		return models.WorkspaceImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport,
+			fmt.Errorf("Insomnia export format %d is not supported, export the data as Insomnia v4 JSON", e.Format))
+	}
+
+	im := &importer{
+		workspace: models.WorkspaceImport{Collections: []models.CollectionExport{}, Environments: []models.EnvironmentExport{}},
+		report:    models.ImportReport{Source: SOURCE, Warnings: []models.ImportWarning{}},
+		children:  map[string][]resource{},
+		skipped:   map[string]int{},
+	}
+
+	ids := map[string]bool{}
+	for _, r := range e.Resources {
+		ids[r.ID] = true
+	}
+
+	var workspaces []resource
+	for _, r := range e.Resources {
+		switch {
+		case r.Type == "workspace":
+			workspaces = append(workspaces, r)
+		case !ids[r.ParentID]:
+			im.children[orphans] = append(im.children[orphans], r)
+		default:
+			im.children[r.ParentID] = append(im.children[r.ParentID], r)
+		}
+	}
+	for _, children := range im.children {
+		sort.SliceStable(children, func(i, j int) bool { return children[i].SortKey < children[j].SortKey })
+	}
+
+	// Exports of a folder or a few requests leave their workspace out.
+	if len(im.children[orphans]) > 0 {
+		workspaces = append(workspaces, resource{ID: orphans, Name: "Insomnia import"})
+	}
+
+	names := []string{}
+	for _, ws := range workspaces {
+		names = append(names, im.importWorkspace(ws))
+	}
+	im.report.Name = strings.Join(names, ", ")
+
+	kinds := make([]string, 0, len(im.skipped))
+	for kind := range im.skipped {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		im.warn("", "skipped %s (%d)", kind, im.skipped[kind])
+	}
+
+	return im.workspace, im.report, nil
+}
+
+type importer struct {
+	workspace models.WorkspaceImport
+	report    models.ImportReport
+	children  map[string][]resource // resources by the id of their parent, in Insomnia's order
+	skipped   map[string]int        // resources without a TAPA counterpart, counted by kind
+	tags      map[string]bool       // template tags met in the request being converted
+}
+
+// collection is a workspace being converted.
+type collection struct {
+	export    models.CollectionExport
+	folders   map[string]int // folder name to index in export.Folders
+	variables map[string]bool
+}
+
+// inheritance is what requests take over from the folders they are in.
+type inheritance struct {
+	auth    map[string]any
+	headers []pair
+}
+
+func (im *importer) warn(where string, format string, args ...any) {
+	im.report.Warnings = append(im.report.Warnings, models.ImportWarning{Item: where, Message: fmt.Sprintf(format, args...)})
+}
+
+// importWorkspace converts a workspace and its environments and returns the name of its collection.
+func (im *importer) importWorkspace(ws resource) string {
+	name := strings.TrimSpace(ws.Name)
+	if name == "" {
+		name = "Insomnia workspace"
+	}
+
+	c := &collection{
+		export: models.CollectionExport{
+			Version:    models.COLLECTION_EXPORT_VERSION,
+			Collection: models.Collection{Name: name, Description: ws.Description},
+			Variables:  []models.CollectionVariable{},
+			Modules:    []models.ScriptModule{},
+			Schemas:    []models.CollectionSchema{},
+			DataFiles:  []models.CollectionDataFile{},
+			Folders:    []models.FolderExport{},
+			Requests:   []models.RequestExport{},
+		},
+		folders:   map[string]int{},
+		variables: map[string]bool{},
+	}
+
+	for _, base := range im.children[ws.ID] {
+		if base.Type != "environment" {
+			continue
+		}
+
+		for _, v := range flatten(base.Data) {
+			im.variable(c, v.Key, v.Value)
+		}
+
+		for _, sub := range im.children[base.ID] {
+			if sub.Type != "environment" {
+				continue
+			}
+			im.workspace.Environments = append(im.workspace.Environments, models.EnvironmentExport{
+				Environment: models.Environment{Name: name + " - " + strings.TrimSpace(sub.Name)},
+				Variables:   flatten(sub.Data),
+			})
+			im.report.Environments++
+		}
+	}
+
+	im.walk(c, ws.ID, nil, inheritance{})
+
+	im.workspace.Collections = append(im.workspace.Collections, c.export)
+	im.report.Folders += len(c.export.Folders)
+	return name
+}
+
+// variable adds a collection variable unless the collection has one of that name already.
+func (im *importer) variable(c *collection, key, value string) bool {
+	if key == "" || c.variables[key] {
+		return false
+	}
+	c.variables[key] = true
+	c.export.Variables = append(c.export.Variables, models.CollectionVariable{Key: key, Value: value})
+	im.report.Variables++
+	return true
+}
+
+func (im *importer) walk(c *collection, parentID string, path []string, inherited inheritance) {
+	for _, r := range im.children[parentID] {
+		switch r.Type {
+		case "request_group":
+			name := strings.TrimSpace(r.Name)
+			if name == "" {
+				name = "Untitled folder"
+			}
+			folderPath := append(append([]string{}, path...), name)
+			where := strings.Join(folderPath, FOLDER_SEPARATOR)
+
+			added := false
+			for _, v := range flatten(r.Environment) {
+				added = im.variable(c, v.Key, v.Value) || added
+			}
+			if added {
+				im.warn(where, "folder variables were added to the collection variables")
+			}
+
+			// Folders holding requests are added before their subfolders, whatever order Insomnia sorts them in.
+			for _, child := range im.children[r.ID] {
+				if child.Type == "request" {
+					c.folder(folderPath)
+					break
+				}
+			}
+
+			next := inheritance{auth: inherited.auth, headers: append(append([]pair{}, r.Headers...), inherited.headers...)}
+			if len(r.Authentication) > 0 {
+				next.auth = r.Authentication
+			}
+			im.walk(c, r.ID, folderPath, next)
+		case "request":
+			im.request(c, r, path, inherited)
+		case "environment", "workspace":
+			// Environments are read with their workspace.
+		default:
+			kind, ok := skippedKinds[r.Type]
+			if !ok {
+				kind = r.Type + " resources"
+			}
+			im.skipped[kind]++
+		}
+	}
+}
+
+func (im *importer) request(c *collection, r resource, path []string, inherited inheritance) {
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		name = "Untitled request"
+	}
+	where := strings.Join(append(append([]string{}, path...), name), FOLDER_SEPARATOR)
+
+	method := strings.ToUpper(strings.TrimSpace(r.Method))
+	if method == "" {
+		method = "GET"
+	}
+	if !importing.IsMethod(method) {
+		im.warn(where, "method %s is not supported, the request was skipped", method)
+		return
+	}
+
+	im.tags = map[string]bool{}
+
+	req := models.RequestExport{Examples: []models.RequestExample{}}
+	req.Name = name
+	req.Method = method
+	req.BodyFormat = "raw"
+	req.Timeout = importing.DEFAULT_TIMEOUT_MS
+	req.AllowRedirects = r.SettingFollowRedirects != "off"
+	req.SSLVerification = true
+	req.EncodeURL = r.SettingEncodeURL == nil || *r.SettingEncodeURL
+	req.Notes = r.Description
+	req.Headers = []models.RequestHeader{}
+	req.QueryParams = []models.RequestQueryParam{}
+	req.Cookies = []models.RequestCookie{}
+	req.Scripts = []models.RequestScript{}
+	req.Assertions = []models.RequestAssertion{}
+	req.SkipConditions = []models.SkipCondition{}
+
+	rawURL, _, _ := strings.Cut(im.text(strings.TrimSpace(r.URL)), "#")
+	rawURL, rawQuery, _ := strings.Cut(rawURL, "?")
+	req.URL = rawURL
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: importing.Unescape(key), Value: importing.Unescape(value)})
+	}
+	for _, p := range r.Parameters {
+		if !p.Disabled && p.Name != "" {
+			req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: im.text(p.Name), Value: im.text(p.Value)})
+		}
+	}
+
+	// Headers of the request win over the ones of its folders.
+	for _, h := range append(append([]pair{}, r.Headers...), inherited.headers...) {
+		if !h.Disabled && strings.TrimSpace(h.Name) != "" && !importing.HasHeader(req.Headers, h.Name) {
+			req.Headers = append(req.Headers, models.RequestHeader{Key: strings.TrimSpace(h.Name), Value: im.text(h.Value)})
+		}
+	}
+
+	auth := r.Authentication
+	if len(auth) == 0 {
+		auth = inherited.auth
+	}
+	im.auth(where, auth, &req.RequestWithDetail)
+
+	im.body(where, r.Body, &req.RequestWithDetail)
+
+	for _, s := range []struct {
+		phase  string
+		source string
+	}{{models.ScriptPhasePreRequest, r.PreRequestScript}, {models.ScriptPhasePostResponse, r.AfterResponseScript}} {
+		source := strings.TrimSpace(s.source)
+		if source == "" {
+			continue
+		}
+		req.Scripts = append(req.Scripts, models.RequestScript{Phase: s.phase, Script: source})
+		if insomniaAPI.MatchString(source) {
+			im.warn(where, "the %s script uses the Insomnia API, rewrite it with tapa.* before running it", s.phase)
+		}
+	}
+
+	if len(im.tags) > 0 {
+		tags := make([]string, 0, len(im.tags))
+		for tag := range im.tags {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		im.warn(where, "template tags are not supported, replace them by hand: %s", strings.Join(tags, ", "))
+	}
+
+	im.report.Requests++
+	im.report.Scripts += len(req.Scripts)
+
+	if len(path) == 0 {
+		c.export.Requests = append(c.export.Requests, req)
+		return
+	}
+
+	i := c.folder(path)
+	c.export.Folders[i].Requests = append(c.export.Folders[i].Requests, req)
+}
+
+// folder returns the index of the folder of a path, adding the folder when it is not there yet.
+func (c *collection) folder(path []string) int {
+	name := strings.Join(path, FOLDER_SEPARATOR)
+	i, ok := c.folders[name]
+	if !ok {
+		i = len(c.export.Folders)
+		c.folders[name] = i
+		c.export.Folders = append(c.export.Folders, models.FolderExport{
+			Folder:   models.Folder{Name: name},
+			Requests: []models.RequestExport{},
+		})
+	}
+	return i
+}
+
+// text rewrites the variables of a value and notes the template tags it uses.
+func (im *importer) text(s string) string {
+	for _, match := range templateTag.FindAllStringSubmatch(s, -1) {
+		im.tags[match[1]] = true
+	}
+	return variables(s)
+}
+
+// auth turns supported auth into the header, query parameter or cookie it sends, unless the request sets it itself.
+func (im *importer) auth(where string, a map[string]any, req *models.RequestWithDetail) {
+	if disabled, _ := a["disabled"].(bool); disabled {
+		return
+	}
+
+	switch kind := str(a["type"]); kind {
+	case "", "none":
+	case "bearer":
+		prefix := str(a["prefix"])
+		if prefix == "" {
+			prefix = "Bearer"
+		}
+		if !importing.HasHeader(req.Headers, "Authorization") {
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: prefix + " " + im.text(str(a["token"]))})
+		}
+	case "basic":
+		username, password := im.text(str(a["username"])), im.text(str(a["password"]))
+		if strings.Contains(username+password, "{{") {
+			im.warn(where, "basic auth with variables cannot be encoded ahead of time, add the Authorization header by hand")
+			return
+		}
+		if !importing.HasHeader(req.Headers, "Authorization") {
+			credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: "Basic " + credentials})
+		}
+	case "apikey":
+		key, value := im.text(str(a["key"])), im.text(str(a["value"]))
+		if key == "" {
+			return
+		}
+		switch str(a["addTo"]) {
+		case "queryParams":
+			req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: key, Value: value})
+		case "cookie":
+			req.Cookies = append(req.Cookies, models.RequestCookie{Key: key, Value: value})
+		default:
+			if !importing.HasHeader(req.Headers, key) {
+				req.Headers = append(req.Headers, models.RequestHeader{Key: key, Value: value})
+			}
+		}
+	default:
+		im.warn(where, "%s auth is not supported, add the credentials by hand", kind)
+	}
+}
+
+// body sets the body of req from an Insomnia body.
+func (im *importer) body(where string, b body, req *models.RequestWithDetail) {
+	mt := importing.MediaType(b.MimeType)
+	contentType := ""
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Key, "Content-Type") {
+			contentType = importing.MediaType(h.Value)
+		}
+	}
+
+	switch {
+	case mt == "multipart/form-data":
+		fields := map[string]string{}
+		for _, p := range b.Params {
+			if p.Disabled || p.Name == "" {
+				continue
+			}
+			if p.Type == "file" {
+				im.warn(where, "file field %s was skipped, files cannot be attached to requests", p.Name)
+				continue
+			}
+			fields[im.text(p.Name)] = im.text(p.Value)
+		}
+		encoded, _ := json.Marshal(fields)
+		req.Body = string(encoded)
+		req.BodyFormat = "form-data"
+		// The boundary is chosen when the request is sent.
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+		return
+	case mt == "application/x-www-form-urlencoded":
+		pairs := []string{}
+		for _, p := range b.Params {
+			if !p.Disabled && p.Name != "" {
+				pairs = append(pairs, importing.FormEscape(im.text(p.Name))+"="+importing.FormEscape(im.text(p.Value)))
+			}
+		}
+		req.Body = strings.Join(pairs, "&")
+	case b.FileName != "":
+		im.warn(where, "file bodies are not supported, the body was skipped")
+		return
+	case mt == "" && b.Text == "":
+		return
+	case mt == "application/graphql":
+		// Insomnia stores GraphQL bodies as the JSON document it sends.
+		req.Body = im.text(b.Text)
+		req.BodyFormat = "JSON"
+		mt = "application/json"
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		req.Body = im.text(b.Text)
+		req.BodyFormat = "JSON"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		req.Body = im.text(b.Text)
+		req.BodyFormat = "XML"
+	default:
+		req.Body = im.text(b.Text)
+	}
+
+	if contentType == "" && mt != "" && req.BodyFormat == "raw" {
+		req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: b.MimeType})
+	}
+
+	// JSON and XML bodies get their Content-Type from the body format.
+	if (req.BodyFormat == "JSON" && contentType == "application/json") || (req.BodyFormat == "XML" && contentType == "application/xml") {
+		req.Headers = importing.WithoutHeader(req.Headers, "Content-Type")
+	}
+}
+
+// flatten turns the data of an environment into variables, nested objects with dotted keys as Insomnia
+// refers to them, e.g. {{ _.auth.token }}.
+func flatten(data map[string]any) []models.EnvironmentVariable {
+	vars := []models.EnvironmentVariable{}
+
+	var walk func(prefix string, data map[string]any)
+	walk = func(prefix string, data map[string]any) {
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch v := data[key].(type) {
+			case map[string]any:
+				walk(prefix+key+".", v)
+			case string:
+				vars = append(vars, models.EnvironmentVariable{Key: prefix + key, Value: variables(v)})
+			case float64:
+				vars = append(vars, models.EnvironmentVariable{Key: prefix + key, Value: strconv.FormatFloat(v, 'f', -1, 64)})
+			case nil:
+				vars = append(vars, models.EnvironmentVariable{Key: prefix + key})
+			default:
+				encoded, _ := json.Marshal(v)
+				vars = append(vars, models.EnvironmentVariable{Key: prefix + key, Value: string(encoded)})
+			}
+		}
+	}
+	walk("", data)
+
+	return vars
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
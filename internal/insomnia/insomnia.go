@@ -0,0 +1,86 @@
+// Package insomnia converts Insomnia v4 exports into TAPA collections and environments.
+package insomnia
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+const SOURCE string = "insomnia"
+
+// EXPORT_FORMAT is the Insomnia export format this package reads.
+const EXPORT_FORMAT int = 4
+
+// FOLDER_SEPARATOR joins the names of nested Insomnia folders into the name of one TAPA folder.
+const FOLDER_SEPARATOR string = " / "
+
+var (
+	// variableRef matches Insomnia variables, {{ _.name }} or, in older exports, {{ name }}.
+	variableRef = regexp.MustCompile(`\{\{\s*(?:_\.)?([A-Za-z0-9_\-.$]+)\s*\}\}`)
+
+	// templateTag matches Nunjucks tags such as {% response 'body', 'req_1', '$.token' %}.
+	templateTag = regexp.MustCompile(`\{%\s*([A-Za-z0-9_]+)[^%]*%\}`)
+
+	insomniaAPI = regexp.MustCompile(`\binsomnia\.[A-Za-z]`)
+)
+
+type export struct {
+	Type      string     `json:"_type"`
+	Format    int        `json:"__export_format"`
+	Resources []resource `json:"resources"`
+}
+
+// resource is any object of an export; which fields are set depends on Type.
+type resource struct {
+	ID          string  `json:"_id"`
+	Type        string  `json:"_type"` // "workspace", "request_group", "request", "environment", ...
+	ParentID    string  `json:"parentId"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	SortKey     float64 `json:"metaSortKey"`
+
+	// Requests.
+	Method                 string         `json:"method"`
+	URL                    string         `json:"url"`
+	Body                   body           `json:"body"`
+	Parameters             []pair         `json:"parameters"`
+	Headers                []pair         `json:"headers"`
+	Authentication         map[string]any `json:"authentication"`
+	SettingFollowRedirects string         `json:"settingFollowRedirects"` // "global", "on" or "off"
+	SettingEncodeURL       *bool          `json:"settingEncodeUrl"`
+	PreRequestScript       string         `json:"preRequestScript"`
+	AfterResponseScript    string         `json:"afterResponseScript"`
+
+	// Environments keep their variables in Data, folders in Environment.
+	Data        map[string]any `json:"data"`
+	Environment map[string]any `json:"environment"`
+}
+
+type pair struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Type     string `json:"type"` // "file" for multipart file fields
+	FileName string `json:"fileName"`
+}
+
+type body struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Params   []pair `json:"params"`
+	FileName string `json:"fileName"`
+}
+
+// Detect reports whether data looks like an Insomnia export.
+func Detect(data []byte) bool {
+	var probe struct {
+		Type      string           `json:"_type"`
+		Resources *json.RawMessage `json:"resources"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Type == "export" && probe.Resources != nil
+}
+
+// variables rewrites Insomnia variable references as TAPA ones, e.g. {{ _.base_url }} as {{base_url}}.
+func variables(s string) string {
+	return variableRef.ReplaceAllString(s, "{{$1}}")
+}
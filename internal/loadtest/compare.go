@@ -0,0 +1,31 @@
+package loadtest
+
+import "github.com/Amir-Zouerami/TAPA/internal/models"
+
+// Compare reports how the target test differs from the base test: throughput, error rate and latency.
+func Compare(base, target models.LoadTestReport) models.LoadTestComparison {
+	base.Timeline, target.Timeline = nil, nil
+
+	return models.LoadTestComparison{
+		Base:            base,
+		Target:          target,
+		ThroughputDelta: round(target.Throughput - base.Throughput),
+		BaseErrorRate:   errorRate(base),
+		TargetErrorRate: errorRate(target),
+		LatencyDelta: models.LatencyStats{
+			Min:  round(target.Latency.Min - base.Latency.Min),
+			Mean: round(target.Latency.Mean - base.Latency.Mean),
+			P50:  round(target.Latency.P50 - base.Latency.P50),
+			P90:  round(target.Latency.P90 - base.Latency.P90),
+			P99:  round(target.Latency.P99 - base.Latency.P99),
+			Max:  round(target.Latency.Max - base.Latency.Max),
+		},
+	}
+}
+
+func errorRate(report models.LoadTestReport) float64 {
+	if report.Requests == 0 {
+		return 0
+	}
+	return float64(report.Errors) / float64(report.Requests)
+}
@@ -0,0 +1,335 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+const (
+	// DEFAULT_MAX_IN_FLIGHT caps the requests in flight of a rate-driven test without virtual users.
+	DEFAULT_MAX_IN_FLIGHT int = 100
+	MAX_VIRTUAL_USERS     int = 1000
+)
+
+// Listener receives the progress of a load test. It is called from the test's goroutine, once per second.
+type Listener func(event models.LoadTestEvent)
+
+// Store keeps load tests after they finished. With a store, test ids are the ids it assigns.
+type Store interface {
+	InsertLoadTest(report models.LoadTestReport) (int, error)
+	SaveLoadTest(report models.LoadTestReport) error
+}
+
+// Tester drives stored requests through the executor with many virtual users at once.
+type Tester struct {
+	executor *executor.Executor
+	store    Store // nil keeps tests in memory only
+
+	mu     sync.Mutex
+	nextID int
+	tests  map[int]*Test
+}
+
+// Test is a load test in progress, or finished, that can be stopped.
+type Test struct {
+	id       int
+	probes   []*executor.Probe
+	options  models.LoadTestOptions
+	listener Listener
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu     sync.Mutex
+	report models.LoadTestReport
+}
+
+// Start sends targets in the background for options.DurationMs, either back to back by options.VirtualUsers,
+// or started at options.RatePerSecond. A folder's requests are sent in order, over and over.
+// Scripts, assertions and the history are skipped so the requests go out as fast as the server answers;
+// all virtual users share one session with the environment's variables.
+func (t *Tester) Start(ctx context.Context, name string, targets []models.RequestBasic, options models.LoadTestOptions, listener Listener) (*Test, error) {
+	if err := validate(&options); err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.Wrap(errors.ErrLoadTestOptions, fmt.Errorf("%s has no requests to send", name))
+	}
+
+	session, err := t.executor.NewSession(options.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	probes := make([]*executor.Probe, len(targets))
+	for i, target := range targets {
+		if probes[i], err = t.executor.NewProbe(session, target.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if listener == nil {
+		listener = func(models.LoadTestEvent) {}
+	}
+
+	report := models.LoadTestReport{
+		CollectionID:   options.CollectionID,
+		FolderID:       options.FolderID,
+		RequestID:      options.RequestID,
+		EnvironmentID:  session.EnvironmentID,
+		Name:           name,
+		VirtualUsers:   options.VirtualUsers,
+		RatePerSecond:  options.RatePerSecond,
+		DurationMs:     options.DurationMs,
+		Status:         models.RunStatusRunning,
+		Timeline:       []models.LoadTestSecond{},
+		ErrorBreakdown: []models.LoadTestError{},
+		StartedAt:      time.Now(),
+	}
+
+	if t.store != nil {
+		id, err := t.store.InsertLoadTest(report)
+		if err != nil {
+			return nil, err
+		}
+		report.ID = id
+	}
+
+	t.mu.Lock()
+	if t.store == nil {
+		t.nextID++
+		report.ID = t.nextID
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	test := &Test{
+		id:       report.ID,
+		probes:   probes,
+		options:  options,
+		listener: listener,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		report:   report,
+	}
+	t.tests[test.id] = test
+	t.mu.Unlock()
+
+	go t.execute(ctx, test)
+
+	return test, nil
+}
+
+// Get returns a load test started by this tester that is running, or finished without a store to save it to.
+func (t *Tester) Get(id int) (*Test, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	test, ok := t.tests[id]
+	return test, ok
+}
+
+// validate rejects options that cannot produce load and fills in the in-flight cap of rate-driven tests.
+func validate(options *models.LoadTestOptions) error {
+	switch {
+	case options.DurationMs <= 0:
+		return errors.Wrap(errors.ErrLoadTestOptions, fmt.Errorf("the duration must be positive"))
+	case options.VirtualUsers < 0 || options.VirtualUsers > MAX_VIRTUAL_USERS:
+		return errors.Wrap(errors.ErrLoadTestOptions, fmt.Errorf("at most %d virtual users", MAX_VIRTUAL_USERS))
+	case options.RatePerSecond < 0:
+		return errors.Wrap(errors.ErrLoadTestOptions, fmt.Errorf("the rate must not be negative"))
+	case options.RatePerSecond == 0 && options.VirtualUsers == 0:
+		return errors.Wrap(errors.ErrLoadTestOptions, fmt.Errorf("set a number of virtual users or a rate"))
+	}
+
+	if options.VirtualUsers == 0 {
+		options.VirtualUsers = DEFAULT_MAX_IN_FLIGHT
+	}
+
+	return nil
+}
+
+func (t *Tester) execute(ctx context.Context, test *Test) {
+	defer close(test.done)
+	defer test.cancel()
+
+	test.emit(models.LoadTestEvent{Type: models.LoadTestEventStarted})
+
+	begin := time.Now()
+	deadline := begin.Add(time.Duration(test.options.DurationMs) * time.Millisecond)
+	stats := newCollector(begin)
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		if test.options.RatePerSecond > 0 {
+			test.open(ctx, stats, deadline)
+		} else {
+			test.closed(ctx, stats, deadline)
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+progress:
+	for second := 0; ; second++ {
+		select {
+		case <-ticker.C:
+			test.update(stats, models.RunStatusRunning)
+
+			entry := stats.second(second)
+			requests, failed := stats.totals()
+			test.emit(models.LoadTestEvent{Type: models.LoadTestEventProgress, Requests: requests, Errors: failed, Second: &entry})
+		case <-finished:
+			break progress
+		}
+	}
+
+	status := models.RunStatusCompleted
+	if ctx.Err() != nil {
+		status = models.RunStatusStopped
+	}
+	report := test.update(stats, status)
+
+	// Once saved, the test is read back from the store; the tester only keeps tests that are running or unsaved.
+	if t.store != nil {
+		if err := t.store.SaveLoadTest(report); err != nil {
+			log.Printf("Saving load test %d failed: %v", report.ID, err)
+		} else {
+			t.mu.Lock()
+			delete(t.tests, test.id)
+			t.mu.Unlock()
+		}
+	}
+
+	test.emit(models.LoadTestEvent{Type: models.LoadTestEventFinished, Requests: report.Requests, Errors: report.Errors, Report: &report})
+}
+
+// closed lets every virtual user send its requests back to back until the deadline.
+func (test *Test) closed(ctx context.Context, stats *collector, deadline time.Time) {
+	var wg sync.WaitGroup
+
+	for user := 0; user < test.options.VirtualUsers; user++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; ctx.Err() == nil && time.Now().Before(deadline); i++ {
+				test.send(ctx, stats, test.probes[i%len(test.probes)])
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// open starts requests at the target rate until the deadline, whether or not earlier ones completed.
+// A request that is due while every virtual user is busy is dropped and counted as such.
+func (test *Test) open(ctx context.Context, stats *collector, deadline time.Time) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	users := make(chan struct{}, test.options.VirtualUsers)
+	interval := time.Duration(float64(time.Second) / test.options.RatePerSecond)
+	begin := time.Now()
+
+	for i := 0; ; i++ {
+		// Starts are scheduled from the beginning so a late wake-up does not lower the rate.
+		at := begin.Add(time.Duration(i) * interval)
+		if !at.Before(deadline) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(at)):
+		}
+
+		select {
+		case users <- struct{}{}:
+			wg.Add(1)
+			go func(probe *executor.Probe) {
+				defer wg.Done()
+				test.send(ctx, stats, probe)
+				<-users
+			}(test.probes[i%len(test.probes)])
+		default:
+			stats.drop()
+		}
+	}
+}
+
+// send performs one request; requests cancelled by Stop are not counted.
+func (test *Test) send(ctx context.Context, stats *collector, probe *executor.Probe) {
+	start := time.Now()
+	result := probe.Send(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	stats.add(start, time.Now(), result)
+}
+
+// ID identifies the test for Stop calls.
+func (test *Test) ID() int {
+	return test.id
+}
+
+// Stop ends the test early, cancelling the requests in flight.
+func (test *Test) Stop() {
+	test.cancel()
+}
+
+// Wait blocks until the test has finished and returns its final report.
+func (test *Test) Wait() models.LoadTestReport {
+	<-test.done
+	return test.Report()
+}
+
+// Report returns a snapshot of the test's report, as of its last second.
+func (test *Test) Report() models.LoadTestReport {
+	test.mu.Lock()
+	defer test.mu.Unlock()
+
+	report := test.report
+	report.Timeline = append([]models.LoadTestSecond{}, test.report.Timeline...)
+	report.ErrorBreakdown = append([]models.LoadTestError{}, test.report.ErrorBreakdown...)
+	return report
+}
+
+// update refreshes the report from the collected outcomes and returns a snapshot of it.
+func (test *Test) update(stats *collector, status string) models.LoadTestReport {
+	now := time.Now()
+
+	test.mu.Lock()
+	stats.summarize(&test.report, now)
+	test.report.Status = status
+	if status != models.RunStatusRunning {
+		test.report.FinishedAt = &now
+	}
+	test.mu.Unlock()
+
+	return test.Report()
+}
+
+func (test *Test) emit(event models.LoadTestEvent) {
+	event.TestID = test.id
+	test.listener(event)
+}
+
+func NewTester(executor *executor.Executor, store Store) *Tester {
+	return &Tester{
+		executor: executor,
+		store:    store,
+		tests:    map[int]*Test{},
+	}
+}
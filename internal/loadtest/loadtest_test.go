@@ -0,0 +1,164 @@
+package loadtest_test
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Amir-Zouerami/TAPA/internal/database"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/loadtest"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// newTestDB creates an in-memory database with the app's schema and a collection with one GET request to url.
+func newTestDB(t *testing.T, url string) (*sqlx.DB, models.RequestBasic) {
+	t.Helper()
+
+	db, err := database.InitializeDBAt(os.DirFS("../..").(fs.ReadFileFS), ":memory:")
+	if err != nil {
+		t.Fatalf("initializing the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`INSERT INTO collections (id, name, position) VALUES (1, 'Load', 1)`); err != nil {
+		t.Fatalf("inserting the collection: %v", err)
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO requests (id, collection_id, position, name, method, url, body, timeout)
+		VALUES (1, 1, 1, 'Ping', 'GET', ?, '', 30000)`, url); err != nil {
+		t.Fatalf("inserting the request: %v", err)
+	}
+
+	collectionID := 1
+	return db, models.RequestBasic{ID: 1, CollectionID: &collectionID, Name: "Ping", Method: "GET"}
+}
+
+// runTest starts a load test of the request against url and waits for it to finish.
+func runTest(t *testing.T, url string, options models.LoadTestOptions) (*loadtest.Tester, *repository.LoadTestsRepository, models.LoadTestReport) {
+	t.Helper()
+
+	db, target := newTestDB(t, url)
+	store := repository.NewLoadTestsRepository(db)
+	tester := loadtest.NewTester(executor.NewExecutor(db), store)
+
+	options.CollectionID = 1
+	options.RequestID = &target.ID
+
+	test, err := tester.Start(context.Background(), target.Name, []models.RequestBasic{target}, options, nil)
+	if err != nil {
+		t.Fatalf("starting the load test: %v", err)
+	}
+
+	return tester, store, test.Wait()
+}
+
+func TestClosedModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	tester, store, report := runTest(t, server.URL, models.LoadTestOptions{VirtualUsers: 4, DurationMs: 300})
+
+	if report.Status != models.RunStatusCompleted {
+		t.Errorf("status = %q, want %q", report.Status, models.RunStatusCompleted)
+	}
+	if report.Requests == 0 || report.Errors != 0 || report.Dropped != 0 {
+		t.Errorf("requests = %d, errors = %d, dropped = %d; want requests and no errors or drops", report.Requests, report.Errors, report.Dropped)
+	}
+	if report.BytesReceived != int64(report.Requests*len(`{"ok":true}`)) {
+		t.Errorf("bytes received = %d for %d requests", report.BytesReceived, report.Requests)
+	}
+	if report.Latency.Max <= 0 || report.Latency.Min > report.Latency.P50 || report.Latency.P99 > report.Latency.Max {
+		t.Errorf("latency stats out of order: %+v", report.Latency)
+	}
+
+	if _, ok := tester.Get(report.ID); ok {
+		t.Errorf("finished test %d is still kept by the tester after it was saved", report.ID)
+	}
+
+	saved, err := store.GetLoadTest(report.ID)
+	if err != nil {
+		t.Fatalf("loading the saved test: %v", err)
+	}
+	if saved.Requests != report.Requests || saved.Status != models.RunStatusCompleted {
+		t.Errorf("saved test = %d requests, %q; want %d, %q", saved.Requests, saved.Status, report.Requests, models.RunStatusCompleted)
+	}
+}
+
+func TestOpenModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// 50 starts per second for 400ms are due at 0, 20, ..., 380ms.
+	_, _, report := runTest(t, server.URL, models.LoadTestOptions{RatePerSecond: 50, DurationMs: 400})
+
+	if report.Requests != 20 {
+		t.Errorf("requests = %d, want 20", report.Requests)
+	}
+	if report.VirtualUsers != loadtest.DEFAULT_MAX_IN_FLIGHT {
+		t.Errorf("virtual users = %d, want the default in-flight cap %d", report.VirtualUsers, loadtest.DEFAULT_MAX_IN_FLIGHT)
+	}
+	if report.Errors != 0 || report.Dropped != 0 {
+		t.Errorf("errors = %d, dropped = %d; want none", report.Errors, report.Dropped)
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, _, report := runTest(t, server.URL, models.LoadTestOptions{VirtualUsers: 2, DurationMs: 200})
+
+	if report.Requests == 0 || report.Errors != report.Requests {
+		t.Fatalf("errors = %d of %d requests, want all of them", report.Errors, report.Requests)
+	}
+	if len(report.ErrorBreakdown) != 1 {
+		t.Fatalf("error breakdown = %+v, want one entry", report.ErrorBreakdown)
+	}
+
+	failure := report.ErrorBreakdown[0]
+	if failure.Kind != models.LoadTestErrorStatus || !strings.Contains(failure.Error, "500") || failure.Count != report.Requests {
+		t.Errorf("error breakdown = %+v, want %d status errors for 500", failure, report.Requests)
+	}
+
+	// Error responses still measure the server's latency.
+	if report.Latency.Max <= 0 {
+		t.Errorf("latency = %+v, want the latency of the error responses", report.Latency)
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	_, _, report := runTest(t, url, models.LoadTestOptions{VirtualUsers: 2, DurationMs: 200})
+
+	if report.Requests == 0 || report.Errors != report.Requests {
+		t.Fatalf("errors = %d of %d requests, want all of them", report.Errors, report.Requests)
+	}
+	if len(report.ErrorBreakdown) != 1 || report.ErrorBreakdown[0].Kind != models.LoadTestErrorTransport {
+		t.Fatalf("error breakdown = %+v, want one transport error", report.ErrorBreakdown)
+	}
+	if strings.Contains(report.ErrorBreakdown[0].Error, url) {
+		t.Errorf("transport error %q keeps the URL, want only its cause", report.ErrorBreakdown[0].Error)
+	}
+
+	// Requests without a response say nothing about the server's latency.
+	if report.Latency != (models.LatencyStats{}) {
+		t.Errorf("latency = %+v, want none", report.Latency)
+	}
+}
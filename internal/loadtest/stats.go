@@ -0,0 +1,210 @@
+package loadtest
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// collector aggregates the outcomes of a load test while virtual users report them concurrently.
+type collector struct {
+	begin time.Time
+
+	mu        sync.Mutex
+	requests  int
+	failures  int
+	dropped   int
+	sent      int64
+	received  int64
+	latencies []float64 // milliseconds, of every request that got a response
+	seconds   []*bucket
+	errors    map[models.LoadTestError]int // keyed without Count
+}
+
+// bucket is one second of the timeline.
+type bucket struct {
+	requests  int
+	errors    int
+	received  int64
+	latencies []float64
+}
+
+func newCollector(begin time.Time) *collector {
+	return &collector{begin: begin, errors: map[models.LoadTestError]int{}}
+}
+
+// add records a request that started at start and completed at end.
+func (c *collector) add(start, end time.Time, result *models.ExecutionResult) {
+	latency := float64(end.Sub(start).Microseconds()) / 1000
+	failure, failed := classify(result)
+	second := int(end.Sub(c.begin) / time.Second)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.seconds) <= second {
+		c.seconds = append(c.seconds, &bucket{})
+	}
+	b := c.seconds[second]
+
+	c.requests++
+	b.requests++
+	c.sent += int64(len(result.RequestBody))
+	c.received += int64(result.DataVolume)
+	b.received += int64(result.DataVolume)
+
+	if failed {
+		c.failures++
+		b.errors++
+		c.errors[failure]++
+	}
+
+	// Requests that never got a response say nothing about the server's latency.
+	if failure.Kind != models.LoadTestErrorTransport {
+		c.latencies = append(c.latencies, latency)
+		b.latencies = append(b.latencies, latency)
+	}
+}
+
+func (c *collector) drop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dropped++
+}
+
+// totals returns the number of requests and errors so far.
+func (c *collector) totals() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.requests, c.failures
+}
+
+// second returns the timeline entry of a second, empty if nothing completed in it.
+func (c *collector) second(index int) models.LoadTestSecond {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if index >= len(c.seconds) {
+		return models.LoadTestSecond{Second: index}
+	}
+	return c.seconds[index].summary(index)
+}
+
+// summarize writes the aggregates so far into report.
+func (c *collector) summarize(report *models.LoadTestReport, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elapsed := now.Sub(c.begin)
+
+	report.Requests = c.requests
+	report.Errors = c.failures
+	report.Dropped = c.dropped
+	report.BytesSent = c.sent
+	report.BytesReceived = c.received
+	report.Duration = int(elapsed.Milliseconds())
+	report.Latency = latencyStats(c.latencies)
+
+	report.Throughput = 0
+	if elapsed > 0 {
+		report.Throughput = round(float64(c.requests) / elapsed.Seconds())
+	}
+
+	report.Timeline = make([]models.LoadTestSecond, len(c.seconds))
+	for i, b := range c.seconds {
+		report.Timeline[i] = b.summary(i)
+	}
+
+	report.ErrorBreakdown = make([]models.LoadTestError, 0, len(c.errors))
+	for failure, count := range c.errors {
+		failure.Count = count
+		report.ErrorBreakdown = append(report.ErrorBreakdown, failure)
+	}
+	sort.Slice(report.ErrorBreakdown, func(i, j int) bool {
+		a, b := report.ErrorBreakdown[i], report.ErrorBreakdown[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Kind+a.Error < b.Kind+b.Error
+	})
+}
+
+func (b *bucket) summary(index int) models.LoadTestSecond {
+	stats := latencyStats(b.latencies)
+	return models.LoadTestSecond{
+		Second:        index,
+		Requests:      b.requests,
+		Errors:        b.errors,
+		BytesReceived: b.received,
+		MeanLatency:   stats.Mean,
+		P90Latency:    stats.P90,
+	}
+}
+
+// classify tells whether a request failed and how: no response at all, or a 4xx/5xx status.
+func classify(result *models.ExecutionResult) (models.LoadTestError, bool) {
+	if result.Error != "" {
+		return models.LoadTestError{Kind: models.LoadTestErrorTransport, Error: transportError(result.Error)}, true
+	}
+
+	if result.StatusCode >= 400 {
+		status := result.Status
+		if status == "" {
+			status = http.StatusText(result.StatusCode)
+		}
+		return models.LoadTestError{Kind: models.LoadTestErrorStatus, Error: status}, true
+	}
+
+	return models.LoadTestError{}, false
+}
+
+// transportError keeps the innermost cause of an executor error, e.g. "connection refused"
+// out of `[4001] Sending the HTTP request failed : Get "...": dial tcp ...: connect: connection refused`,
+// so failures against different URLs group together.
+func transportError(message string) string {
+	if i := strings.LastIndex(message, ": "); i >= 0 && i+2 < len(message) {
+		return strings.TrimSpace(message[i+2:])
+	}
+	return strings.TrimSpace(message)
+}
+
+func latencyStats(latencies []float64) models.LatencyStats {
+	if len(latencies) == 0 {
+		return models.LatencyStats{}
+	}
+
+	sorted := append([]float64{}, latencies...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, l := range sorted {
+		sum += l
+	}
+
+	return models.LatencyStats{
+		Min:  round(sorted[0]),
+		Mean: round(sum / float64(len(sorted))),
+		P50:  round(percentile(sorted, 50)),
+		P90:  round(percentile(sorted, 90)),
+		P99:  round(percentile(sorted, 99)),
+		Max:  round(sorted[len(sorted)-1]),
+	}
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// round keeps two decimals, enough for milliseconds and requests per second.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
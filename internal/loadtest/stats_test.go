@@ -0,0 +1,25 @@
+package loadtest
+
+import (
+	"testing"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	latencies := make([]float64, 100)
+	for i := range latencies {
+		// Unsorted on purpose: 100, 99, ..., 1.
+		latencies[i] = float64(100 - i)
+	}
+
+	stats := latencyStats(latencies)
+	want := models.LatencyStats{Min: 1, Mean: 50.5, P50: 50, P90: 90, P99: 99, Max: 100}
+	if stats != want {
+		t.Errorf("latencyStats = %+v, want %+v", stats, want)
+	}
+
+	if single := latencyStats([]float64{12.345}); single.P50 != 12.35 || single.P99 != 12.35 {
+		t.Errorf("latencyStats of one value = %+v, want every percentile at 12.35", single)
+	}
+}
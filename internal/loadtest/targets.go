@@ -0,0 +1,51 @@
+package loadtest
+
+import (
+	"fmt"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+)
+
+// Targets lists the requests a load test sends, in order, and names what is under test:
+// the request of options.RequestID, the folder of options.FolderID or the whole collection.
+func Targets(list models.FullDashboardRequestList, options models.LoadTestOptions) ([]models.RequestBasic, string, error) {
+	folderID := options.FolderID
+	if options.RequestID != nil {
+		folderID = nil
+	}
+
+	plan, err := runner.Plan(list, options.CollectionID, folderID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if options.RequestID != nil {
+		for _, request := range plan {
+			if request.ID == *options.RequestID {
+				return []models.RequestBasic{request}, request.Name, nil
+			}
+		}
+
+		return nil, "", errors.Wrap(errors.ErrRunTargetNotFound, fmt.Errorf("request %d in collection %d", *options.RequestID, options.CollectionID))
+	}
+
+	for _, collection := range list.Collections {
+		if collection.Collection.ID != options.CollectionID {
+			continue
+		}
+
+		if folderID == nil {
+			return plan, collection.Collection.Name, nil
+		}
+
+		for _, folder := range collection.Folders {
+			if folder.Folder.ID == *folderID {
+				return plan, collection.Collection.Name + " / " + folder.Folder.Name, nil
+			}
+		}
+	}
+
+	return plan, "", nil
+}
@@ -0,0 +1,69 @@
+package models
+
+const (
+	AssertionTypeStatus       string = "status"
+	AssertionTypeHeader       string = "header"
+	AssertionTypeJSONPath     string = "jsonpath"
+	AssertionTypeXPath        string = "xpath"
+	AssertionTypeBody         string = "body"
+	AssertionTypeResponseTime string = "response_time"
+	AssertionTypeBodySize     string = "body_size"
+	AssertionTypeJSONSchema   string = "json_schema"
+	AssertionTypeExample      string = "example"
+)
+
+const (
+	AssertionOperatorEquals   string = "equals"
+	AssertionOperatorIn       string = "in"
+	AssertionOperatorInRange  string = "in_range"
+	AssertionOperatorExists   string = "exists"
+	AssertionOperatorMatches  string = "matches"
+	AssertionOperatorContains string = "contains"
+	AssertionOperatorType     string = "type"
+	AssertionOperatorBelow    string = "below"
+	AssertionOperatorAbove    string = "above"
+	AssertionOperatorValid    string = "valid"
+)
+
+// RequestAssertion is a no-code check evaluated against every response of a request.
+type RequestAssertion struct {
+	ID        int    `json:"id" db:"id"`
+	RequestID int    `json:"request_id" db:"request_id"`
+	Position  int    `json:"position" db:"position"`
+	Name      string `json:"name,omitempty" db:"name"`
+	Type      string `json:"type" db:"type"`                   // "status", "header", "jsonpath", "xpath", "body", "response_time", "body_size", "json_schema", "example"
+	Target    string `json:"target,omitempty" db:"target"`     // header name, JSONPath or XPath expression, collection schema name or request example id
+	Operator  string `json:"operator" db:"operator"`           // "equals", "in", "in_range", "exists", "matches", "contains", "type", "below", "above", "valid"
+	Expected  string `json:"expected,omitempty" db:"expected"` // inline JSON Schema (json_schema) or ExampleIgnoreRules as JSON (example)
+	Enabled   bool   `json:"enabled" db:"enabled"`
+}
+
+// ExampleIgnoreRules lists what an example assertion leaves out of the comparison.
+type ExampleIgnoreRules struct {
+	Paths    []string `json:"paths,omitempty"`    // JSONPaths into the body, e.g. "$.id" or "$..createdAt"
+	Headers  []string `json:"headers,omitempty"`  // response header names, case-insensitive
+	Patterns []string `json:"patterns,omitempty"` // regular expressions masked in header values and body text, e.g. timestamps
+}
+
+const (
+	DiffKindAdded   string = "added"
+	DiffKindRemoved string = "removed"
+	DiffKindChanged string = "changed"
+)
+
+// DiffEntry is a single difference between a saved example and a live response.
+type DiffEntry struct {
+	Path     string `json:"path"` // "status", "headers.Name" or a JSONPath into the body ("body" for non-JSON bodies)
+	Kind     string `json:"kind"` // "added", "removed", "changed"
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// TestOutcome is the result of a script test or an assertion for a single execution.
+type TestOutcome struct {
+	Name    string      `json:"name"`
+	Source  string      `json:"source"` // "script", "assertion"
+	Passed  bool        `json:"passed"`
+	Message string      `json:"message,omitempty"`
+	Diff    []DiffEntry `json:"diff,omitempty"` // only set by failed example assertions
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	DataFileFormatCSV  string = "csv"
+	DataFileFormatJSON string = "json"
+)
+
+// CollectionDataFile is a dataset stored with a collection, the runner iterates over its rows.
+type CollectionDataFile struct {
+	ID           int       `json:"id" db:"id"`
+	CollectionID int       `json:"collection_id" db:"collection_id"`
+	Name         string    `json:"name" db:"name"`
+	Format       string    `json:"format" db:"format"`   // "csv", "json"
+	Content      string    `json:"content" db:"content"` // CSV with a header row, or a JSON array of objects
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+const COLLECTION_EXPORT_VERSION int = 1
+
+// CollectionExport is the self-contained JSON form of a collection used for import and export.
+// IDs inside an export are only meaningful within it; importing always creates new rows.
+type CollectionExport struct {
+	Version    int                  `json:"tapa_export_version"`
+	Collection Collection           `json:"collection"`
+	Variables  []CollectionVariable `json:"variables"`
+	Modules    []ScriptModule       `json:"modules"`
+	Schemas    []CollectionSchema   `json:"schemas"`
+	DataFiles  []CollectionDataFile `json:"data_files"`
+	Folders    []FolderExport       `json:"folders"`
+	Requests   []RequestExport      `json:"requests"` // requests with no folder.
+}
+
+type FolderExport struct {
+	Folder   Folder          `json:"folder"`
+	Requests []RequestExport `json:"requests"`
+}
+
+type RequestExport struct {
+	RequestWithDetail
+	Examples []RequestExample `json:"examples"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	SchemaSourceManual  string = "manual"
+	SchemaSourceFile    string = "file"
+	SchemaSourceOpenAPI string = "openapi"
+)
+
+// CollectionSchema is a JSON Schema stored on a collection and referenced by json_schema assertions.
+// Schemas of the same collection can $ref each other by name.
+type CollectionSchema struct {
+	ID           int       `json:"id" db:"id"`
+	CollectionID int       `json:"collection_id" db:"collection_id"`
+	Name         string    `json:"name" db:"name"`
+	Schema       string    `json:"schema" db:"schema"`
+	Source       string    `json:"source" db:"source"` // "manual", "file", "openapi"
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
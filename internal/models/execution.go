@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	ConsoleLevelLog     string = "log"
+	ConsoleLevelInfo    string = "info"
+	ConsoleLevelWarn    string = "warn"
+	ConsoleLevelError   string = "error"
+	ConsoleLevelRequest string = "request"
+)
+
+// ConsoleEntry is a single line written to the script console during an execution.
+type ConsoleEntry struct {
+	Level     string    `json:"level"` // "log", "info", "warn", "error", "request"
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ExecutionResult is the outcome of sending a request through the executor.
+// Transport and script failures are reported in Error so the console is never lost.
+type ExecutionResult struct {
+	HistoryID       int                 `json:"history_id"`
+	ParentHistoryID *int                `json:"parent_history_id,omitempty"`
+	RequestID       *int                `json:"request_id,omitempty"` // nil for ad-hoc requests
+	Name            string              `json:"name"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	RequestHeaders  map[string]string   `json:"request_headers"`
+	RequestBody     string              `json:"request_body,omitempty"`
+	StatusCode      int                 `json:"status_code"`
+	Status          string              `json:"status"`
+	ResponseHeaders map[string][]string `json:"response_headers"`
+	ResponseBody    string              `json:"response_body"`
+	ResponseTime    int                 `json:"response_time"` // milliseconds
+	DataVolume      int                 `json:"data_volume"`   // bytes
+	Error           string              `json:"error,omitempty"`
+	Console         []ConsoleEntry      `json:"console"`
+	Tests           []TestOutcome       `json:"tests"`                  // script tests followed by assertions
+	SubRequests     []ExecutionResult   `json:"sub_requests"`           // requests sent by this request's scripts
+	NextRequest     *NextRequest        `json:"next_request,omitempty"` // set by tapa.setNextRequest, only collection runs follow it
+	StartedAt       time.Time           `json:"started_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+const (
+	SkipOperatorEquals    string = "equals"
+	SkipOperatorNotEquals string = "not_equals"
+	SkipOperatorExists    string = "exists"
+	SkipOperatorNotExists string = "not_exists"
+	SkipOperatorContains  string = "contains"
+	SkipOperatorMatches   string = "matches"
+)
+
+// SkipCondition makes collection runs skip a request when a variable has, or lacks, a value.
+// A request with several enabled conditions is skipped as soon as one of them holds.
+type SkipCondition struct {
+	ID        int    `json:"id" db:"id"`
+	RequestID int    `json:"request_id" db:"request_id"`
+	Position  int    `json:"position" db:"position"`
+	Variable  string `json:"variable" db:"variable"`
+	Operator  string `json:"operator" db:"operator"`     // "equals", "not_equals", "exists", "not_exists", "contains", "matches"
+	Value     string `json:"value,omitempty" db:"value"` // may reference {{variables}}, a regular expression for "matches"
+	Enabled   bool   `json:"enabled" db:"enabled"`
+}
+
+// NextRequest is what a script passed to tapa.setNextRequest: the request to continue the run with,
+// by id or name, or nothing to end the current iteration.
+type NextRequest struct {
+	ID   *int   `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Stop bool   `json:"stop,omitempty"` // tapa.setNextRequest(null)
+}
+
+const (
+	RunStepSent      string = "sent"
+	RunStepSkipped   string = "skipped"   // a skip condition held
+	RunStepJump      string = "jump"      // tapa.setNextRequest picked the next request
+	RunStepStop      string = "stop"      // tapa.setNextRequest(null) ended the iteration
+	RunStepNotFound  string = "not_found" // tapa.setNextRequest named a request that is not part of the run
+	RunStepLoopLimit string = "loop_limit"
+)
+
+// RunStep is one entry of the path a run took, so it can be replayed in reports.
+type RunStep struct {
+	Step      int    `json:"step"` // 1 based, counted over the whole run
+	Iteration int    `json:"iteration"`
+	RequestID int    `json:"request_id"`
+	Name      string `json:"name"`
+	Action    string `json:"action"`           // one of the RunStep constants
+	Detail    string `json:"detail,omitempty"` // skip reason, jump target or failure
+}
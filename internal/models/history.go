@@ -3,15 +3,18 @@ package models
 import "time"
 
 type RequestHistory struct {
-	ID           int       `json:"id" db:"id"`
-	RequestID    int       `json:"request_id" db:"request_id"`
-	Timestamp    time.Time `json:"timestamp" db:"timestamp"`
-	Method       string    `json:"method" db:"method"`
-	URL          string    `json:"url" db:"url"`
-	Headers      string    `json:"headers,omitempty" db:"headers"`           // Stored as JSON string
-	QueryParams  string    `json:"query_params,omitempty" db:"query_params"` // Stored as JSON string
-	Body         string    `json:"body,omitempty" db:"body"`
-	StatusCode   int       `json:"status_code" db:"status_code"`
-	ResponseTime int       `json:"response_time" db:"response_time"`
-	DataVolume   int       `json:"data_volume" db:"data_volume"`
+	ID              int       `json:"id" db:"id"`
+	RequestID       *int      `json:"request_id,omitempty" db:"request_id"` // nil for ad-hoc requests sent from scripts
+	ParentID        *int      `json:"parent_id,omitempty" db:"parent_id"`   // history entry of the execution that sent this one
+	Timestamp       time.Time `json:"timestamp" db:"timestamp"`
+	Method          string    `json:"method" db:"method"`
+	URL             string    `json:"url" db:"url"`
+	Headers         string    `json:"headers,omitempty" db:"headers"`           // Stored as JSON string
+	QueryParams     string    `json:"query_params,omitempty" db:"query_params"` // Stored as JSON string
+	Body            string    `json:"body,omitempty" db:"body"`
+	StatusCode      int       `json:"status_code" db:"status_code"`
+	ResponseTime    int       `json:"response_time" db:"response_time"`
+	DataVolume      int       `json:"data_volume" db:"data_volume"`
+	ResponseSnippet string    `json:"response_snippet,omitempty" db:"response_snippet"` // first bytes of the response body
+	Error           string    `json:"error,omitempty" db:"error"`
 }
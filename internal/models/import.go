@@ -0,0 +1,72 @@
+package models
+
+// ImportReport summarizes what an import from another tool created, and what it could not bring over.
+type ImportReport struct {
+	CollectionID  int             `json:"collection_id"`
+	CollectionIDs []int           `json:"collection_ids,omitempty"` // every collection created, when the import created several
+	Name          string          `json:"name"`
+	Source        string          `json:"source"` // the format imported from, e.g. "postman"
+	Folders       int             `json:"folders"`
+	Requests      int             `json:"requests"`  // requests created
+	Updated       int             `json:"updated"`   // requests of an earlier import replaced by their new version
+	Unchanged     int             `json:"unchanged"` // requests of an earlier import the new version does not change
+	Kept          int             `json:"kept"`      // requests edited since an earlier import, left as they are
+	Examples      int             `json:"examples"`
+	Variables     int             `json:"variables"`
+	Scripts       int             `json:"scripts"`
+	Environments  int             `json:"environments"` // environments created, or given the variables they lacked
+	Warnings      []ImportWarning `json:"warnings"`
+}
+
+// ImportWarning is something an import skipped or changed on the way in, or an export could not write as it is.
+type ImportWarning struct {
+	Item    string `json:"item"` // path of the folder or request, e.g. "Users / Admin / Delete user"; empty for the collection
+	Message string `json:"message"`
+}
+
+// TextExport is a collection written in a text format, with what the format could not represent.
+type TextExport struct {
+	Content  string          `json:"content"`
+	Warnings []ImportWarning `json:"warnings"`
+}
+
+// SpecImport is a collection generated from an API description such as an OpenAPI document. Every request
+// carries the operation it was generated from, so importing the description again can merge into the collection.
+type SpecImport struct {
+	Source       string               `json:"source"` // e.g. "openapi"
+	Collection   Collection           `json:"collection"`
+	Variables    []CollectionVariable `json:"variables"`
+	Schemas      []CollectionSchema   `json:"schemas"`
+	Environments []EnvironmentExport  `json:"environments"`
+	Operations   []SpecOperation      `json:"operations"`
+}
+
+// SpecOperation is one request generated from an API description.
+type SpecOperation struct {
+	Key     string        `json:"key"`    // identifies the operation across imports, e.g. "GET /users/{id}"
+	Folder  string        `json:"folder"` // empty for the collection root
+	Request RequestExport `json:"request"`
+}
+
+// WorkspaceImport is what an import from a tool that keeps several collections and their environments together
+// creates, e.g. the workspaces of an Insomnia export.
+type WorkspaceImport struct {
+	Collections  []CollectionExport  `json:"collections"`
+	Environments []EnvironmentExport `json:"environments"`
+}
+
+// EnvironmentExport is an environment with its variables, e.g. one per server of an OpenAPI document.
+type EnvironmentExport struct {
+	Environment Environment           `json:"environment"`
+	Variables   []EnvironmentVariable `json:"variables"`
+}
+
+// RequestSource links a request to the operation of an imported API description it was generated from.
+// Fingerprint is a hash of the request as it was generated; a request that no longer matches it was edited.
+type RequestSource struct {
+	RequestID    int    `json:"request_id" db:"request_id"`
+	CollectionID int    `json:"collection_id" db:"collection_id"`
+	Source       string `json:"source" db:"source"`
+	Operation    string `json:"operation" db:"operation"`
+	Fingerprint  string `json:"fingerprint" db:"fingerprint"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+const (
+	LoadTestEventStarted  string = "started"
+	LoadTestEventProgress string = "progress"
+	LoadTestEventFinished string = "finished"
+)
+
+const (
+	LoadTestErrorStatus    string = "status"    // the response had a 4xx or 5xx status code
+	LoadTestErrorTransport string = "transport" // no response: refused connections, timeouts, TLS failures
+)
+
+// LoadTestOptions selects what a load test sends and how hard.
+// With RatePerSecond the test starts requests at that rate and VirtualUsers caps how many are in flight;
+// without, VirtualUsers send requests back to back.
+type LoadTestOptions struct {
+	CollectionID  int     `json:"collection_id"`
+	FolderID      *int    `json:"folder_id,omitempty"`      // the folder's requests, in order
+	RequestID     *int    `json:"request_id,omitempty"`     // a single request, takes precedence over FolderID
+	EnvironmentID *int    `json:"environment_id,omitempty"` // nil uses the selected environment
+	VirtualUsers  int     `json:"virtual_users"`
+	RatePerSecond float64 `json:"rate_per_second"` // 0 for back-to-back virtual users
+	DurationMs    int     `json:"duration_ms"`
+}
+
+// LatencyStats summarizes response times in milliseconds.
+type LatencyStats struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// LoadTestSecond is one second of a load test's timeline, by the time requests completed.
+type LoadTestSecond struct {
+	Second        int     `json:"second"` // 0 based, since the test started
+	Requests      int     `json:"requests"`
+	Errors        int     `json:"errors"`
+	BytesReceived int64   `json:"bytes_received"`
+	MeanLatency   float64 `json:"mean_latency"` // milliseconds
+	P90Latency    float64 `json:"p90_latency"`  // milliseconds
+}
+
+// LoadTestError counts the failures of one kind, e.g. status "503" or transport "connection refused".
+type LoadTestError struct {
+	Kind  string `json:"kind"` // "status" or "transport"
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// LoadTestReport summarizes a load test. It is updated while the test progresses.
+type LoadTestReport struct {
+	ID             int              `json:"id"`
+	CollectionID   int              `json:"collection_id"`
+	FolderID       *int             `json:"folder_id,omitempty"`
+	RequestID      *int             `json:"request_id,omitempty"`
+	EnvironmentID  *int             `json:"environment_id,omitempty"` // the environment the test used
+	Name           string           `json:"name"`                     // the request or folder under test
+	VirtualUsers   int              `json:"virtual_users"`
+	RatePerSecond  float64          `json:"rate_per_second"`
+	DurationMs     int              `json:"duration_ms"` // as requested
+	Status         string           `json:"status"`      // "running", "stopped", "completed"
+	Requests       int              `json:"requests"`
+	Errors         int              `json:"errors"`
+	Dropped        int              `json:"dropped"` // requests the target rate called for while every virtual user was busy
+	BytesSent      int64            `json:"bytes_sent"`
+	BytesReceived  int64            `json:"bytes_received"`
+	Throughput     float64          `json:"throughput"` // completed requests per second
+	Latency        LatencyStats     `json:"latency"`
+	Timeline       []LoadTestSecond `json:"timeline"`
+	ErrorBreakdown []LoadTestError  `json:"error_breakdown"` // most frequent first
+	Duration       int              `json:"duration"`        // milliseconds, as it ran
+	StartedAt      time.Time        `json:"started_at"`
+	FinishedAt     *time.Time       `json:"finished_at,omitempty"`
+}
+
+// LoadTestComparison compares two load tests, target minus base.
+type LoadTestComparison struct {
+	Base            LoadTestReport `json:"base"`   // without timeline
+	Target          LoadTestReport `json:"target"` // without timeline
+	ThroughputDelta float64        `json:"throughput_delta"`
+	BaseErrorRate   float64        `json:"base_error_rate"` // errors per request, 0 to 1
+	TargetErrorRate float64        `json:"target_error_rate"`
+	LatencyDelta    LatencyStats   `json:"latency_delta"` // milliseconds, positive when the target is slower
+}
+
+// LoadTestEvent reports the progress of a load test to the UI.
+type LoadTestEvent struct {
+	TestID   int             `json:"test_id"`
+	Type     string          `json:"type"` // "started", "progress", "finished"
+	Requests int             `json:"requests"`
+	Errors   int             `json:"errors"`
+	Second   *LoadTestSecond `json:"second,omitempty"` // set on "progress", the second that just ended
+	Report   *LoadTestReport `json:"report,omitempty"` // set on "finished"
+}
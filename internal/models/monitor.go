@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	MonitorStatusPassing string = "passing"
+	MonitorStatusFailing string = "failing"
+)
+
+const (
+	MonitorEventRun       string = "run"       // a scheduled run finished
+	MonitorEventFailing   string = "failing"   // the first failed run after passing ones, or after none
+	MonitorEventRecovered string = "recovered" // the first passing run after failed ones
+)
+
+// Monitor runs a collection, or one of its folders, on a schedule while TAPA is open or `tapa monitor` runs.
+type Monitor struct {
+	ID                  int        `json:"id" db:"id"`
+	CollectionID        int        `json:"collection_id" db:"collection_id"`
+	FolderID            *int       `json:"folder_id,omitempty" db:"folder_id"`           // nil runs the whole collection
+	EnvironmentID       *int       `json:"environment_id,omitempty" db:"environment_id"` // nil uses the selected environment
+	Name                string     `json:"name" db:"name"`
+	IntervalMinutes     int        `json:"interval_minutes" db:"interval_minutes"`
+	Enabled             bool       `json:"enabled" db:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"`
+	LastStatus          string     `json:"last_status,omitempty" db:"last_status"` // "passing", "failing" or "" before the first run
+	LastRunID           *int       `json:"last_run_id,omitempty" db:"last_run_id"`
+	LastRunAt           *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MonitorEvent reports a monitor's run, and its failure and recovery transitions, to the UI.
+type MonitorEvent struct {
+	Type                string    `json:"type"` // "run", "failing", "recovered"
+	MonitorID           int       `json:"monitor_id"`
+	Name                string    `json:"name"`
+	RunID               int       `json:"run_id"`
+	Status              string    `json:"status"` // "passing" or "failing"
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Passed              int       `json:"passed"` // requests of the run
+	Failed              int       `json:"failed"`
+	Error               string    `json:"error,omitempty"` // why the run could not start or ended early
+	At                  time.Time `json:"at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Report is the document run reports (JUnit, JSON, HTML) are written from.
+// It is assembled from request_history and test_results, so it can be rebuilt for past runs.
+type Report struct {
+	Title            string            `json:"title"`
+	RunID            int               `json:"run_id"`
+	CollectionID     int               `json:"collection_id"`
+	Status           string            `json:"status"`
+	StartedAt        time.Time         `json:"started_at"`
+	FinishedAt       *time.Time        `json:"finished_at,omitempty"`
+	Duration         int               `json:"duration"` // milliseconds
+	Requests         int               `json:"requests"`
+	RequestsFailed   int               `json:"requests_failed"`
+	Tests            int               `json:"tests"`
+	TestsFailed      int               `json:"tests_failed"`
+	Skipped          int               `json:"skipped"`
+	Error            string            `json:"error,omitempty"`
+	FailedIterations []int             `json:"failed_iterations"`
+	Iterations       []ReportIteration `json:"iterations"`
+	Steps            []RunStep         `json:"steps"` // the path the run took, in order
+}
+
+type ReportIteration struct {
+	Index   int               `json:"index"`
+	Data    map[string]string `json:"data,omitempty"`
+	Passed  bool              `json:"passed"`
+	Entries []ReportEntry     `json:"entries"`
+}
+
+// ReportEntry is one sent request with its test and assertion results.
+type ReportEntry struct {
+	HistoryID       int           `json:"history_id,omitempty"`
+	RequestID       int           `json:"request_id"`
+	Name            string        `json:"name"`
+	Method          string        `json:"method"`
+	URL             string        `json:"url"`
+	Timestamp       time.Time     `json:"timestamp"`
+	StatusCode      int           `json:"status_code"`
+	ResponseTime    int           `json:"response_time"` // milliseconds
+	DataVolume      int           `json:"data_volume"`   // bytes
+	Passed          bool          `json:"passed"`
+	Error           string        `json:"error,omitempty"`
+	ResponseSnippet string        `json:"response_snippet,omitempty"`
+	Tests           []TestOutcome `json:"tests"`
+}
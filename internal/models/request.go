@@ -26,6 +26,7 @@ type RequestBasic struct {
 	ID           int    `json:"id" db:"id"`
 	CollectionID *int   `json:"collection_id,omitempty" db:"collection_id"`
 	FolderID     *int   `json:"folder_id,omitempty" db:"folder_id"`
+	Position     int    `json:"position" db:"position"`
 	Name         string `json:"name" db:"name"`
 	Method       string `json:"method" db:"method"`
 }
@@ -36,11 +37,12 @@ type FullDashboardRequestList struct {
 	LooseRequests []RequestBasic `json:"loose_requests"`
 }
 
-// TODO: for later usage
-// type RequestWithDetail struct {
-// 	Request
-// 	Headers     []RequestHeader     `json:"headers"`
-// 	QueryParams []RequestQueryParam `json:"query_params"`
-// 	Cookies     []RequestCookie     `json:"cookies"`
-// 	Scripts     []RequestScript     `json:"scripts"`
-// }
+type RequestWithDetail struct {
+	Request
+	Headers        []RequestHeader     `json:"headers"`
+	QueryParams    []RequestQueryParam `json:"query_params"`
+	Cookies        []RequestCookie     `json:"cookies"`
+	Scripts        []RequestScript     `json:"scripts"`
+	Assertions     []RequestAssertion  `json:"assertions"`
+	SkipConditions []SkipCondition     `json:"skip_conditions"`
+}
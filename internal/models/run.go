@@ -0,0 +1,140 @@
+package models
+
+import "time"
+
+const (
+	RunStatusRunning   string = "running"
+	RunStatusPaused    string = "paused"
+	RunStatusStopped   string = "stopped"
+	RunStatusCompleted string = "completed"
+)
+
+const (
+	RunEventStarted           string = "started"
+	RunEventIterationStarted  string = "iteration_started"
+	RunEventRequestStarted    string = "request_started"
+	RunEventRequestFinished   string = "request_finished"
+	RunEventRequestSkipped    string = "request_skipped"
+	RunEventIterationFinished string = "iteration_finished"
+	RunEventPaused            string = "paused"
+	RunEventResumed           string = "resumed"
+	RunEventFinished          string = "finished"
+)
+
+// RunOptions selects what a collection run sends and how.
+type RunOptions struct {
+	CollectionID  int  `json:"collection_id"`
+	FolderID      *int `json:"folder_id,omitempty"`      // nil runs the whole collection
+	EnvironmentID *int `json:"environment_id,omitempty"` // nil uses the selected environment
+	DataFileID    *int `json:"data_file_id,omitempty"`   // collection data file, the collection runs once per row
+	Iterations    int  `json:"iterations"`               // runs without a data file, at least 1
+	StopOnFailure bool `json:"stop_on_failure"`
+	DelayMs       int  `json:"delay_ms"`  // pause between requests, between request starts in parallel runs
+	MaxSteps      int  `json:"max_steps"` // steps per iteration before the flow is considered a loop, DEFAULT_MAX_STEPS when 0
+
+	// Parallel runs send the requests of an iteration concurrently and report them in plan order.
+	// Iterations still run one after another and tapa.setNextRequest is ignored.
+	Concurrency   int     `json:"concurrency"`     // requests in flight at once, 0 or 1 sends them one after another
+	MaxPerHost    int     `json:"max_per_host"`    // requests in flight to one host at once, 0 for no cap
+	RatePerSecond float64 `json:"rate_per_second"` // requests started per second, 0 for no limit
+}
+
+// RunRequestResult is the outcome of one request of a run.
+type RunRequestResult struct {
+	Iteration    int           `json:"iteration"`
+	RequestID    int           `json:"request_id"`
+	HistoryID    int           `json:"history_id"`
+	Name         string        `json:"name"`
+	Method       string        `json:"method"`
+	URL          string        `json:"url"`
+	StatusCode   int           `json:"status_code"`
+	ResponseTime int           `json:"response_time"` // milliseconds
+	DataVolume   int           `json:"data_volume"`   // bytes
+	Passed       bool          `json:"passed"`        // a response arrived, scripts succeeded and every test passed
+	Error        string        `json:"error,omitempty"`
+	Tests        []TestOutcome `json:"tests"`
+}
+
+// RunIteration groups the results of one pass over the collection, with the data row it used.
+type RunIteration struct {
+	Index   int                `json:"index"`          // 0 based, also the data row index
+	Data    map[string]string  `json:"data,omitempty"` // nil without a data file
+	Passed  bool               `json:"passed"`         // every request of the iteration passed
+	Results []RunRequestResult `json:"results"`
+}
+
+// RunReport summarizes a collection run. It is updated while the run progresses.
+type RunReport struct {
+	ID               int            `json:"id"`
+	CollectionID     int            `json:"collection_id"`
+	FolderID         *int           `json:"folder_id,omitempty"`
+	EnvironmentID    *int           `json:"environment_id,omitempty"` // the environment the run used
+	DataFileID       *int           `json:"data_file_id,omitempty"`
+	Status           string         `json:"status"` // "running", "paused", "stopped", "completed"
+	Total            int            `json:"total"`  // requests planned, over all iterations
+	TotalIterations  int            `json:"total_iterations"`
+	Passed           int            `json:"passed"`
+	Failed           int            `json:"failed"`
+	TestsPassed      int            `json:"tests_passed"`
+	TestsFailed      int            `json:"tests_failed"`
+	Skipped          int            `json:"skipped"`           // requests skipped by their skip conditions
+	FailedIterations []int          `json:"failed_iterations"` // indexes of the iterations (data rows) with a failed request
+	Duration         int            `json:"duration"`          // milliseconds
+	StartedAt        time.Time      `json:"started_at"`
+	FinishedAt       *time.Time     `json:"finished_at,omitempty"`
+	Error            string         `json:"error,omitempty"` // why the run ended early, e.g. a flow that loops
+	Iterations       []RunIteration `json:"iterations"`
+	Steps            []RunStep      `json:"steps"` // the path the run took, including skipped requests and jumps
+}
+
+const (
+	RunChangeNewFailure   string = "new_failure"   // passed in the base run, fails now
+	RunChangeFixed        string = "fixed"         // failed in the base run, passes now
+	RunChangeStillFailing string = "still_failing" // fails in both runs
+	RunChangePassing      string = "passing"       // passes in both runs
+	RunChangeAdded        string = "added"         // only sent by the target run
+	RunChangeRemoved      string = "removed"       // only sent by the base run
+)
+
+// RunComparison lists what changed between two runs, request by request.
+type RunComparison struct {
+	Base          RunReport           `json:"base"`   // without iterations
+	Target        RunReport           `json:"target"` // without iterations
+	NewFailures   int                 `json:"new_failures"`
+	Fixed         int                 `json:"fixed"`
+	StatusChanges int                 `json:"status_changes"`
+	FailedTests   int                 `json:"failed_tests"` // tests that passed in the base run and fail now
+	FixedTests    int                 `json:"fixed_tests"`
+	Requests      []RequestComparison `json:"requests"`
+}
+
+// RequestComparison compares the executions of one request, in the same iteration, of two runs.
+type RequestComparison struct {
+	Iteration          int      `json:"iteration"`
+	RequestID          int      `json:"request_id"`
+	Name               string   `json:"name"`
+	Method             string   `json:"method"`
+	Change             string   `json:"change"` // one of the RunChange constants
+	BaseStatus         int      `json:"base_status"`
+	TargetStatus       int      `json:"target_status"`
+	StatusChanged      bool     `json:"status_changed"`
+	BaseResponseTime   int      `json:"base_response_time"`   // milliseconds
+	TargetResponseTime int      `json:"target_response_time"` // milliseconds
+	ResponseTimeDelta  int      `json:"response_time_delta"`  // target minus base, milliseconds
+	FailedTests        []string `json:"failed_tests"`         // tests that passed, or did not exist, in the base run
+	FixedTests         []string `json:"fixed_tests"`          // tests that failed in the base run and pass now
+}
+
+// RunEvent reports the progress of a run to the UI.
+type RunEvent struct {
+	RunID     int               `json:"run_id"`
+	Type      string            `json:"type"` // "started", "iteration_started", "request_started", "request_finished", "request_skipped", "iteration_finished", "paused", "resumed", "finished"
+	Iteration int               `json:"iteration"`
+	Index     int               `json:"index"` // position of the request in the plan, 0 based
+	Total     int               `json:"total"` // requests per iteration
+	Request   *RequestBasic     `json:"request,omitempty"`
+	Result    *RunRequestResult `json:"result,omitempty"`
+	Step      *RunStep          `json:"step,omitempty"`   // set on "request_finished" and "request_skipped"
+	Data      map[string]string `json:"data,omitempty"`   // set on "iteration_started"
+	Report    *RunReport        `json:"report,omitempty"` // set on "finished"
+}
@@ -1,7 +1,13 @@
 package models
 
+const (
+	ScriptPhasePreRequest   string = "pre-request"
+	ScriptPhasePostResponse string = "post-response"
+)
+
 type RequestScript struct {
 	ID        int    `json:"id" db:"id"`
 	RequestID int    `json:"request_id" db:"request_id"`
+	Phase     string `json:"phase" db:"phase"` // "pre-request", "post-response"
 	Script    string `json:"script" db:"script"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ScriptModule is a named script shared by the requests of a collection through require("name").
+type ScriptModule struct {
+	ID           int       `json:"id" db:"id"`
+	CollectionID int       `json:"collection_id" db:"collection_id"`
+	Name         string    `json:"name" db:"name"`
+	Script       string    `json:"script" db:"script"`
+	Version      int       `json:"version" db:"version"` // bumped on every change, used to invalidate compiled modules
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// SnippetTarget is a language and library code snippets can be generated for.
+type SnippetTarget struct {
+	ID       string `json:"id"`
+	Language string `json:"language"`
+	Library  string `json:"library"`
+}
@@ -2,10 +2,19 @@ package models
 
 import "time"
 
+const (
+	TestSourceScript    string = "script"
+	TestSourceAssertion string = "assertion"
+)
+
 type TestResult struct {
 	ID        int       `json:"id" db:"id"`
 	RequestID int       `json:"request_id" db:"request_id"`
+	HistoryID *int      `json:"history_id,omitempty" db:"history_id"`
+	Source    string    `json:"source" db:"source"` // "script", "assertion"
 	TestName  string    `json:"test_name" db:"test_name"`
-	Result    string    `json:"result,omitempty" db:"result"`
+	Passed    bool      `json:"passed" db:"passed"`
+	Result    string    `json:"result,omitempty" db:"result"` // failure message
+	Diff      string    `json:"diff,omitempty" db:"diff"`     // JSON encoded []DiffEntry of example assertions
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
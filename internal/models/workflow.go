@@ -0,0 +1,113 @@
+package models
+
+import "time"
+
+const (
+	WorkflowNodePending string = "pending"
+	WorkflowNodeRunning string = "running"
+	WorkflowNodePassed  string = "passed"
+	WorkflowNodeFailed  string = "failed"
+	WorkflowNodeSkipped string = "skipped" // a node it depends on failed or was skipped
+)
+
+const (
+	WorkflowEventStarted      string = "started"
+	WorkflowEventNodeStarted  string = "node_started"
+	WorkflowEventNodeFinished string = "node_finished"
+	WorkflowEventFinished     string = "finished"
+)
+
+// Workflow chains stored requests as a graph: every node runs once all the nodes it has edges from
+// have passed, and nodes without a path between them run in parallel.
+type Workflow struct {
+	ID            int            `json:"id" db:"id"`
+	Name          string         `json:"name" db:"name"`
+	Description   string         `json:"description" db:"description"`
+	EnvironmentID *int           `json:"environment_id,omitempty" db:"environment_id"` // nil uses the selected environment
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+	Nodes         []WorkflowNode `json:"nodes" db:"-"`
+	Edges         []WorkflowEdge `json:"edges" db:"-"`
+}
+
+// WorkflowNode is a stored request within a workflow. Its name, unique in the workflow, is how edges
+// and mappings refer to it, e.g. "login" in "login.token -> createOrder.header.Authorization".
+type WorkflowNode struct {
+	ID         int     `json:"id" db:"id"`
+	WorkflowID int     `json:"workflow_id" db:"workflow_id"`
+	RequestID  int     `json:"request_id" db:"request_id"` // 0 once the request was deleted
+	Name       string  `json:"name" db:"name"`
+	PositionX  float64 `json:"position_x" db:"position_x"` // where the node is drawn in the editor
+	PositionY  float64 `json:"position_y" db:"position_y"`
+}
+
+// WorkflowEdge makes To wait for From and carries values from From's response into To's request.
+// An edge without mappings only orders the two nodes.
+type WorkflowEdge struct {
+	ID       int               `json:"id"`
+	From     string            `json:"from"` // node name
+	To       string            `json:"to"`   // node name
+	Mappings []WorkflowMapping `json:"mappings"`
+}
+
+// WorkflowMapping copies one value from the response of an edge's From node into the request of its To node.
+//
+// Sources: "status", "header.<name>", "body", "body.<JSONPath>", "variable.<name>" (set by the node's scripts);
+// anything else is read as a JSONPath into the body, so "token" is "body.token".
+// Targets: "header.<name>", "query.<name>", "variable.<name>" (for {{name}} references), "body.<field.path>" (JSON bodies).
+type WorkflowMapping struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// WorkflowValue is a value a mapping carried between two nodes, as shown in the inputs and outputs of a run.
+type WorkflowValue struct {
+	From   string `json:"from"` // node name
+	Source string `json:"source"`
+	To     string `json:"to"` // node name
+	Target string `json:"target"`
+	Value  string `json:"value"` // JSON for values that are not strings
+	Error  string `json:"error,omitempty"`
+}
+
+// WorkflowNodeResult is the outcome of one node of a workflow run.
+type WorkflowNodeResult struct {
+	Node         string          `json:"node"`
+	RequestID    int             `json:"request_id"`
+	HistoryID    int             `json:"history_id"` // the full request and response, 0 when nothing was sent
+	Status       string          `json:"status"`     // one of the WorkflowNode constants
+	Method       string          `json:"method"`
+	URL          string          `json:"url"`
+	StatusCode   int             `json:"status_code"`
+	ResponseTime int             `json:"response_time"` // milliseconds
+	Error        string          `json:"error,omitempty"`
+	Tests        []TestOutcome   `json:"tests"`
+	Inputs       []WorkflowValue `json:"inputs"`  // values mapped into this node's request
+	Outputs      []WorkflowValue `json:"outputs"` // values mapped from this node's response into later nodes
+	StartedAt    *time.Time      `json:"started_at,omitempty"`
+	Duration     int             `json:"duration"` // milliseconds
+}
+
+// WorkflowRunReport summarizes a workflow run. It is updated while the run progresses.
+type WorkflowRunReport struct {
+	ID            int                  `json:"id"`
+	WorkflowID    int                  `json:"workflow_id"`
+	Name          string               `json:"name"`
+	EnvironmentID *int                 `json:"environment_id,omitempty"` // the environment the run used
+	Status        string               `json:"status"`                   // "running", "stopped", "completed"
+	Passed        int                  `json:"passed"`
+	Failed        int                  `json:"failed"`
+	Skipped       int                  `json:"skipped"`
+	Nodes         []WorkflowNodeResult `json:"nodes"`    // in the order the nodes were defined
+	Duration      int                  `json:"duration"` // milliseconds
+	StartedAt     time.Time            `json:"started_at"`
+	FinishedAt    *time.Time           `json:"finished_at,omitempty"`
+}
+
+// WorkflowEvent reports the progress of a workflow run to the UI.
+type WorkflowEvent struct {
+	RunID  int                 `json:"run_id"`
+	Type   string              `json:"type"` // "started", "node_started", "node_finished", "finished"
+	Node   *WorkflowNodeResult `json:"node,omitempty"`
+	Report *WorkflowRunReport  `json:"report,omitempty"` // set on "finished"
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// MemoryStore keeps monitors that are not saved, e.g. one given on the `tapa monitor` command line.
+type MemoryStore struct {
+	mu       sync.Mutex
+	monitors []models.Monitor
+}
+
+func (m *MemoryStore) GetMonitors() ([]models.Monitor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.Monitor{}, m.monitors...), nil
+}
+
+func (m *MemoryStore) RecordMonitorRun(id, runID int, passed bool, at time.Time) (models.Monitor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.monitors {
+		monitor := &m.monitors[i]
+		if monitor.ID != id {
+			continue
+		}
+
+		if passed {
+			monitor.ConsecutiveFailures = 0
+			monitor.LastStatus = models.MonitorStatusPassing
+		} else {
+			monitor.ConsecutiveFailures++
+			monitor.LastStatus = models.MonitorStatusFailing
+		}
+		if runID != 0 {
+			monitor.LastRunID = &runID
+		}
+		monitor.LastRunAt = &at
+
+		return *monitor, nil
+	}
+
+	return models.Monitor{}, errors.Wrap(errors.ErrMonitorNotFound, fmt.Errorf("monitor %d", id))
+}
+
+func NewMemoryStore(monitors ...models.Monitor) *MemoryStore {
+	return &MemoryStore{monitors: monitors}
+}
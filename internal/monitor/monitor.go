@@ -0,0 +1,210 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+)
+
+// CHECK_INTERVAL is how often the scheduler looks for monitors that are due.
+// Monitors run every whole minute or more, so a run starts at most this late.
+const CHECK_INTERVAL = 15 * time.Second
+
+// Listener receives the runs of monitors and their failure and recovery transitions.
+// It is called from the goroutine of each run.
+type Listener func(event models.MonitorEvent)
+
+// Store holds the monitors and their run state.
+type Store interface {
+	GetMonitors() ([]models.Monitor, error)
+	RecordMonitorRun(id, runID int, passed bool, at time.Time) (models.Monitor, error)
+}
+
+// Planner lists the requests a run sends, e.g. services.RunnerService.
+type Planner interface {
+	Plan(options models.RunOptions) ([]models.RequestBasic, error)
+}
+
+// Scheduler starts the runs of enabled monitors when they are due. Runs go through a runner with a store,
+// so they end up in the run history like any other collection run.
+type Scheduler struct {
+	runner   *runner.Runner
+	planner  Planner
+	store    Store
+	listener Listener
+	wake     chan struct{}
+
+	mu        sync.Mutex
+	running   map[int]bool
+	triggered map[int]bool // monitors to run at the next check, due or not
+}
+
+// Run checks for due monitors until ctx is done, then waits for the runs in flight, which are stopped.
+// Runs stopped this way are not counted against their monitor.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(CHECK_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		s.check(ctx, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Trigger runs a monitor at once, whether it is due or not, unless it is already running.
+func (s *Scheduler) Trigger(monitorID int) {
+	s.mu.Lock()
+	s.triggered[monitorID] = true
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) check(ctx context.Context, wg *sync.WaitGroup) {
+	monitors, err := s.store.GetMonitors()
+	if err != nil {
+		log.Printf("Loading monitors failed: %v", err)
+		return
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range monitors {
+		triggered := s.triggered[m.ID]
+		if s.running[m.ID] || !(triggered || (m.Enabled && Due(m, now))) {
+			continue
+		}
+
+		delete(s.triggered, m.ID)
+		s.running[m.ID] = true
+
+		wg.Add(1)
+		go func(m models.Monitor) {
+			defer wg.Done()
+			s.execute(ctx, m)
+
+			s.mu.Lock()
+			delete(s.running, m.ID)
+			s.mu.Unlock()
+		}(m)
+	}
+}
+
+// Due reports whether a monitor's interval has passed since its last run, or it never ran.
+func Due(m models.Monitor, now time.Time) bool {
+	if m.LastRunAt == nil {
+		return true
+	}
+
+	return !now.Before(m.LastRunAt.Add(time.Duration(m.IntervalMinutes) * time.Minute))
+}
+
+// execute runs the monitor's collection once, records the outcome and reports transitions.
+func (s *Scheduler) execute(ctx context.Context, m models.Monitor) {
+	options := models.RunOptions{
+		CollectionID:  m.CollectionID,
+		FolderID:      m.FolderID,
+		EnvironmentID: m.EnvironmentID,
+		Iterations:    1,
+	}
+
+	var report models.RunReport
+	plan, err := s.planner.Plan(options)
+	if err == nil {
+		var run *runner.Run
+		if run, err = s.runner.Start(ctx, plan, nil, options, nil); err == nil {
+			report = run.Wait()
+			// The runner keeps a run it could not save; that would grow its memory every tick.
+			s.runner.Forget(report.ID)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	passed := err == nil && report.Status == models.RunStatusCompleted && report.Failed == 0 && report.Error == ""
+	at := time.Now()
+
+	updated, recordErr := s.store.RecordMonitorRun(m.ID, report.ID, passed, at)
+	if recordErr != nil {
+		log.Printf("Recording the run of monitor %d failed: %v", m.ID, recordErr)
+		return
+	}
+
+	event := models.MonitorEvent{
+		Type:                models.MonitorEventRun,
+		MonitorID:           m.ID,
+		Name:                m.Name,
+		RunID:               report.ID,
+		Status:              updated.LastStatus,
+		ConsecutiveFailures: updated.ConsecutiveFailures,
+		Passed:              report.Passed,
+		Failed:              report.Failed,
+		Error:               report.Error,
+		At:                  at,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	s.listener(event)
+
+	switch {
+	case !passed && m.LastStatus != models.MonitorStatusFailing:
+		event.Type = models.MonitorEventFailing
+		s.listener(event)
+	case passed && m.LastStatus == models.MonitorStatusFailing:
+		event.Type = models.MonitorEventRecovered
+		s.listener(event)
+	}
+}
+
+// Validate rejects monitors that could never run.
+func Validate(m models.Monitor) error {
+	switch {
+	case m.CollectionID == 0:
+		return errors.Wrap(errors.ErrInvalidMonitor, fmt.Errorf("the monitor needs a collection"))
+	case m.IntervalMinutes < 1:
+		return errors.Wrap(errors.ErrInvalidMonitor, fmt.Errorf("the interval must be at least one minute"))
+	}
+
+	return nil
+}
+
+func NewScheduler(runner *runner.Runner, planner Planner, store Store, listener Listener) *Scheduler {
+	if listener == nil {
+		listener = func(models.MonitorEvent) {}
+	}
+
+	return &Scheduler{
+		runner:    runner,
+		planner:   planner,
+		store:     store,
+		listener:  listener,
+		wake:      make(chan struct{}, 1),
+		running:   map[int]bool{},
+		triggered: map[int]bool{},
+	}
+}
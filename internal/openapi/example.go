@@ -0,0 +1,243 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+)
+
+// MAX_EXAMPLE_DEPTH bounds how deep generated examples nest, so recursive schemas end.
+const MAX_EXAMPLE_DEPTH int = 8
+
+// explicitExample returns the example a parameter, media type or schema gives itself, without generating one.
+func (d *document) explicitExample(v map[string]any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+
+	if example, ok := v["example"]; ok {
+		return example, true
+	}
+
+	// "examples" is a map of example objects on parameters and media types, and a list in 3.1 schemas.
+	switch examples := v["examples"].(type) {
+	case map[string]any:
+		for _, name := range importing.SortedKeys(examples) {
+			if example := d.resolve(examples[name]); example != nil {
+				if value, ok := example["value"]; ok {
+					return value, true
+				}
+			}
+		}
+	case []any:
+		if len(examples) > 0 {
+			return examples[0], true
+		}
+	}
+
+	if schema := d.resolve(v["schema"]); schema != nil {
+		return d.explicitExample(schema)
+	}
+
+	if value, ok := v["default"]; ok {
+		return value, true
+	}
+	if value, ok := v["const"]; ok {
+		return value, true
+	}
+	if enum := list(v, "enum"); len(enum) > 0 {
+		return enum[0], true
+	}
+
+	return nil, false
+}
+
+// example generates a value that satisfies a schema, preferring the schema's own examples.
+// Read-only properties are left out, as they are in request bodies.
+func (d *document) example(schema any) any {
+	return d.generate(schema, 0, map[string]bool{})
+}
+
+func (d *document) generate(v any, depth int, refs map[string]bool) any {
+	raw, _ := v.(map[string]any)
+	if ref, ok := raw["$ref"].(string); ok {
+		if refs[ref] {
+			return nil
+		}
+		refs[ref] = true
+		defer delete(refs, ref)
+	}
+
+	schema := d.resolve(v)
+	if schema == nil || depth > MAX_EXAMPLE_DEPTH {
+		return nil
+	}
+
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if examples := list(schema, "examples"); len(examples) > 0 {
+		return examples[0]
+	}
+	for _, key := range []string{"default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if enum := list(schema, "enum"); len(enum) > 0 {
+		return enum[0]
+	}
+
+	if all := list(schema, "allOf"); len(all) > 0 {
+		merged := map[string]any{}
+		for _, sub := range all {
+			if obj, ok := d.generate(sub, depth+1, refs).(map[string]any); ok {
+				for key, value := range obj {
+					merged[key] = value
+				}
+			}
+		}
+		if _, ok := schema["properties"]; ok {
+			for key, value := range d.properties(schema, depth, refs) {
+				merged[key] = value
+			}
+		}
+		return merged
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options := list(schema, key); len(options) > 0 {
+			return d.generate(options[0], depth+1, refs)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		return d.properties(schema, depth, refs)
+	case "array":
+		item := d.generate(schema["items"], depth+1, refs)
+		if item == nil {
+			return []any{}
+		}
+		return []any{item}
+	case "string":
+		return stringExample(text(schema, "format"))
+	case "integer", "number":
+		if minimum, ok := schema["minimum"].(float64); ok {
+			return minimum
+		}
+		return float64(0)
+	case "boolean":
+		return true
+	}
+
+	return nil
+}
+
+func (d *document) properties(schema map[string]any, depth int, refs map[string]bool) map[string]any {
+	out := map[string]any{}
+
+	props := object(schema, "properties")
+	for _, name := range importing.SortedKeys(props) {
+		if prop := d.resolve(props[name]); prop != nil && prop["readOnly"] == true {
+			continue
+		}
+		// Properties that cannot be generated, e.g. recursive ones, are left out.
+		if value := d.generate(props[name], depth+1, refs); value != nil {
+			out[name] = value
+		}
+	}
+
+	if len(props) == 0 {
+		if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+			out["key"] = d.generate(additional, depth+1, refs)
+		}
+	}
+
+	return out
+}
+
+// schemaType returns the type of a schema, the first one that is not null for 3.1 type lists,
+// and "object" for untyped schemas with properties.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+func stringExample(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte", "binary", "password":
+		return ""
+	}
+	return "string"
+}
+
+// xmlExample writes a generated value as XML, named after the schema's xml.name or, failing that, root.
+func (d *document) xmlExample(schema any, value any) string {
+	name := "root"
+	if s := d.resolve(schema); s != nil {
+		if xmlName := text(object(s, "xml"), "name"); xmlName != "" {
+			name = xmlName
+		}
+	}
+
+	var b strings.Builder
+	writeXML(&b, name, value, "")
+	return b.String()
+}
+
+func writeXML(b *strings.Builder, name string, value any, indent string) {
+	switch v := value.(type) {
+	case map[string]any:
+		fmt.Fprintf(b, "%s<%s>\n", indent, name)
+		for _, key := range importing.SortedKeys(v) {
+			writeXML(b, key, v[key], indent+"  ")
+		}
+		fmt.Fprintf(b, "%s</%s>\n", indent, name)
+	case []any:
+		for _, item := range v {
+			writeXML(b, name, item, indent)
+		}
+	default:
+		fmt.Fprintf(b, "%s<%s>%s</%s>\n", indent, name, xmlEscape(scalar(v)), name)
+	}
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
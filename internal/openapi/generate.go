@@ -0,0 +1,858 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// OPENAPI_VERSION is the version of the documents Generate writes.
+const OPENAPI_VERSION string = "3.1.0"
+
+// Formats Generate writes the document in.
+const (
+	FORMAT_JSON string = "json"
+	FORMAT_YAML string = "yaml"
+)
+
+var (
+	variableReference = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+	hexID             = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	credentialName    = regexp.MustCompile(`(?i)^(x[-_])?(api[-_]?key|access[-_]?token|auth[-_]?token|token)$`)
+
+	// skippedHeaders are described by media types and security schemes, or set by the client.
+	skippedHeaders = map[string]bool{
+		"accept": true, "content-type": true, "content-length": true, "authorization": true, "cookie": true,
+		"host": true, "user-agent": true, "connection": true, "accept-encoding": true,
+	}
+)
+
+type spec struct {
+	OpenAPI    string                           `json:"openapi" yaml:"openapi"`
+	Info       info                             `json:"info" yaml:"info"`
+	Servers    []server                         `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Tags       []tag                            `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      map[string]map[string]*operation `json:"paths" yaml:"paths"`
+	Components *components                      `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+type info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type server struct {
+	URL string `json:"url" yaml:"url"`
+}
+
+type tag struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type operation struct {
+	Tags        []string              `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string                `json:"operationId" yaml:"operationId"`
+	Parameters  []parameter           `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses" yaml:"responses"`
+	Security    []map[string][]string `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+type parameter struct {
+	Name     string         `json:"name" yaml:"name"`
+	In       string         `json:"in" yaml:"in"`
+	Required bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   map[string]any `json:"schema" yaml:"schema"`
+	Example  any            `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]mediaType `json:"content" yaml:"content"`
+}
+
+type mediaType struct {
+	Schema  map[string]any `json:"schema" yaml:"schema"`
+	Example any            `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+type response struct {
+	Description string               `json:"description" yaml:"description"`
+	Content     map[string]mediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type components struct {
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes" yaml:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type   string `json:"type" yaml:"type"`
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	In     string `json:"in,omitempty" yaml:"in,omitempty"`
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// endpoint gathers the requests that call the same method and templated path.
+type endpoint struct {
+	method   string
+	path     string
+	folder   string
+	params   []pathParam
+	requests []models.RequestExport
+	segments int // segments of the path, to find its values in example URLs
+}
+
+// pathParam is a templated path segment: a variable, or a literal that looked like an id.
+type pathParam struct {
+	name    string
+	segment int    // index of the segment, -1 when the parameter is only part of it
+	literal string // the id the request URL held, if any
+}
+
+type generator struct {
+	variables   map[string]string
+	endpoints   map[string]*endpoint
+	order       []*endpoint
+	servers     []server
+	schemes     map[string]securityScheme
+	operationID map[string]int
+}
+
+// Generate infers an OpenAPI 3.1 document from a collection and the examples saved for its requests. Requests
+// calling the same method and path become one operation: segments that are variables or look like ids become path
+// parameters, query parameters and headers become parameters, and request and response schemas are inferred from
+// the example bodies. Requests with methods OpenAPI has no operation for are left out.
+func Generate(export models.CollectionExport, format string) ([]byte, error) {
+	g := &generator{
+		variables:   map[string]string{},
+		endpoints:   map[string]*endpoint{},
+		schemes:     map[string]securityScheme{},
+		operationID: map[string]int{},
+	}
+	for _, v := range export.Variables {
+		g.variables[v.Key] = v.Value
+	}
+
+	doc := spec{
+		OpenAPI: OPENAPI_VERSION,
+		Info:    info{Title: export.Collection.Name, Description: export.Collection.Description, Version: "1.0.0"},
+		Paths:   map[string]map[string]*operation{},
+	}
+
+	for _, folder := range export.Folders {
+		if len(folder.Requests) > 0 {
+			doc.Tags = append(doc.Tags, tag{Name: folder.Folder.Name})
+		}
+		for _, req := range folder.Requests {
+			g.add(folder.Folder.Name, req)
+		}
+	}
+	for _, req := range export.Requests {
+		g.add("", req)
+	}
+
+	for _, e := range g.order {
+		if doc.Paths[e.path] == nil {
+			doc.Paths[e.path] = map[string]*operation{}
+		}
+		doc.Paths[e.path][strings.ToLower(e.method)] = g.operation(e)
+	}
+
+	doc.Servers = g.servers
+	if len(g.schemes) > 0 {
+		doc.Components = &components{SecuritySchemes: g.schemes}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FORMAT_JSON:
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return nil, errors.Wrap(errors.ErrCollectionExport, err)
+		}
+	case FORMAT_YAML:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, errors.Wrap(errors.ErrCollectionExport, err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, errors.Wrap(errors.ErrCollectionExport, err)
+		}
+	default:
+		return nil, errors.Wrap(errors.ErrUnsupportedExportFormat, fmt.Errorf("unknown format %q", format))
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// add files a request under the endpoint its method and templated path name.
+func (g *generator) add(folder string, req models.RequestExport) {
+	method := strings.ToLower(req.Method)
+	if !contains(methods, method) {
+		return
+	}
+
+	origin, path := splitURL(req.URL)
+
+	var params []pathParam
+	names := map[string]int{}
+	unique := func(name string) string {
+		if name == "" {
+			name = "id"
+		}
+		if names[name]++; names[name] > 1 {
+			name += strconv.Itoa(names[name])
+		}
+		return name
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		switch {
+		case strings.Contains(segment, "{{"):
+			whole := variableReference.FindString(segment) == segment
+			segments[i] = variableReference.ReplaceAllStringFunc(segment, func(match string) string {
+				name := unique(variableName(match[2 : len(match)-2]))
+				index := i
+				if !whole {
+					index = -1
+				}
+				params = append(params, pathParam{name: name, segment: index})
+				return "{" + name + "}"
+			})
+		case looksLikeID(segment):
+			name := ""
+			if i > 0 && !strings.Contains(segments[i-1], "{") {
+				name = singular(variableName(segments[i-1])) + "Id"
+			}
+			name = unique(name)
+			params = append(params, pathParam{name: name, segment: i, literal: segment})
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	templated := "/" + strings.Join(segments, "/")
+	if path == "/" {
+		templated, segments = "/", nil
+	}
+
+	if url := g.server(origin, req, len(segments)); url != "" && !containsServer(g.servers, url) {
+		g.servers = append(g.servers, server{URL: url})
+	}
+
+	key := method + " " + templated
+	e, ok := g.endpoints[key]
+	if !ok {
+		e = &endpoint{method: method, path: templated, folder: folder, params: params, segments: len(segments)}
+		g.endpoints[key] = e
+		g.order = append(g.order, e)
+	} else {
+		// Ids the request held are examples of the endpoint's parameters too.
+		for i, p := range params {
+			if i < len(e.params) && p.literal != "" && e.params[i].literal == "" {
+				e.params[i].literal = p.literal
+			}
+		}
+	}
+	e.requests = append(e.requests, req)
+}
+
+// server returns the URL requests with this origin are sent to: the origin with collection variables resolved,
+// or else the part of an example URL before the request's path.
+func (g *generator) server(origin string, req models.RequestExport, segments int) string {
+	resolved := variableReference.ReplaceAllStringFunc(origin, func(match string) string {
+		if value, ok := g.variables[strings.TrimSpace(match[2:len(match)-2])]; ok {
+			return value
+		}
+		return match
+	})
+	if strings.Contains(resolved, "://") && !strings.Contains(resolved, "{{") {
+		return strings.TrimRight(resolved, "/")
+	}
+
+	for _, example := range req.Examples {
+		u, err := neturl.Parse(example.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		parts := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+		if len(parts) < segments {
+			continue
+		}
+		base := strings.Join(parts[:len(parts)-segments], "/")
+		return strings.TrimRight(u.Scheme+"://"+u.Host+"/"+base, "/")
+	}
+
+	return ""
+}
+
+func (g *generator) operation(e *endpoint) *operation {
+	first := e.requests[0].RequestWithDetail
+
+	op := &operation{
+		Summary:     first.Name,
+		Description: first.Notes,
+		OperationID: g.operationIDFor(first.Name, e),
+		Responses:   map[string]response{},
+	}
+	if e.folder != "" {
+		op.Tags = []string{e.folder}
+	}
+
+	op.Parameters = append(op.Parameters, g.pathParameters(e)...)
+	op.Parameters = append(op.Parameters, g.parameters(e, &op.Security)...)
+	op.RequestBody = g.requestBody(e)
+	op.Responses = g.responses(e)
+
+	return op
+}
+
+func (g *generator) operationIDFor(name string, e *endpoint) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if len(words) == 0 {
+		words = strings.FieldsFunc(e.method+" "+e.path, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		})
+	}
+
+	id := strings.ToLower(words[0])
+	for _, word := range words[1:] {
+		id += strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+
+	if g.operationID[id]++; g.operationID[id] > 1 {
+		id += strconv.Itoa(g.operationID[id])
+	}
+	return id
+}
+
+// pathParameters infers the path parameters from the ids the requests held and the segments of their example URLs.
+func (g *generator) pathParameters(e *endpoint) []parameter {
+	out := make([]parameter, 0, len(e.params))
+
+	for _, p := range e.params {
+		values := newShape()
+		var example any
+
+		record := func(s string) {
+			v := literal(s)
+			values.add(v)
+			if example == nil {
+				example = v
+			}
+		}
+
+		if p.literal != "" {
+			record(p.literal)
+		}
+		for _, req := range e.requests {
+			for _, ex := range req.Examples {
+				if segment, ok := exampleSegment(ex.URL, e.segments, p.segment); ok {
+					record(segment)
+				}
+			}
+		}
+
+		schema := values.schema()
+		if len(schema) == 0 {
+			schema = map[string]any{"type": "string"}
+		}
+		out = append(out, parameter{Name: p.name, In: "path", Required: true, Schema: schema, Example: example})
+	}
+
+	return out
+}
+
+// observed collects the values of the parameters of one location, e.g. the query, over requests and examples.
+type observed struct {
+	location     string
+	order        []string
+	values       map[string]*shape
+	examples     map[string]any
+	seen         map[string]int
+	observations int
+}
+
+func newObserved(location string) *observed {
+	return &observed{location: location, values: map[string]*shape{}, examples: map[string]any{}, seen: map[string]int{}}
+}
+
+// observe records the parameters of one request or example. Values that are variable references only count as seen.
+func (o *observed) observe(params map[string][]string, keys []string) {
+	o.observations++
+	for _, key := range keys {
+		if o.values[key] == nil {
+			o.values[key] = newShape()
+			o.order = append(o.order, key)
+		}
+		o.seen[key]++
+
+		for _, value := range params[key] {
+			if strings.Contains(value, "{{") {
+				continue
+			}
+			v := literal(value)
+			o.values[key].add(v)
+			if _, ok := o.examples[key]; !ok {
+				o.examples[key] = v
+			}
+		}
+	}
+}
+
+// parameters returns the query, header and cookie parameters, and sets security to the schemes of any credentials.
+func (g *generator) parameters(e *endpoint, security *[]map[string][]string) []parameter {
+	query, headers, cookies := newObserved("query"), newObserved("header"), newObserved("cookie")
+	requirement := map[string][]string{}
+
+	for _, req := range e.requests {
+		params, keys := map[string][]string{}, []string{}
+		add := func(key, value string) {
+			if _, ok := params[key]; !ok {
+				keys = append(keys, key)
+			}
+			params[key] = append(params[key], value)
+		}
+
+		if _, rawQuery, ok := strings.Cut(req.URL, "?"); ok {
+			rawQuery, _, _ = strings.Cut(rawQuery, "#")
+			for _, pair := range strings.Split(rawQuery, "&") {
+				key, value, _ := strings.Cut(pair, "=")
+				key, _ = neturl.QueryUnescape(key)
+				value, _ = neturl.QueryUnescape(value)
+				if key != "" {
+					add(key, value)
+				}
+			}
+		}
+		for _, p := range req.QueryParams {
+			add(p.Key, p.Value)
+		}
+
+		keys = g.credentials(keys, params, "query", requirement)
+		query.observe(params, keys)
+
+		// Examples hold the query the request was sent with, credentials included.
+		for _, ex := range req.Examples {
+			sent := map[string]string{}
+			if json.Unmarshal([]byte(ex.QueryParams), &sent) != nil {
+				continue
+			}
+			exampleParams, exampleKeys := map[string][]string{}, []string{}
+			for _, key := range importing.SortedKeys(sent) {
+				if credentialName.MatchString(key) {
+					continue
+				}
+				exampleParams[key] = []string{sent[key]}
+				exampleKeys = append(exampleKeys, key)
+			}
+			query.observe(exampleParams, exampleKeys)
+		}
+
+		params, keys = map[string][]string{}, []string{}
+		for _, h := range req.Headers {
+			if !skippedHeaders[strings.ToLower(h.Key)] || strings.EqualFold(h.Key, "authorization") {
+				add(h.Key, h.Value)
+			}
+		}
+		keys = g.credentials(keys, params, "header", requirement)
+
+		// Header values the request left to variables are filled in from what its examples sent.
+		for _, ex := range req.Examples {
+			sent := map[string]string{}
+			if json.Unmarshal([]byte(ex.Headers), &sent) != nil {
+				continue
+			}
+			for name, value := range sent {
+				for _, key := range keys {
+					if strings.EqualFold(name, key) {
+						params[key] = append(params[key], value)
+					}
+				}
+			}
+		}
+		headers.observe(params, keys)
+
+		params, keys = map[string][]string{}, []string{}
+		for _, c := range req.Cookies {
+			add(c.Key, c.Value)
+		}
+		cookies.observe(params, keys)
+	}
+
+	if len(requirement) > 0 {
+		*security = []map[string][]string{requirement}
+	}
+
+	var out []parameter
+	for _, o := range []*observed{query, headers, cookies} {
+		for _, key := range o.order {
+			schema := o.values[key].schema()
+			if len(schema) == 0 {
+				schema = map[string]any{"type": "string"}
+			}
+			out = append(out, parameter{
+				Name:     key,
+				In:       o.location,
+				Required: o.seen[key] == o.observations,
+				Schema:   schema,
+				Example:  o.examples[key],
+			})
+		}
+	}
+	return out
+}
+
+// credentials moves the Authorization header and parameters named like API keys or tokens into security schemes,
+// and returns the keys that remain parameters.
+func (g *generator) credentials(keys []string, params map[string][]string, in string, requirement map[string][]string) []string {
+	remaining := keys[:0]
+
+	for _, key := range keys {
+		var name string
+		var scheme securityScheme
+
+		switch {
+		case in == "header" && strings.EqualFold(key, "authorization"):
+			value := ""
+			if len(params[key]) > 0 {
+				value = strings.ToLower(params[key][0])
+			}
+			switch {
+			case strings.HasPrefix(value, "bearer "):
+				name, scheme = "bearerAuth", securityScheme{Type: "http", Scheme: "bearer"}
+			case strings.HasPrefix(value, "basic "):
+				name, scheme = "basicAuth", securityScheme{Type: "http", Scheme: "basic"}
+			default:
+				name, scheme = "authorization", securityScheme{Type: "apiKey", In: in, Name: key}
+			}
+		case credentialName.MatchString(key):
+			name, scheme = variableName(key), securityScheme{Type: "apiKey", In: in, Name: key}
+		default:
+			remaining = append(remaining, key)
+			continue
+		}
+
+		g.schemes[name] = scheme
+		requirement[name] = []string{}
+	}
+
+	return remaining
+}
+
+// requestBody infers the request body from the bodies of the requests and of their examples, in the media type of
+// the first request with a body.
+func (g *generator) requestBody(e *endpoint) *requestBody {
+	contentType := ""
+	withBody := 0
+	for _, req := range e.requests {
+		if req.Body == "" {
+			continue
+		}
+		withBody++
+		if contentType == "" {
+			contentType = bodyContentType(req.RequestWithDetail)
+		}
+	}
+	if contentType == "" {
+		return nil
+	}
+
+	base, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	body := newShape()
+	var example any
+
+	record := func(v any) {
+		body.add(v)
+		if example == nil {
+			example = v
+		}
+	}
+
+	switch {
+	case base == "application/json" || strings.HasSuffix(base, "+json"):
+		// Examples hold the bodies as sent, with variables resolved, so they come first.
+		for _, req := range e.requests {
+			for _, ex := range req.Examples {
+				if v, ok := decodeJSON(ex.Body); ok {
+					record(v)
+				}
+			}
+		}
+		for _, req := range e.requests {
+			if v, ok := decodeJSON(req.Body); ok {
+				record(v)
+			}
+		}
+	case base == "application/x-www-form-urlencoded":
+		for _, req := range e.requests {
+			for _, raw := range append([]string{req.Body}, exampleBodies(req)...) {
+				values, err := neturl.ParseQuery(raw)
+				if err != nil || len(values) == 0 {
+					continue
+				}
+				fields := map[string]any{}
+				for key, v := range values {
+					fields[key] = literal(v[0])
+				}
+				record(fields)
+			}
+		}
+	case base == "multipart/form-data":
+		for _, req := range e.requests {
+			fields := map[string]string{}
+			if json.Unmarshal([]byte(req.Body), &fields) != nil {
+				continue
+			}
+			converted := map[string]any{}
+			for key, value := range fields {
+				converted[key] = literal(value)
+			}
+			record(converted)
+		}
+	default:
+		body.add("")
+		example = e.requests[0].Body
+		for _, req := range e.requests {
+			if req.Body != "" {
+				example = req.Body
+				break
+			}
+		}
+	}
+
+	schema := body.schema()
+	if base != "application/json" && !strings.HasSuffix(base, "+json") && schemaType(schema) != "object" {
+		schema = map[string]any{"type": "string"}
+	}
+
+	return &requestBody{
+		Required: withBody == len(e.requests),
+		Content:  map[string]mediaType{contentType: {Schema: schema, Example: example}},
+	}
+}
+
+// responses infers a response for every status code the examples recorded, with a schema per content type.
+func (g *generator) responses(e *endpoint) map[string]response {
+	type observedResponse struct {
+		content  map[string]*shape
+		examples map[string]any
+	}
+	byCode := map[int]*observedResponse{}
+
+	for _, req := range e.requests {
+		for _, ex := range req.Examples {
+			if ex.StatusCode == 0 {
+				continue
+			}
+
+			r := byCode[ex.StatusCode]
+			if r == nil {
+				r = &observedResponse{content: map[string]*shape{}, examples: map[string]any{}}
+				byCode[ex.StatusCode] = r
+			}
+
+			if strings.TrimSpace(ex.Response) == "" {
+				continue
+			}
+
+			contentType := responseContentType(ex.ResponseHeaders)
+			value, isJSON := decodeJSON(ex.Response)
+			if contentType == "" {
+				contentType = "text/plain"
+				if isJSON {
+					contentType = "application/json"
+				}
+			}
+
+			if r.content[contentType] == nil {
+				r.content[contentType] = newShape()
+			}
+
+			base, _, _ := strings.Cut(contentType, ";")
+			if isJSON && (base == "application/json" || strings.HasSuffix(base, "+json")) {
+				r.content[contentType].add(value)
+				if _, ok := r.examples[contentType]; !ok {
+					r.examples[contentType] = value
+				}
+			} else {
+				r.content[contentType].add("")
+			}
+		}
+	}
+
+	out := map[string]response{}
+	for code, r := range byCode {
+		description := http.StatusText(code)
+		if description == "" {
+			description = "Response"
+		}
+
+		res := response{Description: description}
+		if len(r.content) > 0 {
+			res.Content = map[string]mediaType{}
+			for contentType, body := range r.content {
+				res.Content[contentType] = mediaType{Schema: body.schema(), Example: r.examples[contentType]}
+			}
+		}
+		out[strconv.Itoa(code)] = res
+	}
+
+	if len(out) == 0 {
+		out["default"] = response{Description: "No response was recorded"}
+	}
+	return out
+}
+
+// bodyContentType returns the Content-Type header of a request, or the one its body format implies.
+func bodyContentType(req models.RequestWithDetail) string {
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Key, "content-type") && h.Value != "" && !strings.Contains(h.Value, "{{") {
+			return h.Value
+		}
+	}
+
+	switch req.BodyFormat {
+	case "JSON":
+		return "application/json"
+	case "XML":
+		return "application/xml"
+	case "form-data":
+		return "multipart/form-data"
+	}
+	return "text/plain"
+}
+
+// responseContentType returns the media type of a recorded response, without parameters.
+func responseContentType(raw string) string {
+	headers := map[string][]string{}
+	if json.Unmarshal([]byte(raw), &headers) != nil {
+		return ""
+	}
+
+	for name, values := range headers {
+		if strings.EqualFold(name, "content-type") && len(values) > 0 {
+			base, _, _ := strings.Cut(values[0], ";")
+			return strings.ToLower(strings.TrimSpace(base))
+		}
+	}
+	return ""
+}
+
+func exampleBodies(req models.RequestExport) []string {
+	bodies := make([]string, 0, len(req.Examples))
+	for _, ex := range req.Examples {
+		bodies = append(bodies, ex.Body)
+	}
+	return bodies
+}
+
+// exampleSegment returns a segment of an example URL's path, counted from the end of the endpoint's path so that
+// a base path in the server URL does not shift it.
+func exampleSegment(rawURL string, segments, index int) (string, bool) {
+	if index < 0 {
+		return "", false
+	}
+
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	i := len(parts) - segments + index
+	if len(parts) < segments || i < 0 || parts[i] == "" {
+		return "", false
+	}
+	return parts[i], true
+}
+
+// splitURL splits a request URL into its origin, a variable reference or a scheme and host, and its path.
+func splitURL(raw string) (origin, path string) {
+	raw = strings.TrimSpace(raw)
+	raw, _, _ = strings.Cut(raw, "#")
+	raw, _, _ = strings.Cut(raw, "?")
+
+	switch {
+	case strings.HasPrefix(raw, "{{") && strings.Contains(raw, "}}"):
+		end := strings.Index(raw, "}}") + 2
+		origin, path = raw[:end], raw[end:]
+	case strings.Contains(raw, "://"):
+		scheme, rest, _ := strings.Cut(raw, "://")
+		host, p, _ := strings.Cut(rest, "/")
+		origin, path = scheme+"://"+host, "/"+p
+	default:
+		host, p, _ := strings.Cut(raw, "/")
+		origin, path = host, "/"+p
+	}
+
+	// A port or anything else up to the first slash still belongs to the origin.
+	if path != "" && !strings.HasPrefix(path, "/") {
+		rest, p, _ := strings.Cut(path, "/")
+		origin, path = origin+rest, "/"+p
+	}
+	if path == "" {
+		path = "/"
+	}
+	return origin, path
+}
+
+// looksLikeID reports whether a literal path segment is more likely a record's id than a fixed name.
+func looksLikeID(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if _, err := strconv.ParseInt(segment, 10, 64); err == nil {
+		return true
+	}
+	return uuidPattern.MatchString(segment) || (hexID.MatchString(segment) && strings.ContainsAny(segment, "0123456789"))
+}
+
+// singular turns a collection segment such as "pets" or "categories" into a name for one of its items.
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsServer(servers []server, url string) bool {
+	for _, s := range servers {
+		if s.URL == url {
+			return true
+		}
+	}
+	return false
+}
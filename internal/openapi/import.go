@@ -0,0 +1,544 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/schemas"
+)
+
+// BASE_URL_VARIABLE is the variable requests start their URL with; every server becomes an environment setting it.
+const BASE_URL_VARIABLE string = "baseUrl"
+
+var (
+	// methods are the operations of a path item in the order requests are created.
+	methods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+	pathTemplate   = regexp.MustCompile(`\{([^{}]+)\}`)
+	variableSyntax = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+)
+
+// Import generates a collection from an OpenAPI 3.0 or 3.1 or a Swagger 2.0 document, in JSON or YAML. Every
+// operation becomes a request in the folder of its first tag, keyed by method and path so a later import can merge
+// into the collection. The returned report only holds warnings; counts are filled in when the import is stored.
+func Import(data []byte) (models.SpecImport, models.ImportReport, error) {
+	root, err := schemas.DecodeDocument(data)
+	if err != nil {
+		return models.SpecImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+	}
+
+	version, _ := root["openapi"].(string)
+	swagger := isSwagger(root)
+	if !strings.HasPrefix(version, "3.") && !swagger {
+		return models.SpecImport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("not an OpenAPI 3.x or Swagger 2.0 document"))
+	}
+
+	im := &importer{
+		doc:       &document{root: root},
+		report:    models.ImportReport{Source: SOURCE, Warnings: []models.ImportWarning{}},
+		variables: map[string]bool{},
+		warned:    map[string]bool{},
+	}
+
+	if swagger {
+		im.upgradeSwagger()
+	}
+
+	spec := im.convert()
+
+	// Component schemas are stored as well, for json_schema assertions against the responses.
+	if components, err := schemas.ComponentSchemas(data); err == nil {
+		for _, name := range importing.SortedKeys(components) {
+			spec.Schemas = append(spec.Schemas, models.CollectionSchema{Name: name, Schema: components[name], Source: models.SchemaSourceOpenAPI})
+		}
+	}
+
+	im.report.Name = spec.Collection.Name
+	return spec, im.report, nil
+}
+
+type importer struct {
+	doc       *document
+	report    models.ImportReport
+	spec      models.SpecImport
+	variables map[string]bool // collection variables added so far
+	warned    map[string]bool // warnings given once per document
+}
+
+func (im *importer) warn(where string, format string, args ...any) {
+	im.report.Warnings = append(im.report.Warnings, models.ImportWarning{Item: where, Message: fmt.Sprintf(format, args...)})
+}
+
+// warnOnce gives a warning about the document only the first time it comes up.
+func (im *importer) warnOnce(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if !im.warned[message] {
+		im.warned[message] = true
+		im.warn("", "%s", message)
+	}
+}
+
+func (im *importer) variable(key, value string) {
+	if !im.variables[key] {
+		im.variables[key] = true
+		im.spec.Variables = append(im.spec.Variables, models.CollectionVariable{Key: key, Value: value})
+	}
+}
+
+func (im *importer) convert() models.SpecImport {
+	root := im.doc.root
+	info := object(root, "info")
+
+	name := strings.TrimSpace(text(info, "title"))
+	if name == "" {
+		name = "OpenAPI import"
+	}
+
+	im.spec = models.SpecImport{
+		Source:       SOURCE,
+		Collection:   models.Collection{Name: name, Description: text(info, "description")},
+		Variables:    []models.CollectionVariable{},
+		Schemas:      []models.CollectionSchema{},
+		Environments: []models.EnvironmentExport{},
+		Operations:   []models.SpecOperation{},
+	}
+
+	im.servers(name, list(root, "servers"))
+
+	if webhooks := object(root, "webhooks"); len(webhooks) > 0 {
+		im.warn("", "webhooks are not imported (%d)", len(webhooks))
+	}
+
+	// Folders follow the order of the document's tag list, then the order tags are first used in.
+	order := map[string]int{}
+	for _, t := range list(root, "tags") {
+		if tag, ok := t.(map[string]any); ok && text(tag, "name") != "" {
+			if _, ok := order[text(tag, "name")]; !ok {
+				order[text(tag, "name")] = len(order)
+			}
+		}
+	}
+
+	paths := object(root, "paths")
+	for _, path := range importing.SortedKeys(paths) {
+		item := im.doc.resolve(paths[path])
+		if item == nil {
+			continue
+		}
+
+		if len(list(item, "servers")) > 0 {
+			im.warn(path, "path servers are not supported, requests use {{%s}}", BASE_URL_VARIABLE)
+		}
+
+		for _, method := range methods {
+			op := object(item, method)
+			if op == nil {
+				continue
+			}
+
+			key := strings.ToUpper(method) + " " + path
+			if method == "trace" {
+				im.warn(key, "TRACE is not supported, the operation was skipped")
+				continue
+			}
+
+			operation := im.operation(key, path, method, item, op)
+			if _, ok := order[operation.Folder]; !ok && operation.Folder != "" {
+				order[operation.Folder] = len(order)
+			}
+			im.spec.Operations = append(im.spec.Operations, operation)
+		}
+	}
+
+	sort.SliceStable(im.spec.Operations, func(i, j int) bool {
+		a, b := im.spec.Operations[i].Folder, im.spec.Operations[j].Folder
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		return order[a] < order[b]
+	})
+
+	return im.spec
+}
+
+// servers sets the base URL variable to the first server and creates an environment for every server.
+func (im *importer) servers(title string, servers []any) {
+	if len(servers) == 0 {
+		im.warn("", "the document lists no servers, set {{%s}} before sending requests", BASE_URL_VARIABLE)
+		im.variable(BASE_URL_VARIABLE, "")
+		return
+	}
+
+	names := map[string]int{}
+	for _, s := range servers {
+		server, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		vars := []models.EnvironmentVariable{}
+		values := map[string]string{}
+		serverVars := object(server, "variables")
+		for _, key := range importing.SortedKeys(serverVars) {
+			value := scalar(object(serverVars, key)["default"])
+			values[key] = value
+			vars = append(vars, models.EnvironmentVariable{Key: key, Value: value})
+		}
+
+		url := pathTemplate.ReplaceAllStringFunc(text(server, "url"), func(match string) string {
+			return values[match[1:len(match)-1]]
+		})
+		url = strings.TrimRight(url, "/")
+
+		if !strings.Contains(url, "://") {
+			im.warnOnce("server %q is relative, prefix {{%s}} with the host the document is served from", url, BASE_URL_VARIABLE)
+		}
+
+		im.variable(BASE_URL_VARIABLE, url)
+
+		label := strings.TrimSpace(text(server, "description"))
+		if label == "" {
+			label = url
+		}
+		envName := title + " - " + label
+		if names[envName]++; names[envName] > 1 {
+			envName = fmt.Sprintf("%s (%d)", envName, names[envName])
+		}
+
+		vars = append([]models.EnvironmentVariable{{Key: BASE_URL_VARIABLE, Value: url}}, vars...)
+		im.spec.Environments = append(im.spec.Environments, models.EnvironmentExport{
+			Environment: models.Environment{Name: envName},
+			Variables:   vars,
+		})
+	}
+}
+
+func (im *importer) operation(key, path, method string, item, op map[string]any) models.SpecOperation {
+	req := models.RequestExport{Examples: []models.RequestExample{}}
+	req.Name = strings.TrimSpace(text(op, "summary"))
+	if req.Name == "" {
+		req.Name = text(op, "operationId")
+	}
+	if req.Name == "" {
+		req.Name = key
+	}
+	req.Method = strings.ToUpper(method)
+	req.BodyFormat = "raw"
+	req.Timeout = importing.DEFAULT_TIMEOUT_MS
+	req.AllowRedirects = true
+	req.SSLVerification = true
+	req.EncodeURL = true
+	req.Headers = []models.RequestHeader{}
+	req.QueryParams = []models.RequestQueryParam{}
+	req.Cookies = []models.RequestCookie{}
+	req.Scripts = []models.RequestScript{}
+	req.Assertions = []models.RequestAssertion{}
+	req.SkipConditions = []models.SkipCondition{}
+
+	notes := []string{}
+	if op["deprecated"] == true {
+		notes = append(notes, "Deprecated.")
+	}
+	if description := strings.TrimSpace(text(op, "description")); description != "" {
+		notes = append(notes, description)
+	}
+
+	pathValues := map[string]string{}
+	var optional []string
+	for _, p := range im.parameters(key, item, op) {
+		name, in := text(p, "name"), text(p, "in")
+		value, hasValue := im.doc.explicitExample(p)
+
+		if in == "path" {
+			if hasValue {
+				pathValues[name] = scalar(value)
+			}
+			continue
+		}
+
+		required := p["required"] == true
+		if !required && !hasValue {
+			optional = append(optional, fmt.Sprintf("%s (%s)", name, in))
+			continue
+		}
+
+		values := []string{"{{" + variableName(name) + "}}"}
+		if hasValue {
+			values = []string{scalar(value)}
+			if items, ok := value.([]any); ok && in == "query" && p["explode"] != false {
+				values = values[:0]
+				for _, item := range items {
+					values = append(values, scalar(item))
+				}
+			} else if ok {
+				parts := make([]string, len(items))
+				for i, item := range items {
+					parts[i] = scalar(item)
+				}
+				values = []string{strings.Join(parts, ",")}
+			}
+		}
+
+		switch in {
+		case "query":
+			for _, v := range values {
+				req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: name, Value: v})
+			}
+		case "header":
+			req.Headers = append(req.Headers, models.RequestHeader{Key: name, Value: values[0]})
+		case "cookie":
+			req.Cookies = append(req.Cookies, models.RequestCookie{Key: name, Value: values[0]})
+		}
+	}
+
+	if len(optional) > 0 {
+		notes = append(notes, "Optional parameters: "+strings.Join(optional, ", ")+".")
+	}
+	req.Notes = strings.Join(notes, "\n\n")
+
+	req.URL = "{{" + BASE_URL_VARIABLE + "}}" + pathTemplate.ReplaceAllStringFunc(path, func(match string) string {
+		name := match[1 : len(match)-1]
+		if value, ok := pathValues[name]; ok {
+			return value
+		}
+		return "{{" + variableName(name) + "}}"
+	})
+
+	im.body(key, op, &req.RequestWithDetail)
+	im.accept(op, &req.RequestWithDetail)
+	im.security(key, op, &req.RequestWithDetail)
+
+	if callbacks := object(op, "callbacks"); len(callbacks) > 0 {
+		im.warn(key, "callbacks are not imported")
+	}
+	if len(list(op, "servers")) > 0 {
+		im.warn(key, "operation servers are not supported, the request uses {{%s}}", BASE_URL_VARIABLE)
+	}
+
+	folder := ""
+	if tags := list(op, "tags"); len(tags) > 0 {
+		folder, _ = tags[0].(string)
+	}
+
+	return models.SpecOperation{Key: key, Folder: folder, Request: req}
+}
+
+// parameters returns the parameters of an operation, path item parameters included unless the operation
+// overrides them by name and location.
+func (im *importer) parameters(key string, item, op map[string]any) []map[string]any {
+	var out []map[string]any
+	index := map[string]int{}
+
+	for _, source := range [][]any{list(item, "parameters"), list(op, "parameters")} {
+		for _, raw := range source {
+			p := im.doc.resolve(raw)
+			if p == nil {
+				im.warn(key, "a parameter reference could not be resolved")
+				continue
+			}
+
+			name, in := text(p, "name"), text(p, "in")
+			if in == "header" {
+				switch strings.ToLower(name) {
+				case "accept", "content-type", "authorization":
+					continue // described by the media types and security schemes instead
+				}
+			}
+
+			id := in + ":" + name
+			if i, ok := index[id]; ok {
+				out[i] = p
+				continue
+			}
+			index[id] = len(out)
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
+
+// body sets the request body from the preferred media type of the operation's request body.
+func (im *importer) body(key string, op map[string]any, req *models.RequestWithDetail) {
+	requestBody := im.doc.resolve(op["requestBody"])
+	if requestBody == nil {
+		return
+	}
+
+	content := object(requestBody, "content")
+	if len(content) == 0 {
+		return
+	}
+
+	mediaType := preferredMediaType(content)
+	media := object(content, mediaType)
+
+	value, ok := im.doc.explicitExample(media)
+	if !ok {
+		value = im.doc.example(media["schema"])
+	}
+
+	base, _, _ := strings.Cut(strings.ToLower(mediaType), ";")
+	switch {
+	case base == "application/json" || strings.HasSuffix(base, "+json"):
+		if s, ok := value.(string); ok && json.Valid([]byte(s)) {
+			req.Body = s
+		} else {
+			encoded, _ := json.MarshalIndent(value, "", "  ")
+			req.Body = string(encoded)
+		}
+		req.BodyFormat = "JSON"
+		if base != "application/json" {
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: mediaType})
+		}
+	case base == "application/xml" || base == "text/xml" || strings.HasSuffix(base, "+xml"):
+		if s, ok := value.(string); ok {
+			req.Body = s
+		} else {
+			req.Body = im.doc.xmlExample(media["schema"], value)
+		}
+		req.BodyFormat = "XML"
+	case base == "application/x-www-form-urlencoded":
+		fields, _ := value.(map[string]any)
+		pairs := make([]string, 0, len(fields))
+		for _, name := range importing.SortedKeys(fields) {
+			pairs = append(pairs, neturl.QueryEscape(name)+"="+neturl.QueryEscape(scalar(fields[name])))
+		}
+		req.Body = strings.Join(pairs, "&")
+		req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: mediaType})
+	case base == "multipart/form-data":
+		fields, _ := value.(map[string]any)
+		form := map[string]string{}
+		properties := object(im.doc.resolve(media["schema"]), "properties")
+		for _, name := range importing.SortedKeys(fields) {
+			if prop := im.doc.resolve(properties[name]); prop != nil && text(prop, "format") == "binary" {
+				im.warn(key, "form field %s is a file, which is not supported, it was skipped", name)
+				continue
+			}
+			form[name] = scalar(fields[name])
+		}
+		encoded, _ := json.Marshal(form)
+		req.Body = string(encoded)
+		req.BodyFormat = "form-data"
+	case strings.HasPrefix(base, "text/"):
+		req.Body = scalar(value)
+		req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: mediaType})
+	default:
+		im.warn(key, "%s bodies cannot be generated, the body was left empty", mediaType)
+		req.Headers = append(req.Headers, models.RequestHeader{Key: "Content-Type", Value: mediaType})
+	}
+
+	if len(content) > 1 {
+		im.warn(key, "the request body was generated for %s, other media types were skipped", mediaType)
+	}
+}
+
+// preferredMediaType picks JSON, then forms, then XML, then text, then whichever media type sorts first.
+func preferredMediaType(content map[string]any) string {
+	keys := importing.SortedKeys(content)
+	for _, match := range []func(string) bool{
+		func(t string) bool { return t == "application/json" },
+		func(t string) bool { return strings.HasSuffix(t, "+json") || strings.HasSuffix(t, "/json") },
+		func(t string) bool { return t == "application/x-www-form-urlencoded" },
+		func(t string) bool { return t == "multipart/form-data" },
+		func(t string) bool { return strings.HasSuffix(t, "/xml") || strings.HasSuffix(t, "+xml") },
+		func(t string) bool { return strings.HasPrefix(t, "text/") },
+	} {
+		for _, key := range keys {
+			base, _, _ := strings.Cut(strings.ToLower(key), ";")
+			if match(strings.TrimSpace(base)) {
+				return key
+			}
+		}
+	}
+	return keys[0]
+}
+
+// accept sets the Accept header to the media types of the operation's success response.
+func (im *importer) accept(op map[string]any, req *models.RequestWithDetail) {
+	responses := object(op, "responses")
+
+	code := "default"
+	for _, c := range importing.SortedKeys(responses) {
+		if strings.HasPrefix(c, "2") {
+			code = c
+			break
+		}
+	}
+
+	content := object(im.doc.resolve(responses[code]), "content")
+	if len(content) == 0 {
+		return
+	}
+
+	req.Headers = append(req.Headers, models.RequestHeader{Key: "Accept", Value: strings.Join(importing.SortedKeys(content), ", ")})
+}
+
+// security adds the credentials of the operation's first security requirement, or of the document's,
+// as headers, query parameters or cookies that refer to collection variables.
+func (im *importer) security(key string, op map[string]any, req *models.RequestWithDetail) {
+	requirements, ok := op["security"].([]any)
+	if !ok {
+		requirements = list(im.doc.root, "security")
+	}
+	if len(requirements) == 0 {
+		return
+	}
+
+	requirement, _ := requirements[0].(map[string]any)
+	schemes := object(object(im.doc.root, "components"), "securitySchemes")
+
+	for _, name := range importing.SortedKeys(requirement) {
+		scheme := im.doc.resolve(schemes[name])
+		if scheme == nil {
+			im.warn(key, "security scheme %s is not defined", name)
+			continue
+		}
+
+		variable := variableName(name)
+		reference := "{{" + variable + "}}"
+
+		switch text(scheme, "type") {
+		case "apiKey":
+			im.variable(variable, "")
+			switch text(scheme, "in") {
+			case "query":
+				req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: text(scheme, "name"), Value: reference})
+			case "cookie":
+				req.Cookies = append(req.Cookies, models.RequestCookie{Key: text(scheme, "name"), Value: reference})
+			default:
+				req.Headers = append(req.Headers, models.RequestHeader{Key: text(scheme, "name"), Value: reference})
+			}
+		case "http":
+			im.variable(variable, "")
+			switch strings.ToLower(text(scheme, "scheme")) {
+			case "bearer":
+				req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: "Bearer " + reference})
+			case "basic":
+				im.warnOnce("security scheme %s: set {{%s}} to the base64 encoding of user:password", name, variable)
+				req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: "Basic " + reference})
+			default:
+				im.warnOnce("security scheme %s: HTTP %s auth is sent as the Authorization header {{%s}}", name, text(scheme, "scheme"), variable)
+				req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: reference})
+			}
+		case "oauth2", "openIdConnect":
+			im.variable(variable, "")
+			im.warnOnce("security scheme %s: TAPA does not fetch %s tokens, set {{%s}} to an access token", name, text(scheme, "type"), variable)
+			req.Headers = append(req.Headers, models.RequestHeader{Key: "Authorization", Value: "Bearer " + reference})
+		default:
+			im.warnOnce("security scheme %s: %s is not supported", name, text(scheme, "type"))
+		}
+	}
+}
+
+// variableName turns a parameter or scheme name into a variable name, e.g. "api key" into "api_key".
+func variableName(name string) string {
+	return strings.Trim(variableSyntax.ReplaceAllString(name, "_"), "_")
+}
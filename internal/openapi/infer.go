@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// typeOrder is the order the types of a schema that allows several are listed in.
+var typeOrder = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// shape accumulates the JSON values seen in one place of a document, e.g. the response bodies of an endpoint,
+// and infers the schema they all satisfy.
+type shape struct {
+	samples    int
+	types      map[string]int
+	formats    map[string]int // the formats strings looked like, "" for plain strings
+	objects    int
+	properties map[string]*shape
+	items      *shape
+}
+
+func newShape() *shape {
+	return &shape{types: map[string]int{}, formats: map[string]int{}, properties: map[string]*shape{}}
+}
+
+// add records a value decoded by decodeJSON.
+func (s *shape) add(v any) {
+	s.samples++
+
+	switch t := v.(type) {
+	case nil:
+		s.types["null"]++
+	case bool:
+		s.types["boolean"]++
+	case int64:
+		s.types["integer"]++
+	case float64:
+		s.types["number"]++
+	case string:
+		s.types["string"]++
+		s.formats[stringFormat(t)]++
+	case []any:
+		s.types["array"]++
+		if s.items == nil {
+			s.items = newShape()
+		}
+		for _, item := range t {
+			s.items.add(item)
+		}
+	case map[string]any:
+		s.types["object"]++
+		s.objects++
+		for key, value := range t {
+			if s.properties[key] == nil {
+				s.properties[key] = newShape()
+			}
+			s.properties[key].add(value)
+		}
+	}
+}
+
+// schema returns the inferred schema. Properties present in every object seen are required, and a format is given
+// when every string seen had it.
+func (s *shape) schema() map[string]any {
+	out := map[string]any{}
+	if s == nil || s.samples == 0 {
+		return out
+	}
+
+	// Whole numbers seen next to fractional ones make a number, not both.
+	types := []any{}
+	for _, t := range typeOrder {
+		if s.types[t] > 0 && !(t == "integer" && s.types["number"] > 0) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 1 {
+		out["type"] = types[0]
+	} else {
+		out["type"] = types
+	}
+
+	if s.types["string"] > 0 && len(s.formats) == 1 {
+		for format := range s.formats {
+			if format != "" {
+				out["format"] = format
+			}
+		}
+	}
+
+	if s.objects > 0 && len(s.properties) > 0 {
+		properties := map[string]any{}
+		required := []string{}
+		for name, property := range s.properties {
+			properties[name] = property.schema()
+			if property.samples == s.objects {
+				required = append(required, name)
+			}
+		}
+		sort.Strings(required)
+
+		out["properties"] = properties
+		if len(required) > 0 {
+			out["required"] = required
+		}
+	}
+
+	if s.items != nil && s.items.samples > 0 {
+		out["items"] = s.items.schema()
+	}
+
+	return out
+}
+
+// stringFormat returns the format a string value looks like, or "".
+func stringFormat(s string) string {
+	if _, err := time.Parse(time.RFC3339, s); err == nil {
+		return "date-time"
+	}
+	if _, err := time.Parse(time.DateOnly, s); err == nil {
+		return "date"
+	}
+
+	switch {
+	case uuidPattern.MatchString(s):
+		return "uuid"
+	case emailPattern.MatchString(s):
+		return "email"
+	case strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://"):
+		return "uri"
+	}
+	return ""
+}
+
+// decodeJSON decodes a JSON document keeping whole numbers as int64, so integers can be told from numbers.
+func decodeJSON(data string) (any, bool) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return nil, false
+	}
+	return numbers(v), true
+}
+
+func numbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case []any:
+		for i := range t {
+			t[i] = numbers(t[i])
+		}
+	case map[string]any:
+		for key := range t {
+			t[key] = numbers(t[key])
+		}
+	}
+	return v
+}
+
+// literal turns a parameter value as it is sent, e.g. a query value, into the value it most likely stands for.
+func literal(s string) any {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.ContainsAny(s, ".eE") && !strings.ContainsAny(s, "nN") {
+		return f
+	}
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	return s
+}
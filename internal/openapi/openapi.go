@@ -0,0 +1,115 @@
+// Package openapi generates TAPA collections from OpenAPI documents. Swagger 2.0 documents are upgraded to the
+// OpenAPI 3 shape first.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/schemas"
+)
+
+const SOURCE string = "openapi"
+
+// MAX_REF_DEPTH bounds how many references are followed in a row, so reference loops end.
+const MAX_REF_DEPTH int = 32
+
+// Detect reports whether data is an OpenAPI 3.x or Swagger 2.0 document, in JSON or YAML.
+func Detect(data []byte) bool {
+	doc, err := schemas.DecodeDocument(data)
+	if err != nil {
+		return false
+	}
+
+	version, _ := doc["openapi"].(string)
+	return strings.HasPrefix(version, "3.") || isSwagger(doc)
+}
+
+// isSwagger reports whether a decoded document is Swagger 2.0, whose version YAML may read as a number.
+func isSwagger(doc map[string]any) bool {
+	return scalar(doc["swagger"]) == "2.0" || doc["swagger"] == float64(2)
+}
+
+// document is a decoded OpenAPI document. Its values are plain maps, slices and scalars.
+type document struct {
+	root map[string]any
+}
+
+// resolve follows local references, e.g. {"$ref": "#/components/parameters/Limit"}, and returns the object
+// they lead to. It returns nil for values that are not objects and for references it cannot follow.
+func (d *document) resolve(v any) map[string]any {
+	for range MAX_REF_DEPTH {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+
+		v = d.pointer(ref)
+	}
+
+	return nil
+}
+
+// pointer returns the value a local reference points at, or nil.
+func (d *document) pointer(ref string) any {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+
+	var current any = d.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = obj[token]
+	}
+
+	return current
+}
+
+// object returns the object under key, or nil.
+func object(v map[string]any, key string) map[string]any {
+	obj, _ := v[key].(map[string]any)
+	return obj
+}
+
+// list returns the list under key, or nil.
+func list(v map[string]any, key string) []any {
+	l, _ := v[key].([]any)
+	return l
+}
+
+// text returns the string under key, or "".
+func text(v map[string]any, key string) string {
+	s, _ := v[key].(string)
+	return s
+}
+
+// scalar renders a parameter value as it is sent: strings as they are, everything else as JSON.
+func scalar(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(encoded)
+}
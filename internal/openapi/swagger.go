@@ -0,0 +1,284 @@
+package openapi
+
+import (
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+)
+
+// upgradeSwagger rewrites a Swagger 2.0 document in place into the OpenAPI 3 shape the importer reads:
+// schemes, host and basePath become a server with those variables, body and form parameters become request
+// bodies for every media type the operation consumes, and produces becomes the media types of the responses.
+// Definitions stay where they are, so references to them still resolve.
+func (im *importer) upgradeSwagger() {
+	root := im.doc.root
+
+	im.swaggerServers()
+
+	if definitions := object(root, "securityDefinitions"); definitions != nil {
+		schemes := map[string]any{}
+		for name, raw := range definitions {
+			scheme, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			if text(scheme, "type") == "basic" {
+				scheme = map[string]any{"type": "http", "scheme": "basic"}
+			}
+			schemes[name] = scheme
+		}
+
+		components := object(root, "components")
+		if components == nil {
+			components = map[string]any{}
+			root["components"] = components
+		}
+		components["securitySchemes"] = schemes
+	}
+
+	consumes := strings_(list(root, "consumes"))
+	produces := strings_(list(root, "produces"))
+
+	paths := object(root, "paths")
+	for _, path := range importing.SortedKeys(paths) {
+		item := im.doc.resolve(paths[path])
+		if item == nil {
+			continue
+		}
+
+		for _, method := range methods {
+			op := object(item, method)
+			if op == nil {
+				continue
+			}
+
+			key := strings.ToUpper(method) + " " + path
+			opConsumes, opProduces := consumes, produces
+			if c, ok := op["consumes"].([]any); ok {
+				opConsumes = strings_(c)
+			}
+			if p, ok := op["produces"].([]any); ok {
+				opProduces = strings_(p)
+			}
+
+			im.swaggerOperation(key, item, op, opConsumes)
+			swaggerResponses(im.doc, op, opProduces)
+
+			delete(op, "consumes")
+			delete(op, "produces")
+		}
+
+		// Path item parameters were merged into every operation.
+		delete(item, "parameters")
+	}
+}
+
+// swaggerServers turns host and basePath into a server for every scheme, whose variables hold them.
+func (im *importer) swaggerServers() {
+	root := im.doc.root
+
+	basePath := text(root, "basePath")
+	if basePath == "" {
+		basePath = "/"
+	}
+
+	host := text(root, "host")
+	if host == "" {
+		root["servers"] = []any{map[string]any{"url": basePath}}
+		return
+	}
+
+	schemes := list(root, "schemes")
+	if len(schemes) == 0 {
+		schemes = []any{"https"}
+	}
+
+	servers := make([]any, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, map[string]any{
+			"url": "{scheme}://{host}{basePath}",
+			"variables": map[string]any{
+				"scheme":   map[string]any{"default": scheme},
+				"host":     map[string]any{"default": host},
+				"basePath": map[string]any{"default": strings.TrimRight(basePath, "/")},
+			},
+		})
+	}
+	root["servers"] = servers
+}
+
+// swaggerOperation converts the parameters of an operation, its path item's included, and builds its request body
+// from body or form parameters.
+func (im *importer) swaggerOperation(key string, item, op map[string]any, consumes []string) {
+	var (
+		parameters []any
+		body       map[string]any
+		form       = map[string]any{}
+		required   []any
+		files      bool
+	)
+
+	// The importer would merge the path item's parameters, but those may be body or form parameters too.
+	merged := map[string]int{}
+	var all []map[string]any
+	for _, source := range [][]any{list(item, "parameters"), list(op, "parameters")} {
+		for _, raw := range source {
+			p := im.doc.resolve(raw)
+			if p == nil {
+				im.warn(key, "a parameter reference could not be resolved")
+				continue
+			}
+
+			id := text(p, "in") + ":" + text(p, "name")
+			if i, ok := merged[id]; ok {
+				all[i] = p
+				continue
+			}
+			merged[id] = len(all)
+			all = append(all, p)
+		}
+	}
+
+	for _, p := range all {
+		switch text(p, "in") {
+		case "body":
+			media := map[string]any{"schema": p["schema"]}
+			if example, ok := p["x-example"]; ok {
+				media["example"] = example
+			}
+			body = map[string]any{"content": contentFor(consumes, "application/json", media), "required": p["required"] == true}
+		case "formData":
+			schema := swaggerSchema(p)
+			if text(p, "type") == "file" {
+				files = true
+			}
+			form[text(p, "name")] = schema
+			if p["required"] == true {
+				required = append(required, text(p, "name"))
+			}
+		default:
+			parameters = append(parameters, swaggerParameter(p))
+		}
+	}
+
+	if len(form) > 0 {
+		mediaType := "application/x-www-form-urlencoded"
+		for _, c := range consumes {
+			if strings.HasPrefix(c, "multipart/form-data") {
+				mediaType = "multipart/form-data"
+			}
+		}
+		if files {
+			mediaType = "multipart/form-data"
+		}
+
+		schema := map[string]any{"type": "object", "properties": form}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		body = map[string]any{"content": map[string]any{mediaType: map[string]any{"schema": schema}}}
+	}
+
+	op["parameters"] = parameters
+	if body != nil {
+		op["requestBody"] = body
+	}
+}
+
+// swaggerResponses moves the schema and examples of every response under the media types the operation produces.
+func swaggerResponses(doc *document, op map[string]any, produces []string) {
+	responses := object(op, "responses")
+	for code, raw := range responses {
+		response := doc.resolve(raw)
+		if response == nil {
+			continue
+		}
+
+		converted := map[string]any{}
+		for key, value := range response {
+			if key != "schema" && key != "examples" {
+				converted[key] = value
+			}
+		}
+
+		if schema, ok := response["schema"]; ok {
+			examples := object(response, "examples")
+			content := map[string]any{}
+			types := produces
+			if len(types) == 0 {
+				types = []string{"application/json"}
+			}
+			for _, t := range types {
+				media := map[string]any{"schema": schema}
+				if example, ok := examples[t]; ok {
+					media["example"] = example
+				}
+				content[t] = media
+			}
+			converted["content"] = content
+		}
+
+		responses[code] = converted
+	}
+}
+
+// swaggerParameter converts a query, header or path parameter, whose schema keywords sit on the parameter itself.
+func swaggerParameter(p map[string]any) map[string]any {
+	out := map[string]any{"name": p["name"], "in": p["in"], "schema": swaggerSchema(p)}
+	for _, key := range []string{"required", "description", "deprecated"} {
+		if value, ok := p[key]; ok {
+			out[key] = value
+		}
+	}
+
+	if example, ok := p["x-example"]; ok {
+		out["example"] = example
+	}
+
+	// csv, the default, sends arrays as one comma separated value; multi repeats the parameter.
+	if text(p, "type") == "array" {
+		out["explode"] = text(p, "collectionFormat") == "multi"
+	}
+
+	return out
+}
+
+// swaggerSchema collects the schema keywords of a parameter. File parameters become binary strings.
+func swaggerSchema(p map[string]any) map[string]any {
+	if text(p, "type") == "file" {
+		return map[string]any{"type": "string", "format": "binary"}
+	}
+
+	schema := map[string]any{}
+	for key, value := range p {
+		switch key {
+		case "name", "in", "required", "description", "collectionFormat", "allowEmptyValue", "x-example":
+		default:
+			schema[key] = value
+		}
+	}
+	return schema
+}
+
+// contentFor lists the same media type object under every media type, or under fallback when there are none.
+func contentFor(types []string, fallback string, media map[string]any) map[string]any {
+	if len(types) == 0 {
+		types = []string{fallback}
+	}
+
+	content := map[string]any{}
+	for _, t := range types {
+		content[t] = media
+	}
+	return content
+}
+
+func strings_(values []any) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,346 @@
+package postman
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// secretKey matches the names of variables that usually hold credentials.
+var secretKey = regexp.MustCompile(`(?i)(secret|passw(or)?d|token|api[_-]?key|auth|credential|private|session|cookie)`)
+
+// IsSecret reports whether a variable name looks like it holds a credential, e.g. "API_KEY" or "refreshToken".
+func IsSecret(key string) bool {
+	return secretKey.MatchString(key)
+}
+
+// Export writes a collection export as a Postman v2.1 collection. Folders named after a path, e.g. "Users / Admin",
+// are nested again. Assertions, skip conditions, script modules, schemas and data files have no Postman
+// equivalent and are left out.
+func Export(export models.CollectionExport) ([]byte, error) {
+	c := collection{
+		Info: info{
+			PostmanID:   newID(),
+			Name:        export.Collection.Name,
+			Description: description(export.Collection.Description),
+			Schema:      SCHEMA_V21,
+		},
+		Item: []item{},
+	}
+
+	for _, v := range export.Variables {
+		c.Variable = append(c.Variable, variable{Key: v.Key, Value: v.Value, Type: "string"})
+	}
+
+	for _, f := range export.Folders {
+		folder := nestedFolder(&c.Item, strings.Split(f.Folder.Name, FOLDER_SEPARATOR))
+		for _, r := range f.Requests {
+			folder.Item = append(folder.Item, exportRequest(r))
+		}
+	}
+
+	for _, r := range export.Requests {
+		c.Item = append(c.Item, exportRequest(r))
+	}
+
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionExport, err)
+	}
+
+	return data, nil
+}
+
+// nestedFolder returns the folder at path within items, creating the folders that are missing.
+func nestedFolder(items *[]item, path []string) *item {
+	name := strings.TrimSpace(path[0])
+
+	var folder *item
+	for i := range *items {
+		if (*items)[i].Request == nil && (*items)[i].Name == name {
+			folder = &(*items)[i]
+			break
+		}
+	}
+
+	if folder == nil {
+		*items = append(*items, item{Name: name, Item: []item{}})
+		folder = &(*items)[len(*items)-1]
+	}
+
+	if len(path) == 1 {
+		return folder
+	}
+	return nestedFolder(&folder.Item, path[1:])
+}
+
+func exportRequest(r models.RequestExport) item {
+	headers := []keyValue{}
+	for _, h := range r.Headers {
+		headers = append(headers, keyValue{Key: h.Key, Value: h.Value})
+	}
+
+	// Postman has no separate cookie list; cookies are sent as a Cookie header.
+	if len(r.Cookies) > 0 && !importing.HasHeader(r.Headers, "Cookie") {
+		pairs := make([]string, len(r.Cookies))
+		for i, c := range r.Cookies {
+			pairs[i] = c.Key + "=" + c.Value
+		}
+		headers = append(headers, keyValue{Key: "Cookie", Value: strings.Join(pairs, "; ")})
+	}
+
+	query := make([]keyValue, len(r.QueryParams))
+	for i, q := range r.QueryParams {
+		query[i] = keyValue{Key: q.Key, Value: q.Value}
+	}
+
+	it := item{
+		Name: r.Name,
+		Request: &request{
+			Method:      r.Method,
+			Header:      headers,
+			Body:        exportBody(r.Body, r.BodyFormat),
+			URL:         exportURL(r.URL, query),
+			Description: description(r.Notes),
+		},
+		Response: []response{},
+	}
+
+	for _, phase := range []struct{ tapa, postman string }{
+		{models.ScriptPhasePreRequest, "prerequest"},
+		{models.ScriptPhasePostResponse, "test"},
+	} {
+		var source []string
+		for _, s := range r.Scripts {
+			if s.Phase == phase.tapa {
+				source = append(source, strings.Split(s.Script, "\n")...)
+			}
+		}
+
+		if len(source) > 0 {
+			it.Event = append(it.Event, event{Listen: phase.postman, Script: script{Type: "text/javascript", Exec: source}})
+		}
+	}
+
+	behavior := map[string]any{}
+	if !r.AllowRedirects {
+		behavior["followRedirects"] = false
+	}
+	if !r.SSLVerification {
+		behavior["strictSSL"] = false
+	}
+	if !r.EncodeURL {
+		behavior["disableUrlEncoding"] = true
+	}
+	if len(behavior) > 0 {
+		it.ProtocolProfileBehavior = behavior
+	}
+
+	for i, e := range r.Examples {
+		it.Response = append(it.Response, exportExample(r, e, i))
+	}
+
+	return it
+}
+
+// exportURL splits a URL into the parts Postman stores next to the raw form, keeping {{variables}} as they are.
+func exportURL(raw string, query []keyValue) url {
+	u := url{Raw: raw, Query: query}
+
+	rest := raw
+	if scheme, after, ok := strings.Cut(rest, "://"); ok && !strings.Contains(scheme, "{{") {
+		u.Protocol, rest = scheme, after
+	}
+
+	host, path, _ := strings.Cut(rest, "/")
+	if host != "" {
+		u.Host = strings.Split(host, ".")
+		if strings.Contains(host, "{{") {
+			u.Host = []string{host}
+		}
+	}
+	if path != "" {
+		u.Path = strings.Split(path, "/")
+	}
+
+	if len(query) > 0 {
+		pairs := make([]string, len(query))
+		for i, q := range query {
+			pairs[i] = q.Key + "=" + q.Value
+		}
+		u.Raw += "?" + strings.Join(pairs, "&")
+	}
+
+	return u
+}
+
+func exportBody(content, format string) *body {
+	if content == "" {
+		return nil
+	}
+
+	switch format {
+	case "JSON", "XML":
+		language := strings.ToLower(format)
+		return &body{Mode: "raw", Raw: content, Options: map[string]any{"raw": map[string]any{"language": language}}}
+	case "form-data":
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(content), &fields); err != nil {
+			return &body{Mode: "raw", Raw: content}
+		}
+
+		b := &body{Mode: "formdata", FormData: []keyValue{}}
+		for _, key := range importing.SortedKeys(fields) {
+			b.FormData = append(b.FormData, keyValue{Key: key, Value: stringify(fields[key]), Type: "text"})
+		}
+		return b
+	}
+
+	return &body{Mode: "raw", Raw: content}
+}
+
+// exportExample writes an example as a saved response whose original request is the one the example recorded.
+func exportExample(r models.RequestExport, e models.RequestExample, i int) response {
+	var headers map[string]string
+	_ = json.Unmarshal([]byte(e.Headers), &headers)
+
+	sentHeaders := []keyValue{}
+	for _, key := range importing.SortedKeys(headers) {
+		sentHeaders = append(sentHeaders, keyValue{Key: key, Value: headers[key]})
+	}
+
+	base, rawQuery, _ := strings.Cut(e.URL, "?")
+	query := []keyValue{}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key != "" {
+			query = append(query, keyValue{Key: importing.Unescape(key), Value: importing.Unescape(value)})
+		}
+	}
+
+	method := e.Method
+	if method == "" {
+		method = r.Method
+	}
+
+	var responseHeaders map[string][]string
+	_ = json.Unmarshal([]byte(e.ResponseHeaders), &responseHeaders)
+
+	resp := response{
+		Name: fmt.Sprintf("%s %d", r.Name, e.StatusCode),
+		OriginalRequest: &request{
+			Method: method,
+			Header: sentHeaders,
+			Body:   exportBody(e.Body, r.BodyFormat),
+			URL:    exportURL(base, query),
+		},
+		Status:       http.StatusText(e.StatusCode),
+		Code:         e.StatusCode,
+		Header:       []keyValue{},
+		Body:         e.Response,
+		ResponseTime: e.ResponseTime,
+	}
+	if i > 0 {
+		resp.Name = fmt.Sprintf("%s %d (%d)", r.Name, e.StatusCode, i+1)
+	}
+
+	for _, key := range importing.SortedKeys(responseHeaders) {
+		for _, value := range responseHeaders[key] {
+			resp.Header = append(resp.Header, keyValue{Key: key, Value: value})
+			if strings.EqualFold(key, "Content-Type") {
+				resp.PreviewLanguage = previewLanguage(value)
+			}
+		}
+	}
+
+	var cookies map[string]string
+	if json.Unmarshal([]byte(e.ResponseCookies), &cookies) == nil {
+		for _, name := range importing.SortedKeys(cookies) {
+			resp.Cookie = append(resp.Cookie, cookie{Name: name, Value: cookies[name]})
+		}
+	}
+
+	return resp
+}
+
+// environment is a Postman environment file.
+type environment struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Values     []environmentValue `json:"values"`
+	Scope      string             `json:"_postman_variable_scope"`
+	ExportedAt string             `json:"_postman_exported_at"`
+	ExportedBy string             `json:"_postman_exported_using"`
+}
+
+type environmentValue struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"` // "default" or "secret"
+	Enabled bool   `json:"enabled"`
+}
+
+// ExportEnvironment writes an environment in Postman's environment format. Variables whose names look like
+// credentials (see IsSecret) are marked secret, and with redact their values are left empty.
+func ExportEnvironment(env models.Environment, vars []models.EnvironmentVariable, redact bool) ([]byte, error) {
+	e := environment{
+		ID:         newID(),
+		Name:       env.Name,
+		Values:     []environmentValue{},
+		Scope:      "environment",
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		ExportedBy: "TAPA",
+	}
+
+	sorted := append([]models.EnvironmentVariable{}, vars...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	for _, v := range sorted {
+		value := environmentValue{Key: v.Key, Value: v.Value, Type: "default", Enabled: true}
+		if IsSecret(v.Key) {
+			value.Type = "secret"
+			if redact {
+				value.Value = ""
+			}
+		}
+		e.Values = append(e.Values, value)
+	}
+
+	data, err := json.MarshalIndent(e, "", "\t")
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionExport, err)
+	}
+
+	return data, nil
+}
+
+func previewLanguage(contentType string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return "json"
+	case strings.Contains(contentType, "xml"):
+		return "xml"
+	case strings.Contains(contentType, "html"):
+		return "html"
+	}
+	return "text"
+}
+
+// newID returns a random version 4 UUID, the form Postman uses for collection and environment ids.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,635 @@
+package postman
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+const (
+	SOURCE string = "postman"
+
+	// FOLDER_SEPARATOR joins the names of nested Postman folders into the name of one TAPA folder.
+	FOLDER_SEPARATOR string = " / "
+)
+
+var (
+	dynamicVariable = regexp.MustCompile(`\{\{\s*\$([A-Za-z0-9_]+)\s*\}\}`)
+	postmanAPI      = regexp.MustCompile(`\b(pm|postman)\.[A-Za-z]`)
+)
+
+// Detect reports whether data looks like a Postman v2.0 or v2.1 collection.
+func Detect(data []byte) bool {
+	var probe struct {
+		Info *struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+		Item json.RawMessage `json:"item"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil || probe.Info == nil {
+		return false
+	}
+
+	return strings.Contains(probe.Info.Schema, "/collection/v2") || (probe.Info.Schema == "" && probe.Item != nil)
+}
+
+// Import converts a Postman v2.1 (or v2.0) collection into a collection export for
+// CollectionsRepository.ImportCollection. Nested folders become one folder each, named after their path;
+// the auth and scripts of collections and folders are copied into the requests that inherit them.
+// Whatever cannot be mapped is listed in the report's warnings.
+func Import(data []byte) (models.CollectionExport, models.ImportReport, error) {
+	if !Detect(data) {
+		return models.CollectionExport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, fmt.Errorf("not a Postman v2.0 or v2.1 collection"))
+	}
+
+	var c collection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return models.CollectionExport{}, models.ImportReport{}, errors.Wrap(errors.ErrInvalidImport, err)
+	}
+
+	name := strings.TrimSpace(c.Info.Name)
+	if name == "" {
+		name = "Postman collection"
+	}
+
+	im := &importer{
+		export: models.CollectionExport{
+			Version:    models.COLLECTION_EXPORT_VERSION,
+			Collection: models.Collection{Name: name, Description: string(c.Info.Description)},
+			Variables:  []models.CollectionVariable{},
+			Modules:    []models.ScriptModule{},
+			Schemas:    []models.CollectionSchema{},
+			DataFiles:  []models.CollectionDataFile{},
+			Folders:    []models.FolderExport{},
+			Requests:   []models.RequestExport{},
+		},
+		report:  models.ImportReport{Name: name, Source: SOURCE, Warnings: []models.ImportWarning{}},
+		folders: map[string]int{},
+	}
+
+	im.variables(c.Variable)
+
+	root := inheritance{}
+	root = im.inherit(root, "", c.Auth, c.Event)
+	im.walk(c.Item, nil, root)
+
+	im.report.Folders = len(im.export.Folders)
+	return im.export, im.report, nil
+}
+
+type importer struct {
+	export  models.CollectionExport
+	report  models.ImportReport
+	folders map[string]int // folder name to index in export.Folders
+}
+
+// inheritance is what requests take over from the collection and the folders they are in.
+type inheritance struct {
+	auth    *auth
+	pre     []string
+	post    []string
+	authErr bool // the inherited auth could not be mapped, which was reported where it is defined
+}
+
+func (im *importer) warn(where string, format string, args ...any) {
+	im.report.Warnings = append(im.report.Warnings, models.ImportWarning{Item: where, Message: fmt.Sprintf(format, args...)})
+}
+
+func (im *importer) variables(vars []variable) {
+	seen := map[string]int{}
+	for _, v := range vars {
+		if v.Disabled || strings.TrimSpace(v.Key) == "" {
+			continue
+		}
+
+		value := stringify(v.Value)
+		if i, ok := seen[v.Key]; ok {
+			im.warn("", "variable %q is defined twice, the last value is kept", v.Key)
+			im.export.Variables[i].Value = value
+			continue
+		}
+
+		seen[v.Key] = len(im.export.Variables)
+		im.export.Variables = append(im.export.Variables, models.CollectionVariable{Key: v.Key, Value: value})
+	}
+
+	im.report.Variables = len(im.export.Variables)
+}
+
+// inherit adds the auth and scripts of a collection or folder to what its requests inherit.
+func (im *importer) inherit(parent inheritance, where string, a *auth, events []event) inheritance {
+	next := inheritance{auth: parent.auth, authErr: parent.authErr}
+	next.pre = append([]string{}, parent.pre...)
+	next.post = append([]string{}, parent.post...)
+
+	if a != nil && a.Type != "inherit" {
+		next.auth = a
+		next.authErr = im.checkAuth(where, a)
+	}
+
+	pre, post := im.scripts(where, events)
+	next.pre = append(next.pre, pre...)
+	next.post = append(next.post, post...)
+
+	return next
+}
+
+func (im *importer) walk(items []item, path []string, inherited inheritance) {
+	for _, it := range items {
+		if !it.isFolder() {
+			im.request(it, path, inherited)
+			continue
+		}
+
+		folderPath := append(append([]string{}, path...), folderName(it.Name))
+		where := strings.Join(folderPath, FOLDER_SEPARATOR)
+
+		if it.Description != "" {
+			im.warn(where, "folder descriptions are not imported")
+		}
+		if len(it.Variable) > 0 {
+			im.warn(where, "folder variables are not supported, %d dropped", len(it.Variable))
+		}
+
+		if len(it.Item) == 0 {
+			im.folder(where)
+		}
+
+		im.walk(it.Item, folderPath, im.inherit(inherited, where, it.Auth, it.Event))
+	}
+}
+
+// folder returns the index of the folder named name in the export, adding it the first time.
+func (im *importer) folder(name string) int {
+	if i, ok := im.folders[name]; ok {
+		return i
+	}
+
+	im.folders[name] = len(im.export.Folders)
+	im.export.Folders = append(im.export.Folders, models.FolderExport{
+		Folder:   models.Folder{Name: name},
+		Requests: []models.RequestExport{},
+	})
+
+	return im.folders[name]
+}
+
+func (im *importer) request(it item, path []string, inherited inheritance) {
+	name := strings.TrimSpace(it.Name)
+	if name == "" {
+		name = "Untitled request"
+	}
+	where := strings.Join(append(append([]string{}, path...), name), FOLDER_SEPARATOR)
+
+	r := it.Request
+	if r == nil {
+		r = &request{}
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(r.Method))
+	if method == "" {
+		method = "GET"
+	}
+	if !importing.IsMethod(method) {
+		im.warn(where, "method %s is not supported, the request was skipped", method)
+		return
+	}
+
+	req := models.RequestExport{Examples: []models.RequestExample{}}
+	req.Name = name
+	req.Method = method
+	req.BodyFormat = "raw"
+	req.Timeout = importing.DEFAULT_TIMEOUT_MS
+	req.AllowRedirects = true
+	req.SSLVerification = true
+	req.EncodeURL = true
+	req.Notes = string(r.Description)
+	if req.Notes == "" {
+		req.Notes = string(it.Description)
+	}
+	req.Headers = []models.RequestHeader{}
+	req.QueryParams = []models.RequestQueryParam{}
+	req.Cookies = []models.RequestCookie{}
+	req.Scripts = []models.RequestScript{}
+	req.Assertions = []models.RequestAssertion{}
+	req.SkipConditions = []models.SkipCondition{}
+
+	var warnings []string
+	req.URL, req.QueryParams, warnings = convertURL(r.URL)
+	for _, w := range warnings {
+		im.warn(where, "%s", w)
+	}
+
+	for _, h := range r.Header {
+		if h.Disabled || strings.TrimSpace(h.Key) == "" {
+			continue
+		}
+		if setHeader(&req.RequestWithDetail, h.Key, h.Value) {
+			im.warn(where, "header %s is set twice, the last value is kept", h.Key)
+		}
+	}
+
+	a, authErr := inherited.auth, inherited.authErr
+	if r.Auth != nil && r.Auth.Type != "inherit" {
+		a, authErr = r.Auth, im.checkAuth(where, r.Auth)
+	}
+	if a != nil && !authErr {
+		applyAuth(&req.RequestWithDetail, a)
+	}
+
+	for _, w := range convertBody(r.Body, &req.RequestWithDetail) {
+		im.warn(where, "%s", w)
+	}
+
+	pre, post := im.scripts(where, it.Event)
+	for _, s := range append(inherited.pre, pre...) {
+		req.Scripts = append(req.Scripts, models.RequestScript{Phase: models.ScriptPhasePreRequest, Script: s})
+	}
+	for _, s := range append(inherited.post, post...) {
+		req.Scripts = append(req.Scripts, models.RequestScript{Phase: models.ScriptPhasePostResponse, Script: s})
+	}
+
+	im.settings(where, it.ProtocolProfileBehavior, &req.RequestWithDetail)
+
+	for _, resp := range it.Response {
+		req.Examples = append(req.Examples, example(resp, r))
+	}
+
+	if names := dynamicVariables(req.RequestWithDetail); len(names) > 0 {
+		im.warn(where, "Postman dynamic variables are not supported: %s", strings.Join(names, ", "))
+	}
+
+	im.report.Requests++
+	im.report.Examples += len(req.Examples)
+	im.report.Scripts += len(req.Scripts)
+
+	if len(path) == 0 {
+		im.export.Requests = append(im.export.Requests, req)
+		return
+	}
+
+	i := im.folder(strings.Join(path, FOLDER_SEPARATOR))
+	im.export.Folders[i].Requests = append(im.export.Folders[i].Requests, req)
+}
+
+// scripts returns the pre-request and test scripts of an item's events.
+func (im *importer) scripts(where string, events []event) ([]string, []string) {
+	var pre, post []string
+	for _, e := range events {
+		source := strings.TrimSpace(strings.Join(e.Script.Exec, "\n"))
+		if e.Disabled || source == "" {
+			continue
+		}
+
+		switch e.Listen {
+		case "prerequest":
+			pre = append(pre, source)
+		case "test":
+			post = append(post, source)
+		default:
+			im.warn(where, "%s scripts are not supported", e.Listen)
+			continue
+		}
+
+		if postmanAPI.MatchString(source) {
+			im.warn(where, "the %s script uses the Postman pm API, rewrite it with tapa.* before running it", e.Listen)
+		}
+	}
+
+	return pre, post
+}
+
+// checkAuth reports auth that cannot be mapped where it is defined and returns whether it could not.
+func (im *importer) checkAuth(where string, a *auth) bool {
+	switch a.Type {
+	case "noauth", "bearer", "apikey":
+		return false
+	case "basic":
+		if strings.Contains(a.Params["username"]+a.Params["password"], "{{") {
+			im.warn(where, "basic auth with variables cannot be encoded ahead of time, add the Authorization header by hand")
+			return true
+		}
+		return false
+	}
+
+	im.warn(where, "%s auth is not supported, add the credentials by hand", a.Type)
+	return true
+}
+
+// applyAuth turns supported auth into the header or query parameter it sends, unless the request sets it itself.
+func applyAuth(req *models.RequestWithDetail, a *auth) {
+	switch a.Type {
+	case "bearer":
+		if !importing.HasHeader(req.Headers, "Authorization") {
+			setHeader(req, "Authorization", "Bearer "+a.Params["token"])
+		}
+	case "basic":
+		if !importing.HasHeader(req.Headers, "Authorization") {
+			credentials := base64.StdEncoding.EncodeToString([]byte(a.Params["username"] + ":" + a.Params["password"]))
+			setHeader(req, "Authorization", "Basic "+credentials)
+		}
+	case "apikey":
+		key := a.Params["key"]
+		if key == "" {
+			return
+		}
+
+		if a.Params["in"] == "query" {
+			req.QueryParams = append(req.QueryParams, models.RequestQueryParam{Key: key, Value: a.Params["value"]})
+		} else if !importing.HasHeader(req.Headers, key) {
+			setHeader(req, key, a.Params["value"])
+		}
+	}
+}
+
+// settings maps the request settings TAPA has, and reports the others.
+func (im *importer) settings(where string, behavior map[string]any, req *models.RequestWithDetail) {
+	ignored := []string{}
+	for _, key := range importing.SortedKeys(behavior) {
+		value, isBool := behavior[key].(bool)
+
+		switch {
+		case key == "followRedirects" && isBool:
+			req.AllowRedirects = value
+		case key == "strictSSL" && isBool:
+			req.SSLVerification = value
+		case key == "disableUrlEncoding" && isBool:
+			req.EncodeURL = !value
+		case key == "disableBodyPruning":
+			// TAPA always sends the body it is given.
+		default:
+			ignored = append(ignored, key)
+		}
+	}
+
+	if len(ignored) > 0 {
+		im.warn(where, "request settings not supported: %s", strings.Join(ignored, ", "))
+	}
+}
+
+// convertURL splits a Postman URL into the URL and query parameters of a request and fills in path variables.
+func convertURL(u url) (string, []models.RequestQueryParam, []string) {
+	raw := strings.TrimSpace(u.Raw)
+	if raw == "" && len(u.Host) > 0 {
+		raw = strings.Join(u.Host, ".")
+		if u.Protocol != "" {
+			raw = u.Protocol + "://" + raw
+		}
+		if len(u.Path) > 0 {
+			raw += "/" + strings.Join(u.Path, "/")
+		}
+	}
+
+	raw, _, _ = strings.Cut(raw, "#")
+	base, rawQuery, hasQuery := strings.Cut(raw, "?")
+
+	params := []models.RequestQueryParam{}
+	if u.Query != nil {
+		for _, q := range u.Query {
+			if !q.Disabled && q.Key != "" {
+				params = append(params, models.RequestQueryParam{Key: importing.Unescape(q.Key), Value: importing.Unescape(q.Value)})
+			}
+		}
+	} else if hasQuery {
+		for _, pair := range strings.Split(rawQuery, "&") {
+			key, value, _ := strings.Cut(pair, "=")
+			if key != "" {
+				params = append(params, models.RequestQueryParam{Key: importing.Unescape(key), Value: importing.Unescape(value)})
+			}
+		}
+	}
+
+	values := map[string]string{}
+	for _, v := range u.Variable {
+		values[v.Key] = v.Value
+	}
+
+	var warnings []string
+	parts := strings.Split(base, "/")
+	for i, part := range parts {
+		if len(part) < 2 || part[0] != ':' {
+			continue
+		}
+
+		key := part[1:]
+		if value := values[key]; value != "" {
+			parts[i] = value
+			continue
+		}
+
+		parts[i] = "{{" + key + "}}"
+		warnings = append(warnings, fmt.Sprintf("path variable :%s has no value, it was replaced by the variable {{%s}}", key, key))
+	}
+	base = strings.Join(parts, "/")
+
+	return base, params, warnings
+}
+
+// convertBody sets the body of req and returns what could not be mapped.
+func convertBody(b *body, req *models.RequestWithDetail) []string {
+	if b == nil || b.Disabled {
+		return nil
+	}
+
+	var warnings []string
+	switch b.Mode {
+	case "", "none":
+	case "raw":
+		req.Body = b.Raw
+		switch b.language() {
+		case "json":
+			req.BodyFormat = "JSON"
+		case "xml":
+			req.BodyFormat = "XML"
+		}
+	case "urlencoded":
+		pairs := []string{}
+		for _, f := range b.URLEncoded {
+			if !f.Disabled && f.Key != "" {
+				pairs = append(pairs, importing.FormEscape(f.Key)+"="+importing.FormEscape(f.Value))
+			}
+		}
+		req.Body = strings.Join(pairs, "&")
+		if !importing.HasHeader(req.Headers, "Content-Type") {
+			setHeader(req, "Content-Type", "application/x-www-form-urlencoded")
+		}
+	case "formdata":
+		fields := map[string]string{}
+		for _, f := range b.FormData {
+			if f.Disabled || f.Key == "" {
+				continue
+			}
+			if f.Type == "file" {
+				warnings = append(warnings, fmt.Sprintf("form field %s is a file, which is not supported, it was skipped", f.Key))
+				continue
+			}
+			if _, ok := fields[f.Key]; ok {
+				warnings = append(warnings, fmt.Sprintf("form field %s is set twice, the last value is kept", f.Key))
+			}
+			fields[f.Key] = f.Value
+		}
+
+		encoded, _ := json.Marshal(fields)
+		req.Body = string(encoded)
+		req.BodyFormat = "form-data"
+	case "graphql":
+		if b.GraphQL == nil {
+			break
+		}
+
+		payload := map[string]any{"query": b.GraphQL.Query}
+		if vars := strings.TrimSpace(b.GraphQL.Variables); vars != "" {
+			var parsed any
+			if err := json.Unmarshal([]byte(vars), &parsed); err == nil {
+				payload["variables"] = parsed
+			} else {
+				warnings = append(warnings, "the GraphQL variables are not valid JSON and were dropped")
+			}
+		}
+
+		encoded, _ := json.Marshal(payload)
+		req.Body = string(encoded)
+		req.BodyFormat = "JSON"
+	case "file":
+		warnings = append(warnings, "file bodies are not supported, the body was dropped")
+	default:
+		warnings = append(warnings, fmt.Sprintf("%s bodies are not supported, the body was dropped", b.Mode))
+	}
+
+	return warnings
+}
+
+// example turns a saved response into a request example, sent as its original request or, without one, as the request.
+func example(resp response, r *request) models.RequestExample {
+	sent := r
+	if resp.OriginalRequest != nil {
+		sent = resp.OriginalRequest
+	}
+
+	detail := models.RequestWithDetail{Headers: []models.RequestHeader{}}
+	detail.Method = strings.ToUpper(sent.Method)
+	if detail.Method == "" {
+		detail.Method = r.Method
+	}
+	detail.URL, detail.QueryParams, _ = convertURL(sent.URL)
+	for _, h := range sent.Header {
+		if !h.Disabled {
+			setHeader(&detail, h.Key, h.Value)
+		}
+	}
+	convertBody(sent.Body, &detail)
+
+	headers := map[string]string{}
+	for _, h := range detail.Headers {
+		headers[h.Key] = h.Value
+	}
+
+	query := map[string]string{}
+	url := detail.URL
+	for i, p := range detail.QueryParams {
+		query[p.Key] = p.Value
+		separator := "&"
+		if i == 0 {
+			separator = "?"
+		}
+		url += separator + neturl.QueryEscape(p.Key) + "=" + neturl.QueryEscape(p.Value)
+	}
+
+	responseHeaders := map[string][]string{}
+	for _, h := range resp.Header {
+		if !h.Disabled {
+			responseHeaders[h.Key] = append(responseHeaders[h.Key], h.Value)
+		}
+	}
+
+	e := models.RequestExample{
+		Timestamp:       time.Now(),
+		Method:          detail.Method,
+		URL:             url,
+		Headers:         importing.ToJSON(headers),
+		QueryParams:     importing.ToJSON(query),
+		Body:            detail.Body,
+		StatusCode:      resp.Code,
+		Response:        resp.Body,
+		ResponseHeaders: importing.ToJSON(responseHeaders),
+		ResponseTime:    responseTime(resp.ResponseTime),
+		DataVolume:      len(resp.Body),
+	}
+
+	if len(resp.Cookie) > 0 {
+		cookies := map[string]string{}
+		for _, c := range resp.Cookie {
+			cookies[c.Name] = c.Value
+		}
+		e.ResponseCookies = importing.ToJSON(cookies)
+	}
+
+	return e
+}
+
+// setHeader sets a header, replacing one of the same name, and reports whether it replaced one.
+func setHeader(req *models.RequestWithDetail, key, value string) bool {
+	for i, h := range req.Headers {
+		if strings.EqualFold(h.Key, key) {
+			req.Headers[i].Value = value
+			return true
+		}
+	}
+
+	req.Headers = append(req.Headers, models.RequestHeader{Key: key, Value: value})
+	return false
+}
+
+// dynamicVariables lists the Postman dynamic variables, e.g. {{$guid}}, a request refers to.
+func dynamicVariables(req models.RequestWithDetail) []string {
+	texts := []string{req.URL, req.Body}
+	for _, h := range req.Headers {
+		texts = append(texts, h.Key, h.Value)
+	}
+	for _, p := range req.QueryParams {
+		texts = append(texts, p.Key, p.Value)
+	}
+
+	seen := map[string]bool{}
+	names := []string{}
+	for _, text := range texts {
+		for _, match := range dynamicVariable.FindAllStringSubmatch(text, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, "{{$"+match[1]+"}}")
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func folderName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "Untitled folder"
+	}
+	return name
+}
+
+func responseTime(value any) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,331 @@
+// Package postman converts between Postman Collection v2.1 documents and TAPA collection exports.
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SCHEMA_V21 is the schema exported collections declare.
+const SCHEMA_V21 string = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type collection struct {
+	Info     info       `json:"info"`
+	Item     []item     `json:"item"`
+	Event    []event    `json:"event,omitempty"`
+	Variable []variable `json:"variable,omitempty"`
+	Auth     *auth      `json:"auth,omitempty"`
+}
+
+type info struct {
+	PostmanID   string      `json:"_postman_id,omitempty"`
+	Name        string      `json:"name"`
+	Description description `json:"description,omitempty"`
+	Schema      string      `json:"schema"`
+}
+
+// item is a folder when Item is set, a request otherwise.
+type item struct {
+	Name        string      `json:"name"`
+	Description description `json:"description,omitempty"`
+	Item        []item      `json:"item,omitempty"`
+	Request     *request    `json:"request,omitempty"`
+	Response    []response  `json:"response,omitempty"`
+	Event       []event     `json:"event,omitempty"`
+	Variable    []variable  `json:"variable,omitempty"`
+	Auth        *auth       `json:"auth,omitempty"`
+
+	ProtocolProfileBehavior map[string]any `json:"protocolProfileBehavior,omitempty"`
+}
+
+func (i item) isFolder() bool {
+	return i.Request == nil && (i.Item != nil || i.Response == nil)
+}
+
+// MarshalJSON always writes the item list of a folder, which Postman requires even when it is empty.
+func (i item) MarshalJSON() ([]byte, error) {
+	type plain item
+	if i.Request != nil {
+		return json.Marshal(plain(i))
+	}
+
+	items := i.Item
+	if items == nil {
+		items = []item{}
+	}
+
+	return json.Marshal(struct {
+		plain
+		Item []item `json:"item"`
+	}{plain(i), items})
+}
+
+type request struct {
+	Method      string      `json:"method"`
+	Header      []keyValue  `json:"header"`
+	Body        *body       `json:"body,omitempty"`
+	URL         url         `json:"url"`
+	Auth        *auth       `json:"auth,omitempty"`
+	Description description `json:"description,omitempty"`
+}
+
+// UnmarshalJSON accepts the short form of a request, a bare URL string.
+func (r *request) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*r = request{Method: "GET", URL: url{Raw: raw}}
+		return nil
+	}
+
+	type plain request
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*r = request(p)
+	return nil
+}
+
+type url struct {
+	Raw      string     `json:"raw"`
+	Protocol string     `json:"protocol,omitempty"`
+	Host     []string   `json:"host,omitempty"`
+	Path     []string   `json:"path,omitempty"`
+	Query    []keyValue `json:"query,omitempty"`
+	Variable []keyValue `json:"variable,omitempty"`
+}
+
+// UnmarshalJSON accepts a URL given as a string, and host and path given as strings instead of lists.
+func (u *url) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = url{Raw: raw}
+		return nil
+	}
+
+	var p struct {
+		Raw      string          `json:"raw"`
+		Protocol string          `json:"protocol"`
+		Host     json.RawMessage `json:"host"`
+		Path     json.RawMessage `json:"path"`
+		Query    []keyValue      `json:"query"`
+		Variable []keyValue      `json:"variable"`
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	*u = url{Raw: p.Raw, Protocol: p.Protocol, Query: p.Query, Variable: p.Variable}
+	u.Host = segments(p.Host, ".")
+	u.Path = segments(p.Path, "/")
+	return nil
+}
+
+func segments(data json.RawMessage, separator string) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return strings.Split(strings.Trim(s, separator), separator)
+	}
+
+	// Path segments may also be objects, {"type": "string", "value": "users"}.
+	var objects []struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &objects); err == nil {
+		list = make([]string, len(objects))
+		for i, o := range objects {
+			list[i] = o.Value
+		}
+	}
+
+	return list
+}
+
+type keyValue struct {
+	Key         string      `json:"key"`
+	Value       string      `json:"value"`
+	Disabled    bool        `json:"disabled,omitempty"`
+	Type        string      `json:"type,omitempty"` // "text" or "file" for form data
+	Src         any         `json:"src,omitempty"`  // the file of a form data field
+	Description description `json:"description,omitempty"`
+}
+
+// UnmarshalJSON accepts values that are not strings, as Postman writes for numbers and booleans.
+func (kv *keyValue) UnmarshalJSON(data []byte) error {
+	var p struct {
+		Key         string      `json:"key"`
+		Value       any         `json:"value"`
+		Disabled    bool        `json:"disabled"`
+		Type        string      `json:"type"`
+		Src         any         `json:"src"`
+		Description description `json:"description"`
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	*kv = keyValue{Key: p.Key, Value: stringify(p.Value), Disabled: p.Disabled, Type: p.Type, Src: p.Src, Description: p.Description}
+	return nil
+}
+
+type variable struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type body struct {
+	Mode       string         `json:"mode"` // "raw", "urlencoded", "formdata", "file", "graphql"
+	Raw        string         `json:"raw,omitempty"`
+	URLEncoded []keyValue     `json:"urlencoded,omitempty"`
+	FormData   []keyValue     `json:"formdata,omitempty"`
+	GraphQL    *graphQL       `json:"graphql,omitempty"`
+	Disabled   bool           `json:"disabled,omitempty"`
+	Options    map[string]any `json:"options,omitempty"`
+}
+
+type graphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables,omitempty"`
+}
+
+// language returns the language of a raw body, e.g. "json", from its options.
+func (b *body) language() string {
+	raw, _ := b.Options["raw"].(map[string]any)
+	language, _ := raw["language"].(string)
+	return strings.ToLower(language)
+}
+
+type response struct {
+	Name            string      `json:"name"`
+	OriginalRequest *request    `json:"originalRequest,omitempty"`
+	Status          string      `json:"status,omitempty"`
+	Code            int         `json:"code"`
+	Header          []keyValue  `json:"header"`
+	Cookie          []cookie    `json:"cookie,omitempty"`
+	Body            string      `json:"body"`
+	ResponseTime    any         `json:"responseTime,omitempty"`
+	PreviewLanguage string      `json:"_postman_previewlanguage,omitempty"`
+	Description     description `json:"description,omitempty"`
+}
+
+type cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type event struct {
+	Listen   string `json:"listen"` // "prerequest" or "test"
+	Script   script `json:"script"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type script struct {
+	Type string `json:"type,omitempty"`
+	Exec lines  `json:"exec"`
+}
+
+// lines is a script's source, one entry per line; Postman also writes it as a single string.
+type lines []string
+
+func (l *lines) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = strings.Split(s, "\n")
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+type auth struct {
+	Type   string
+	Params map[string]string
+}
+
+// UnmarshalJSON reads the parameters of the auth's type, written as a list of key/value pairs in v2.1
+// and as an object in v2.0.
+func (a *auth) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(fields["type"], &a.Type); err != nil {
+		return fmt.Errorf("auth without a type")
+	}
+
+	a.Params = map[string]string{}
+	params, ok := fields[a.Type]
+	if !ok {
+		return nil
+	}
+
+	var list []keyValue
+	if err := json.Unmarshal(params, &list); err == nil {
+		for _, kv := range list {
+			a.Params[kv.Key] = kv.Value
+		}
+		return nil
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal(params, &object); err == nil {
+		for key, value := range object {
+			a.Params[key] = stringify(value)
+		}
+	}
+
+	return nil
+}
+
+// description is the text of a description, which Postman writes either as a string or as {"content": ...}.
+type description string
+
+func (d *description) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = description(s)
+		return nil
+	}
+
+	var object struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*d = description(object.Content)
+	return nil
+}
+
+func stringify(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
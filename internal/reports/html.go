@@ -0,0 +1,127 @@
+package reports
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// htmlTemplate is a single self-contained page: styles are inline and nothing is loaded from the network.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"add":      func(a, b int) int { return a + b },
+	"time":     func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+	"duration": func(ms int) string { return (time.Duration(ms) * time.Millisecond).String() },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - TAPA run report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2rem; color: #1f2328; background: #fff; }
+h1 { margin-bottom: .25rem; }
+.meta { color: #59636e; margin-bottom: 1.5rem; }
+.summary { display: flex; gap: 1rem; flex-wrap: wrap; margin-bottom: 2rem; }
+.card { border: 1px solid #d1d9e0; border-radius: 6px; padding: .75rem 1.25rem; min-width: 8rem; }
+.card b { display: block; font-size: 1.5rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { text-align: left; padding: .4rem .6rem; border-bottom: 1px solid #d1d9e0; vertical-align: top; }
+th { background: #f6f8fa; }
+.pass { color: #1a7f37; font-weight: 600; }
+.fail { color: #d1242f; font-weight: 600; }
+.num { text-align: right; white-space: nowrap; }
+details summary { cursor: pointer; }
+ul.tests { margin: .25rem 0; padding-left: 1.25rem; }
+pre { background: #f6f8fa; padding: .5rem; overflow-x: auto; max-height: 20rem; white-space: pre-wrap; word-break: break-all; }
+.url { color: #59636e; font-size: .85rem; word-break: break-all; }
+.data { color: #59636e; font-size: .9rem; }
+.error { border: 1px solid #d1242f; background: #ffebe9; border-radius: 6px; padding: .75rem 1rem; margin-bottom: 1.5rem; }
+.skip { color: #9a6700; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Run #{{.RunID}} &middot; {{.Status}} &middot; started {{time .StartedAt}} &middot; {{duration .Duration}}</div>
+{{if .Error}}<div class="error">{{.Error}}</div>{{end}}
+
+<div class="summary">
+<div class="card">Requests<b>{{.Requests}}</b></div>
+<div class="card">Failed requests<b class="{{if .RequestsFailed}}fail{{else}}pass{{end}}">{{.RequestsFailed}}</b></div>
+<div class="card">Tests<b>{{.Tests}}</b></div>
+<div class="card">Failed tests<b class="{{if .TestsFailed}}fail{{else}}pass{{end}}">{{.TestsFailed}}</b></div>
+{{if .Skipped}}<div class="card">Skipped<b class="skip">{{.Skipped}}</b></div>{{end}}
+{{if gt (len .Iterations) 1}}<div class="card">Iterations<b>{{len .Iterations}}</b></div>{{end}}
+</div>
+
+{{$multiple := gt (len .Iterations) 1}}
+{{range .Iterations}}
+{{if $multiple}}
+<h2>Iteration {{add .Index 1}} <span class="{{if .Passed}}pass{{else}}fail{{end}}">{{if .Passed}}passed{{else}}failed{{end}}</span></h2>
+{{if .Data}}<div class="data">{{range $key, $value := .Data}}{{$key}}={{$value}} {{end}}</div>{{end}}
+{{end}}
+<table>
+<thead><tr><th></th><th>Request</th><th class="num">Status</th><th class="num">Time</th><th class="num">Size</th><th>Tests</th></tr></thead>
+<tbody>
+{{range .Entries}}
+<tr>
+<td class="{{if .Passed}}pass{{else}}fail{{end}}">{{if .Passed}}PASS{{else}}FAIL{{end}}</td>
+<td><b>{{.Method}}</b> {{.Name}}<div class="url">{{.URL}}</div></td>
+<td class="num">{{if .StatusCode}}{{.StatusCode}}{{else}}-{{end}}</td>
+<td class="num">{{.ResponseTime}} ms</td>
+<td class="num">{{.DataVolume}} B</td>
+<td>
+{{if .Error}}<div class="fail">{{.Error}}</div>{{end}}
+{{if .Tests}}
+<details{{if not .Passed}} open{{end}}>
+<summary>{{len .Tests}} test(s)</summary>
+<ul class="tests">
+{{range .Tests}}
+<li><span class="{{if .Passed}}pass{{else}}fail{{end}}">{{if .Passed}}&#10003;{{else}}&#10007;{{end}}</span> {{.Name}}{{if and (not .Passed) .Message}}: {{.Message}}{{end}}
+{{if .Diff}}<pre>{{range .Diff}}{{.Kind}} {{.Path}}: {{.Expected}} -> {{.Actual}}
+{{end}}</pre>{{end}}
+</li>
+{{end}}
+</ul>
+</details>
+{{end}}
+{{if .ResponseSnippet}}
+<details>
+<summary>Response</summary>
+<pre>{{.ResponseSnippet}}</pre>
+</details>
+{{end}}
+</td>
+</tr>
+{{end}}
+</tbody>
+</table>
+{{end}}
+
+{{if .Steps}}
+<details>
+<summary><b>Executed path</b> ({{len .Steps}} steps)</summary>
+<table>
+<thead><tr><th class="num">Step</th>{{if $multiple}}<th class="num">Iteration</th>{{end}}<th>Request</th><th>Action</th><th>Detail</th></tr></thead>
+<tbody>
+{{range .Steps}}
+<tr>
+<td class="num">{{.Step}}</td>
+{{if $multiple}}<td class="num">{{add .Iteration 1}}</td>{{end}}
+<td>{{.Name}}</td>
+<td class="{{if eq .Action "skipped"}}skip{{else if eq .Action "loop_limit" "not_found"}}fail{{end}}">{{.Action}}</td>
+<td>{{.Detail}}</td>
+</tr>
+{{end}}
+</tbody>
+</table>
+</details>
+{{end}}
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, report models.Report) error {
+	return htmlTemplate.Execute(w, report)
+}
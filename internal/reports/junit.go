@@ -0,0 +1,188 @@
+package reports
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes one test suite per sent request and one test case per test or assertion.
+// A request that failed to send is reported as an error case; a request without tests as a single case.
+// Requests skipped by their skip conditions and runs that ended early get suites of their own.
+func writeJUnit(w io.Writer, report models.Report) error {
+	suites := junitSuites{
+		Name: report.Title,
+		Time: seconds(report.Duration),
+	}
+
+	multiple := len(report.Iterations) > 1
+	suiteName := func(iteration int, name string) string {
+		if multiple {
+			return fmt.Sprintf("Iteration %d / %s", iteration+1, name)
+		}
+		return name
+	}
+
+	add := func(suite junitSuite) {
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	for _, iteration := range report.Iterations {
+		for _, entry := range iteration.Entries {
+			name := suiteName(iteration.Index, entry.Name)
+
+			suite := junitSuite{
+				Name:      name,
+				Time:      seconds(entry.ResponseTime),
+				SystemOut: fmt.Sprintf("%s %s -> %d (%d ms, %d bytes)", entry.Method, entry.URL, entry.StatusCode, entry.ResponseTime, entry.DataVolume),
+			}
+			if !entry.Timestamp.IsZero() {
+				suite.Timestamp = entry.Timestamp.UTC().Format("2006-01-02T15:04:05")
+			}
+
+			className := strings.TrimSpace(report.Title + "." + entry.Name)
+
+			if entry.Error != "" {
+				suite.Cases = append(suite.Cases, junitCase{
+					Name:      entry.Method + " " + entry.Name,
+					ClassName: className,
+					Time:      seconds(entry.ResponseTime),
+					Error:     &junitProblem{Message: entry.Error, Type: "RequestError", Text: entry.Error},
+				})
+				suite.Errors++
+			}
+
+			for _, test := range entry.Tests {
+				c := junitCase{Name: test.Name, ClassName: className, Time: "0"}
+				if !test.Passed {
+					c.Failure = &junitProblem{Message: test.Message, Type: test.Source, Text: failureText(test)}
+					suite.Failures++
+				}
+				suite.Cases = append(suite.Cases, c)
+			}
+
+			if len(suite.Cases) == 0 {
+				suite.Cases = append(suite.Cases, junitCase{
+					Name:      entry.Method + " " + entry.Name,
+					ClassName: className,
+					Time:      seconds(entry.ResponseTime),
+				})
+			}
+
+			add(suite)
+		}
+
+		for _, step := range report.Steps {
+			if step.Iteration != iteration.Index || step.Action != models.RunStepSkipped {
+				continue
+			}
+
+			add(junitSuite{
+				Name:    suiteName(iteration.Index, step.Name),
+				Time:    "0",
+				Skipped: 1,
+				Cases: []junitCase{{
+					Name:      step.Name,
+					ClassName: strings.TrimSpace(report.Title + "." + step.Name),
+					Time:      "0",
+					Skipped:   &junitSkipped{Message: step.Detail},
+				}},
+			})
+		}
+	}
+
+	if report.Error != "" {
+		add(junitSuite{
+			Name:   "Run",
+			Time:   "0",
+			Errors: 1,
+			Cases: []junitCase{{
+				Name:      "run",
+				ClassName: report.Title,
+				Time:      "0",
+				Error:     &junitProblem{Message: report.Error, Type: "RunError", Text: report.Error},
+			}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// failureText adds the differences of example assertions to the failure message.
+func failureText(test models.TestOutcome) string {
+	if len(test.Diff) == 0 {
+		return test.Message
+	}
+
+	var sb strings.Builder
+	sb.WriteString(test.Message)
+	for _, d := range test.Diff {
+		fmt.Fprintf(&sb, "\n%s %s: %s -> %s", d.Kind, d.Path, d.Expected, d.Actual)
+	}
+	return sb.String()
+}
+
+func seconds(ms int) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
@@ -0,0 +1,193 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	FORMAT_JUNIT string = "junit"
+	FORMAT_JSON  string = "json"
+	FORMAT_HTML  string = "html"
+)
+
+// FORMATS lists every supported report format.
+var FORMATS = []string{FORMAT_JUNIT, FORMAT_JSON, FORMAT_HTML}
+
+type HistoryRepository interface {
+	GetHistoryByIDs(ids []int) ([]models.RequestHistory, error)
+}
+
+type TestResultsRepository interface {
+	GetTestResultsByHistoryIDs(historyIDs []int) ([]models.TestResult, error)
+}
+
+// Builder assembles reports from the history and test results a run recorded.
+type Builder struct {
+	history     HistoryRepository
+	testResults TestResultsRepository
+}
+
+// Build creates the report of a run. The run provides the order, iterations and data rows;
+// every request's details come from request_history and test_results.
+// Requests whose history was pruned fall back to what the run itself kept.
+func (b *Builder) Build(title string, run models.RunReport) (models.Report, error) {
+	ids := []int{}
+	for _, iteration := range run.Iterations {
+		for _, result := range iteration.Results {
+			if result.HistoryID != 0 {
+				ids = append(ids, result.HistoryID)
+			}
+		}
+	}
+
+	history, err := b.history.GetHistoryByIDs(ids)
+	if err != nil {
+		return models.Report{}, err
+	}
+
+	results, err := b.testResults.GetTestResultsByHistoryIDs(ids)
+	if err != nil {
+		return models.Report{}, err
+	}
+
+	historyByID := make(map[int]models.RequestHistory, len(history))
+	for _, h := range history {
+		historyByID[h.ID] = h
+	}
+
+	testsByHistory := map[int][]models.TestOutcome{}
+	for _, r := range results {
+		outcome := models.TestOutcome{Name: r.TestName, Source: r.Source, Passed: r.Passed, Message: r.Result}
+		if r.Diff != "" {
+			_ = json.Unmarshal([]byte(r.Diff), &outcome.Diff)
+		}
+		testsByHistory[*r.HistoryID] = append(testsByHistory[*r.HistoryID], outcome)
+	}
+
+	report := models.Report{
+		Title:            title,
+		RunID:            run.ID,
+		CollectionID:     run.CollectionID,
+		Status:           run.Status,
+		StartedAt:        run.StartedAt,
+		FinishedAt:       run.FinishedAt,
+		Duration:         run.Duration,
+		Skipped:          run.Skipped,
+		Error:            run.Error,
+		FailedIterations: append([]int{}, run.FailedIterations...),
+		Steps:            append([]models.RunStep{}, run.Steps...),
+		Iterations:       make([]models.ReportIteration, 0, len(run.Iterations)),
+	}
+
+	for _, iteration := range run.Iterations {
+		ri := models.ReportIteration{
+			Index:   iteration.Index,
+			Data:    iteration.Data,
+			Passed:  iteration.Passed,
+			Entries: make([]models.ReportEntry, 0, len(iteration.Results)),
+		}
+
+		for _, result := range iteration.Results {
+			entry := models.ReportEntry{
+				HistoryID:    result.HistoryID,
+				RequestID:    result.RequestID,
+				Name:         result.Name,
+				Method:       result.Method,
+				URL:          result.URL,
+				StatusCode:   result.StatusCode,
+				ResponseTime: result.ResponseTime,
+				DataVolume:   result.DataVolume,
+				Passed:       result.Passed,
+				Error:        result.Error,
+				Tests:        result.Tests,
+			}
+
+			if h, ok := historyByID[result.HistoryID]; ok {
+				entry.Method = h.Method
+				entry.URL = h.URL
+				entry.Timestamp = h.Timestamp
+				entry.StatusCode = h.StatusCode
+				entry.ResponseTime = h.ResponseTime
+				entry.DataVolume = h.DataVolume
+				entry.ResponseSnippet = h.ResponseSnippet
+				if h.Error != "" {
+					entry.Error = h.Error
+				}
+			}
+
+			if tests, ok := testsByHistory[result.HistoryID]; ok {
+				entry.Tests = tests
+			}
+			if entry.Tests == nil {
+				entry.Tests = []models.TestOutcome{}
+			}
+
+			report.Requests++
+			if !entry.Passed {
+				report.RequestsFailed++
+			}
+			for _, test := range entry.Tests {
+				report.Tests++
+				if !test.Passed {
+					report.TestsFailed++
+				}
+			}
+
+			ri.Entries = append(ri.Entries, entry)
+		}
+
+		report.Iterations = append(report.Iterations, ri)
+	}
+
+	return report, nil
+}
+
+// Write renders a report in one of FORMATS.
+func Write(w io.Writer, format string, report models.Report) error {
+	switch format {
+	case FORMAT_JUNIT:
+		return writeJUnit(w, report)
+	case FORMAT_JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FORMAT_HTML:
+		return writeHTML(w, report)
+	}
+
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+// IsFormat reports whether format is one of FORMATS.
+func IsFormat(format string) bool {
+	for _, f := range FORMATS {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Extension returns the file extension of a report format, including the dot.
+func Extension(format string) string {
+	switch format {
+	case FORMAT_JUNIT:
+		return ".xml"
+	case FORMAT_JSON:
+		return ".json"
+	}
+	return ".html"
+}
+
+func NewBuilder(db *sqlx.DB) *Builder {
+	return &Builder{
+		history:     repository.NewHistoryRepository(db),
+		testResults: repository.NewTestResultsRepository(db),
+	}
+}
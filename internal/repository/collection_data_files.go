@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type CollectionDataFilesRepository struct {
+	db *sqlx.DB
+}
+
+// GetCollectionDataFiles returns every data file stored with a collection.
+func (r *CollectionDataFilesRepository) GetCollectionDataFiles(collectionID int) ([]models.CollectionDataFile, error) {
+	files := []models.CollectionDataFile{}
+	query := `
+		SELECT id, collection_id, name, format, content, created_at, updated_at
+		FROM collection_data_files
+		WHERE collection_id = ?
+		ORDER BY name ASC`
+
+	if err := r.db.Select(&files, query, collectionID); err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionDataFilesRetrieval, err)
+	}
+
+	return files, nil
+}
+
+func (r *CollectionDataFilesRepository) GetCollectionDataFile(id int) (models.CollectionDataFile, error) {
+	var file models.CollectionDataFile
+	query := `
+		SELECT id, collection_id, name, format, content, created_at, updated_at
+		FROM collection_data_files
+		WHERE id = ?`
+
+	if err := r.db.Get(&file, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.CollectionDataFile{}, errors.Wrap(errors.ErrCollectionDataFileNotFound, err)
+		}
+		return models.CollectionDataFile{}, errors.Wrap(errors.ErrCollectionDataFilesRetrieval, err)
+	}
+
+	return file, nil
+}
+
+// SaveCollectionDataFile creates a data file or replaces the one with the same name in the collection.
+func (r *CollectionDataFilesRepository) SaveCollectionDataFile(file models.CollectionDataFile) (models.CollectionDataFile, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return models.CollectionDataFile{}, errors.Wrap(errors.ErrCollectionDataFileSave, err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertCollectionDataFile(tx, file); err != nil {
+		return models.CollectionDataFile{}, errors.Wrap(errors.ErrCollectionDataFileSave, err)
+	}
+
+	var saved models.CollectionDataFile
+	if err := tx.Get(&saved, `
+		SELECT id, collection_id, name, format, content, created_at, updated_at
+		FROM collection_data_files
+		WHERE collection_id = ? AND name = ?`, file.CollectionID, file.Name); err != nil {
+		return models.CollectionDataFile{}, errors.Wrap(errors.ErrCollectionDataFileSave, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.CollectionDataFile{}, errors.Wrap(errors.ErrCollectionDataFileSave, err)
+	}
+
+	return saved, nil
+}
+
+func (r *CollectionDataFilesRepository) DeleteCollectionDataFile(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM collection_data_files WHERE id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrCollectionDataFileDeletion, err)
+	}
+
+	return nil
+}
+
+func upsertCollectionDataFile(tx *sqlx.Tx, file models.CollectionDataFile) error {
+	_, err := tx.Exec(`
+		INSERT INTO collection_data_files (collection_id, name, format, content)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (collection_id, name) DO UPDATE SET
+			format = excluded.format,
+			content = excluded.content,
+			updated_at = CURRENT_TIMESTAMP`,
+		file.CollectionID, file.Name, file.Format, file.Content)
+
+	return err
+}
+
+func NewCollectionDataFilesRepository(db *sqlx.DB) *CollectionDataFilesRepository {
+	return &CollectionDataFilesRepository{db: db}
+}
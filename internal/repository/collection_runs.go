@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type CollectionRunsRepository struct {
+	db *sqlx.DB
+}
+
+// collectionRun is a row of collection_runs.
+type collectionRun struct {
+	ID              int        `db:"id"`
+	CollectionID    int        `db:"collection_id"`
+	FolderID        *int       `db:"folder_id"`
+	EnvironmentID   *int       `db:"environment_id"`
+	DataFileID      *int       `db:"data_file_id"`
+	Status          string     `db:"status"`
+	Total           int        `db:"total"`
+	TotalIterations int        `db:"total_iterations"`
+	Passed          int        `db:"passed"`
+	Failed          int        `db:"failed"`
+	TestsPassed     int        `db:"tests_passed"`
+	TestsFailed     int        `db:"tests_failed"`
+	Skipped         int        `db:"skipped"`
+	Error           string     `db:"error"`
+	IterationData   string     `db:"iteration_data"`
+	Steps           string     `db:"steps"`
+	Duration        int        `db:"duration"`
+	StartedAt       time.Time  `db:"started_at"`
+	FinishedAt      *time.Time `db:"finished_at"`
+}
+
+// collectionRunResult is a row of collection_run_results.
+type collectionRunResult struct {
+	Iteration    int    `db:"iteration"`
+	RequestID    int    `db:"request_id"`
+	HistoryID    int    `db:"history_id"`
+	Name         string `db:"name"`
+	Method       string `db:"method"`
+	URL          string `db:"url"`
+	StatusCode   int    `db:"status_code"`
+	ResponseTime int    `db:"response_time"`
+	DataVolume   int    `db:"data_volume"`
+	Passed       bool   `db:"passed"`
+	Error        string `db:"error"`
+	Tests        string `db:"tests"`
+}
+
+const collectionRunColumns = `
+	id, collection_id, folder_id, environment_id, data_file_id, status, total, total_iterations, passed, failed,
+	tests_passed, tests_failed, skipped, COALESCE(error, '') AS error, COALESCE(iteration_data, '[]') AS iteration_data,
+	COALESCE(steps, '[]') AS steps, duration, started_at, finished_at`
+
+// InsertCollectionRun records a run that just started and returns its id.
+func (r *CollectionRunsRepository) InsertCollectionRun(report models.RunReport) (int, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO collection_runs
+		(collection_id, folder_id, environment_id, data_file_id, status, total, total_iterations, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		report.CollectionID, report.FolderID, report.EnvironmentID, report.DataFileID, report.Status, report.Total,
+		report.TotalIterations, report.StartedAt.UTC())
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+
+	return int(id), nil
+}
+
+// SaveCollectionRun stores the totals and the per-request outcomes of a run, replacing what was stored before.
+func (r *CollectionRunsRepository) SaveCollectionRun(report models.RunReport) error {
+	rows := make([]map[string]string, len(report.Iterations))
+	for i, iteration := range report.Iterations {
+		rows[i] = iteration.Data
+	}
+
+	iterationData, err := json.Marshal(rows)
+	if err != nil {
+		return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+
+	steps, err := json.Marshal(report.Steps)
+	if err != nil {
+		return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+
+	var finishedAt *time.Time
+	if report.FinishedAt != nil {
+		t := report.FinishedAt.UTC()
+		finishedAt = &t
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE collection_runs SET
+			status = ?, total = ?, total_iterations = ?, passed = ?, failed = ?, tests_passed = ?, tests_failed = ?,
+			skipped = ?, error = ?, iteration_data = ?, steps = ?, duration = ?, finished_at = ?
+		WHERE id = ?`,
+		report.Status, report.Total, report.TotalIterations, report.Passed, report.Failed, report.TestsPassed,
+		report.TestsFailed, report.Skipped, nullIfEmpty(report.Error), string(iterationData), string(steps),
+		report.Duration, finishedAt, report.ID); err != nil {
+		return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM collection_run_results WHERE run_id = ?`, report.ID); err != nil {
+		return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+
+	for _, iteration := range report.Iterations {
+		for position, result := range iteration.Results {
+			var historyID *int
+			if result.HistoryID != 0 {
+				historyID = &result.HistoryID
+			}
+
+			tests, err := json.Marshal(result.Tests)
+			if err != nil {
+				return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+			}
+
+			if _, err := tx.Exec(`
+				INSERT INTO collection_run_results
+				(run_id, iteration, position, request_id, history_id, name, method, url, status_code, response_time,
+					data_volume, passed, error, tests)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				report.ID, iteration.Index, position, result.RequestID, historyID, result.Name, result.Method, result.URL,
+				result.StatusCode, result.ResponseTime, result.DataVolume, result.Passed, result.Error,
+				string(tests)); err != nil {
+				return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(errors.ErrCollectionRunInsertion, err)
+	}
+
+	return nil
+}
+
+// GetCollectionRuns returns the runs of a collection, newest first, without their per-request outcomes and steps.
+func (r *CollectionRunsRepository) GetCollectionRuns(collectionID int) ([]models.RunReport, error) {
+	rows := []collectionRun{}
+	query := `SELECT ` + collectionRunColumns + `
+		FROM collection_runs
+		WHERE collection_id = ?
+		ORDER BY id DESC`
+
+	if err := r.db.Select(&rows, query, collectionID); err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionRunsRetrieval, err)
+	}
+
+	failed := []struct {
+		RunID     int `db:"run_id"`
+		Iteration int `db:"iteration"`
+	}{}
+	if err := r.db.Select(&failed, `
+		SELECT DISTINCT res.run_id, res.iteration
+		FROM collection_run_results res
+		JOIN collection_runs run ON run.id = res.run_id
+		WHERE run.collection_id = ? AND res.passed = FALSE
+		ORDER BY res.run_id, res.iteration`, collectionID); err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionRunsRetrieval, err)
+	}
+
+	failedIterations := map[int][]int{}
+	for _, f := range failed {
+		failedIterations[f.RunID] = append(failedIterations[f.RunID], f.Iteration)
+	}
+
+	reports := make([]models.RunReport, len(rows))
+	for i, row := range rows {
+		reports[i] = row.report()
+		if iterations, ok := failedIterations[row.ID]; ok {
+			reports[i].FailedIterations = iterations
+		}
+	}
+
+	return reports, nil
+}
+
+// GetCollectionRun returns a stored run with the outcome of every request, grouped by iteration.
+func (r *CollectionRunsRepository) GetCollectionRun(id int) (models.RunReport, error) {
+	var row collectionRun
+	if err := r.db.Get(&row, `SELECT `+collectionRunColumns+` FROM collection_runs WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.RunReport{}, errors.Wrap(errors.ErrCollectionRunNotFound, err)
+		}
+		return models.RunReport{}, errors.Wrap(errors.ErrCollectionRunsRetrieval, err)
+	}
+
+	results := []collectionRunResult{}
+	if err := r.db.Select(&results, `
+		SELECT iteration, request_id, COALESCE(history_id, 0) AS history_id, name, method, COALESCE(url, '') AS url,
+			COALESCE(status_code, 0) AS status_code, COALESCE(response_time, 0) AS response_time,
+			COALESCE(data_volume, 0) AS data_volume, passed, COALESCE(error, '') AS error, COALESCE(tests, '[]') AS tests
+		FROM collection_run_results
+		WHERE run_id = ?
+		ORDER BY iteration ASC, position ASC`, id); err != nil {
+		return models.RunReport{}, errors.Wrap(errors.ErrCollectionRunsRetrieval, err)
+	}
+
+	report := row.report()
+
+	if err := json.Unmarshal([]byte(row.Steps), &report.Steps); err != nil {
+		return models.RunReport{}, errors.Wrap(errors.ErrCollectionRunsRetrieval, err)
+	}
+
+	var data []map[string]string
+	if err := json.Unmarshal([]byte(row.IterationData), &data); err != nil {
+		return models.RunReport{}, errors.Wrap(errors.ErrCollectionRunsRetrieval, err)
+	}
+
+	iterations := make([]models.RunIteration, len(data))
+	for i, d := range data {
+		iterations[i] = models.RunIteration{Index: i, Data: d, Passed: true, Results: []models.RunRequestResult{}}
+	}
+
+	for _, res := range results {
+		if res.Iteration >= len(iterations) {
+			continue
+		}
+
+		result := models.RunRequestResult{
+			Iteration:    res.Iteration,
+			RequestID:    res.RequestID,
+			HistoryID:    res.HistoryID,
+			Name:         res.Name,
+			Method:       res.Method,
+			URL:          res.URL,
+			StatusCode:   res.StatusCode,
+			ResponseTime: res.ResponseTime,
+			DataVolume:   res.DataVolume,
+			Passed:       res.Passed,
+			Error:        res.Error,
+			Tests:        []models.TestOutcome{},
+		}
+		if err := json.Unmarshal([]byte(res.Tests), &result.Tests); err != nil {
+			return models.RunReport{}, errors.Wrap(errors.ErrCollectionRunsRetrieval, err)
+		}
+
+		iteration := &iterations[res.Iteration]
+		iteration.Results = append(iteration.Results, result)
+		if !result.Passed && iteration.Passed {
+			iteration.Passed = false
+			report.FailedIterations = append(report.FailedIterations, res.Iteration)
+		}
+	}
+
+	report.Iterations = iterations
+	return report, nil
+}
+
+// DeleteCollectionRun removes a run and its per-request outcomes.
+func (r *CollectionRunsRepository) DeleteCollectionRun(id int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.Wrap(errors.ErrCollectionRunDeletion, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM collection_run_results WHERE run_id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrCollectionRunDeletion, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM collection_runs WHERE id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrCollectionRunDeletion, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(errors.ErrCollectionRunDeletion, err)
+	}
+
+	return nil
+}
+
+func (row collectionRun) report() models.RunReport {
+	return models.RunReport{
+		ID:               row.ID,
+		CollectionID:     row.CollectionID,
+		FolderID:         row.FolderID,
+		EnvironmentID:    row.EnvironmentID,
+		DataFileID:       row.DataFileID,
+		Status:           row.Status,
+		Total:            row.Total,
+		TotalIterations:  row.TotalIterations,
+		Passed:           row.Passed,
+		Failed:           row.Failed,
+		TestsPassed:      row.TestsPassed,
+		TestsFailed:      row.TestsFailed,
+		Skipped:          row.Skipped,
+		Error:            row.Error,
+		FailedIterations: []int{},
+		Duration:         row.Duration,
+		StartedAt:        row.StartedAt,
+		FinishedAt:       row.FinishedAt,
+		Iterations:       []models.RunIteration{},
+		Steps:            []models.RunStep{},
+	}
+}
+
+func NewCollectionRunsRepository(db *sqlx.DB) *CollectionRunsRepository {
+	return &CollectionRunsRepository{db: db}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type CollectionSchemasRepository struct {
+	db *sqlx.DB
+}
+
+// GetCollectionSchemas returns every JSON Schema stored on a collection.
+func (r *CollectionSchemasRepository) GetCollectionSchemas(collectionID int) ([]models.CollectionSchema, error) {
+	schemas := []models.CollectionSchema{}
+	query := `
+		SELECT id, collection_id, name, schema, COALESCE(source, 'manual') AS source, created_at, updated_at
+		FROM collection_schemas
+		WHERE collection_id = ?
+		ORDER BY name ASC`
+
+	if err := r.db.Select(&schemas, query, collectionID); err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionSchemasRetrieval, err)
+	}
+
+	return schemas, nil
+}
+
+// SaveCollectionSchemas creates or replaces schemas by name, all or none of them.
+func (r *CollectionSchemasRepository) SaveCollectionSchemas(schemas []models.CollectionSchema) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.Wrap(errors.ErrCollectionSchemaSave, err)
+	}
+	defer tx.Rollback()
+
+	for _, s := range schemas {
+		if err := upsertCollectionSchema(tx, s); err != nil {
+			return errors.Wrap(errors.ErrCollectionSchemaSave, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(errors.ErrCollectionSchemaSave, err)
+	}
+
+	return nil
+}
+
+// DeleteCollectionSchema removes a schema. Assertions still referencing it will fail.
+func (r *CollectionSchemasRepository) DeleteCollectionSchema(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM collection_schemas WHERE id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrCollectionSchemaDeletion, err)
+	}
+
+	return nil
+}
+
+func upsertCollectionSchema(tx *sqlx.Tx, s models.CollectionSchema) error {
+	source := s.Source
+	if source == "" {
+		source = models.SchemaSourceManual
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO collection_schemas (collection_id, name, schema, source)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (collection_id, name) DO UPDATE SET
+			schema = excluded.schema,
+			source = excluded.source,
+			updated_at = CURRENT_TIMESTAMP`,
+		s.CollectionID, s.Name, s.Schema, source)
+
+	return err
+}
+
+func NewCollectionSchemasRepository(db *sqlx.DB) *CollectionSchemasRepository {
+	return &CollectionSchemasRepository{db: db}
+}
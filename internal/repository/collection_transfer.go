@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetCollectionExport assembles a collection with its variables, script modules, schemas, data files, folders and requests.
+func (r *CollectionsRepository) GetCollectionExport(collectionID int) (models.CollectionExport, error) {
+	export := models.CollectionExport{
+		Version:  models.COLLECTION_EXPORT_VERSION,
+		Folders:  []models.FolderExport{},
+		Requests: []models.RequestExport{},
+	}
+
+	if err := r.db.Get(&export.Collection, `
+		SELECT id, name, COALESCE(description, '') AS description, position, created_at, updated_at
+		FROM collections
+		WHERE id = ?`, collectionID); err != nil {
+		return models.CollectionExport{}, errors.Wrap(errors.ErrCollectionExport, err)
+	}
+
+	variables, err := r.GetCollectionVariables(collectionID)
+	if err != nil {
+		return models.CollectionExport{}, err
+	}
+	export.Variables = append([]models.CollectionVariable{}, variables...)
+
+	modules, err := NewScriptModulesRepository(r.db).GetScriptModules(collectionID)
+	if err != nil {
+		return models.CollectionExport{}, err
+	}
+	export.Modules = modules
+
+	schemas, err := NewCollectionSchemasRepository(r.db).GetCollectionSchemas(collectionID)
+	if err != nil {
+		return models.CollectionExport{}, err
+	}
+	export.Schemas = schemas
+
+	dataFiles, err := NewCollectionDataFilesRepository(r.db).GetCollectionDataFiles(collectionID)
+	if err != nil {
+		return models.CollectionExport{}, err
+	}
+	export.DataFiles = dataFiles
+
+	var folders []models.Folder
+	if err := r.db.Select(&folders, `
+		SELECT id, collection_id, name, position, created_at, updated_at
+		FROM folders
+		WHERE collection_id = ?
+		ORDER BY position ASC`, collectionID); err != nil {
+		return models.CollectionExport{}, errors.Wrap(errors.ErrCollectionExport, err)
+	}
+
+	var requestIDs []struct {
+		ID       int  `db:"id"`
+		FolderID *int `db:"folder_id"`
+	}
+	if err := r.db.Select(&requestIDs, `
+		SELECT id, folder_id
+		FROM requests
+		WHERE collection_id = ?
+		ORDER BY position ASC`, collectionID); err != nil {
+		return models.CollectionExport{}, errors.Wrap(errors.ErrCollectionExport, err)
+	}
+
+	requests := NewRequestsRepository(r.db)
+	byFolder := map[int][]models.RequestExport{}
+
+	for _, row := range requestIDs {
+		detail, err := requests.GetRequestWithDetail(row.ID)
+		if err != nil {
+			return models.CollectionExport{}, err
+		}
+
+		examples, err := requests.GetRequestExamples(row.ID)
+		if err != nil {
+			return models.CollectionExport{}, err
+		}
+
+		req := models.RequestExport{RequestWithDetail: detail, Examples: examples}
+		if row.FolderID != nil {
+			byFolder[*row.FolderID] = append(byFolder[*row.FolderID], req)
+		} else {
+			export.Requests = append(export.Requests, req)
+		}
+	}
+
+	for _, folder := range folders {
+		folderRequests := byFolder[folder.ID]
+		if folderRequests == nil {
+			folderRequests = []models.RequestExport{}
+		}
+		export.Folders = append(export.Folders, models.FolderExport{Folder: folder, Requests: folderRequests})
+	}
+
+	return export, nil
+}
+
+// ImportCollection stores an exported collection as a new collection, inside a single transaction.
+// The collection is appended after the existing ones and every row gets a new id.
+func (r *CollectionsRepository) ImportCollection(export models.CollectionExport) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	defer tx.Rollback()
+
+	collectionID, err := insertCollectionExport(tx, export)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	return collectionID, nil
+}
+
+// ImportWorkspace stores the collections and environments of a workspace import inside a single transaction and
+// returns the ids of the new collections. Environments are matched by name; existing ones only gain the variables
+// they do not have yet.
+func (r *CollectionsRepository) ImportWorkspace(workspace models.WorkspaceImport) ([]int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, 0, len(workspace.Collections))
+	for _, export := range workspace.Collections {
+		collectionID, err := insertCollectionExport(tx, export)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, collectionID)
+	}
+
+	for _, env := range workspace.Environments {
+		if err := mergeEnvironment(tx, env); err != nil {
+			return nil, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	return ids, nil
+}
+
+// insertCollectionExport inserts an exported collection after the existing ones and returns its id.
+func insertCollectionExport(tx *sqlx.Tx, export models.CollectionExport) (int, error) {
+	res, err := tx.Exec(`
+		INSERT INTO collections (name, description, position)
+		VALUES (?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM collections))`,
+		export.Collection.Name, export.Collection.Description)
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	collectionID := int(id)
+
+	for _, v := range export.Variables {
+		if _, err := tx.Exec(`
+			INSERT INTO collection_variables (collection_id, key, value) VALUES (?, ?, ?)`,
+			collectionID, v.Key, v.Value); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	for _, m := range export.Modules {
+		version := m.Version
+		if version < 1 {
+			version = 1
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO collection_script_modules (collection_id, name, script, version) VALUES (?, ?, ?, ?)`,
+			collectionID, m.Name, m.Script, version); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	for _, schema := range export.Schemas {
+		schema.CollectionID = collectionID
+		if err := upsertCollectionSchema(tx, schema); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	for _, file := range export.DataFiles {
+		file.CollectionID = collectionID
+		if err := upsertCollectionDataFile(tx, file); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	position := 0
+	for i, folder := range export.Folders {
+		res, err := tx.Exec(`
+			INSERT INTO folders (collection_id, name, position) VALUES (?, ?, ?)`,
+			collectionID, folder.Folder.Name, i+1)
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+		folderID := int(id)
+
+		for _, req := range folder.Requests {
+			position++
+			if _, err := insertRequestExport(tx, collectionID, &folderID, position, req); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	for _, req := range export.Requests {
+		position++
+		if _, err := insertRequestExport(tx, collectionID, nil, position, req); err != nil {
+			return 0, err
+		}
+	}
+
+	return collectionID, nil
+}
+
+// AddRequest stores a request at the end of a collection, in a folder of it when folderID is not nil,
+// and returns its id.
+func (r *CollectionsRepository) AddRequest(collectionID int, folderID *int, req models.RequestExport) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	defer tx.Rollback()
+
+	var position int
+	if err := tx.Get(&position, `SELECT COALESCE(MAX(position), 0) + 1 FROM requests WHERE collection_id = ?`, collectionID); err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	requestID, err := insertRequestExport(tx, collectionID, folderID, position, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	return requestID, nil
+}
+
+// insertRequestExport inserts a request and everything attached to it and returns the id of the request.
+func insertRequestExport(tx *sqlx.Tx, collectionID int, folderID *int, position int, req models.RequestExport) (int, error) {
+	bodyFormat := req.BodyFormat
+	if bodyFormat == "" {
+		bodyFormat = "raw"
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = 30000
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO requests
+		(collection_id, folder_id, position, name, method, url, body, body_format, notes, timeout, allow_redirects, ssl_verification, remove_referer_on_redirect, encode_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		collectionID, folderID, position, req.Name, req.Method, req.URL, nullIfEmpty(req.Body), bodyFormat, nullIfEmpty(req.Notes),
+		timeout, req.AllowRedirects, req.SSLVerification, req.RemoveRefererOnRedirect, req.EncodeURL)
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	requestID := int(id)
+
+	for _, h := range req.Headers {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO request_headers (request_id, key, value) VALUES (?, ?, ?)`, requestID, h.Key, h.Value); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	for _, p := range req.QueryParams {
+		if _, err := tx.Exec(`INSERT INTO request_query_params (request_id, key, value) VALUES (?, ?, ?)`, requestID, p.Key, p.Value); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	for _, c := range req.Cookies {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO request_cookies (request_id, key, value) VALUES (?, ?, ?)`, requestID, c.Key, c.Value); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	for _, s := range req.Scripts {
+		phase := s.Phase
+		if phase == "" {
+			phase = models.ScriptPhasePreRequest
+		}
+
+		if _, err := tx.Exec(`INSERT INTO request_scripts (request_id, phase, script) VALUES (?, ?, ?)`, requestID, phase, s.Script); err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	// Examples get new ids, example assertions are pointed at them.
+	exampleIDs := make(map[string]string, len(req.Examples))
+	for _, e := range req.Examples {
+		if e.Timestamp.IsZero() {
+			e.Timestamp = time.Now()
+		}
+
+		res, err := tx.Exec(`
+			INSERT INTO request_examples
+			(request_id, timestamp, method, url, headers, query_params, body, status_code, response, response_headers, response_cookies, response_time, data_volume)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			requestID, e.Timestamp, e.Method, e.URL, e.Headers, e.QueryParams, e.Body, e.StatusCode,
+			e.Response, e.ResponseHeaders, e.ResponseCookies, e.ResponseTime, e.DataVolume)
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+		exampleIDs[strconv.Itoa(e.ID)] = strconv.FormatInt(id, 10)
+	}
+
+	assertions := make([]models.RequestAssertion, len(req.Assertions))
+	for i, a := range req.Assertions {
+		if a.Type == models.AssertionTypeExample {
+			if id, ok := exampleIDs[strings.TrimSpace(a.Target)]; ok {
+				a.Target = id
+			}
+		}
+		assertions[i] = a
+	}
+
+	if err := insertAssertions(tx, requestID, assertions); err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	if err := insertSkipConditions(tx, requestID, req.SkipConditions); err != nil {
+		return 0, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	return requestID, nil
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
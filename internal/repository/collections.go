@@ -14,7 +14,7 @@ type CollectionsRepository struct {
 func (r *CollectionsRepository) GetAllCollections() ([]models.Collection, error) {
 	var cols []models.Collection
 	query := `
-		SELECT id, name, description, position, created_at, updated_at
+		SELECT id, name, COALESCE(description, '') AS description, position, created_at, updated_at
 		FROM collections
 		ORDER BY position ASC`
 
@@ -40,12 +40,13 @@ func (r *CollectionsRepository) GetAllFolders() ([]models.Folder, error) {
 	return folders, nil
 }
 
-// GetAllRequestSummaries returns minimal request data: id, collection_id, folder_id, name, and method.
+// GetAllRequestSummaries returns minimal request data: id, collection_id, folder_id, position, name, and method.
 func (r *CollectionsRepository) GetAllRequestSummaries() ([]models.RequestBasic, error) {
 	var reqs []models.RequestBasic
 	query := `
-		SELECT id, collection_id, folder_id, name, method 
-		FROM requests`
+		SELECT id, collection_id, folder_id, position, name, method 
+		FROM requests
+		ORDER BY position ASC`
 
 	if err := r.db.Select(&reqs, query); err != nil {
 		return nil, errors.Wrap(errors.ErrRequestSummariesRetrieval, err)
@@ -54,6 +55,21 @@ func (r *CollectionsRepository) GetAllRequestSummaries() ([]models.RequestBasic,
 	return reqs, nil
 }
 
+// GetCollectionVariables returns the variables defined on a single collection.
+func (r *CollectionsRepository) GetCollectionVariables(collectionID int) ([]models.CollectionVariable, error) {
+	var vars []models.CollectionVariable
+	query := `
+		SELECT id, collection_id, key, value
+		FROM collection_variables
+		WHERE collection_id = ?`
+
+	if err := r.db.Select(&vars, query, collectionID); err != nil {
+		return nil, errors.Wrap(errors.ErrCollectionVariablesRetrieval, err)
+	}
+
+	return vars, nil
+}
+
 func NewCollectionsRepository(db *sqlx.DB) *CollectionsRepository {
 	return &CollectionsRepository{db: db}
 }
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type EnvironmentsRepository struct {
+	db *sqlx.DB
+}
+
+// GetEnvironments returns every environment, sorted by name.
+func (r *EnvironmentsRepository) GetEnvironments() ([]models.Environment, error) {
+	envs := []models.Environment{}
+	query := `
+		SELECT id, name, created_at
+		FROM environments
+		ORDER BY name ASC`
+
+	if err := r.db.Select(&envs, query); err != nil {
+		return nil, errors.Wrap(errors.ErrEnvironmentsRetrieval, err)
+	}
+
+	return envs, nil
+}
+
+// GetEnvironment returns a single environment.
+func (r *EnvironmentsRepository) GetEnvironment(id int) (models.Environment, error) {
+	var env models.Environment
+	query := `
+		SELECT id, name, created_at
+		FROM environments
+		WHERE id = ?`
+
+	if err := r.db.Get(&env, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Environment{}, errors.Wrap(errors.ErrEnvironmentNotFound, err)
+		}
+		return models.Environment{}, errors.Wrap(errors.ErrEnvironmentsRetrieval, err)
+	}
+
+	return env, nil
+}
+
+// GetEnvironmentVariables returns the variables of a single environment.
+func (r *EnvironmentsRepository) GetEnvironmentVariables(environmentID int) ([]models.EnvironmentVariable, error) {
+	var vars []models.EnvironmentVariable
+	query := `
+		SELECT id, environment_id, key, value
+		FROM environment_variables
+		WHERE environment_id = ?`
+
+	if err := r.db.Select(&vars, query, environmentID); err != nil {
+		return nil, errors.Wrap(errors.ErrEnvironmentVariablesRetrieval, err)
+	}
+
+	return vars, nil
+}
+
+// GetSelectedEnvironmentID returns the environment selected in the UI, or nil if there is none.
+func (r *EnvironmentsRepository) GetSelectedEnvironmentID() (*int, error) {
+	var id *int
+	query := `
+		SELECT selected_environment
+		FROM app_state
+		WHERE id = 1`
+
+	if err := r.db.Get(&id, query); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrap(errors.ErrSelectedEnvironmentRetrieval, err)
+	}
+
+	return id, nil
+}
+
+func NewEnvironmentsRepository(db *sqlx.DB) *EnvironmentsRepository {
+	return &EnvironmentsRepository{db: db}
+}
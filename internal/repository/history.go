@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type HistoryRepository struct {
+	db *sqlx.DB
+}
+
+// InsertHistory stores a history record and returns its id.
+func (r *HistoryRepository) InsertHistory(h models.RequestHistory) (int, error) {
+	query := `
+		INSERT INTO request_history
+		(request_id, parent_id, timestamp, method, url, headers, query_params, body, status_code, response_time, data_volume,
+			response_snippet, error)
+		VALUES (:request_id, :parent_id, :timestamp, :method, :url, :headers, :query_params, :body, :status_code, :response_time,
+			:data_volume, :response_snippet, :error)`
+
+	res, err := r.db.NamedExec(query, h)
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrHistoryInsertion, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrHistoryInsertion, err)
+	}
+
+	return int(id), nil
+}
+
+// LinkHistoryToParent points the given history records at their parent execution.
+// Sub-requests sent from pre-request scripts are recorded before their parent, so they are linked afterwards.
+func (r *HistoryRepository) LinkHistoryToParent(parentID int, childIDs []int) error {
+	if len(childIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`UPDATE request_history SET parent_id = ? WHERE id IN (?)`, parentID, childIDs)
+	if err != nil {
+		return errors.Wrap(errors.ErrHistoryLinking, err)
+	}
+
+	if _, err := r.db.Exec(r.db.Rebind(query), args...); err != nil {
+		return errors.Wrap(errors.ErrHistoryLinking, err)
+	}
+
+	return nil
+}
+
+// GetHistoryByIDs returns the history records with the given ids. Records pruned from the history are skipped.
+func (r *HistoryRepository) GetHistoryByIDs(ids []int) ([]models.RequestHistory, error) {
+	history := []models.RequestHistory{}
+	if len(ids) == 0 {
+		return history, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT id, request_id, parent_id, timestamp, method, url,
+			COALESCE(headers, '') AS headers, COALESCE(query_params, '') AS query_params, COALESCE(body, '') AS body,
+			COALESCE(status_code, 0) AS status_code, COALESCE(response_time, 0) AS response_time,
+			COALESCE(data_volume, 0) AS data_volume, COALESCE(response_snippet, '') AS response_snippet,
+			COALESCE(error, '') AS error
+		FROM request_history
+		WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrHistoryRetrieval, err)
+	}
+
+	if err := r.db.Select(&history, r.db.Rebind(query), args...); err != nil {
+		return nil, errors.Wrap(errors.ErrHistoryRetrieval, err)
+	}
+
+	return history, nil
+}
+
+// GetHistory returns the history records of a request, or every record when requestID is nil, oldest first.
+func (r *HistoryRepository) GetHistory(requestID *int) ([]models.RequestHistory, error) {
+	history := []models.RequestHistory{}
+
+	query := `
+		SELECT id, request_id, parent_id, timestamp, method, url,
+			COALESCE(headers, '') AS headers, COALESCE(query_params, '') AS query_params, COALESCE(body, '') AS body,
+			COALESCE(status_code, 0) AS status_code, COALESCE(response_time, 0) AS response_time,
+			COALESCE(data_volume, 0) AS data_volume, COALESCE(response_snippet, '') AS response_snippet,
+			COALESCE(error, '') AS error
+		FROM request_history
+		WHERE ? IS NULL OR request_id = ?
+		ORDER BY timestamp ASC, id ASC`
+
+	if err := r.db.Select(&history, query, requestID, requestID); err != nil {
+		return nil, errors.Wrap(errors.ErrHistoryRetrieval, err)
+	}
+
+	return history, nil
+}
+
+func NewHistoryRepository(db *sqlx.DB) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoadTestsRepository struct {
+	db *sqlx.DB
+}
+
+// loadTest is a row of load_tests.
+type loadTest struct {
+	ID             int        `db:"id"`
+	CollectionID   int        `db:"collection_id"`
+	FolderID       *int       `db:"folder_id"`
+	RequestID      *int       `db:"request_id"`
+	EnvironmentID  *int       `db:"environment_id"`
+	Name           string     `db:"name"`
+	VirtualUsers   int        `db:"virtual_users"`
+	RatePerSecond  float64    `db:"rate_per_second"`
+	DurationMs     int        `db:"duration_ms"`
+	Status         string     `db:"status"`
+	Requests       int        `db:"requests"`
+	Errors         int        `db:"errors"`
+	Dropped        int        `db:"dropped"`
+	BytesSent      int64      `db:"bytes_sent"`
+	BytesReceived  int64      `db:"bytes_received"`
+	Throughput     float64    `db:"throughput"`
+	LatencyMin     float64    `db:"latency_min"`
+	LatencyMean    float64    `db:"latency_mean"`
+	LatencyP50     float64    `db:"latency_p50"`
+	LatencyP90     float64    `db:"latency_p90"`
+	LatencyP99     float64    `db:"latency_p99"`
+	LatencyMax     float64    `db:"latency_max"`
+	Timeline       string     `db:"timeline"`
+	ErrorBreakdown string     `db:"error_breakdown"`
+	Duration       int        `db:"duration"`
+	StartedAt      time.Time  `db:"started_at"`
+	FinishedAt     *time.Time `db:"finished_at"`
+}
+
+const loadTestColumns = `
+	id, collection_id, folder_id, request_id, environment_id, name, virtual_users, rate_per_second, duration_ms, status,
+	requests, errors, dropped, bytes_sent, bytes_received, throughput, latency_min, latency_mean, latency_p50, latency_p90,
+	latency_p99, latency_max, COALESCE(timeline, '[]') AS timeline, COALESCE(error_breakdown, '[]') AS error_breakdown,
+	duration, started_at, finished_at`
+
+// InsertLoadTest records a load test that just started and returns its id.
+func (r *LoadTestsRepository) InsertLoadTest(report models.LoadTestReport) (int, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO load_tests
+		(collection_id, folder_id, request_id, environment_id, name, virtual_users, rate_per_second, duration_ms, status, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		report.CollectionID, report.FolderID, report.RequestID, report.EnvironmentID, report.Name, report.VirtualUsers,
+		report.RatePerSecond, report.DurationMs, report.Status, report.StartedAt.UTC())
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrLoadTestInsertion, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrLoadTestInsertion, err)
+	}
+
+	return int(id), nil
+}
+
+// SaveLoadTest stores the results of a load test.
+func (r *LoadTestsRepository) SaveLoadTest(report models.LoadTestReport) error {
+	timeline, err := json.Marshal(report.Timeline)
+	if err != nil {
+		return errors.Wrap(errors.ErrLoadTestInsertion, err)
+	}
+
+	breakdown, err := json.Marshal(report.ErrorBreakdown)
+	if err != nil {
+		return errors.Wrap(errors.ErrLoadTestInsertion, err)
+	}
+
+	var finishedAt *time.Time
+	if report.FinishedAt != nil {
+		t := report.FinishedAt.UTC()
+		finishedAt = &t
+	}
+
+	if _, err := r.db.Exec(`
+		UPDATE load_tests SET
+			status = ?, requests = ?, errors = ?, dropped = ?, bytes_sent = ?, bytes_received = ?, throughput = ?,
+			latency_min = ?, latency_mean = ?, latency_p50 = ?, latency_p90 = ?, latency_p99 = ?, latency_max = ?,
+			timeline = ?, error_breakdown = ?, duration = ?, finished_at = ?
+		WHERE id = ?`,
+		report.Status, report.Requests, report.Errors, report.Dropped, report.BytesSent, report.BytesReceived,
+		report.Throughput, report.Latency.Min, report.Latency.Mean, report.Latency.P50, report.Latency.P90,
+		report.Latency.P99, report.Latency.Max, string(timeline), string(breakdown), report.Duration, finishedAt,
+		report.ID); err != nil {
+		return errors.Wrap(errors.ErrLoadTestInsertion, err)
+	}
+
+	return nil
+}
+
+// GetLoadTests returns the load tests of a collection, newest first, without their timelines.
+func (r *LoadTestsRepository) GetLoadTests(collectionID int) ([]models.LoadTestReport, error) {
+	rows := []loadTest{}
+	query := `SELECT ` + loadTestColumns + `
+		FROM load_tests
+		WHERE collection_id = ?
+		ORDER BY id DESC`
+
+	if err := r.db.Select(&rows, query, collectionID); err != nil {
+		return nil, errors.Wrap(errors.ErrLoadTestsRetrieval, err)
+	}
+
+	reports := make([]models.LoadTestReport, len(rows))
+	for i, row := range rows {
+		report, err := row.report()
+		if err != nil {
+			return nil, err
+		}
+		report.Timeline = []models.LoadTestSecond{}
+		reports[i] = report
+	}
+
+	return reports, nil
+}
+
+// GetLoadTest returns a stored load test with its timeline and error breakdown.
+func (r *LoadTestsRepository) GetLoadTest(id int) (models.LoadTestReport, error) {
+	var row loadTest
+	if err := r.db.Get(&row, `SELECT `+loadTestColumns+` FROM load_tests WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.LoadTestReport{}, errors.Wrap(errors.ErrLoadTestNotFound, err)
+		}
+		return models.LoadTestReport{}, errors.Wrap(errors.ErrLoadTestsRetrieval, err)
+	}
+
+	return row.report()
+}
+
+func (r *LoadTestsRepository) DeleteLoadTest(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM load_tests WHERE id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrLoadTestDeletion, err)
+	}
+
+	return nil
+}
+
+func (row loadTest) report() (models.LoadTestReport, error) {
+	report := models.LoadTestReport{
+		ID:            row.ID,
+		CollectionID:  row.CollectionID,
+		FolderID:      row.FolderID,
+		RequestID:     row.RequestID,
+		EnvironmentID: row.EnvironmentID,
+		Name:          row.Name,
+		VirtualUsers:  row.VirtualUsers,
+		RatePerSecond: row.RatePerSecond,
+		DurationMs:    row.DurationMs,
+		Status:        row.Status,
+		Requests:      row.Requests,
+		Errors:        row.Errors,
+		Dropped:       row.Dropped,
+		BytesSent:     row.BytesSent,
+		BytesReceived: row.BytesReceived,
+		Throughput:    row.Throughput,
+		Latency: models.LatencyStats{
+			Min:  row.LatencyMin,
+			Mean: row.LatencyMean,
+			P50:  row.LatencyP50,
+			P90:  row.LatencyP90,
+			P99:  row.LatencyP99,
+			Max:  row.LatencyMax,
+		},
+		Timeline:       []models.LoadTestSecond{},
+		ErrorBreakdown: []models.LoadTestError{},
+		Duration:       row.Duration,
+		StartedAt:      row.StartedAt,
+		FinishedAt:     row.FinishedAt,
+	}
+
+	if err := json.Unmarshal([]byte(row.Timeline), &report.Timeline); err != nil {
+		return models.LoadTestReport{}, errors.Wrap(errors.ErrLoadTestsRetrieval, err)
+	}
+	if err := json.Unmarshal([]byte(row.ErrorBreakdown), &report.ErrorBreakdown); err != nil {
+		return models.LoadTestReport{}, errors.Wrap(errors.ErrLoadTestsRetrieval, err)
+	}
+
+	return report, nil
+}
+
+func NewLoadTestsRepository(db *sqlx.DB) *LoadTestsRepository {
+	return &LoadTestsRepository{db: db}
+}
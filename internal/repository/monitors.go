@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type MonitorsRepository struct {
+	db *sqlx.DB
+}
+
+const monitorColumns = `
+	id, collection_id, folder_id, environment_id, name, interval_minutes, enabled, consecutive_failures,
+	COALESCE(last_status, '') AS last_status, last_run_id, last_run_at, created_at`
+
+// GetMonitors returns every monitor, enabled or not, oldest first.
+func (r *MonitorsRepository) GetMonitors() ([]models.Monitor, error) {
+	monitors := []models.Monitor{}
+	if err := r.db.Select(&monitors, `SELECT `+monitorColumns+` FROM monitors ORDER BY id ASC`); err != nil {
+		return nil, errors.Wrap(errors.ErrMonitorsRetrieval, err)
+	}
+
+	return monitors, nil
+}
+
+func (r *MonitorsRepository) GetMonitor(id int) (models.Monitor, error) {
+	var monitor models.Monitor
+	if err := r.db.Get(&monitor, `SELECT `+monitorColumns+` FROM monitors WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Monitor{}, errors.Wrap(errors.ErrMonitorNotFound, err)
+		}
+		return models.Monitor{}, errors.Wrap(errors.ErrMonitorsRetrieval, err)
+	}
+
+	return monitor, nil
+}
+
+// InsertMonitor stores a new monitor and returns its id.
+func (r *MonitorsRepository) InsertMonitor(m models.Monitor) (int, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO monitors (collection_id, folder_id, environment_id, name, interval_minutes, enabled)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		m.CollectionID, m.FolderID, m.EnvironmentID, m.Name, m.IntervalMinutes, m.Enabled)
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrMonitorSave, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrMonitorSave, err)
+	}
+
+	return int(id), nil
+}
+
+// UpdateMonitor changes what a monitor runs and when. Its run state is kept.
+func (r *MonitorsRepository) UpdateMonitor(m models.Monitor) error {
+	res, err := r.db.Exec(`
+		UPDATE monitors SET collection_id = ?, folder_id = ?, environment_id = ?, name = ?, interval_minutes = ?, enabled = ?
+		WHERE id = ?`,
+		m.CollectionID, m.FolderID, m.EnvironmentID, m.Name, m.IntervalMinutes, m.Enabled, m.ID)
+	if err != nil {
+		return errors.Wrap(errors.ErrMonitorSave, err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Wrap(errors.ErrMonitorNotFound, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *MonitorsRepository) DeleteMonitor(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM monitors WHERE id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrMonitorDeletion, err)
+	}
+
+	return nil
+}
+
+// RecordMonitorRun stores the outcome of a scheduled run: a failure adds to the consecutive failures,
+// a pass resets them. It returns the monitor as updated.
+func (r *MonitorsRepository) RecordMonitorRun(id, runID int, passed bool, at time.Time) (models.Monitor, error) {
+	status := models.MonitorStatusFailing
+	if passed {
+		status = models.MonitorStatusPassing
+	}
+
+	// A run that could not start has no id; the monitor keeps pointing at its last stored run.
+	var lastRunID *int
+	if runID != 0 {
+		lastRunID = &runID
+	}
+
+	if _, err := r.db.Exec(`
+		UPDATE monitors SET
+			consecutive_failures = CASE WHEN ? THEN 0 ELSE consecutive_failures + 1 END,
+			last_status = ?, last_run_id = COALESCE(?, last_run_id), last_run_at = ?
+		WHERE id = ?`,
+		passed, status, lastRunID, at.UTC(), id); err != nil {
+		return models.Monitor{}, errors.Wrap(errors.ErrMonitorSave, err)
+	}
+
+	return r.GetMonitor(id)
+}
+
+func NewMonitorsRepository(db *sqlx.DB) *MonitorsRepository {
+	return &MonitorsRepository{db: db}
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type RequestsRepository struct {
+	db *sqlx.DB
+}
+
+// GetRequest returns a single request without its headers, params, cookies or scripts.
+func (r *RequestsRepository) GetRequest(id int) (models.Request, error) {
+	var req models.Request
+	query := `
+		SELECT id, collection_id, folder_id, position, name, method, url,
+			COALESCE(body, '') AS body, COALESCE(body_format, 'JSON') AS body_format, COALESCE(notes, '') AS notes,
+			timeout, allow_redirects, ssl_verification, remove_referer_on_redirect, encode_url,
+			created_at, updated_at
+		FROM requests
+		WHERE id = ?`
+
+	if err := r.db.Get(&req, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Request{}, errors.Wrap(errors.ErrRequestNotFound, err)
+		}
+		return models.Request{}, errors.Wrap(errors.ErrRequestRetrieval, err)
+	}
+
+	return req, nil
+}
+
+// GetRequestWithDetail returns a request together with its headers, query params, cookies and scripts.
+func (r *RequestsRepository) GetRequestWithDetail(id int) (models.RequestWithDetail, error) {
+	req, err := r.GetRequest(id)
+	if err != nil {
+		return models.RequestWithDetail{}, err
+	}
+
+	detail := models.RequestWithDetail{
+		Request:        req,
+		Headers:        []models.RequestHeader{},
+		QueryParams:    []models.RequestQueryParam{},
+		Cookies:        []models.RequestCookie{},
+		Scripts:        []models.RequestScript{},
+		Assertions:     []models.RequestAssertion{},
+		SkipConditions: []models.SkipCondition{},
+	}
+
+	if err := r.db.Select(&detail.Headers, `
+		SELECT id, request_id, key, COALESCE(value, '') AS value
+		FROM request_headers
+		WHERE request_id = ?
+		ORDER BY id ASC`, id); err != nil {
+		return models.RequestWithDetail{}, errors.Wrap(errors.ErrRequestDetailsRetrieval, err)
+	}
+
+	if err := r.db.Select(&detail.QueryParams, `
+		SELECT id, request_id, key, COALESCE(value, '') AS value
+		FROM request_query_params
+		WHERE request_id = ?
+		ORDER BY id ASC`, id); err != nil {
+		return models.RequestWithDetail{}, errors.Wrap(errors.ErrRequestDetailsRetrieval, err)
+	}
+
+	if err := r.db.Select(&detail.Cookies, `
+		SELECT id, request_id, key, COALESCE(value, '') AS value
+		FROM request_cookies
+		WHERE request_id = ?
+		ORDER BY id ASC`, id); err != nil {
+		return models.RequestWithDetail{}, errors.Wrap(errors.ErrRequestDetailsRetrieval, err)
+	}
+
+	if err := r.db.Select(&detail.Scripts, `
+		SELECT id, request_id, COALESCE(phase, 'pre-request') AS phase, script
+		FROM request_scripts
+		WHERE request_id = ?
+		ORDER BY id ASC`, id); err != nil {
+		return models.RequestWithDetail{}, errors.Wrap(errors.ErrRequestDetailsRetrieval, err)
+	}
+
+	assertions, err := r.GetRequestAssertions(id)
+	if err != nil {
+		return models.RequestWithDetail{}, err
+	}
+	detail.Assertions = assertions
+
+	conditions, err := r.GetRequestSkipConditions(id)
+	if err != nil {
+		return models.RequestWithDetail{}, err
+	}
+	detail.SkipConditions = conditions
+
+	return detail, nil
+}
+
+// GetRequestAssertions returns the assertions of a request in evaluation order.
+func (r *RequestsRepository) GetRequestAssertions(requestID int) ([]models.RequestAssertion, error) {
+	assertions := []models.RequestAssertion{}
+	query := `
+		SELECT id, request_id, position, COALESCE(name, '') AS name, type, COALESCE(target, '') AS target,
+			operator, COALESCE(expected, '') AS expected, enabled
+		FROM request_assertions
+		WHERE request_id = ?
+		ORDER BY position ASC, id ASC`
+
+	if err := r.db.Select(&assertions, query, requestID); err != nil {
+		return nil, errors.Wrap(errors.ErrRequestAssertionsRetrieval, err)
+	}
+
+	return assertions, nil
+}
+
+// ReplaceRequestAssertions replaces every assertion of a request with the given list, keeping its order.
+func (r *RequestsRepository) ReplaceRequestAssertions(requestID int, assertions []models.RequestAssertion) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.Wrap(errors.ErrRequestAssertionsSave, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM request_assertions WHERE request_id = ?`, requestID); err != nil {
+		return errors.Wrap(errors.ErrRequestAssertionsSave, err)
+	}
+
+	if err := insertAssertions(tx, requestID, assertions); err != nil {
+		return errors.Wrap(errors.ErrRequestAssertionsSave, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(errors.ErrRequestAssertionsSave, err)
+	}
+
+	return nil
+}
+
+func insertAssertions(tx *sqlx.Tx, requestID int, assertions []models.RequestAssertion) error {
+	for i, a := range assertions {
+		_, err := tx.Exec(`
+			INSERT INTO request_assertions (request_id, position, name, type, target, operator, expected, enabled)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			requestID, i+1, nullIfEmpty(a.Name), a.Type, nullIfEmpty(a.Target), a.Operator, a.Expected, a.Enabled)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRequestSkipConditions returns the skip conditions of a request in order.
+func (r *RequestsRepository) GetRequestSkipConditions(requestID int) ([]models.SkipCondition, error) {
+	conditions := []models.SkipCondition{}
+	query := `
+		SELECT id, request_id, position, variable, operator, COALESCE(value, '') AS value, enabled
+		FROM request_skip_conditions
+		WHERE request_id = ?
+		ORDER BY position ASC, id ASC`
+
+	if err := r.db.Select(&conditions, query, requestID); err != nil {
+		return nil, errors.Wrap(errors.ErrSkipConditionsRetrieval, err)
+	}
+
+	return conditions, nil
+}
+
+// ReplaceRequestSkipConditions replaces every skip condition of a request with the given list, keeping its order.
+func (r *RequestsRepository) ReplaceRequestSkipConditions(requestID int, conditions []models.SkipCondition) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.Wrap(errors.ErrSkipConditionsSave, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM request_skip_conditions WHERE request_id = ?`, requestID); err != nil {
+		return errors.Wrap(errors.ErrSkipConditionsSave, err)
+	}
+
+	if err := insertSkipConditions(tx, requestID, conditions); err != nil {
+		return errors.Wrap(errors.ErrSkipConditionsSave, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(errors.ErrSkipConditionsSave, err)
+	}
+
+	return nil
+}
+
+func insertSkipConditions(tx *sqlx.Tx, requestID int, conditions []models.SkipCondition) error {
+	for i, c := range conditions {
+		_, err := tx.Exec(`
+			INSERT INTO request_skip_conditions (request_id, position, variable, operator, value, enabled)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			requestID, i+1, c.Variable, c.Operator, nullIfEmpty(c.Value), c.Enabled)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindRequestIDByName looks a request up by its name.
+// When collectionID is set, requests of that collection win over identically named ones elsewhere.
+func (r *RequestsRepository) FindRequestIDByName(name string, collectionID *int) (int, error) {
+	var id int
+	query := `
+		SELECT id
+		FROM requests
+		WHERE name = ?
+		ORDER BY CASE WHEN collection_id IS ? THEN 0 ELSE 1 END, position ASC
+		LIMIT 1`
+
+	if err := r.db.Get(&id, query, name, collectionID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.Wrap(errors.ErrRequestNotFound, err)
+		}
+		return 0, errors.Wrap(errors.ErrRequestRetrieval, err)
+	}
+
+	return id, nil
+}
+
+// GetRequestExamples returns the saved examples of a request, oldest first.
+func (r *RequestsRepository) GetRequestExamples(requestID int) ([]models.RequestExample, error) {
+	examples := []models.RequestExample{}
+	query := `
+		SELECT id, request_id, timestamp, method, url,
+			COALESCE(headers, '') AS headers, COALESCE(query_params, '') AS query_params, COALESCE(body, '') AS body,
+			COALESCE(status_code, 0) AS status_code, COALESCE(response, '') AS response,
+			COALESCE(response_headers, '') AS response_headers, COALESCE(response_cookies, '') AS response_cookies,
+			COALESCE(response_time, 0) AS response_time, COALESCE(data_volume, 0) AS data_volume
+		FROM request_examples
+		WHERE request_id = ?
+		ORDER BY timestamp ASC, id ASC`
+
+	if err := r.db.Select(&examples, query, requestID); err != nil {
+		return nil, errors.Wrap(errors.ErrRequestExamplesRetrieval, err)
+	}
+
+	return examples, nil
+}
+
+// GetRequestExample returns a single saved example.
+func (r *RequestsRepository) GetRequestExample(id int) (models.RequestExample, error) {
+	var example models.RequestExample
+	query := `
+		SELECT id, request_id, timestamp, method, url,
+			COALESCE(headers, '') AS headers, COALESCE(query_params, '') AS query_params, COALESCE(body, '') AS body,
+			COALESCE(status_code, 0) AS status_code, COALESCE(response, '') AS response,
+			COALESCE(response_headers, '') AS response_headers, COALESCE(response_cookies, '') AS response_cookies,
+			COALESCE(response_time, 0) AS response_time, COALESCE(data_volume, 0) AS data_volume
+		FROM request_examples
+		WHERE id = ?`
+
+	if err := r.db.Get(&example, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.RequestExample{}, errors.Wrap(errors.ErrRequestExampleNotFound, err)
+		}
+		return models.RequestExample{}, errors.Wrap(errors.ErrRequestExamplesRetrieval, err)
+	}
+
+	return example, nil
+}
+
+// GetRequestExamplesByIDs returns the examples with the given ids; unknown ids are skipped.
+func (r *RequestsRepository) GetRequestExamplesByIDs(ids []int) ([]models.RequestExample, error) {
+	examples := []models.RequestExample{}
+	if len(ids) == 0 {
+		return examples, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT id, request_id, timestamp, method, url,
+			COALESCE(headers, '') AS headers, COALESCE(query_params, '') AS query_params, COALESCE(body, '') AS body,
+			COALESCE(status_code, 0) AS status_code, COALESCE(response, '') AS response,
+			COALESCE(response_headers, '') AS response_headers, COALESCE(response_cookies, '') AS response_cookies,
+			COALESCE(response_time, 0) AS response_time, COALESCE(data_volume, 0) AS data_volume
+		FROM request_examples
+		WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrRequestExamplesRetrieval, err)
+	}
+
+	if err := r.db.Select(&examples, r.db.Rebind(query), args...); err != nil {
+		return nil, errors.Wrap(errors.ErrRequestExamplesRetrieval, err)
+	}
+
+	return examples, nil
+}
+
+// InsertRequestExample saves an example and returns its id.
+func (r *RequestsRepository) InsertRequestExample(e models.RequestExample) (int, error) {
+	query := `
+		INSERT INTO request_examples
+		(request_id, timestamp, method, url, headers, query_params, body, status_code, response, response_headers, response_cookies, response_time, data_volume)
+		VALUES (:request_id, :timestamp, :method, :url, :headers, :query_params, :body, :status_code, :response,
+			:response_headers, :response_cookies, :response_time, :data_volume)`
+
+	res, err := r.db.NamedExec(query, e)
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrRequestExampleSave, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrRequestExampleSave, err)
+	}
+
+	return int(id), nil
+}
+
+func NewRequestsRepository(db *sqlx.DB) *RequestsRepository {
+	return &RequestsRepository{db: db}
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type ScriptModulesRepository struct {
+	db *sqlx.DB
+}
+
+// GetScriptModules returns every script module of a collection.
+func (r *ScriptModulesRepository) GetScriptModules(collectionID int) ([]models.ScriptModule, error) {
+	modules := []models.ScriptModule{}
+	query := `
+		SELECT id, collection_id, name, script, version, created_at, updated_at
+		FROM collection_script_modules
+		WHERE collection_id = ?
+		ORDER BY name ASC`
+
+	if err := r.db.Select(&modules, query, collectionID); err != nil {
+		return nil, errors.Wrap(errors.ErrScriptModulesRetrieval, err)
+	}
+
+	return modules, nil
+}
+
+// GetScriptModule returns a collection's script module by its name.
+func (r *ScriptModulesRepository) GetScriptModule(collectionID int, name string) (models.ScriptModule, error) {
+	var module models.ScriptModule
+	query := `
+		SELECT id, collection_id, name, script, version, created_at, updated_at
+		FROM collection_script_modules
+		WHERE collection_id = ? AND name = ?`
+
+	if err := r.db.Get(&module, query, collectionID, name); err != nil {
+		if err == sql.ErrNoRows {
+			return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModuleNotFound, err)
+		}
+		return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModulesRetrieval, err)
+	}
+
+	return module, nil
+}
+
+// SaveScriptModule creates a module or, when one with the same name exists in the collection, replaces its script.
+// Every save bumps the module's version and touches the collection so both are seen as changed.
+func (r *ScriptModulesRepository) SaveScriptModule(module models.ScriptModule) (models.ScriptModule, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModuleSave, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO collection_script_modules (collection_id, name, script)
+		VALUES (?, ?, ?)
+		ON CONFLICT (collection_id, name) DO UPDATE SET
+			script = excluded.script,
+			version = version + 1,
+			updated_at = CURRENT_TIMESTAMP`,
+		module.CollectionID, module.Name, module.Script)
+	if err != nil {
+		return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModuleSave, err)
+	}
+
+	if _, err := tx.Exec(`UPDATE collections SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, module.CollectionID); err != nil {
+		return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModuleSave, err)
+	}
+
+	var saved models.ScriptModule
+	if err := tx.Get(&saved, `
+		SELECT id, collection_id, name, script, version, created_at, updated_at
+		FROM collection_script_modules
+		WHERE collection_id = ? AND name = ?`, module.CollectionID, module.Name); err != nil {
+		return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModuleSave, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ScriptModule{}, errors.Wrap(errors.ErrScriptModuleSave, err)
+	}
+
+	return saved, nil
+}
+
+// DeleteScriptModule removes a script module.
+func (r *ScriptModulesRepository) DeleteScriptModule(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM collection_script_modules WHERE id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrScriptModuleDeletion, err)
+	}
+
+	return nil
+}
+
+func NewScriptModulesRepository(db *sqlx.DB) *ScriptModulesRepository {
+	return &ScriptModulesRepository{db: db}
+}
@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportSpec stores a collection generated from an API description, inside a single transaction.
+//
+// With collectionID 0 a new collection is created. Otherwise the description is merged into that collection:
+// operations imported before are updated only when their request was not edited since, new operations are
+// added to the folder of their tag, and requests of operations that are gone are left in place.
+// Existing collection and environment variables keep their values.
+func (r *CollectionsRepository) ImportSpec(spec models.SpecImport, collectionID int) (models.ImportReport, error) {
+	report := models.ImportReport{Source: spec.Source, Name: spec.Collection.Name, Warnings: []models.ImportWarning{}}
+
+	// The requests of an earlier import are read before the transaction, to tell which were edited since.
+	sources := map[string]models.RequestSource{}
+	current := map[int]models.RequestWithDetail{}
+	if collectionID != 0 {
+		if err := r.db.Get(&report.Name, `SELECT name FROM collections WHERE id = ?`, collectionID); err != nil {
+			if err == sql.ErrNoRows {
+				return models.ImportReport{}, errors.Wrap(errors.ErrImportTargetNotFound, fmt.Errorf("collection %d", collectionID))
+			}
+			return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+
+		var rows []models.RequestSource
+		if err := r.db.Select(&rows, `
+			SELECT request_id, collection_id, source, operation, fingerprint
+			FROM request_sources
+			WHERE collection_id = ? AND source = ?`, collectionID, spec.Source); err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+
+		requests := NewRequestsRepository(r.db)
+		for _, row := range rows {
+			detail, err := requests.GetRequestWithDetail(row.RequestID)
+			if err != nil {
+				continue // the request was deleted, the operation is imported again
+			}
+			sources[row.Operation] = row
+			current[row.RequestID] = detail
+		}
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	defer tx.Rollback()
+
+	if collectionID == 0 {
+		res, err := tx.Exec(`
+			INSERT INTO collections (name, description, position)
+			VALUES (?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM collections))`,
+			spec.Collection.Name, spec.Collection.Description)
+		if err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+		collectionID = int(id)
+	}
+	report.CollectionID = collectionID
+
+	for _, v := range spec.Variables {
+		res, err := tx.Exec(`
+			INSERT OR IGNORE INTO collection_variables (collection_id, key, value) VALUES (?, ?, ?)`,
+			collectionID, v.Key, v.Value)
+		if err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			report.Variables++
+		}
+	}
+
+	for _, schema := range spec.Schemas {
+		schema.CollectionID = collectionID
+		if err := upsertCollectionSchema(tx, schema); err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	for _, env := range spec.Environments {
+		if err := mergeEnvironment(tx, env); err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+		}
+	}
+
+	folders := map[string]int{}
+	var rows []models.Folder
+	if err := tx.Select(&rows, `SELECT id, name FROM folders WHERE collection_id = ? ORDER BY position ASC`, collectionID); err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	for _, f := range rows {
+		if _, ok := folders[f.Name]; !ok {
+			folders[f.Name] = f.ID
+		}
+	}
+
+	var position int
+	if err := tx.Get(&position, `SELECT COALESCE(MAX(position), 0) FROM requests WHERE collection_id = ?`, collectionID); err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	seen := map[string]bool{}
+	for _, op := range spec.Operations {
+		seen[op.Key] = true
+		fingerprint := requestFingerprint(op.Request.RequestWithDetail)
+
+		source, imported := sources[op.Key]
+		if !imported {
+			var folderID *int
+			if op.Folder != "" {
+				id, err := folderFor(tx, collectionID, folders, op.Folder)
+				if err != nil {
+					return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+				}
+				folderID = &id
+			}
+
+			position++
+			requestID, err := insertRequestExport(tx, collectionID, folderID, position, op.Request)
+			if err != nil {
+				return models.ImportReport{}, err
+			}
+
+			if err := saveRequestSource(tx, requestID, collectionID, spec.Source, op.Key, fingerprint); err != nil {
+				return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+			}
+
+			report.Requests++
+			report.Examples += len(op.Request.Examples)
+			continue
+		}
+
+		edited := requestFingerprint(current[source.RequestID]) != source.Fingerprint
+		switch {
+		case edited:
+			report.Kept++
+			if fingerprint != source.Fingerprint {
+				report.Warnings = append(report.Warnings, models.ImportWarning{
+					Item:    op.Key,
+					Message: "the request was edited since the last import, so the changes to this operation were not applied",
+				})
+			}
+		case fingerprint == source.Fingerprint:
+			report.Unchanged++
+		default:
+			if err := updateSpecRequest(tx, source.RequestID, op.Request.RequestWithDetail); err != nil {
+				return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+			}
+			if err := saveRequestSource(tx, source.RequestID, collectionID, spec.Source, op.Key, fingerprint); err != nil {
+				return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+			}
+			report.Updated++
+		}
+	}
+
+	var removed []string
+	for key := range sources {
+		if !seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	for _, key := range removed {
+		report.Warnings = append(report.Warnings, models.ImportWarning{
+			Item:    key,
+			Message: "the operation is no longer described, its request was kept",
+		})
+	}
+
+	var folderCount int
+	if err := tx.Get(&folderCount, `SELECT COUNT(*) FROM folders WHERE collection_id = ?`, collectionID); err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+	report.Folders = folderCount
+
+	if err := tx.Commit(); err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrCollectionImport, err)
+	}
+
+	return report, nil
+}
+
+// requestFingerprint hashes the parts of a request an import generates: name, method, URL, body, notes,
+// headers, query parameters and cookies. Scripts, assertions and settings are not part of it, so adding
+// tests to an imported request does not keep it from being updated.
+func requestFingerprint(req models.RequestWithDetail) string {
+	type pair struct{ K, V string }
+
+	pairs := func(n int, at func(int) (string, string), sorted bool) []pair {
+		out := make([]pair, n)
+		for i := range out {
+			out[i].K, out[i].V = at(i)
+		}
+		if sorted {
+			sort.Slice(out, func(i, j int) bool { return out[i].K < out[j].K })
+		}
+		return out
+	}
+
+	bodyFormat := req.BodyFormat
+	if bodyFormat == "" {
+		bodyFormat = "raw"
+	}
+
+	encoded, _ := json.Marshal(struct {
+		Name, Method, URL, Body, BodyFormat, Notes string
+		Headers, Query, Cookies                    []pair
+	}{
+		Name: req.Name, Method: req.Method, URL: req.URL, Body: req.Body, BodyFormat: bodyFormat, Notes: req.Notes,
+		Headers: pairs(len(req.Headers), func(i int) (string, string) { return req.Headers[i].Key, req.Headers[i].Value }, true),
+		Query:   pairs(len(req.QueryParams), func(i int) (string, string) { return req.QueryParams[i].Key, req.QueryParams[i].Value }, false),
+		Cookies: pairs(len(req.Cookies), func(i int) (string, string) { return req.Cookies[i].Key, req.Cookies[i].Value }, true),
+	})
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// folderFor returns the id of the folder called name, creating it after the collection's other folders.
+func folderFor(tx *sqlx.Tx, collectionID int, folders map[string]int, name string) (int, error) {
+	if id, ok := folders[name]; ok {
+		return id, nil
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO folders (collection_id, name, position)
+		VALUES (?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM folders WHERE collection_id = ?))`,
+		collectionID, name, collectionID)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	folders[name] = int(id)
+	return int(id), nil
+}
+
+func saveRequestSource(tx *sqlx.Tx, requestID, collectionID int, source, operation, fingerprint string) error {
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO request_sources (request_id, collection_id, source, operation, fingerprint, imported_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		requestID, collectionID, source, operation, fingerprint)
+	return err
+}
+
+// updateSpecRequest replaces the generated parts of an imported request, leaving its scripts, assertions,
+// examples and settings alone.
+func updateSpecRequest(tx *sqlx.Tx, requestID int, req models.RequestWithDetail) error {
+	bodyFormat := req.BodyFormat
+	if bodyFormat == "" {
+		bodyFormat = "raw"
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE requests
+		SET name = ?, method = ?, url = ?, body = ?, body_format = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		req.Name, req.Method, req.URL, nullIfEmpty(req.Body), bodyFormat, nullIfEmpty(req.Notes), requestID); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"request_headers", "request_query_params", "request_cookies"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE request_id = ?`, requestID); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range req.Headers {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO request_headers (request_id, key, value) VALUES (?, ?, ?)`, requestID, h.Key, h.Value); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range req.QueryParams {
+		if _, err := tx.Exec(`INSERT INTO request_query_params (request_id, key, value) VALUES (?, ?, ?)`, requestID, p.Key, p.Value); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range req.Cookies {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO request_cookies (request_id, key, value) VALUES (?, ?, ?)`, requestID, c.Key, c.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeEnvironment creates an environment, matched by name, and adds the variables it does not have yet.
+func mergeEnvironment(tx *sqlx.Tx, env models.EnvironmentExport) error {
+	var id int
+	err := tx.Get(&id, `SELECT id FROM environments WHERE name = ? ORDER BY id LIMIT 1`, env.Environment.Name)
+	if err == sql.ErrNoRows {
+		res, err := tx.Exec(`INSERT INTO environments (name) VALUES (?)`, env.Environment.Name)
+		if err != nil {
+			return err
+		}
+
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id = int(lastID)
+	} else if err != nil {
+		return err
+	}
+
+	for _, v := range env.Variables {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO environment_variables (environment_id, key, value) VALUES (?, ?, ?)`,
+			id, v.Key, v.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type TestResultsRepository struct {
+	db *sqlx.DB
+}
+
+// InsertTestResults stores the test and assertion results of one execution.
+func (r *TestResultsRepository) InsertTestResults(results []models.TestResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO test_results (request_id, history_id, source, test_name, passed, result, diff)
+		VALUES (:request_id, :history_id, :source, :test_name, :passed, :result, :diff)`
+
+	if _, err := r.db.NamedExec(query, results); err != nil {
+		return errors.Wrap(errors.ErrTestResultsInsertion, err)
+	}
+
+	return nil
+}
+
+// GetTestResultsByHistory returns the results produced by a single execution.
+func (r *TestResultsRepository) GetTestResultsByHistory(historyID int) ([]models.TestResult, error) {
+	results := []models.TestResult{}
+	query := `
+		SELECT id, request_id, history_id, COALESCE(source, 'script') AS source, test_name, passed,
+			COALESCE(result, '') AS result, COALESCE(diff, '') AS diff, created_at
+		FROM test_results
+		WHERE history_id = ?
+		ORDER BY id ASC`
+
+	if err := r.db.Select(&results, query, historyID); err != nil {
+		return nil, errors.Wrap(errors.ErrTestResultsRetrieval, err)
+	}
+
+	return results, nil
+}
+
+// GetTestResultsByHistoryIDs returns the results produced by the given executions, in insertion order.
+func (r *TestResultsRepository) GetTestResultsByHistoryIDs(historyIDs []int) ([]models.TestResult, error) {
+	results := []models.TestResult{}
+	if len(historyIDs) == 0 {
+		return results, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT id, request_id, history_id, COALESCE(source, 'script') AS source, test_name, passed,
+			COALESCE(result, '') AS result, COALESCE(diff, '') AS diff, created_at
+		FROM test_results
+		WHERE history_id IN (?)
+		ORDER BY id ASC`, historyIDs)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrTestResultsRetrieval, err)
+	}
+
+	if err := r.db.Select(&results, r.db.Rebind(query), args...); err != nil {
+		return nil, errors.Wrap(errors.ErrTestResultsRetrieval, err)
+	}
+
+	return results, nil
+}
+
+func NewTestResultsRepository(db *sqlx.DB) *TestResultsRepository {
+	return &TestResultsRepository{db: db}
+}
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type WorkflowsRepository struct {
+	db *sqlx.DB
+}
+
+// workflowEdge is a row of workflow_edges with the names of the nodes it connects.
+type workflowEdge struct {
+	ID   int    `db:"id"`
+	From string `db:"from_name"`
+	To   string `db:"to_name"`
+}
+
+// workflowMapping is a row of workflow_mappings.
+type workflowMapping struct {
+	EdgeID int    `db:"edge_id"`
+	Source string `db:"source"`
+	Target string `db:"target"`
+}
+
+// workflowRun is a row of workflow_runs.
+type workflowRun struct {
+	ID            int        `db:"id"`
+	WorkflowID    int        `db:"workflow_id"`
+	Name          string     `db:"name"`
+	EnvironmentID *int       `db:"environment_id"`
+	Status        string     `db:"status"`
+	Passed        int        `db:"passed"`
+	Failed        int        `db:"failed"`
+	Skipped       int        `db:"skipped"`
+	Nodes         string     `db:"nodes"`
+	Duration      int        `db:"duration"`
+	StartedAt     time.Time  `db:"started_at"`
+	FinishedAt    *time.Time `db:"finished_at"`
+}
+
+const workflowColumns = `id, name, COALESCE(description, '') AS description, environment_id, created_at, updated_at`
+
+const workflowRunColumns = `
+	run.id, run.workflow_id, w.name, run.environment_id, run.status, run.passed, run.failed, run.skipped,
+	COALESCE(run.nodes, '[]') AS nodes, run.duration, run.started_at, run.finished_at`
+
+// GetWorkflows returns every workflow by name, without its nodes and edges.
+func (r *WorkflowsRepository) GetWorkflows() ([]models.Workflow, error) {
+	workflows := []models.Workflow{}
+	if err := r.db.Select(&workflows, `SELECT `+workflowColumns+` FROM workflows ORDER BY name COLLATE NOCASE ASC, id ASC`); err != nil {
+		return nil, errors.Wrap(errors.ErrWorkflowsRetrieval, err)
+	}
+
+	return workflows, nil
+}
+
+// GetWorkflow returns a workflow with its nodes, in the order they were saved, and its edges with their mappings.
+func (r *WorkflowsRepository) GetWorkflow(id int) (models.Workflow, error) {
+	var workflow models.Workflow
+	if err := r.db.Get(&workflow, `SELECT `+workflowColumns+` FROM workflows WHERE id = ?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Workflow{}, errors.Wrap(errors.ErrWorkflowNotFound, err)
+		}
+		return models.Workflow{}, errors.Wrap(errors.ErrWorkflowsRetrieval, err)
+	}
+
+	workflow.Nodes = []models.WorkflowNode{}
+	if err := r.db.Select(&workflow.Nodes, `
+		SELECT id, workflow_id, COALESCE(request_id, 0) AS request_id, name, COALESCE(position_x, 0) AS position_x, COALESCE(position_y, 0) AS position_y
+		FROM workflow_nodes
+		WHERE workflow_id = ?
+		ORDER BY id ASC`, id); err != nil {
+		return models.Workflow{}, errors.Wrap(errors.ErrWorkflowsRetrieval, err)
+	}
+
+	edges := []workflowEdge{}
+	if err := r.db.Select(&edges, `
+		SELECT e.id, src.name AS from_name, dst.name AS to_name
+		FROM workflow_edges e
+		JOIN workflow_nodes src ON src.id = e.from_node_id
+		JOIN workflow_nodes dst ON dst.id = e.to_node_id
+		WHERE e.workflow_id = ?
+		ORDER BY e.id ASC`, id); err != nil {
+		return models.Workflow{}, errors.Wrap(errors.ErrWorkflowsRetrieval, err)
+	}
+
+	mappings := []workflowMapping{}
+	if err := r.db.Select(&mappings, `
+		SELECT m.edge_id, m.source, m.target
+		FROM workflow_mappings m
+		JOIN workflow_edges e ON e.id = m.edge_id
+		WHERE e.workflow_id = ?
+		ORDER BY m.edge_id ASC, m.position ASC`, id); err != nil {
+		return models.Workflow{}, errors.Wrap(errors.ErrWorkflowsRetrieval, err)
+	}
+
+	byEdge := map[int][]models.WorkflowMapping{}
+	for _, m := range mappings {
+		byEdge[m.EdgeID] = append(byEdge[m.EdgeID], models.WorkflowMapping{Source: m.Source, Target: m.Target})
+	}
+
+	workflow.Edges = make([]models.WorkflowEdge, len(edges))
+	for i, e := range edges {
+		workflow.Edges[i] = models.WorkflowEdge{ID: e.ID, From: e.From, To: e.To, Mappings: byEdge[e.ID]}
+		if workflow.Edges[i].Mappings == nil {
+			workflow.Edges[i].Mappings = []models.WorkflowMapping{}
+		}
+	}
+
+	return workflow, nil
+}
+
+// SaveWorkflow creates the workflow when it has no id and updates it otherwise. Its nodes, edges and mappings
+// replace the stored ones; nodes keep their ids when they have one. It returns the workflow's id.
+func (r *WorkflowsRepository) SaveWorkflow(w models.Workflow) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrWorkflowSave, err)
+	}
+	defer tx.Rollback()
+
+	id := w.ID
+	if id == 0 {
+		res, err := tx.Exec(`INSERT INTO workflows (name, description, environment_id) VALUES (?, ?, ?)`,
+			w.Name, nullIfEmpty(w.Description), w.EnvironmentID)
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrWorkflowSave, err)
+		}
+
+		inserted, err := res.LastInsertId()
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrWorkflowSave, err)
+		}
+		id = int(inserted)
+	} else {
+		res, err := tx.Exec(`
+			UPDATE workflows SET name = ?, description = ?, environment_id = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`,
+			w.Name, nullIfEmpty(w.Description), w.EnvironmentID, id)
+		if err != nil {
+			return 0, errors.Wrap(errors.ErrWorkflowSave, err)
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return 0, errors.Wrap(errors.ErrWorkflowNotFound, sql.ErrNoRows)
+		}
+
+		if err := deleteWorkflowGraph(tx, id); err != nil {
+			return 0, errors.Wrap(errors.ErrWorkflowSave, err)
+		}
+	}
+
+	if err := insertWorkflowGraph(tx, id, w); err != nil {
+		return 0, errors.Wrap(errors.ErrWorkflowSave, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(errors.ErrWorkflowSave, err)
+	}
+
+	return id, nil
+}
+
+func insertWorkflowGraph(tx *sqlx.Tx, workflowID int, w models.Workflow) error {
+	nodeIDs := make(map[string]int, len(w.Nodes))
+	for _, n := range w.Nodes {
+		res, err := tx.Exec(`
+			INSERT INTO workflow_nodes (id, workflow_id, request_id, name, position_x, position_y)
+			VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?)`,
+			n.ID, workflowID, n.RequestID, n.Name, n.PositionX, n.PositionY)
+		if err != nil {
+			return err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		nodeIDs[n.Name] = int(id)
+	}
+
+	for _, e := range w.Edges {
+		from, ok := nodeIDs[e.From]
+		if !ok {
+			return fmt.Errorf("edge from unknown node %q", e.From)
+		}
+		to, ok := nodeIDs[e.To]
+		if !ok {
+			return fmt.Errorf("edge to unknown node %q", e.To)
+		}
+
+		res, err := tx.Exec(`INSERT INTO workflow_edges (workflow_id, from_node_id, to_node_id) VALUES (?, ?, ?)`,
+			workflowID, from, to)
+		if err != nil {
+			return err
+		}
+
+		edgeID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for i, m := range e.Mappings {
+			if _, err := tx.Exec(`INSERT INTO workflow_mappings (edge_id, position, source, target) VALUES (?, ?, ?, ?)`,
+				edgeID, i+1, m.Source, m.Target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func deleteWorkflowGraph(tx *sqlx.Tx, workflowID int) error {
+	for _, query := range []string{
+		`DELETE FROM workflow_mappings WHERE edge_id IN (SELECT id FROM workflow_edges WHERE workflow_id = ?)`,
+		`DELETE FROM workflow_edges WHERE workflow_id = ?`,
+		`DELETE FROM workflow_nodes WHERE workflow_id = ?`,
+	} {
+		if _, err := tx.Exec(query, workflowID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteWorkflow removes a workflow with its nodes, edges and runs.
+func (r *WorkflowsRepository) DeleteWorkflow(id int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.Wrap(errors.ErrWorkflowDeletion, err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteWorkflowGraph(tx, id); err != nil {
+		return errors.Wrap(errors.ErrWorkflowDeletion, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM workflow_runs WHERE workflow_id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrWorkflowDeletion, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM workflows WHERE id = ?`, id); err != nil {
+		return errors.Wrap(errors.ErrWorkflowDeletion, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(errors.ErrWorkflowDeletion, err)
+	}
+
+	return nil
+}
+
+// InsertWorkflowRun records a workflow run that just started and returns its id.
+func (r *WorkflowsRepository) InsertWorkflowRun(report models.WorkflowRunReport) (int, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO workflow_runs (workflow_id, environment_id, status, started_at)
+		VALUES (?, ?, ?, ?)`,
+		report.WorkflowID, report.EnvironmentID, report.Status, report.StartedAt.UTC())
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrWorkflowRunInsertion, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrWorkflowRunInsertion, err)
+	}
+
+	return int(id), nil
+}
+
+// SaveWorkflowRun stores the totals and the node results of a workflow run.
+func (r *WorkflowsRepository) SaveWorkflowRun(report models.WorkflowRunReport) error {
+	nodes, err := json.Marshal(report.Nodes)
+	if err != nil {
+		return errors.Wrap(errors.ErrWorkflowRunInsertion, err)
+	}
+
+	var finishedAt *time.Time
+	if report.FinishedAt != nil {
+		t := report.FinishedAt.UTC()
+		finishedAt = &t
+	}
+
+	if _, err := r.db.Exec(`
+		UPDATE workflow_runs SET status = ?, passed = ?, failed = ?, skipped = ?, nodes = ?, duration = ?, finished_at = ?
+		WHERE id = ?`,
+		report.Status, report.Passed, report.Failed, report.Skipped, string(nodes), report.Duration, finishedAt,
+		report.ID); err != nil {
+		return errors.Wrap(errors.ErrWorkflowRunInsertion, err)
+	}
+
+	return nil
+}
+
+// GetWorkflowRuns returns the runs of a workflow, newest first, without their node results.
+func (r *WorkflowsRepository) GetWorkflowRuns(workflowID int) ([]models.WorkflowRunReport, error) {
+	rows := []workflowRun{}
+	query := `SELECT ` + workflowRunColumns + `
+		FROM workflow_runs run
+		JOIN workflows w ON w.id = run.workflow_id
+		WHERE run.workflow_id = ?
+		ORDER BY run.id DESC`
+
+	if err := r.db.Select(&rows, query, workflowID); err != nil {
+		return nil, errors.Wrap(errors.ErrWorkflowRunsRetrieval, err)
+	}
+
+	reports := make([]models.WorkflowRunReport, len(rows))
+	for i, row := range rows {
+		reports[i] = row.report()
+	}
+
+	return reports, nil
+}
+
+// GetWorkflowRun returns a stored workflow run with the inputs and outputs of every node.
+func (r *WorkflowsRepository) GetWorkflowRun(id int) (models.WorkflowRunReport, error) {
+	var row workflowRun
+	query := `SELECT ` + workflowRunColumns + `
+		FROM workflow_runs run
+		JOIN workflows w ON w.id = run.workflow_id
+		WHERE run.id = ?`
+
+	if err := r.db.Get(&row, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.WorkflowRunReport{}, errors.Wrap(errors.ErrWorkflowRunNotFound, err)
+		}
+		return models.WorkflowRunReport{}, errors.Wrap(errors.ErrWorkflowRunsRetrieval, err)
+	}
+
+	report := row.report()
+	if err := json.Unmarshal([]byte(row.Nodes), &report.Nodes); err != nil {
+		return models.WorkflowRunReport{}, errors.Wrap(errors.ErrWorkflowRunsRetrieval, err)
+	}
+
+	return report, nil
+}
+
+func (row workflowRun) report() models.WorkflowRunReport {
+	return models.WorkflowRunReport{
+		ID:            row.ID,
+		WorkflowID:    row.WorkflowID,
+		Name:          row.Name,
+		EnvironmentID: row.EnvironmentID,
+		Status:        row.Status,
+		Passed:        row.Passed,
+		Failed:        row.Failed,
+		Skipped:       row.Skipped,
+		Nodes:         []models.WorkflowNodeResult{},
+		Duration:      row.Duration,
+		StartedAt:     row.StartedAt,
+		FinishedAt:    row.FinishedAt,
+	}
+}
+
+func NewWorkflowsRepository(db *sqlx.DB) *WorkflowsRepository {
+	return &WorkflowsRepository{db: db}
+}
@@ -0,0 +1,165 @@
+package runner
+
+import "github.com/Amir-Zouerami/TAPA/internal/models"
+
+// Compare matches the requests of two runs by iteration, request and, for requests tapa.setNextRequest sent
+// more than once, by occurrence. It reports what changed from base to target:
+// requests and tests that started or stopped failing, status code changes and response time deltas.
+// Requests sent by only one of the runs are listed as added or removed.
+func Compare(base, target models.RunReport) models.RunComparison {
+	baseResults := map[resultKey]models.RunRequestResult{}
+	for _, iteration := range base.Iterations {
+		keys := keysOf(iteration)
+		for i, result := range iteration.Results {
+			baseResults[keys[i]] = result
+		}
+	}
+
+	comparison := models.RunComparison{
+		Base:     summary(base),
+		Target:   summary(target),
+		Requests: []models.RequestComparison{},
+	}
+
+	seen := map[resultKey]bool{}
+	for _, iteration := range target.Iterations {
+		keys := keysOf(iteration)
+		for i, result := range iteration.Results {
+			k := keys[i]
+			seen[k] = true
+
+			before, ok := baseResults[k]
+			if !ok {
+				c := newComparison(iteration.Index, result, models.RunChangeAdded)
+				c.TargetStatus = result.StatusCode
+				c.TargetResponseTime = result.ResponseTime
+				c.FailedTests = failedTests(nil, result.Tests)
+				comparison.Requests = append(comparison.Requests, c)
+				continue
+			}
+
+			c := newComparison(iteration.Index, result, change(before.Passed, result.Passed))
+			c.BaseStatus = before.StatusCode
+			c.TargetStatus = result.StatusCode
+			c.StatusChanged = before.StatusCode != result.StatusCode
+			c.BaseResponseTime = before.ResponseTime
+			c.TargetResponseTime = result.ResponseTime
+			c.ResponseTimeDelta = result.ResponseTime - before.ResponseTime
+			c.FailedTests = failedTests(before.Tests, result.Tests)
+			c.FixedTests = failedTests(result.Tests, before.Tests)
+
+			// Tests that failed before and are gone now were not fixed, only the passing ones count.
+			passing := passedTests(result.Tests)
+			fixed := c.FixedTests[:0]
+			for _, name := range c.FixedTests {
+				if passing[name] {
+					fixed = append(fixed, name)
+				}
+			}
+			c.FixedTests = fixed
+
+			comparison.Requests = append(comparison.Requests, c)
+		}
+	}
+
+	for _, iteration := range base.Iterations {
+		keys := keysOf(iteration)
+		for i, result := range iteration.Results {
+			if seen[keys[i]] {
+				continue
+			}
+
+			c := newComparison(iteration.Index, result, models.RunChangeRemoved)
+			c.BaseStatus = result.StatusCode
+			c.BaseResponseTime = result.ResponseTime
+			comparison.Requests = append(comparison.Requests, c)
+		}
+	}
+
+	for _, c := range comparison.Requests {
+		switch c.Change {
+		case models.RunChangeNewFailure:
+			comparison.NewFailures++
+		case models.RunChangeFixed:
+			comparison.Fixed++
+		}
+		if c.StatusChanged {
+			comparison.StatusChanges++
+		}
+		comparison.FailedTests += len(c.FailedTests)
+		comparison.FixedTests += len(c.FixedTests)
+	}
+
+	return comparison
+}
+
+// resultKey identifies an execution across runs: the nth time a request was sent in an iteration.
+type resultKey struct{ iteration, requestID, occurrence int }
+
+func keysOf(iteration models.RunIteration) []resultKey {
+	counts := map[int]int{}
+	keys := make([]resultKey, len(iteration.Results))
+	for i, result := range iteration.Results {
+		keys[i] = resultKey{iteration.Index, result.RequestID, counts[result.RequestID]}
+		counts[result.RequestID]++
+	}
+	return keys
+}
+
+func newComparison(iteration int, result models.RunRequestResult, change string) models.RequestComparison {
+	return models.RequestComparison{
+		Iteration:   iteration,
+		RequestID:   result.RequestID,
+		Name:        result.Name,
+		Method:      result.Method,
+		Change:      change,
+		FailedTests: []string{},
+		FixedTests:  []string{},
+	}
+}
+
+func change(before, now bool) string {
+	switch {
+	case before && !now:
+		return models.RunChangeNewFailure
+	case !before && now:
+		return models.RunChangeFixed
+	case !now:
+		return models.RunChangeStillFailing
+	}
+	return models.RunChangePassing
+}
+
+// failedTests returns the tests failing in now that did not fail in before, in the order of now.
+func failedTests(before, now []models.TestOutcome) []string {
+	failedBefore := map[string]bool{}
+	for _, test := range before {
+		if !test.Passed {
+			failedBefore[test.Name] = true
+		}
+	}
+
+	names := []string{}
+	for _, test := range now {
+		if !test.Passed && !failedBefore[test.Name] {
+			names = append(names, test.Name)
+		}
+	}
+	return names
+}
+
+func passedTests(tests []models.TestOutcome) map[string]bool {
+	passed := map[string]bool{}
+	for _, test := range tests {
+		if test.Passed {
+			passed[test.Name] = true
+		}
+	}
+	return passed
+}
+
+// summary strips the per-request results off a report.
+func summary(report models.RunReport) models.RunReport {
+	report.Iterations = []models.RunIteration{}
+	return report
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// ParseDataFile reads the rows of a data file. CSV files need a header row naming the variables;
+// JSON files hold an array of flat objects, non-string values become their JSON text.
+func ParseDataFile(format string, content string) ([]map[string]string, error) {
+	switch format {
+	case models.DataFileFormatCSV:
+		return parseCSV(content)
+	case models.DataFileFormatJSON:
+		return parseJSON(content)
+	}
+
+	return nil, fmt.Errorf("unknown data file format %q", format)
+}
+
+// DataFileFormat guesses the format of a data file from its name, falling back to its content.
+func DataFileFormat(name string, content string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".json"):
+		return models.DataFileFormatJSON
+	case strings.HasSuffix(lower, ".csv"):
+		return models.DataFileFormatCSV
+	case strings.HasPrefix(strings.TrimSpace(content), "["):
+		return models.DataFileFormatJSON
+	}
+
+	return models.DataFileFormatCSV
+}
+
+func parseCSV(content string) ([]map[string]string, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the CSV file is empty")
+	}
+
+	header := records[0]
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if header[i] == "" {
+			return nil, fmt.Errorf("column %d has no name", i+1)
+		}
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseJSON(content string) ([]map[string]string, error) {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &items); err != nil {
+		return nil, fmt.Errorf("expected an array of objects: %v", err)
+	}
+
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		row := make(map[string]string, len(item))
+		for key, raw := range item {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				row[key] = s
+				continue
+			}
+
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return nil, err
+			}
+			row[key] = compact.String()
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,205 @@
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// closed is returned to callers that need not wait.
+var closed = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// pacer spaces out requests: at most options.RatePerSecond starts per second and options.DelayMs between requests.
+type pacer struct {
+	interval time.Duration // between request starts, from the rate limit
+	delay    time.Duration // between requests
+	next     time.Time     // the next request may not start before
+}
+
+func newPacer(options models.RunOptions) *pacer {
+	p := &pacer{delay: time.Duration(options.DelayMs) * time.Millisecond}
+	if options.RatePerSecond > 0 {
+		p.interval = time.Duration(float64(time.Second) / options.RatePerSecond)
+	}
+	return p
+}
+
+// hold keeps the next request from starting sooner than d from now.
+func (p *pacer) hold(d time.Duration) {
+	if at := time.Now().Add(d); at.After(p.next) {
+		p.next = at
+	}
+}
+
+// ready returns a channel that is closed once the next request may start.
+func (p *pacer) ready() <-chan struct{} {
+	wait := time.Until(p.next)
+	if wait <= 0 {
+		return closed
+	}
+
+	c := make(chan struct{})
+	time.AfterFunc(wait, func() { close(c) })
+	return c
+}
+
+// slot is a request of a parallel iteration once its outcome is known.
+type slot struct {
+	ready  bool
+	skip   string // the skip condition that held, the request was not sent
+	result models.RunRequestResult
+	next   *models.NextRequest
+}
+
+// completion is a request of a parallel iteration that came back.
+type completion struct {
+	index  int
+	result models.RunRequestResult
+	next   *models.NextRequest
+}
+
+// batch is one iteration of a parallel run. Requests complete in any order, but they are
+// recorded, logged and reported to the listener in plan order, all from the run's goroutine.
+type batch struct {
+	ctx       context.Context
+	run       *Run
+	iteration int
+	slots     []slot
+	done      chan completion
+	inFlight  int
+	flushed   int  // requests of the plan already recorded
+	halted    bool // a failed request stopped the run
+}
+
+// executeParallel sends one iteration of the plan with up to options.Concurrency requests in flight.
+// Skip conditions are evaluated when a request is due; tapa.setNextRequest is ignored. With StopOnFailure no
+// request is started after a failure and requests after the failed one in the plan are left out of the report.
+// It reports whether the run halted on a failure.
+func (r *Runner) executeParallel(ctx context.Context, run *Run, session *executor.Session, iteration int, pace *pacer) bool {
+	b := &batch{
+		ctx:       ctx,
+		run:       run,
+		iteration: iteration,
+		slots:     make([]slot, len(run.plan)),
+		done:      make(chan completion, len(run.plan)),
+	}
+	capacity := make(chan struct{}, run.options.Concurrency)
+
+	for i, request := range run.plan {
+		if !b.await(run.resumed()) || b.halted {
+			break
+		}
+
+		reason, err := r.executor.SkipReason(session, request)
+		if err != nil {
+			// A skip condition that cannot be evaluated fails the request instead of silently sending it.
+			run.emit(models.RunEvent{Type: models.RunEventRequestStarted, Iteration: iteration, Index: i, Request: &request})
+			b.receive(completion{index: i, result: newResult(request, err)})
+			continue
+		}
+		if reason != "" {
+			b.slots[i] = slot{ready: true, skip: reason}
+			b.flush()
+			continue
+		}
+
+		if !b.await(pace.ready()) || b.halted || !b.acquire(capacity) {
+			break
+		}
+		if b.halted {
+			<-capacity
+			break
+		}
+
+		pace.hold(max(pace.interval, pace.delay))
+		run.emit(models.RunEvent{Type: models.RunEventRequestStarted, Iteration: iteration, Index: i, Request: &request})
+
+		b.inFlight++
+		go func(i int, request models.RequestBasic) {
+			result, next := r.send(ctx, session, request)
+			<-capacity
+			b.done <- completion{index: i, result: result, next: next}
+		}(i, request)
+	}
+
+	// Requests in flight complete; a stopped run cancels them and leaves them out of the report.
+	for b.inFlight > 0 {
+		b.inFlight--
+		b.receive(<-b.done)
+	}
+
+	return b.halted
+}
+
+// await waits for ready while recording requests that complete. It returns false when the run was stopped.
+func (b *batch) await(ready <-chan struct{}) bool {
+	for {
+		select {
+		case <-ready:
+			return b.ctx.Err() == nil
+		case c := <-b.done:
+			b.inFlight--
+			b.receive(c)
+		case <-b.ctx.Done():
+			return false
+		}
+	}
+}
+
+// acquire takes a place in capacity while recording requests that complete. It returns false when the run was stopped.
+func (b *batch) acquire(capacity chan struct{}) bool {
+	for {
+		select {
+		case capacity <- struct{}{}:
+			return true
+		case c := <-b.done:
+			b.inFlight--
+			b.receive(c)
+		case <-b.ctx.Done():
+			return false
+		}
+	}
+}
+
+func (b *batch) receive(c completion) {
+	c.result.Iteration = b.iteration
+	b.slots[c.index] = slot{ready: true, result: c.result, next: c.next}
+	b.flush()
+}
+
+// flush records the requests whose outcome is known, up to the first one still in flight.
+func (b *batch) flush() {
+	run := b.run
+
+	for !b.halted && b.flushed < len(b.slots) && b.slots[b.flushed].ready && b.ctx.Err() == nil {
+		i, s := b.flushed, b.slots[b.flushed]
+		request := run.plan[i]
+		b.flushed++
+
+		if s.skip != "" {
+			step := run.step(b.iteration, request, models.RunStepSkipped, s.skip)
+			run.emit(models.RunEvent{Type: models.RunEventRequestSkipped, Iteration: b.iteration, Index: i, Request: &request, Step: &step})
+			continue
+		}
+
+		detail := ""
+		if s.next != nil {
+			detail = "tapa.setNextRequest is ignored in parallel runs"
+		}
+
+		result := s.result
+		run.record(result)
+		step := run.step(b.iteration, request, models.RunStepSent, detail)
+		run.emit(models.RunEvent{Type: models.RunEventRequestFinished, Iteration: b.iteration, Index: i, Request: &request, Result: &result, Step: &step})
+
+		if !result.Passed && run.options.StopOnFailure {
+			b.halted = true
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Plan lists the requests a run of a collection, or of one of its folders, sends, in order.
+// It walks the dashboard hierarchy: the collection's folders and folder-less requests in position order
+// (requests first on ties), each folder's requests in position order.
+func Plan(list models.FullDashboardRequestList, collectionID int, folderID *int) ([]models.RequestBasic, error) {
+	var collection *models.PopulatedCollection
+	for i := range list.Collections {
+		if list.Collections[i].Collection.ID == collectionID {
+			collection = &list.Collections[i]
+			break
+		}
+	}
+
+	if collection == nil {
+		return nil, errors.Wrap(errors.ErrRunTargetNotFound, fmt.Errorf("collection %d", collectionID))
+	}
+
+	if folderID != nil {
+		for _, folder := range collection.Folders {
+			if folder.Folder.ID == *folderID {
+				return sortedRequests(folder.Requests), nil
+			}
+		}
+
+		return nil, errors.Wrap(errors.ErrRunTargetNotFound, fmt.Errorf("folder %d in collection %d", *folderID, collectionID))
+	}
+
+	type item struct {
+		position int
+		requests []models.RequestBasic
+	}
+
+	items := make([]item, 0, len(collection.Requests)+len(collection.Folders))
+	for _, request := range collection.Requests {
+		items = append(items, item{position: request.Position, requests: []models.RequestBasic{request}})
+	}
+	for _, folder := range collection.Folders {
+		items = append(items, item{position: folder.Folder.Position, requests: sortedRequests(folder.Requests)})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].position < items[j].position })
+
+	plan := []models.RequestBasic{}
+	for _, it := range items {
+		plan = append(plan, it.requests...)
+	}
+
+	return plan, nil
+}
+
+func sortedRequests(requests []models.RequestBasic) []models.RequestBasic {
+	sorted := append([]models.RequestBasic{}, requests...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+	return sorted
+}
@@ -0,0 +1,533 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// DEFAULT_MAX_STEPS is how many requests, sent or skipped, one iteration may go through
+// before its flow is considered a loop.
+const DEFAULT_MAX_STEPS int = 1000
+
+// Listener receives the progress of a run. It is called from the run's goroutine, except for the paused and
+// resumed events, which come from the goroutine that called Pause or Resume.
+type Listener func(event models.RunEvent)
+
+// Store keeps runs after they finished. With a store, run ids are the ids it assigns.
+type Store interface {
+	InsertCollectionRun(report models.RunReport) (int, error)
+	SaveCollectionRun(report models.RunReport) error
+}
+
+// Runner sends the requests of a collection or folder through the executor, one after another or several at once.
+type Runner struct {
+	executor *executor.Executor
+	store    Store // nil keeps runs in memory only
+
+	mu     sync.Mutex
+	nextID int
+	runs   map[int]*Run
+}
+
+// Run is a collection run in progress, or finished, that can be paused, resumed and stopped.
+type Run struct {
+	id       int
+	plan     []models.RequestBasic
+	rows     []map[string]string // one per iteration, nil entries without a data file
+	options  models.RunOptions
+	listener Listener
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu     sync.Mutex
+	paused bool
+	wake   chan struct{} // closed when a paused run resumes
+	report models.RunReport
+}
+
+// Start runs plan in the background, in order, within a single executor session so variables and cookies carry over.
+// With data rows the plan runs once per row and each row's values are variables of its iteration;
+// without, it runs options.Iterations times. Scripts may jump with tapa.setNextRequest and requests whose
+// skip conditions hold are skipped; every step taken is logged in the report's Steps.
+// With options.Concurrency above 1 the requests of each iteration are sent in parallel, see executeParallel.
+func (r *Runner) Start(ctx context.Context, plan []models.RequestBasic, data []map[string]string, options models.RunOptions, listener Listener) (*Run, error) {
+	session, err := r.executor.NewSession(options.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.LimitPerHost(options.MaxPerHost)
+
+	rows := data
+	if len(rows) == 0 {
+		rows = make([]map[string]string, max(options.Iterations, 1))
+	}
+
+	if listener == nil {
+		listener = func(models.RunEvent) {}
+	}
+
+	report := models.RunReport{
+		CollectionID:     options.CollectionID,
+		FolderID:         options.FolderID,
+		EnvironmentID:    session.EnvironmentID,
+		DataFileID:       options.DataFileID,
+		Status:           models.RunStatusRunning,
+		Total:            len(plan) * len(rows),
+		TotalIterations:  len(rows),
+		FailedIterations: []int{},
+		StartedAt:        time.Now(),
+		Iterations:       []models.RunIteration{},
+		Steps:            []models.RunStep{},
+	}
+
+	if r.store != nil {
+		id, err := r.store.InsertCollectionRun(report)
+		if err != nil {
+			return nil, err
+		}
+		report.ID = id
+	}
+
+	r.mu.Lock()
+	if r.store == nil {
+		r.nextID++
+		report.ID = r.nextID
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	run := &Run{
+		id:       report.ID,
+		plan:     plan,
+		rows:     rows,
+		options:  options,
+		listener: listener,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		report:   report,
+	}
+	r.runs[run.id] = run
+	r.mu.Unlock()
+
+	go r.execute(ctx, run, session)
+
+	return run, nil
+}
+
+// Get returns a run started by this runner.
+func (r *Runner) Get(id int) (*Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[id]
+	return run, ok
+}
+
+// Forget drops a run from the runner, e.g. a finished run that could not be saved when it is deleted.
+func (r *Runner) Forget(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.runs, id)
+}
+
+func (r *Runner) execute(ctx context.Context, run *Run, session *executor.Session) {
+	defer close(run.done)
+	defer run.cancel()
+
+	run.emit(models.RunEvent{Type: models.RunEventStarted})
+
+	maxSteps := run.options.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DEFAULT_MAX_STEPS
+	}
+
+	pace := newPacer(run.options)
+
+	halted, aborted := false, false
+	for iteration, row := range run.rows {
+		if err := run.waitWhilePaused(ctx); err != nil {
+			break
+		}
+
+		if row != nil {
+			session.Variables.SetData(row)
+		}
+
+		run.beginIteration(iteration, row)
+		run.emit(models.RunEvent{Type: models.RunEventIterationStarted, Iteration: iteration, Data: row})
+
+		if run.options.Concurrency > 1 {
+			halted = r.executeParallel(ctx, run, session, iteration, pace)
+		} else {
+			halted, aborted = r.executeSequential(ctx, run, session, iteration, maxSteps, pace)
+		}
+
+		run.emit(models.RunEvent{Type: models.RunEventIterationFinished, Iteration: iteration, Index: len(run.plan)})
+
+		if halted || aborted || ctx.Err() != nil {
+			break
+		}
+	}
+
+	report := run.finish(ctx.Err() != nil || aborted)
+
+	// Once saved, the run is read back from the store; the runner only keeps runs that are running or unsaved.
+	if r.store != nil {
+		if err := r.store.SaveCollectionRun(report); err != nil {
+			log.Printf("Saving collection run %d failed: %v", report.ID, err)
+		} else {
+			r.Forget(run.id)
+		}
+	}
+
+	run.emit(models.RunEvent{Type: models.RunEventFinished, Iteration: len(report.Iterations), Report: &report})
+}
+
+// executeSequential sends one iteration of the plan request by request, following tapa.setNextRequest.
+// It reports whether the run halted on a failure and whether it was aborted because the flow loops.
+func (r *Runner) executeSequential(ctx context.Context, run *Run, session *executor.Session, iteration, maxSteps int, pace *pacer) (bool, bool) {
+	steps := 0
+	for i := 0; i < len(run.plan); {
+		if err := run.waitWhilePaused(ctx); err != nil {
+			break
+		}
+
+		request := run.plan[i]
+
+		if steps == maxSteps {
+			run.step(iteration, request, models.RunStepLoopLimit, fmt.Sprintf("more than %d steps in one iteration, the flow probably loops", maxSteps))
+			run.abort(fmt.Sprintf("Iteration %d exceeded %d steps; check the tapa.setNextRequest calls for a loop", iteration+1, maxSteps))
+			return false, true
+		}
+		steps++
+
+		reason, skipErr := r.executor.SkipReason(session, request)
+		if skipErr == nil && reason != "" {
+			step := run.step(iteration, request, models.RunStepSkipped, reason)
+			run.emit(models.RunEvent{Type: models.RunEventRequestSkipped, Iteration: iteration, Index: i, Request: &request, Step: &step})
+			i++
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-pace.ready():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		run.emit(models.RunEvent{Type: models.RunEventRequestStarted, Iteration: iteration, Index: i, Request: &request})
+		pace.hold(pace.interval)
+
+		var result models.RunRequestResult
+		var next *models.NextRequest
+		if skipErr != nil {
+			// A skip condition that cannot be evaluated fails the request instead of silently sending it.
+			result = newResult(request, skipErr)
+		} else {
+			result, next = r.send(ctx, session, request)
+		}
+		result.Iteration = iteration
+		pace.hold(pace.delay)
+
+		// A stop while the request was in flight cancels it; the cancelled request is not part of the report.
+		if ctx.Err() != nil {
+			break
+		}
+
+		run.record(result)
+		step := run.step(iteration, request, models.RunStepSent, "")
+		run.emit(models.RunEvent{Type: models.RunEventRequestFinished, Iteration: iteration, Index: i, Request: &request, Result: &result, Step: &step})
+
+		if !result.Passed && run.options.StopOnFailure {
+			return true, false
+		}
+
+		var ok bool
+		if i, ok = run.follow(iteration, i, next); !ok {
+			break
+		}
+	}
+
+	return false, false
+}
+
+// send executes one request of the plan and condenses it into a report entry.
+// It also returns where its scripts asked the run to continue, if they did.
+func (r *Runner) send(ctx context.Context, session *executor.Session, request models.RequestBasic) (models.RunRequestResult, *models.NextRequest) {
+	result, err := r.executor.Execute(ctx, session, request.ID)
+	if err != nil {
+		return newResult(request, err), nil
+	}
+
+	entry := newResult(request, nil)
+
+	entry.HistoryID = result.HistoryID
+	entry.URL = result.URL
+	entry.StatusCode = result.StatusCode
+	entry.ResponseTime = result.ResponseTime
+	entry.DataVolume = result.DataVolume
+	entry.Error = result.Error
+	entry.Tests = result.Tests
+	entry.Passed = result.Error == ""
+
+	for _, test := range result.Tests {
+		if !test.Passed {
+			entry.Passed = false
+		}
+	}
+
+	return entry, result.NextRequest
+}
+
+// newResult creates the report entry of a request, failed with err when it is set.
+func newResult(request models.RequestBasic, err error) models.RunRequestResult {
+	entry := models.RunRequestResult{
+		RequestID: request.ID,
+		Name:      request.Name,
+		Method:    request.Method,
+		Tests:     []models.TestOutcome{},
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// ID identifies the run for Pause, Resume and Stop calls.
+func (run *Run) ID() int {
+	return run.id
+}
+
+// Pause holds the run before its next request; the request in flight completes.
+func (run *Run) Pause() {
+	run.mu.Lock()
+	if run.paused || run.report.Status != models.RunStatusRunning {
+		run.mu.Unlock()
+		return
+	}
+	run.paused = true
+	run.wake = make(chan struct{})
+	run.report.Status = models.RunStatusPaused
+	run.mu.Unlock()
+
+	iteration, index := run.position()
+	run.emit(models.RunEvent{Type: models.RunEventPaused, Iteration: iteration, Index: index})
+}
+
+func (run *Run) Resume() {
+	run.mu.Lock()
+	if !run.paused {
+		run.mu.Unlock()
+		return
+	}
+	run.paused = false
+	close(run.wake)
+	run.report.Status = models.RunStatusRunning
+	run.mu.Unlock()
+
+	iteration, index := run.position()
+	run.emit(models.RunEvent{Type: models.RunEventResumed, Iteration: iteration, Index: index})
+}
+
+// Stop cancels the run, including the request in flight. Paused runs stop as well.
+func (run *Run) Stop() {
+	run.cancel()
+}
+
+// Wait blocks until the run has finished and returns its final report.
+func (run *Run) Wait() models.RunReport {
+	<-run.done
+	return run.Report()
+}
+
+// Report returns a snapshot of the run's report.
+func (run *Run) Report() models.RunReport {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	report := run.report
+	report.FailedIterations = append([]int{}, run.report.FailedIterations...)
+	report.Steps = append([]models.RunStep{}, run.report.Steps...)
+	report.Iterations = make([]models.RunIteration, len(run.report.Iterations))
+	for i, iteration := range run.report.Iterations {
+		iteration.Results = append([]models.RunRequestResult{}, iteration.Results...)
+		report.Iterations[i] = iteration
+	}
+	if report.FinishedAt == nil {
+		report.Duration = int(time.Since(report.StartedAt).Milliseconds())
+	}
+
+	return report
+}
+
+func (run *Run) waitWhilePaused(ctx context.Context) error {
+	select {
+	case <-run.resumed():
+	case <-ctx.Done():
+	}
+
+	return ctx.Err()
+}
+
+// resumed returns a channel that is closed once the run is not paused.
+func (run *Run) resumed() <-chan struct{} {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	if !run.paused {
+		return closed
+	}
+	return run.wake
+}
+
+func (run *Run) beginIteration(index int, row map[string]string) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	run.report.Iterations = append(run.report.Iterations, models.RunIteration{
+		Index:   index,
+		Data:    row,
+		Passed:  true,
+		Results: []models.RunRequestResult{},
+	})
+}
+
+// record adds a result to the current iteration.
+func (run *Run) record(result models.RunRequestResult) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	iteration := &run.report.Iterations[len(run.report.Iterations)-1]
+	iteration.Results = append(iteration.Results, result)
+
+	if result.Passed {
+		run.report.Passed++
+	} else {
+		run.report.Failed++
+		if iteration.Passed {
+			iteration.Passed = false
+			run.report.FailedIterations = append(run.report.FailedIterations, iteration.Index)
+		}
+	}
+
+	for _, test := range result.Tests {
+		if test.Passed {
+			run.report.TestsPassed++
+		} else {
+			run.report.TestsFailed++
+		}
+	}
+}
+
+func (run *Run) finish(stopped bool) models.RunReport {
+	run.mu.Lock()
+	now := time.Now()
+	run.report.FinishedAt = &now
+	run.report.Duration = int(now.Sub(run.report.StartedAt).Milliseconds())
+	run.report.Status = models.RunStatusCompleted
+	if stopped {
+		run.report.Status = models.RunStatusStopped
+	}
+	run.paused = false
+	run.mu.Unlock()
+
+	return run.Report()
+}
+
+// step appends an entry to the run's path and returns it.
+func (run *Run) step(iteration int, request models.RequestBasic, action, detail string) models.RunStep {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	step := models.RunStep{
+		Step:      len(run.report.Steps) + 1,
+		Iteration: iteration,
+		RequestID: request.ID,
+		Name:      request.Name,
+		Action:    action,
+		Detail:    detail,
+	}
+	run.report.Steps = append(run.report.Steps, step)
+
+	if action == models.RunStepSkipped {
+		run.report.Skipped++
+	}
+
+	return step
+}
+
+// follow returns the plan index to continue with after the request at i, honouring tapa.setNextRequest.
+// It returns false when the script ended the iteration.
+func (run *Run) follow(iteration, i int, next *models.NextRequest) (int, bool) {
+	if next == nil {
+		return i + 1, true
+	}
+
+	request := run.plan[i]
+
+	if next.Stop {
+		run.step(iteration, request, models.RunStepStop, "tapa.setNextRequest(null)")
+		return 0, false
+	}
+
+	for j, candidate := range run.plan {
+		if (next.ID != nil && candidate.ID == *next.ID) || (next.ID == nil && candidate.Name == next.Name) {
+			run.step(iteration, request, models.RunStepJump, candidate.Name)
+			return j, true
+		}
+	}
+
+	ref := next.Name
+	if next.ID != nil {
+		ref = strconv.Itoa(*next.ID)
+	}
+	run.step(iteration, request, models.RunStepNotFound, fmt.Sprintf("%q is not part of the run, continuing in order", ref))
+
+	return i + 1, true
+}
+
+// abort records why the run ends before its plan is done.
+func (run *Run) abort(reason string) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	run.report.Error = reason
+}
+
+// position returns the current iteration and the number of its requests already completed.
+func (run *Run) position() (int, int) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	if len(run.report.Iterations) == 0 {
+		return 0, 0
+	}
+
+	current := run.report.Iterations[len(run.report.Iterations)-1]
+	return current.Index, len(current.Results)
+}
+
+func (run *Run) emit(event models.RunEvent) {
+	event.RunID = run.id
+	event.Total = len(run.plan)
+	run.listener(event)
+}
+
+func NewRunner(executor *executor.Executor, store Store) *Runner {
+	return &Runner{
+		executor: executor,
+		store:    store,
+		runs:     map[int]*Run{},
+	}
+}
@@ -0,0 +1,204 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeDocument parses a JSON or YAML document into plain maps, slices and scalars.
+func DecodeDocument(data []byte) (map[string]any, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	var doc map[string]any
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+
+	if err := yaml.Unmarshal(trimmed, &doc); err != nil {
+		return nil, err
+	}
+
+	normalized, ok := normalizeYAML(doc).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("document is not an object")
+	}
+
+	return normalized, nil
+}
+
+// normalizeYAML converts YAML specific values into what encoding/json would have produced.
+func normalizeYAML(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			t[key] = normalizeYAML(value)
+		}
+		return t
+	case map[any]any:
+		out := make(map[string]any, len(t))
+		for key, value := range t {
+			out[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+		return out
+	case []any:
+		for i, value := range t {
+			t[i] = normalizeYAML(value)
+		}
+		return t
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	}
+	return v
+}
+
+// ComponentSchemas extracts the reusable schemas of an OpenAPI 3.x (components.schemas)
+// or Swagger 2.0 (definitions) document, converted to draft 2020-12 JSON Schemas.
+// References between components are rewritten so they resolve against other collection schemas by name.
+func ComponentSchemas(data []byte) (map[string]string, error) {
+	doc, err := DecodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var components map[string]any
+	if c, ok := doc["components"].(map[string]any); ok {
+		components, _ = c["schemas"].(map[string]any)
+	} else {
+		components, _ = doc["definitions"].(map[string]any)
+	}
+
+	if len(components) == 0 {
+		return nil, fmt.Errorf("document has no components.schemas or definitions")
+	}
+
+	legacy := !strings.HasPrefix(fmt.Sprint(doc["openapi"]), "3.1")
+
+	out := make(map[string]string, len(components))
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema := ConvertOpenAPISchema(components[name], legacy)
+		if obj, ok := schema.(map[string]any); ok {
+			obj["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		}
+
+		encoded, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		out[name] = string(encoded)
+	}
+
+	return out, nil
+}
+
+// ConvertOpenAPISchema rewrites component references to schema names and, for OpenAPI 3.0
+// and Swagger 2.0 (legacy), turns nullable, boolean exclusive bounds and example into their 2020-12 forms.
+// Only schema positions are converted; enum, default and example values are copied untouched.
+func ConvertOpenAPISchema(v any, legacy bool) any {
+	schema, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+
+	out := make(map[string]any, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "properties", "patternProperties", "$defs", "definitions", "dependentSchemas":
+			if m, ok := value.(map[string]any); ok {
+				converted := make(map[string]any, len(m))
+				for name, sub := range m {
+					converted[name] = ConvertOpenAPISchema(sub, legacy)
+				}
+				value = converted
+			}
+		case "allOf", "anyOf", "oneOf", "prefixItems", "items":
+			if list, ok := value.([]any); ok {
+				converted := make([]any, len(list))
+				for i, sub := range list {
+					converted[i] = ConvertOpenAPISchema(sub, legacy)
+				}
+				value = converted
+			} else {
+				value = ConvertOpenAPISchema(value, legacy)
+			}
+		case "not", "additionalProperties", "additionalItems", "contains", "propertyNames", "if", "then", "else",
+			"unevaluatedItems", "unevaluatedProperties":
+			value = ConvertOpenAPISchema(value, legacy)
+		case "$ref":
+			if ref, ok := value.(string); ok {
+				value = rewriteRef(ref)
+			}
+		}
+		out[key] = value
+	}
+
+	if legacy {
+		convertLegacyKeywords(out)
+	}
+
+	return out
+}
+
+func rewriteRef(ref string) string {
+	for _, prefix := range []string{"#/components/schemas/", "#/definitions/"} {
+		if strings.HasPrefix(ref, prefix) {
+			name := strings.TrimPrefix(ref, prefix)
+			name = strings.ReplaceAll(name, "~1", "/")
+			name = strings.ReplaceAll(name, "~0", "~")
+			return strings.TrimPrefix(URLFor(name), SCHEMA_BASE_URL)
+		}
+	}
+	return ref
+}
+
+func convertLegacyKeywords(schema map[string]any) {
+	if nullable, ok := schema["nullable"].(bool); ok {
+		delete(schema, "nullable")
+		if nullable {
+			switch typ := schema["type"].(type) {
+			case string:
+				schema["type"] = []any{typ, "null"}
+			case []any:
+				schema["type"] = append(typ, "null")
+			}
+		}
+	}
+
+	for keyword, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		exclusive, ok := schema[keyword].(bool)
+		if !ok {
+			continue
+		}
+
+		delete(schema, keyword)
+		if value, ok := schema[bound]; ok && exclusive {
+			schema[keyword] = value
+			delete(schema, bound)
+		}
+	}
+
+	// OpenAPI examples are not a JSON Schema keyword in 3.0; 2020-12 uses an array.
+	if example, ok := schema["example"]; ok {
+		delete(schema, "example")
+		if _, exists := schema["examples"]; !exists {
+			schema["examples"] = []any{example}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package schemas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const (
+	SCHEMA_BASE_URL   string = "tapa:///schemas/"
+	INLINE_SCHEMA_URL string = SCHEMA_BASE_URL + ".inline" // sibling of the collection schemas so $ref by name resolves
+)
+
+// Violation is a single place where a document does not satisfy its schema.
+type Violation struct {
+	Pointer string `json:"pointer"` // JSON pointer into the validated document, "" for the root
+	Message string `json:"message"`
+}
+
+var (
+	printer = message.NewPrinter(language.English)
+	cache   = &schemaCache{entries: map[string]*jsonschema.Schema{}}
+)
+
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[string]*jsonschema.Schema // keyed by a hash of every schema involved
+}
+
+// Validate checks a JSON document against a schema and returns every violation.
+// The root schema is either inline (rootSchema) or the collection schema called name.
+// Collection schemas are available to $ref by name, e.g. {"$ref": "User"}.
+func Validate(document string, rootSchema string, name string, collection []models.CollectionSchema) ([]Violation, error) {
+	schema, err := compile(rootSchema, name, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(document))
+	if err != nil {
+		return nil, fmt.Errorf("response is not valid JSON: %v", err)
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return []Violation{}, nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+
+	violations := []Violation{}
+	collectLeaves(validationErr, &violations)
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Pointer < violations[j].Pointer })
+
+	return violations, nil
+}
+
+// compile builds the root schema with every collection schema registered as a resource.
+// Compiled schemas are cached until one of the involved schemas changes.
+func compile(rootSchema string, name string, collection []models.CollectionSchema) (*jsonschema.Schema, error) {
+	sorted := append([]models.CollectionSchema{}, collection...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	hash := sha256.New()
+	hash.Write([]byte(rootSchema + "\x00" + name + "\x00"))
+	for _, s := range sorted {
+		hash.Write([]byte(s.Name + "\x00" + s.Schema + "\x00"))
+	}
+	key := hex.EncodeToString(hash.Sum(nil))
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if schema, ok := cache.entries[key]; ok {
+		return schema, nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.UseLoader(noLoader{})
+
+	for _, s := range sorted {
+		doc, err := jsonschema.UnmarshalJSON(strings.NewReader(s.Schema))
+		if err != nil {
+			return nil, fmt.Errorf("schema %q is not valid JSON: %v", s.Name, err)
+		}
+		if err := compiler.AddResource(URLFor(s.Name), doc); err != nil {
+			return nil, err
+		}
+	}
+
+	location := URLFor(name)
+	if rootSchema != "" {
+		doc, err := jsonschema.UnmarshalJSON(strings.NewReader(rootSchema))
+		if err != nil {
+			return nil, fmt.Errorf("schema is not valid JSON: %v", err)
+		}
+		if err := compiler.AddResource(INLINE_SCHEMA_URL, doc); err != nil {
+			return nil, err
+		}
+		location = INLINE_SCHEMA_URL
+	}
+
+	schema, err := compiler.Compile(location)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.entries[key] = schema
+	return schema, nil
+}
+
+// URLFor is the location a collection schema is registered under, relative refs resolve against it.
+func URLFor(name string) string {
+	return SCHEMA_BASE_URL + url.PathEscape(name)
+}
+
+// collectLeaves flattens a validation error tree into its most specific causes.
+func collectLeaves(err *jsonschema.ValidationError, out *[]Violation) {
+	if len(err.Causes) == 0 {
+		*out = append(*out, Violation{
+			Pointer: pointerOf(err.InstanceLocation),
+			Message: err.ErrorKind.LocalizedString(printer),
+		})
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectLeaves(cause, out)
+	}
+}
+
+func pointerOf(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		sb.WriteString("/" + token)
+	}
+	return sb.String()
+}
+
+// noLoader keeps validation offline: only schemas stored in TAPA can be referenced.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("%s is not a schema of this collection", url)
+}
@@ -0,0 +1,85 @@
+package scripting
+
+import (
+	"sync"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/dop251/goja"
+)
+
+// programs keeps compiled script modules across runtimes so a collection run parses each module once.
+var programs = &programCache{entries: map[int]cachedProgram{}}
+
+type cachedProgram struct {
+	version int
+	program *goja.Program
+}
+
+type programCache struct {
+	mu      sync.Mutex
+	entries map[int]cachedProgram // keyed by module id, which is never reused for a re-created module
+}
+
+// get returns the compiled module, compiling it when it is new or its version changed.
+func (c *programCache) get(module models.ScriptModule) (*goja.Program, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.entries[module.ID]; ok && cached.version == module.Version {
+		return cached.program, nil
+	}
+
+	// CommonJS style wrapper: the module fills module.exports (or exports) and may require other modules.
+	src := "(function (module, exports, require) {\n" + module.Script + "\n})"
+	program, err := goja.Compile(module.Name, src, false)
+	if err != nil {
+		return nil, err
+	}
+
+	c.entries[module.ID] = cachedProgram{version: module.Version, program: program}
+	return program, nil
+}
+
+// require implements require("name") for the script modules of the request's collection.
+// A module is evaluated once per run; requiring it again returns the same exports.
+func (s *sandbox) require(name string) goja.Value {
+	if exports, ok := s.modules[name]; ok {
+		return exports
+	}
+
+	module, err := s.host.Module(name)
+	if err != nil {
+		panic(s.vm.NewGoError(err))
+	}
+
+	program, err := programs.get(module)
+	if err != nil {
+		panic(s.vm.NewGoError(errors.Wrap(errors.ErrScriptExecution, err)))
+	}
+
+	wrapper, err := s.vm.RunProgram(program)
+	if err != nil {
+		panic(err)
+	}
+
+	fn, ok := goja.AssertFunction(wrapper)
+	if !ok {
+		panic(s.vm.NewTypeError("module %q did not compile to a function", name))
+	}
+
+	moduleObj := s.vm.NewObject()
+	exports := s.vm.NewObject()
+	_ = moduleObj.Set("exports", exports)
+
+	// Registered before evaluation so circular requires get the partial exports instead of looping.
+	s.modules[name] = exports
+
+	if _, err := fn(goja.Undefined(), moduleObj, exports, s.vm.Get("require")); err != nil {
+		delete(s.modules, name)
+		panic(err)
+	}
+
+	s.modules[name] = moduleObj.Get("exports")
+	return s.modules[name]
+}
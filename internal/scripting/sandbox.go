@@ -0,0 +1,411 @@
+package scripting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/dop251/goja"
+)
+
+const (
+	SCRIPT_TIMEOUT        time.Duration = 10 * time.Second
+	SCRIPT_MAX_CALL_STACK int           = 1024
+)
+
+// Host is everything a script can reach outside of the sandbox.
+// The executor implements it for every execution.
+type Host interface {
+	Variable(key string) (string, bool)
+	SetVariable(key, value string)
+	UnsetVariable(key string)
+	Log(level, message string)
+	SendRequest(ctx context.Context, req SubRequest) (*models.ExecutionResult, error)
+	Module(name string) (models.ScriptModule, error)
+	RecordTest(name string, passed bool, message string)
+	SetNextRequest(next models.NextRequest)
+}
+
+// SubRequest is a request sent from a script through tapa.sendRequest.
+// Either a stored request (ID or Name) or an ad-hoc URL is sent.
+type SubRequest struct {
+	ID      *int
+	Name    string
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+// ScriptRequest is the part of the outgoing request that pre-request scripts may change.
+type ScriptRequest struct {
+	Method  string
+	URL     string
+	Body    string
+	Headers map[string]string
+}
+
+// Scope is the data a single script run works on.
+// Response is nil while pre-request scripts run.
+type Scope struct {
+	Request  *ScriptRequest
+	Response *models.ExecutionResult
+}
+
+// Run executes a script in a fresh JS runtime.
+// The run is interrupted once ctx is done or SCRIPT_TIMEOUT has passed, whichever comes first.
+func Run(ctx context.Context, script string, host Host, scope *Scope) error {
+	ctx, cancel := context.WithTimeout(ctx, SCRIPT_TIMEOUT)
+	defer cancel()
+
+	vm := goja.New()
+	vm.SetMaxCallStackSize(SCRIPT_MAX_CALL_STACK)
+
+	s := &sandbox{ctx: ctx, vm: vm, host: host, scope: scope, modules: map[string]goja.Value{}}
+	if err := s.install(); err != nil {
+		return errors.Wrap(errors.ErrScriptExecution, err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			vm.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	_, err := vm.RunString(script)
+	if err != nil {
+		if _, ok := err.(*goja.InterruptedError); ok {
+			return errors.Wrap(errors.ErrScriptTimeout, err)
+		}
+		return errors.Wrap(errors.ErrScriptExecution, err)
+	}
+
+	s.readBack()
+	return nil
+}
+
+type sandbox struct {
+	ctx     context.Context
+	vm      *goja.Runtime
+	host    Host
+	scope   *Scope
+	request *goja.Object
+	modules map[string]goja.Value // exports of the modules required during this run
+}
+
+// install exposes console and the tapa object to the runtime.
+func (s *sandbox) install() error {
+	console := s.vm.NewObject()
+	for _, level := range []string{models.ConsoleLevelLog, models.ConsoleLevelInfo, models.ConsoleLevelWarn, models.ConsoleLevelError} {
+		if err := console.Set(level, s.consoleFunc(level)); err != nil {
+			return err
+		}
+	}
+
+	if err := s.vm.Set("console", console); err != nil {
+		return err
+	}
+
+	if err := s.vm.Set("require", s.require); err != nil {
+		return err
+	}
+
+	tapa := s.vm.NewObject()
+
+	variables := s.vm.NewObject()
+	_ = variables.Set("get", func(key string) goja.Value {
+		if value, ok := s.host.Variable(key); ok {
+			return s.vm.ToValue(value)
+		}
+		return goja.Undefined()
+	})
+	_ = variables.Set("has", func(key string) bool {
+		_, ok := s.host.Variable(key)
+		return ok
+	})
+	_ = variables.Set("set", func(key string, value goja.Value) {
+		s.host.SetVariable(key, value.String())
+	})
+	_ = variables.Set("unset", func(key string) {
+		s.host.UnsetVariable(key)
+	})
+
+	if err := tapa.Set("variables", variables); err != nil {
+		return err
+	}
+
+	if s.scope.Request != nil {
+		s.request = s.vm.NewObject()
+		_ = s.request.Set("method", s.scope.Request.Method)
+		_ = s.request.Set("url", s.scope.Request.URL)
+		_ = s.request.Set("body", s.scope.Request.Body)
+		_ = s.request.Set("headers", stringMapObject(s.vm, s.scope.Request.Headers))
+
+		if err := tapa.Set("request", s.request); err != nil {
+			return err
+		}
+	}
+
+	if s.scope.Response != nil {
+		if err := tapa.Set("response", responseObject(s.vm, s.scope.Response)); err != nil {
+			return err
+		}
+	}
+
+	if err := tapa.Set("sendRequest", s.sendRequest); err != nil {
+		return err
+	}
+
+	if err := tapa.Set("setNextRequest", s.setNextRequest); err != nil {
+		return err
+	}
+
+	if err := tapa.Set("test", s.test); err != nil {
+		return err
+	}
+
+	return s.vm.Set("tapa", tapa)
+}
+
+func (s *sandbox) consoleFunc(level string) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, 0, len(call.Arguments))
+		for _, arg := range call.Arguments {
+			parts = append(parts, stringify(s.vm, arg))
+		}
+
+		s.host.Log(level, strings.Join(parts, " "))
+		return goja.Undefined()
+	}
+}
+
+// test implements tapa.test(name, fn). The test passes when fn returns without throwing.
+func (s *sandbox) test(name string, fn goja.Callable) {
+	if fn == nil {
+		s.host.RecordTest(name, false, "tapa.test needs a function")
+		return
+	}
+
+	if _, err := fn(goja.Undefined()); err != nil {
+		if _, ok := err.(*goja.InterruptedError); ok {
+			panic(err)
+		}
+
+		message := err.Error()
+		if ex, ok := err.(*goja.Exception); ok {
+			message = ex.Value().String()
+		}
+
+		s.host.RecordTest(name, false, message)
+		return
+	}
+
+	s.host.RecordTest(name, true, "")
+}
+
+// setNextRequest implements tapa.setNextRequest(nameOrId). Collection runs continue with that request
+// instead of the next one in order; null ends the current iteration. Single sends ignore it.
+func (s *sandbox) setNextRequest(call goja.FunctionCall) goja.Value {
+	arg := call.Argument(0)
+	if goja.IsNull(arg) {
+		s.host.SetNextRequest(models.NextRequest{Stop: true})
+		return goja.Undefined()
+	}
+
+	switch v := arg.Export().(type) {
+	case int64, float64:
+		id := int(arg.ToInteger())
+		s.host.SetNextRequest(models.NextRequest{ID: &id})
+	case string:
+		if strings.TrimSpace(v) == "" {
+			panic(s.vm.NewTypeError("tapa.setNextRequest needs a request name, an id or null"))
+		}
+		s.host.SetNextRequest(models.NextRequest{Name: v})
+	default:
+		panic(s.vm.NewTypeError("tapa.setNextRequest needs a request name, an id or null"))
+	}
+
+	return goja.Undefined()
+}
+
+// sendRequest implements tapa.sendRequest(urlOrRequest[, callback]).
+// Without a callback the response is returned and failures are thrown.
+// With a callback it is called Node-style as callback(err, response).
+func (s *sandbox) sendRequest(call goja.FunctionCall) goja.Value {
+	callback, hasCallback := goja.AssertFunction(call.Argument(1))
+
+	sub, err := s.subRequest(call.Argument(0))
+	if err == nil {
+		var result *models.ExecutionResult
+		result, err = s.host.SendRequest(s.ctx, sub)
+		if err == nil && result.Error != "" {
+			err = fmt.Errorf("%s", result.Error)
+		}
+
+		if err == nil {
+			response := responseObject(s.vm, result)
+			if hasCallback {
+				if _, cbErr := callback(goja.Undefined(), goja.Null(), response); cbErr != nil {
+					panic(cbErr)
+				}
+			}
+			return response
+		}
+	}
+
+	if hasCallback {
+		if _, cbErr := callback(goja.Undefined(), s.vm.NewGoError(err)); cbErr != nil {
+			panic(cbErr)
+		}
+		return goja.Undefined()
+	}
+
+	panic(s.vm.NewGoError(err))
+}
+
+// subRequest converts the first argument of tapa.sendRequest into a SubRequest.
+// Accepted forms are a URL string, {id}, {name} and {url, method, header|headers, body}.
+func (s *sandbox) subRequest(arg goja.Value) (SubRequest, error) {
+	if goja.IsUndefined(arg) || goja.IsNull(arg) {
+		return SubRequest{}, errors.Wrap(errors.ErrInvalidSubRequest, fmt.Errorf("tapa.sendRequest needs a URL or a request object"))
+	}
+
+	obj, ok := arg.(*goja.Object)
+	if !ok || obj.ClassName() == "String" {
+		return SubRequest{Method: "GET", URL: arg.String()}, nil
+	}
+
+	sub := SubRequest{Method: "GET", Headers: map[string]string{}}
+
+	if v := obj.Get("id"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+		id := int(v.ToInteger())
+		sub.ID = &id
+		return sub, nil
+	}
+
+	if v := obj.Get("name"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+		sub.Name = v.String()
+		return sub, nil
+	}
+
+	if v := obj.Get("url"); v != nil && !goja.IsUndefined(v) {
+		sub.URL = v.String()
+	}
+	if sub.URL == "" {
+		return SubRequest{}, errors.Wrap(errors.ErrInvalidSubRequest, fmt.Errorf("tapa.sendRequest needs an id, a name or a url"))
+	}
+
+	if v := obj.Get("method"); v != nil && !goja.IsUndefined(v) {
+		sub.Method = strings.ToUpper(v.String())
+	}
+
+	if v := obj.Get("body"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+		if body, ok := v.(*goja.Object); ok && body.ClassName() != "String" {
+			sub.Body = stringify(s.vm, v)
+		} else {
+			sub.Body = v.String()
+		}
+	}
+
+	for _, field := range []string{"header", "headers"} {
+		v := obj.Get(field)
+		if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+			continue
+		}
+
+		// Both {"Key": "value"} and Postman's [{key, value}] are accepted.
+		var list []map[string]any
+		if s.vm.ExportTo(v, &list) == nil {
+			for _, kv := range list {
+				sub.Headers[fmt.Sprint(kv["key"])] = fmt.Sprint(kv["value"])
+			}
+			continue
+		}
+
+		var headers map[string]any
+		if err := s.vm.ExportTo(v, &headers); err == nil {
+			for key, value := range headers {
+				sub.Headers[key] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	return sub, nil
+}
+
+// readBack copies changes pre-request scripts made to tapa.request into the scope.
+func (s *sandbox) readBack() {
+	if s.request == nil {
+		return
+	}
+
+	s.scope.Request.Method = strings.ToUpper(s.request.Get("method").String())
+	s.scope.Request.URL = s.request.Get("url").String()
+	s.scope.Request.Body = s.request.Get("body").String()
+
+	var headers map[string]any
+	if err := s.vm.ExportTo(s.request.Get("headers"), &headers); err == nil {
+		s.scope.Request.Headers = make(map[string]string, len(headers))
+		for key, value := range headers {
+			s.scope.Request.Headers[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// responseObject builds the JS view of a response shared by tapa.response and tapa.sendRequest.
+func responseObject(vm *goja.Runtime, result *models.ExecutionResult) *goja.Object {
+	headers := make(map[string]string, len(result.ResponseHeaders))
+	for key, values := range result.ResponseHeaders {
+		headers[key] = strings.Join(values, ", ")
+	}
+
+	obj := vm.NewObject()
+	_ = obj.Set("code", result.StatusCode)
+	_ = obj.Set("status", result.Status)
+	_ = obj.Set("headers", stringMapObject(vm, headers))
+	_ = obj.Set("body", result.ResponseBody)
+	_ = obj.Set("responseTime", result.ResponseTime)
+	_ = obj.Set("size", result.DataVolume)
+	_ = obj.Set("text", func() string { return result.ResponseBody })
+	_ = obj.Set("json", func() goja.Value {
+		parse, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+		v, err := parse(goja.Undefined(), vm.ToValue(result.ResponseBody))
+		if err != nil {
+			panic(err)
+		}
+		return v
+	})
+
+	return obj
+}
+
+func stringMapObject(vm *goja.Runtime, m map[string]string) *goja.Object {
+	obj := vm.NewObject()
+	for key, value := range m {
+		_ = obj.Set(key, value)
+	}
+	return obj
+}
+
+// stringify renders a JS value the way a browser console would: objects as JSON, everything else as text.
+func stringify(vm *goja.Runtime, v goja.Value) string {
+	if obj, ok := v.(*goja.Object); ok {
+		switch obj.ClassName() {
+		case "Object", "Array":
+			stringifyFn, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("stringify"))
+			if out, err := stringifyFn(goja.Undefined(), v); err == nil && !goja.IsUndefined(out) {
+				return out.String()
+			}
+		}
+	}
+
+	return v.String()
+}
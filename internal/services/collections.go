@@ -0,0 +1,560 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/curl"
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/har"
+	"github.com/Amir-Zouerami/TAPA/internal/hoppscotch"
+	"github.com/Amir-Zouerami/TAPA/internal/httpfile"
+	"github.com/Amir-Zouerami/TAPA/internal/insomnia"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/openapi"
+	"github.com/Amir-Zouerami/TAPA/internal/postman"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/Amir-Zouerami/TAPA/internal/schemas"
+	"github.com/jmoiron/sqlx"
+)
+
+type CollectionTransferRepository interface {
+	GetCollectionExport(collectionID int) (models.CollectionExport, error)
+	ImportCollection(export models.CollectionExport) (int, error)
+	ImportSpec(spec models.SpecImport, collectionID int) (models.ImportReport, error)
+	AddRequest(collectionID int, folderID *int, req models.RequestExport) (int, error)
+	ImportWorkspace(workspace models.WorkspaceImport) ([]int, error)
+}
+
+type ScriptModulesRepository interface {
+	GetScriptModules(collectionID int) ([]models.ScriptModule, error)
+	SaveScriptModule(module models.ScriptModule) (models.ScriptModule, error)
+	DeleteScriptModule(id int) error
+}
+
+type CollectionSchemasRepository interface {
+	GetCollectionSchemas(collectionID int) ([]models.CollectionSchema, error)
+	SaveCollectionSchemas(schemas []models.CollectionSchema) error
+	DeleteCollectionSchema(id int) error
+}
+
+type CollectionDataFilesRepository interface {
+	GetCollectionDataFiles(collectionID int) ([]models.CollectionDataFile, error)
+	SaveCollectionDataFile(file models.CollectionDataFile) (models.CollectionDataFile, error)
+	DeleteCollectionDataFile(id int) error
+}
+
+type EnvironmentExportRepository interface {
+	GetEnvironment(id int) (models.Environment, error)
+	GetEnvironmentVariables(environmentID int) ([]models.EnvironmentVariable, error)
+}
+
+type CollectionsService struct {
+	repo         CollectionTransferRepository
+	modules      ScriptModulesRepository
+	schemas      CollectionSchemasRepository
+	dataFiles    CollectionDataFilesRepository
+	environments EnvironmentExportRepository
+}
+
+// ExportCollection returns a collection with everything needed to recreate it elsewhere, script modules included.
+func (s *CollectionsService) ExportCollection(collectionID int) (models.CollectionExport, error) {
+	return s.repo.GetCollectionExport(collectionID)
+}
+
+// ImportCollection creates a new collection from an export and returns its id.
+func (s *CollectionsService) ImportCollection(export models.CollectionExport) (int, error) {
+	if export.Version != models.COLLECTION_EXPORT_VERSION {
+		return 0, errors.Wrap(errors.ErrUnsupportedExportFormat, fmt.Errorf("got version %d", export.Version))
+	}
+
+	return s.repo.ImportCollection(export)
+}
+
+// ImportPostmanCollection creates a new collection from a Postman v2.1 collection file and reports
+// what was imported and what could not be mapped.
+func (s *CollectionsService) ImportPostmanCollection(path string) (models.ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+	}
+
+	export, report, err := postman.Import(data)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	report.CollectionID, err = s.repo.ImportCollection(export)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return report, nil
+}
+
+// ImportOpenAPI generates a collection from an OpenAPI 3.x or Swagger 2.0 file, JSON or YAML. With a collectionID
+// other than 0 the document is merged into that collection instead: requests edited since they were imported are kept.
+func (s *CollectionsService) ImportOpenAPI(path string, collectionID int) (models.ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+	}
+
+	spec, converted, err := openapi.Import(data)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return s.importSpec(spec, converted, collectionID)
+}
+
+// importSpec stores a generated collection and adds the warnings of its conversion to the report.
+func (s *CollectionsService) importSpec(spec models.SpecImport, converted models.ImportReport, collectionID int) (models.ImportReport, error) {
+	report, err := s.repo.ImportSpec(spec, collectionID)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	report.Warnings = append(converted.Warnings, report.Warnings...)
+	return report, nil
+}
+
+// ImportCurl adds the request a curl command sends to a collection, in a folder of it when folderID is not nil.
+// An empty name names the request after its method and path.
+func (s *CollectionsService) ImportCurl(command string, collectionID int, folderID *int, name string) (models.ImportReport, error) {
+	req, report, err := curl.Parse(command)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	if name != "" {
+		req.Name = name
+		report.Name = name
+	}
+
+	if _, err := s.repo.AddRequest(collectionID, folderID, models.RequestExport{RequestWithDetail: req, Examples: []models.RequestExample{}}); err != nil {
+		return models.ImportReport{}, err
+	}
+
+	report.CollectionID = collectionID
+	return report, nil
+}
+
+// ImportHAR creates a new collection from a HAR file, with one request per entry. With examples, the response
+// of every entry is saved as an example of its request.
+func (s *CollectionsService) ImportHAR(path string, examples bool) (models.ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+	}
+
+	export, report, err := har.Import(data, examples)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	report.CollectionID, err = s.repo.ImportCollection(export)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return report, nil
+}
+
+// ImportInsomnia creates a collection for every workspace of an Insomnia v4 export, and an environment for every
+// sub environment. Environments whose name is taken are merged into the existing one.
+func (s *CollectionsService) ImportInsomnia(path string) (models.ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+	}
+
+	workspace, report, err := insomnia.Import(data)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return s.importWorkspace(workspace, report)
+}
+
+// ImportHoppscotch creates collections and environments from a Hoppscotch collection or environment export.
+// Environments whose name is taken are merged into the existing one.
+func (s *CollectionsService) ImportHoppscotch(path string) (models.ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+	}
+
+	workspace, report, err := hoppscotch.Import(data)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return s.importWorkspace(workspace, report)
+}
+
+// ImportHTTPFile creates a collection from an .http or .rest file, or from every such file in a directory and its
+// subdirectories with one folder per file. JetBrains environment files next to them become environments.
+func (s *CollectionsService) ImportHTTPFile(path string) (models.ImportReport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+	}
+
+	dir := filepath.Dir(path)
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var files []httpfile.File
+
+	if info.IsDir() {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+		}
+		dir, name = path, filepath.Base(abs)
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !isHTTPFile(p) {
+				return nil
+			}
+
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(path, p)
+			if err != nil {
+				return err
+			}
+			folder := strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+			files = append(files, httpfile.File{Path: rel, Folder: strings.ReplaceAll(folder, "/", httpfile.FOLDER_SEPARATOR), Data: data})
+			return nil
+		})
+		if err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+		}
+		files = append(files, httpfile.File{Path: filepath.Base(path), Data: data})
+	}
+
+	var environments []httpfile.File
+	for _, envFile := range []string{httpfile.ENV_FILE, httpfile.PRIVATE_ENV_FILE} {
+		data, err := os.ReadFile(filepath.Join(dir, envFile))
+		switch {
+		case err == nil:
+			environments = append(environments, httpfile.File{Path: envFile, Data: data})
+		case !os.IsNotExist(err):
+			return models.ImportReport{}, errors.Wrap(errors.ErrImportFileRead, err)
+		}
+	}
+
+	workspace, report, err := httpfile.Import(name, files, environments)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return s.importWorkspace(workspace, report)
+}
+
+func isHTTPFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range httpfile.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *CollectionsService) importWorkspace(workspace models.WorkspaceImport, report models.ImportReport) (models.ImportReport, error) {
+	ids, err := s.repo.ImportWorkspace(workspace)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	report.CollectionIDs = ids
+	if len(ids) > 0 {
+		report.CollectionID = ids[0]
+	}
+	return report, nil
+}
+
+// ExportPostmanCollection returns a collection as Postman v2.1 JSON.
+func (s *CollectionsService) ExportPostmanCollection(collectionID int) (string, error) {
+	export, err := s.repo.GetCollectionExport(collectionID)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := postman.Export(export)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// ExportHTTPFile returns a collection, or only one folder of it when folderID is not nil, as an .http file,
+// with the requests whose bodies will not import back as they are.
+func (s *CollectionsService) ExportHTTPFile(collectionID int, folderID *int) (models.TextExport, error) {
+	export, err := s.repo.GetCollectionExport(collectionID)
+	if err != nil {
+		return models.TextExport{}, err
+	}
+
+	if folderID != nil {
+		folders := []models.FolderExport{}
+		for _, f := range export.Folders {
+			if f.Folder.ID == *folderID {
+				folders = append(folders, f)
+			}
+		}
+		if len(folders) == 0 {
+			return models.TextExport{}, errors.Wrap(errors.ErrCollectionExport, fmt.Errorf("folder %d is not in collection %d", *folderID, collectionID))
+		}
+		export.Folders = folders
+		export.Requests = []models.RequestExport{}
+	}
+
+	// Like the other exports, without the final newline; whoever writes the file adds it.
+	content, warnings := httpfile.Export(export)
+	return models.TextExport{Content: strings.TrimSuffix(content, "\n"), Warnings: warnings}, nil
+}
+
+// GenerateOpenAPI infers an OpenAPI 3.1 document, written as openapi.FORMAT_JSON or openapi.FORMAT_YAML,
+// from a collection's requests and the examples saved for them.
+func (s *CollectionsService) GenerateOpenAPI(collectionID int, format string) (string, error) {
+	export, err := s.repo.GetCollectionExport(collectionID)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := openapi.Generate(export, format)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// ExportPostmanEnvironment returns an environment in Postman's environment format. With redactSecrets
+// the values of variables that look like credentials are left empty.
+func (s *CollectionsService) ExportPostmanEnvironment(environmentID int, redactSecrets bool) (string, error) {
+	env, err := s.environments.GetEnvironment(environmentID)
+	if err != nil {
+		return "", err
+	}
+
+	vars, err := s.environments.GetEnvironmentVariables(environmentID)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := postman.ExportEnvironment(env, vars, redactSecrets)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// GetScriptModules returns the script modules request scripts of a collection can require.
+func (s *CollectionsService) GetScriptModules(collectionID int) ([]models.ScriptModule, error) {
+	return s.modules.GetScriptModules(collectionID)
+}
+
+// SaveScriptModule creates or updates a script module, matched by collection and name.
+func (s *CollectionsService) SaveScriptModule(module models.ScriptModule) (models.ScriptModule, error) {
+	return s.modules.SaveScriptModule(module)
+}
+
+func (s *CollectionsService) DeleteScriptModule(id int) error {
+	return s.modules.DeleteScriptModule(id)
+}
+
+// GetCollectionSchemas returns the JSON Schemas json_schema assertions of a collection can reference by name.
+func (s *CollectionsService) GetCollectionSchemas(collectionID int) ([]models.CollectionSchema, error) {
+	return s.schemas.GetCollectionSchemas(collectionID)
+}
+
+// SaveCollectionSchema creates or replaces a schema, matched by collection and name. The schema must compile.
+func (s *CollectionsService) SaveCollectionSchema(schema models.CollectionSchema) error {
+	if err := s.checkSchema(schema); err != nil {
+		return err
+	}
+
+	return s.schemas.SaveCollectionSchemas([]models.CollectionSchema{schema})
+}
+
+func (s *CollectionsService) DeleteCollectionSchema(id int) error {
+	return s.schemas.DeleteCollectionSchema(id)
+}
+
+// ImportSchemaFile stores a JSON or YAML schema file as a collection schema called name.
+func (s *CollectionsService) ImportSchemaFile(collectionID int, name string, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(errors.ErrSchemaFileRead, err)
+	}
+
+	doc, err := schemas.DecodeDocument(data)
+	if err != nil {
+		return errors.Wrap(errors.ErrInvalidSchema, err)
+	}
+
+	encoded, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return errors.Wrap(errors.ErrInvalidSchema, err)
+	}
+
+	return s.SaveCollectionSchema(models.CollectionSchema{
+		CollectionID: collectionID,
+		Name:         name,
+		Schema:       string(encoded),
+		Source:       models.SchemaSourceFile,
+	})
+}
+
+// ImportOpenAPISchemas stores every component schema of an OpenAPI (or Swagger) document
+// as a collection schema of the same name and returns the imported names.
+func (s *CollectionsService) ImportOpenAPISchemas(collectionID int, path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrSchemaFileRead, err)
+	}
+
+	components, err := schemas.ComponentSchemas(data)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidSchema, err)
+	}
+
+	existing, err := s.schemas.GetCollectionSchemas(collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Components reference each other, so they are checked together with the schemas already stored.
+	byName := make(map[string]models.CollectionSchema, len(existing)+len(components))
+	for _, schema := range existing {
+		byName[schema.Name] = schema
+	}
+
+	imported := make([]models.CollectionSchema, 0, len(components))
+	names := make([]string, 0, len(components))
+	for name, schema := range components {
+		imported = append(imported, models.CollectionSchema{
+			CollectionID: collectionID,
+			Name:         name,
+			Schema:       schema,
+			Source:       models.SchemaSourceOpenAPI,
+		})
+		byName[name] = imported[len(imported)-1]
+		names = append(names, name)
+	}
+
+	all := make([]models.CollectionSchema, 0, len(byName))
+	for _, schema := range byName {
+		all = append(all, schema)
+	}
+
+	for _, schema := range imported {
+		if _, err := schemas.Validate("null", "", schema.Name, all); err != nil {
+			return nil, errors.Wrap(errors.ErrInvalidSchema, fmt.Errorf("%s: %v", schema.Name, err))
+		}
+	}
+
+	if err := s.schemas.SaveCollectionSchemas(imported); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// checkSchema compiles a schema against the rest of its collection so broken schemas are never stored.
+func (s *CollectionsService) checkSchema(schema models.CollectionSchema) error {
+	others, err := s.schemas.GetCollectionSchemas(schema.CollectionID)
+	if err != nil {
+		return err
+	}
+
+	all := []models.CollectionSchema{schema}
+	for _, other := range others {
+		if other.Name != schema.Name {
+			all = append(all, other)
+		}
+	}
+
+	if _, err := schemas.Validate("null", "", schema.Name, all); err != nil {
+		return errors.Wrap(errors.ErrInvalidSchema, err)
+	}
+
+	return nil
+}
+
+// GetCollectionDataFiles returns the datasets stored with a collection for data-driven runs.
+func (s *CollectionsService) GetCollectionDataFiles(collectionID int) ([]models.CollectionDataFile, error) {
+	return s.dataFiles.GetCollectionDataFiles(collectionID)
+}
+
+// SaveCollectionDataFile creates or replaces a data file, matched by collection and name. Its rows must parse.
+func (s *CollectionsService) SaveCollectionDataFile(file models.CollectionDataFile) (models.CollectionDataFile, error) {
+	if file.Format == "" {
+		file.Format = runner.DataFileFormat(file.Name, file.Content)
+	}
+
+	if _, err := runner.ParseDataFile(file.Format, file.Content); err != nil {
+		return models.CollectionDataFile{}, errors.Wrap(errors.ErrInvalidDataFile, err)
+	}
+
+	return s.dataFiles.SaveCollectionDataFile(file)
+}
+
+func (s *CollectionsService) DeleteCollectionDataFile(id int) error {
+	return s.dataFiles.DeleteCollectionDataFile(id)
+}
+
+// ImportDataFile stores a CSV or JSON file from disk with the collection, named after the file.
+func (s *CollectionsService) ImportDataFile(collectionID int, path string) (models.CollectionDataFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return models.CollectionDataFile{}, errors.Wrap(errors.ErrDataFileRead, err)
+	}
+
+	name := filepath.Base(path)
+
+	return s.SaveCollectionDataFile(models.CollectionDataFile{
+		CollectionID: collectionID,
+		Name:         name,
+		Format:       runner.DataFileFormat(name, string(content)),
+		Content:      string(content),
+	})
+}
+
+func NewCollectionsService(db *sqlx.DB) *CollectionsService {
+	return &CollectionsService{
+		repo:         repository.NewCollectionsRepository(db),
+		modules:      repository.NewScriptModulesRepository(db),
+		schemas:      repository.NewCollectionSchemasRepository(db),
+		dataFiles:    repository.NewCollectionDataFilesRepository(db),
+		environments: repository.NewEnvironmentsRepository(db),
+	}
+}
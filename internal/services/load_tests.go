@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/loadtest"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/jmoiron/sqlx"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// LOAD_TEST_EVENT is the Wails event every models.LoadTestEvent is emitted on.
+const LOAD_TEST_EVENT string = "loadtest:progress"
+
+type LoadTestsRepository interface {
+	GetLoadTests(collectionID int) ([]models.LoadTestReport, error)
+	GetLoadTest(id int) (models.LoadTestReport, error)
+	DeleteLoadTest(id int) error
+}
+
+type LoadTestService struct {
+	ctx       context.Context // Wails runtime context, nil until startup
+	dashboard *DashboardService
+	tests     LoadTestsRepository
+	tester    *loadtest.Tester
+}
+
+// startup receives the Wails runtime context progress events are emitted with.
+func (s *LoadTestService) startup(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// StartLoadTest drives a request, folder or collection in the background and returns the test id.
+// Progress is streamed as LOAD_TEST_EVENT events, once per second.
+func (s *LoadTestService) StartLoadTest(options models.LoadTestOptions) (int, error) {
+	list, err := s.dashboard.GetFullRequestList()
+	if err != nil {
+		return 0, err
+	}
+
+	targets, name, err := loadtest.Targets(list, options)
+	if err != nil {
+		return 0, err
+	}
+
+	test, err := s.tester.Start(context.Background(), name, targets, options, s.emit)
+	if err != nil {
+		return 0, err
+	}
+
+	return test.ID(), nil
+}
+
+func (s *LoadTestService) StopLoadTest(testID int) error {
+	test, ok := s.tester.Get(testID)
+	if !ok {
+		return errors.Wrap(errors.ErrLoadTestNotFound, fmt.Errorf("load test %d", testID))
+	}
+
+	test.Stop()
+	return nil
+}
+
+// GetLoadTest returns a load test: in progress, or finished and possibly from an earlier session.
+func (s *LoadTestService) GetLoadTest(testID int) (models.LoadTestReport, error) {
+	if test, ok := s.tester.Get(testID); ok {
+		return test.Report(), nil
+	}
+
+	return s.tests.GetLoadTest(testID)
+}
+
+// GetLoadTests returns the past load tests of a collection, newest first, without their timelines.
+func (s *LoadTestService) GetLoadTests(collectionID int) ([]models.LoadTestReport, error) {
+	return s.tests.GetLoadTests(collectionID)
+}
+
+func (s *LoadTestService) DeleteLoadTest(testID int) error {
+	if test, ok := s.tester.Get(testID); ok && test.Report().FinishedAt == nil {
+		return errors.Wrap(errors.ErrLoadTestInProgress, fmt.Errorf("load test %d", testID))
+	}
+
+	return s.tests.DeleteLoadTest(testID)
+}
+
+// CompareLoadTests reports the throughput, error rate and latency changes from the base test to the target test.
+func (s *LoadTestService) CompareLoadTests(baseTestID int, targetTestID int) (models.LoadTestComparison, error) {
+	base, err := s.GetLoadTest(baseTestID)
+	if err != nil {
+		return models.LoadTestComparison{}, err
+	}
+
+	target, err := s.GetLoadTest(targetTestID)
+	if err != nil {
+		return models.LoadTestComparison{}, err
+	}
+
+	return loadtest.Compare(base, target), nil
+}
+
+func (s *LoadTestService) emit(event models.LoadTestEvent) {
+	if s.ctx == nil {
+		return
+	}
+
+	runtime.EventsEmit(s.ctx, LOAD_TEST_EVENT, event)
+}
+
+func NewLoadTestService(db *sqlx.DB) *LoadTestService {
+	tests := repository.NewLoadTestsRepository(db)
+
+	return &LoadTestService{
+		dashboard: NewDashboardService(db),
+		tests:     tests,
+		tester:    loadtest.NewTester(executor.NewExecutor(db), tests),
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/monitor"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/jmoiron/sqlx"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// MONITOR_EVENT is the Wails event every models.MonitorEvent is emitted on.
+const MONITOR_EVENT string = "monitor:status"
+
+type MonitorsRepository interface {
+	GetMonitors() ([]models.Monitor, error)
+	GetMonitor(id int) (models.Monitor, error)
+	InsertMonitor(m models.Monitor) (int, error)
+	UpdateMonitor(m models.Monitor) error
+	DeleteMonitor(id int) error
+	RecordMonitorRun(id, runID int, passed bool, at time.Time) (models.Monitor, error)
+}
+
+type MonitorService struct {
+	ctx       context.Context // Wails runtime context, nil until startup
+	monitors  MonitorsRepository
+	runner    *RunnerService
+	scheduler *monitor.Scheduler
+}
+
+// startup schedules the enabled monitors for as long as the app is open.
+func (s *MonitorService) startup(ctx context.Context) {
+	s.ctx = ctx
+	go s.scheduler.Run(ctx)
+}
+
+func (s *MonitorService) GetMonitors() ([]models.Monitor, error) {
+	return s.monitors.GetMonitors()
+}
+
+// SaveMonitor creates the monitor when it has no id and updates it otherwise; the scheduler picks it up
+// at its next check.
+func (s *MonitorService) SaveMonitor(m models.Monitor) (models.Monitor, error) {
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		return models.Monitor{}, errors.Wrap(errors.ErrInvalidMonitor, fmt.Errorf("the monitor needs a name"))
+	}
+
+	if err := monitor.Validate(m); err != nil {
+		return models.Monitor{}, err
+	}
+
+	if _, err := s.runner.Plan(models.RunOptions{CollectionID: m.CollectionID, FolderID: m.FolderID}); err != nil {
+		return models.Monitor{}, err
+	}
+
+	if m.ID == 0 {
+		id, err := s.monitors.InsertMonitor(m)
+		if err != nil {
+			return models.Monitor{}, err
+		}
+		m.ID = id
+	} else if err := s.monitors.UpdateMonitor(m); err != nil {
+		return models.Monitor{}, err
+	}
+
+	return s.monitors.GetMonitor(m.ID)
+}
+
+// DeleteMonitor removes a monitor. Its past runs stay in the run history.
+func (s *MonitorService) DeleteMonitor(monitorID int) error {
+	return s.monitors.DeleteMonitor(monitorID)
+}
+
+// RunMonitorNow runs a monitor at once, even a disabled one, instead of waiting for its interval.
+func (s *MonitorService) RunMonitorNow(monitorID int) error {
+	if _, err := s.monitors.GetMonitor(monitorID); err != nil {
+		return err
+	}
+
+	s.scheduler.Trigger(monitorID)
+	return nil
+}
+
+func (s *MonitorService) emit(event models.MonitorEvent) {
+	if s.ctx == nil {
+		return
+	}
+
+	runtime.EventsEmit(s.ctx, MONITOR_EVENT, event)
+}
+
+func NewMonitorService(db *sqlx.DB, runs *RunnerService) *MonitorService {
+	s := &MonitorService{
+		monitors: repository.NewMonitorsRepository(db),
+		runner:   runs,
+	}
+
+	s.scheduler = monitor.NewScheduler(runs.runner, runs, s.monitors, s.emit)
+
+	return s
+}
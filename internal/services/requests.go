@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Amir-Zouerami/TAPA/internal/curl"
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/har"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+type RequestsRepository interface {
+	GetRequestAssertions(requestID int) ([]models.RequestAssertion, error)
+	ReplaceRequestAssertions(requestID int, assertions []models.RequestAssertion) error
+	GetRequestSkipConditions(requestID int) ([]models.SkipCondition, error)
+	ReplaceRequestSkipConditions(requestID int, conditions []models.SkipCondition) error
+	GetRequestExamples(requestID int) ([]models.RequestExample, error)
+	InsertRequestExample(example models.RequestExample) (int, error)
+}
+
+type HistoryRepository interface {
+	GetHistory(requestID *int) ([]models.RequestHistory, error)
+	GetHistoryByIDs(ids []int) ([]models.RequestHistory, error)
+}
+
+type RequestsService struct {
+	executor *executor.Executor
+	repo     RequestsRepository
+	history  HistoryRepository
+}
+
+// SendRequest executes a stored request with its scripts, using the given environment or the selected one.
+// Requests sent from the request's scripts are returned as its SubRequests.
+func (s *RequestsService) SendRequest(requestID int, environmentID *int) (models.ExecutionResult, error) {
+	session, err := s.executor.NewSession(environmentID)
+	if err != nil {
+		return models.ExecutionResult{}, err
+	}
+
+	result, err := s.executor.Execute(context.Background(), session, requestID)
+	if err != nil {
+		return models.ExecutionResult{}, err
+	}
+
+	return *result, nil
+}
+
+// CurlCommand returns a curl command that sends a stored request with the variables of the given environment,
+// or the selected one, resolved.
+func (s *RequestsService) CurlCommand(requestID int, environmentID *int) (string, error) {
+	session, err := s.executor.NewSession(environmentID)
+	if err != nil {
+		return "", err
+	}
+
+	detail, err := s.executor.Resolve(session, requestID)
+	if err != nil {
+		return "", err
+	}
+
+	return curl.Command(detail), nil
+}
+
+// ExportHistoryHAR returns the request history as a HAR 1.2 file: the history of one request, or all of it when
+// requestID is nil.
+func (s *RequestsService) ExportHistoryHAR(requestID *int) (string, error) {
+	history, err := s.history.GetHistory(requestID)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := har.Export("TAPA request history", []har.Page{{History: history}})
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// GetRequestAssertions returns the no-code assertions of a request.
+func (s *RequestsService) GetRequestAssertions(requestID int) ([]models.RequestAssertion, error) {
+	return s.repo.GetRequestAssertions(requestID)
+}
+
+// SaveRequestAssertions replaces the assertions of a request; their order is the evaluation order.
+func (s *RequestsService) SaveRequestAssertions(requestID int, assertions []models.RequestAssertion) error {
+	return s.repo.ReplaceRequestAssertions(requestID, assertions)
+}
+
+// GetRequestSkipConditions returns the conditions under which collection runs skip a request.
+func (s *RequestsService) GetRequestSkipConditions(requestID int) ([]models.SkipCondition, error) {
+	return s.repo.GetRequestSkipConditions(requestID)
+}
+
+// SaveRequestSkipConditions replaces the skip conditions of a request after checking they can be evaluated.
+func (s *RequestsService) SaveRequestSkipConditions(requestID int, conditions []models.SkipCondition) error {
+	for _, c := range conditions {
+		if err := executor.ValidateSkipCondition(c); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.ReplaceRequestSkipConditions(requestID, conditions)
+}
+
+// GetRequestExamples returns the saved example responses of a request, oldest first.
+func (s *RequestsService) GetRequestExamples(requestID int) ([]models.RequestExample, error) {
+	return s.repo.GetRequestExamples(requestID)
+}
+
+// SaveResponseAsExample stores the response of an execution as an example of its request,
+// so later responses can be compared against it with an example assertion. Returns the example id.
+func (s *RequestsService) SaveResponseAsExample(result models.ExecutionResult) (int, error) {
+	if result.RequestID == nil {
+		return 0, errors.Wrap(errors.ErrRequestExampleSave, fmt.Errorf("the response does not belong to a stored request"))
+	}
+
+	if result.StatusCode == 0 {
+		return 0, errors.Wrap(errors.ErrRequestExampleSave, fmt.Errorf("the request did not receive a response"))
+	}
+
+	return s.repo.InsertRequestExample(executor.ExampleFromResult(&result))
+}
+
+func NewRequestsService(db *sqlx.DB) *RequestsService {
+	return &RequestsService{
+		executor: executor.NewExecutor(db),
+		repo:     repository.NewRequestsRepository(db),
+		history:  repository.NewHistoryRepository(db),
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/har"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/reports"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/runner"
+	"github.com/jmoiron/sqlx"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// RUN_EVENT is the Wails event every models.RunEvent is emitted on.
+const RUN_EVENT string = "runner:progress"
+
+type DataFilesRepository interface {
+	GetCollectionDataFile(id int) (models.CollectionDataFile, error)
+}
+
+type CollectionRunsRepository interface {
+	GetCollectionRuns(collectionID int) ([]models.RunReport, error)
+	GetCollectionRun(id int) (models.RunReport, error)
+	DeleteCollectionRun(id int) error
+}
+
+type RunnerService struct {
+	ctx       context.Context // Wails runtime context, nil until startup
+	dashboard *DashboardService
+	dataFiles DataFilesRepository
+	runs      CollectionRunsRepository
+	history   HistoryRepository
+	runner    *runner.Runner
+	reports   *reports.Builder
+}
+
+// startup receives the Wails runtime context progress events are emitted with.
+func (s *RunnerService) startup(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// StartRun runs a collection, or one of its folders, in the background and returns the run id.
+// With a data file the collection runs once per row. Progress is streamed as RUN_EVENT events.
+func (s *RunnerService) StartRun(options models.RunOptions) (int, error) {
+	plan, err := s.Plan(options)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := s.data(options)
+	if err != nil {
+		return 0, err
+	}
+
+	run, err := s.runner.Start(context.Background(), plan, data, options, s.emit)
+	if err != nil {
+		return 0, err
+	}
+
+	return run.ID(), nil
+}
+
+func (s *RunnerService) PauseRun(runID int) error {
+	run, err := s.get(runID)
+	if err != nil {
+		return err
+	}
+
+	run.Pause()
+	return nil
+}
+
+func (s *RunnerService) ResumeRun(runID int) error {
+	run, err := s.get(runID)
+	if err != nil {
+		return err
+	}
+
+	run.Resume()
+	return nil
+}
+
+func (s *RunnerService) StopRun(runID int) error {
+	run, err := s.get(runID)
+	if err != nil {
+		return err
+	}
+
+	run.Stop()
+	return nil
+}
+
+// GetRunReport returns the report of a run: in progress, or finished and possibly from an earlier session.
+func (s *RunnerService) GetRunReport(runID int) (models.RunReport, error) {
+	if run, ok := s.runner.Get(runID); ok {
+		return run.Report(), nil
+	}
+
+	return s.runs.GetCollectionRun(runID)
+}
+
+// GetCollectionRuns returns the past runs of a collection, newest first, without their per-request outcomes.
+func (s *RunnerService) GetCollectionRuns(collectionID int) ([]models.RunReport, error) {
+	return s.runs.GetCollectionRuns(collectionID)
+}
+
+func (s *RunnerService) DeleteCollectionRun(runID int) error {
+	if run, ok := s.runner.Get(runID); ok && run.Report().FinishedAt == nil {
+		return errors.Wrap(errors.ErrRunInProgress, fmt.Errorf("run %d", runID))
+	}
+
+	if err := s.runs.DeleteCollectionRun(runID); err != nil {
+		return err
+	}
+
+	s.runner.Forget(runID)
+	return nil
+}
+
+// CompareRuns reports what changed from the base run to the target run:
+// new failures, fixed requests and tests, status code changes and response time deltas.
+func (s *RunnerService) CompareRuns(baseRunID int, targetRunID int) (models.RunComparison, error) {
+	base, err := s.GetRunReport(baseRunID)
+	if err != nil {
+		return models.RunComparison{}, err
+	}
+
+	target, err := s.GetRunReport(targetRunID)
+	if err != nil {
+		return models.RunComparison{}, err
+	}
+
+	return runner.Compare(base, target), nil
+}
+
+// GetRunDocument returns the full report of a run: every request with its timings, tests and response snippet.
+func (s *RunnerService) GetRunDocument(runID int) (models.Report, error) {
+	report, err := s.GetRunReport(runID)
+	if err != nil {
+		return models.Report{}, err
+	}
+
+	return s.reports.Build(s.title(report.CollectionID), report)
+}
+
+// ExportRunReport writes the report of a run to path as JUnit XML, JSON or HTML (see reports.FORMATS).
+func (s *RunnerService) ExportRunReport(runID int, format string, path string) error {
+	if !reports.IsFormat(format) {
+		return errors.Wrap(errors.ErrReportFormat, fmt.Errorf("got %q", format))
+	}
+
+	report, err := s.GetRunDocument(runID)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(errors.ErrReportWrite, err)
+	}
+	defer file.Close()
+
+	if err := reports.Write(file, format, report); err != nil {
+		return errors.Wrap(errors.ErrReportWrite, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return errors.Wrap(errors.ErrReportWrite, err)
+	}
+
+	return nil
+}
+
+// ExportRunHAR returns the requests a run sent as a HAR 1.2 file, with one page per iteration.
+// Requests whose history was pruned are left out.
+func (s *RunnerService) ExportRunHAR(runID int) (string, error) {
+	report, err := s.GetRunReport(runID)
+	if err != nil {
+		return "", err
+	}
+
+	ids := []int{}
+	for _, iteration := range report.Iterations {
+		for _, result := range iteration.Results {
+			if result.HistoryID != 0 {
+				ids = append(ids, result.HistoryID)
+			}
+		}
+	}
+
+	history, err := s.history.GetHistoryByIDs(ids)
+	if err != nil {
+		return "", err
+	}
+
+	historyByID := make(map[int]models.RequestHistory, len(history))
+	for _, h := range history {
+		historyByID[h.ID] = h
+	}
+
+	title := s.title(report.CollectionID)
+	pages := make([]har.Page, 0, len(report.Iterations))
+	for _, iteration := range report.Iterations {
+		p := har.Page{Title: title}
+		if len(report.Iterations) > 1 {
+			p.Title = fmt.Sprintf("%s (iteration %d)", title, iteration.Index+1)
+		}
+
+		for _, result := range iteration.Results {
+			if h, ok := historyByID[result.HistoryID]; ok {
+				p.History = append(p.History, h)
+			}
+		}
+		pages = append(pages, p)
+	}
+
+	data, err := har.Export(fmt.Sprintf("Run #%d of %s", report.ID, title), pages)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// Plan lists the requests a run sends, in order.
+func (s *RunnerService) Plan(options models.RunOptions) ([]models.RequestBasic, error) {
+	list, err := s.dashboard.GetFullRequestList()
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.Plan(list, options.CollectionID, options.FolderID)
+}
+
+// data reads the rows of the run's data file, if it has one.
+func (s *RunnerService) data(options models.RunOptions) ([]map[string]string, error) {
+	if options.DataFileID == nil {
+		return nil, nil
+	}
+
+	file, err := s.dataFiles.GetCollectionDataFile(*options.DataFileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.CollectionID != options.CollectionID {
+		return nil, errors.Wrap(errors.ErrCollectionDataFileNotFound, fmt.Errorf("data file %d is not part of collection %d", file.ID, options.CollectionID))
+	}
+
+	rows, err := runner.ParseDataFile(file.Format, file.Content)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidDataFile, fmt.Errorf("%s: %v", file.Name, err))
+	}
+
+	return rows, nil
+}
+
+// title names a report after its collection, falling back to the id if the collection is gone.
+func (s *RunnerService) title(collectionID int) string {
+	list, err := s.dashboard.GetFullRequestList()
+	if err == nil {
+		for _, c := range list.Collections {
+			if c.Collection.ID == collectionID {
+				return c.Collection.Name
+			}
+		}
+	}
+
+	return fmt.Sprintf("Collection %d", collectionID)
+}
+
+func (s *RunnerService) get(runID int) (*runner.Run, error) {
+	run, ok := s.runner.Get(runID)
+	if !ok {
+		return nil, errors.Wrap(errors.ErrRunNotFound, fmt.Errorf("run %d", runID))
+	}
+
+	return run, nil
+}
+
+func (s *RunnerService) emit(event models.RunEvent) {
+	if s.ctx == nil {
+		return
+	}
+
+	runtime.EventsEmit(s.ctx, RUN_EVENT, event)
+}
+
+func NewRunnerService(db *sqlx.DB) *RunnerService {
+	runs := repository.NewCollectionRunsRepository(db)
+
+	return &RunnerService{
+		dashboard: NewDashboardService(db),
+		dataFiles: repository.NewCollectionDataFilesRepository(db),
+		runs:      runs,
+		history:   repository.NewHistoryRepository(db),
+		runner:    runner.NewRunner(executor.NewExecutor(db), runs),
+		reports:   reports.NewBuilder(db),
+	}
+}
@@ -1,15 +1,41 @@
 package services
 
 import (
+	"context"
+
 	"github.com/jmoiron/sqlx"
 )
 
 type Services struct {
-	Dashboard *DashboardService
+	Dashboard   *DashboardService
+	Requests    *RequestsService
+	Collections *CollectionsService
+	Runner      *RunnerService
+	LoadTests   *LoadTestService
+	Monitors    *MonitorService
+	Workflows   *WorkflowService
+	Snippets    *SnippetsService
+}
+
+// Startup hands the Wails runtime context to the services that emit events and starts the monitor scheduler.
+func (s *Services) Startup(ctx context.Context) {
+	s.Runner.startup(ctx)
+	s.LoadTests.startup(ctx)
+	s.Monitors.startup(ctx)
+	s.Workflows.startup(ctx)
 }
 
 func NewServiceContainer(db *sqlx.DB) *Services {
+	runner := NewRunnerService(db)
+
 	return &Services{
-		Dashboard: NewDashboardService(db),
+		Dashboard:   NewDashboardService(db),
+		Requests:    NewRequestsService(db),
+		Collections: NewCollectionsService(db),
+		Runner:      runner,
+		LoadTests:   NewLoadTestService(db),
+		Monitors:    NewMonitorService(db, runner),
+		Workflows:   NewWorkflowService(db),
+		Snippets:    NewSnippetsService(db),
 	}
 }
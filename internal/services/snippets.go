@@ -0,0 +1,37 @@
+package services
+
+import (
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+type SnippetsService struct {
+	executor *executor.Executor
+}
+
+// GetSnippetTargets returns the languages and libraries code snippets can be generated for.
+func (s *SnippetsService) GetSnippetTargets() []models.SnippetTarget {
+	return snippets.Targets()
+}
+
+// GenerateSnippet returns code for a target that sends a stored request with the variables of the given
+// environment, or the selected one, resolved.
+func (s *SnippetsService) GenerateSnippet(requestID int, environmentID *int, target string) (string, error) {
+	session, err := s.executor.NewSession(environmentID)
+	if err != nil {
+		return "", err
+	}
+
+	detail, err := s.executor.Resolve(session, requestID)
+	if err != nil {
+		return "", err
+	}
+
+	return snippets.Generate(target, detail)
+}
+
+func NewSnippetsService(db *sqlx.DB) *SnippetsService {
+	return &SnippetsService{executor: executor.NewExecutor(db)}
+}
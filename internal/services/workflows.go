@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/workflow"
+	"github.com/jmoiron/sqlx"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// WORKFLOW_EVENT is the Wails event every models.WorkflowEvent is emitted on.
+const WORKFLOW_EVENT string = "workflow:progress"
+
+type WorkflowsRepository interface {
+	GetWorkflows() ([]models.Workflow, error)
+	GetWorkflow(id int) (models.Workflow, error)
+	SaveWorkflow(w models.Workflow) (int, error)
+	DeleteWorkflow(id int) error
+	GetWorkflowRuns(workflowID int) ([]models.WorkflowRunReport, error)
+	GetWorkflowRun(id int) (models.WorkflowRunReport, error)
+}
+
+type WorkflowService struct {
+	ctx       context.Context // Wails runtime context, nil until startup
+	workflows WorkflowsRepository
+	runner    *workflow.Runner
+}
+
+// startup receives the Wails runtime context progress events are emitted with.
+func (s *WorkflowService) startup(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// GetWorkflows returns every workflow by name, without its nodes and edges.
+func (s *WorkflowService) GetWorkflows() ([]models.Workflow, error) {
+	return s.workflows.GetWorkflows()
+}
+
+func (s *WorkflowService) GetWorkflow(workflowID int) (models.Workflow, error) {
+	return s.workflows.GetWorkflow(workflowID)
+}
+
+// SaveWorkflow creates the workflow when it has no id and updates it otherwise, nodes, edges and mappings included.
+func (s *WorkflowService) SaveWorkflow(w models.Workflow) (models.Workflow, error) {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" {
+		return models.Workflow{}, errors.Wrap(errors.ErrInvalidWorkflow, fmt.Errorf("the workflow needs a name"))
+	}
+
+	if err := workflow.Validate(w); err != nil {
+		return models.Workflow{}, err
+	}
+
+	id, err := s.workflows.SaveWorkflow(w)
+	if err != nil {
+		return models.Workflow{}, err
+	}
+
+	return s.workflows.GetWorkflow(id)
+}
+
+// DeleteWorkflow removes a workflow and its past runs.
+func (s *WorkflowService) DeleteWorkflow(workflowID int) error {
+	return s.workflows.DeleteWorkflow(workflowID)
+}
+
+// StartWorkflowRun runs a saved workflow in the background and returns the run id.
+// environmentID overrides the workflow's environment when set. Progress is streamed as WORKFLOW_EVENT events.
+func (s *WorkflowService) StartWorkflowRun(workflowID int, environmentID *int) (int, error) {
+	w, err := s.workflows.GetWorkflow(workflowID)
+	if err != nil {
+		return 0, err
+	}
+
+	run, err := s.runner.Start(context.Background(), w, environmentID, s.emit)
+	if err != nil {
+		return 0, err
+	}
+
+	return run.ID(), nil
+}
+
+func (s *WorkflowService) StopWorkflowRun(runID int) error {
+	run, ok := s.runner.Get(runID)
+	if !ok {
+		return errors.Wrap(errors.ErrWorkflowRunNotFound, fmt.Errorf("workflow run %d", runID))
+	}
+
+	run.Stop()
+	return nil
+}
+
+// GetWorkflowRun returns a workflow run with the inputs and outputs of every node:
+// in progress, or finished and possibly from an earlier session.
+func (s *WorkflowService) GetWorkflowRun(runID int) (models.WorkflowRunReport, error) {
+	if run, ok := s.runner.Get(runID); ok {
+		return run.Report(), nil
+	}
+
+	return s.workflows.GetWorkflowRun(runID)
+}
+
+// GetWorkflowRuns returns the past runs of a workflow, newest first, without their node results.
+func (s *WorkflowService) GetWorkflowRuns(workflowID int) ([]models.WorkflowRunReport, error) {
+	return s.workflows.GetWorkflowRuns(workflowID)
+}
+
+func (s *WorkflowService) emit(event models.WorkflowEvent) {
+	if s.ctx == nil {
+		return
+	}
+
+	runtime.EventsEmit(s.ctx, WORKFLOW_EVENT, event)
+}
+
+func NewWorkflowService(db *sqlx.DB) *WorkflowService {
+	workflows := repository.NewWorkflowsRepository(db)
+
+	return &WorkflowService{
+		workflows: workflows,
+		runner:    workflow.NewRunner(executor.NewExecutor(db), workflows),
+	}
+}
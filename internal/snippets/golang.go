@@ -0,0 +1,89 @@
+package snippets
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func goSnippet(r Request) string {
+	imports := map[string]bool{"fmt": true, "io": true, "net/http": true, "time": true}
+
+	var b strings.Builder
+	body := "nil"
+	switch {
+	case len(r.Form) > 0:
+		imports["bytes"], imports["mime/multipart"] = true, true
+		body = "body"
+		b.WriteString("\tbody := &bytes.Buffer{}\n\twriter := multipart.NewWriter(body)\n")
+		for _, f := range r.Form {
+			fmt.Fprintf(&b, "\twriter.WriteField(%s, %s)\n", goString(f.Name), goString(f.Value))
+		}
+		b.WriteString("\twriter.Close()\n\n")
+	case r.Body != "":
+		imports["strings"] = true
+		body = "body"
+		fmt.Fprintf(&b, "\tbody := strings.NewReader(%s)\n\n", goString(r.Body))
+	}
+
+	fmt.Fprintf(&b, "\treq, err := http.NewRequest(%s, %s, %s)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n",
+		goString(r.Method), goString(r.URL), body)
+	for _, h := range r.Headers {
+		fmt.Fprintf(&b, "\treq.Header.Add(%s, %s)\n", goString(h.Name), goString(h.Value))
+	}
+	if len(r.Form) > 0 {
+		b.WriteString("\treq.Header.Set(\"Content-Type\", writer.FormDataContentType())\n")
+	}
+
+	fmt.Fprintf(&b, "\n\tclient := &http.Client{\n\t\tTimeout: %s,\n", goDuration(r.Timeout))
+	if r.Insecure {
+		imports["crypto/tls"] = true
+		b.WriteString("\t\tTransport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},\n")
+	}
+	if !r.FollowRedirects {
+		b.WriteString("\t\tCheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },\n")
+	}
+	b.WriteString("\t}\n\n")
+
+	b.WriteString("\tresp, err := client.Do(req)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tdefer resp.Body.Close()\n\n")
+	b.WriteString("\tdata, err := io.ReadAll(resp.Body)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\n")
+	b.WriteString("\tfmt.Println(resp.Status)\n\tfmt.Println(string(data))\n}\n")
+
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var out strings.Builder
+	out.WriteString("package main\n\nimport (\n")
+	for _, path := range paths {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n\nfunc main() {\n")
+	out.WriteString(b.String())
+
+	// gofmt aligns the fields of the client.
+	code, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return out.String()
+	}
+	return string(code)
+}
+
+// goString writes a Go string literal, raw when the string spans lines and can be written raw.
+func goString(s string) string {
+	if strings.Contains(s, "\n") && !strings.ContainsAny(s, "`\r") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+func goDuration(ms int) string {
+	if ms%1000 == 0 {
+		return fmt.Sprintf("%d * time.Second", ms/1000)
+	}
+	return fmt.Sprintf("%d * time.Millisecond", ms)
+}
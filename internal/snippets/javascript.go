@@ -0,0 +1,89 @@
+package snippets
+
+import (
+	"fmt"
+	"strings"
+)
+
+func fetchSnippet(r Request) string {
+	var b strings.Builder
+	if r.Insecure {
+		b.WriteString("// fetch always verifies certificates; in Node.js, run with NODE_TLS_REJECT_UNAUTHORIZED=0 to skip it.\n\n")
+	}
+	if len(r.Form) > 0 {
+		writeFormData(&b, r.Form)
+	}
+
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n", jsonString(r.URL))
+	fmt.Fprintf(&b, "  method: %s,\n", jsonString(r.Method))
+	writeHeaders(&b, r.Headers)
+	switch {
+	case len(r.Form) > 0:
+		b.WriteString("  body: form,\n")
+	case r.Body != "":
+		fmt.Fprintf(&b, "  body: %s,\n", jsonString(r.Body))
+	}
+	if !r.FollowRedirects {
+		b.WriteString("  redirect: \"manual\",\n")
+	}
+	fmt.Fprintf(&b, "  signal: AbortSignal.timeout(%d),\n", r.Timeout)
+	b.WriteString("});\n\n")
+
+	b.WriteString("console.log(response.status);\nconsole.log(await response.text());\n")
+	return b.String()
+}
+
+func axiosSnippet(r Request) string {
+	var b strings.Builder
+	b.WriteString("const axios = require(\"axios\");\n")
+	if r.Insecure {
+		b.WriteString("const https = require(\"https\");\n")
+	}
+	b.WriteString("\n")
+	if len(r.Form) > 0 {
+		writeFormData(&b, r.Form)
+	}
+
+	b.WriteString("axios({\n")
+	fmt.Fprintf(&b, "  method: %s,\n", jsonString(strings.ToLower(r.Method)))
+	fmt.Fprintf(&b, "  url: %s,\n", jsonString(r.URL))
+	writeHeaders(&b, r.Headers)
+	switch {
+	case len(r.Form) > 0:
+		b.WriteString("  data: form,\n")
+	case r.Body != "":
+		fmt.Fprintf(&b, "  data: %s,\n", jsonString(r.Body))
+	}
+	fmt.Fprintf(&b, "  timeout: %d,\n", r.Timeout)
+	if !r.FollowRedirects {
+		b.WriteString("  maxRedirects: 0,\n")
+	}
+	if r.Insecure {
+		b.WriteString("  httpsAgent: new https.Agent({ rejectUnauthorized: false }),\n")
+	}
+	// Print error responses the way the other targets do instead of rejecting.
+	b.WriteString("  validateStatus: () => true,\n")
+	b.WriteString("})\n")
+	b.WriteString("  .then((response) => {\n    console.log(response.status);\n    console.log(response.data);\n  })\n")
+	b.WriteString("  .catch((error) => {\n    console.error(error.message);\n  });\n")
+	return b.String()
+}
+
+func writeFormData(b *strings.Builder, form []Pair) {
+	b.WriteString("const form = new FormData();\n")
+	for _, f := range form {
+		fmt.Fprintf(b, "form.append(%s, %s);\n", jsonString(f.Name), jsonString(f.Value))
+	}
+	b.WriteString("\n")
+}
+
+func writeHeaders(b *strings.Builder, headers []Pair) {
+	if len(headers) == 0 {
+		return
+	}
+	b.WriteString("  headers: {\n")
+	for _, h := range headers {
+		fmt.Fprintf(b, "    %s: %s,\n", jsonString(h.Name), jsonString(h.Value))
+	}
+	b.WriteString("  },\n")
+}
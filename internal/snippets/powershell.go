@@ -0,0 +1,65 @@
+package snippets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// powershellMethods are the methods Invoke-RestMethod takes with -Method; others need -CustomMethod.
+var powershellMethods = map[string]string{
+	"GET": "Get", "POST": "Post", "PUT": "Put", "DELETE": "Delete", "PATCH": "Patch",
+	"HEAD": "Head", "OPTIONS": "Options", "TRACE": "Trace", "MERGE": "Merge",
+}
+
+func powershellSnippet(r Request) string {
+	var b strings.Builder
+	args := []string{"-Uri " + psString(r.URL)}
+	if method, ok := powershellMethods[r.Method]; ok {
+		args = append(args, "-Method "+method)
+	} else {
+		args = append(args, "-CustomMethod "+psString(r.Method))
+	}
+
+	// Invoke-RestMethod rejects a Content-Type among the headers when it sends a body.
+	headers := r.HeadersWithout("Content-Type")
+	if len(headers) > 0 {
+		b.WriteString("$headers = @{\n")
+		for _, h := range headers {
+			fmt.Fprintf(&b, "    %s = %s\n", psString(h.Name), psString(h.Value))
+		}
+		b.WriteString("}\n\n")
+		args = append(args, "-Headers $headers")
+	}
+
+	switch {
+	case len(r.Form) > 0:
+		b.WriteString("$form = @{\n")
+		for _, f := range r.Form {
+			fmt.Fprintf(&b, "    %s = %s\n", psString(f.Name), psString(f.Value))
+		}
+		b.WriteString("}\n\n")
+		args = append(args, "-Form $form")
+	case r.Body != "":
+		fmt.Fprintf(&b, "$body = %s\n\n", psString(r.Body))
+		if contentType, ok := r.Header("Content-Type"); ok {
+			args = append(args, "-ContentType "+psString(contentType))
+		}
+		args = append(args, "-Body $body")
+	}
+
+	args = append(args, fmt.Sprintf("-TimeoutSec %d", (r.Timeout+999)/1000))
+	if r.Insecure {
+		args = append(args, "-SkipCertificateCheck")
+	}
+	if !r.FollowRedirects {
+		args = append(args, "-MaximumRedirection 0")
+	}
+
+	fmt.Fprintf(&b, "$response = Invoke-RestMethod %s\n$response\n", strings.Join(args, " `\n    "))
+	return b.String()
+}
+
+// psString writes a PowerShell single-quoted string, which expands nothing.
+func psString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
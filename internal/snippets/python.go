@@ -0,0 +1,64 @@
+package snippets
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func pythonSnippet(r Request) string {
+	var b strings.Builder
+	b.WriteString("import requests\n\n")
+	fmt.Fprintf(&b, "url = %s\n", jsonString(r.URL))
+
+	args := []string{jsonString(r.Method), "url"}
+
+	if len(r.Headers) > 0 {
+		b.WriteString("\nheaders = {\n")
+		for _, h := range r.Headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsonString(h.Name), jsonString(h.Value))
+		}
+		b.WriteString("}\n")
+		args = append(args, "headers=headers")
+	}
+
+	switch {
+	case len(r.Form) > 0:
+		// A (None, value) tuple sends a plain multipart field rather than a file.
+		b.WriteString("\nfiles = {\n")
+		for _, f := range r.Form {
+			fmt.Fprintf(&b, "    %s: (None, %s),\n", jsonString(f.Name), jsonString(f.Value))
+		}
+		b.WriteString("}\n")
+		args = append(args, "files=files")
+	case r.Body != "":
+		// requests encodes str bodies as Latin-1.
+		encode := ""
+		if !isASCII(r.Body) {
+			encode = ".encode()"
+		}
+		fmt.Fprintf(&b, "\ndata = %s%s\n", jsonString(r.Body), encode)
+		args = append(args, "data=data")
+	}
+
+	args = append(args, "timeout="+seconds(r.Timeout))
+	if r.Insecure {
+		args = append(args, "verify=False")
+	}
+	if !r.FollowRedirects {
+		args = append(args, "allow_redirects=False")
+	}
+
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s)\n\n", strings.Join(args, ", "))
+	b.WriteString("print(response.status_code)\nprint(response.text)\n")
+	return b.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package snippets
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/curl"
+)
+
+// wgetBoundary separates the fields of multipart bodies wget sends, as wget cannot build them itself.
+const wgetBoundary = "----TapaFormBoundary7MA4YWxkTrZu0gW"
+
+func httpieSnippet(r Request) string {
+	first := "http"
+	if r.FollowRedirects {
+		first += " --follow"
+	}
+	if r.Insecure {
+		first += " --verify=no"
+	}
+	first += " --timeout=" + seconds(r.Timeout)
+	lines := []string{first}
+
+	switch {
+	case len(r.Form) > 0:
+		lines = append(lines, "--multipart")
+	case r.Body != "":
+		lines = append(lines, "--raw "+curl.Quote(r.Body))
+	}
+	lines = append(lines, r.Method+" "+curl.Quote(r.URL))
+
+	for _, h := range r.Headers {
+		// HTTPie sends Name; as a header with an empty value.
+		if h.Value == "" {
+			lines = append(lines, curl.Quote(h.Name+";"))
+		} else {
+			lines = append(lines, curl.Quote(h.Name+":"+h.Value))
+		}
+	}
+	for _, f := range r.Form {
+		lines = append(lines, curl.Quote(f.Name+"="+f.Value))
+	}
+
+	return strings.Join(lines, " \\\n  ")
+}
+
+func wgetSnippet(r Request) string {
+	lines := []string{"wget --quiet --output-document=- --content-on-error"}
+
+	body := r.Body
+	if len(r.Form) > 0 {
+		var b strings.Builder
+		for _, f := range r.Form {
+			fmt.Fprintf(&b, "--%s\r\nContent-Disposition: form-data; name=%s\r\n\r\n%s\r\n",
+				wgetBoundary, strconv.Quote(f.Name), f.Value)
+		}
+		fmt.Fprintf(&b, "--%s--\r\n", wgetBoundary)
+		body = b.String()
+	}
+
+	if r.Method != "GET" || body != "" {
+		lines = append(lines, "--method="+r.Method)
+	}
+	for _, h := range r.Headers {
+		// wget has no way to send a header with an empty value.
+		if h.Value != "" {
+			lines = append(lines, "--header="+curl.Quote(h.Name+": "+h.Value))
+		}
+	}
+	if len(r.Form) > 0 {
+		lines = append(lines, "--header="+curl.Quote("Content-Type: multipart/form-data; boundary="+wgetBoundary))
+	}
+	if body != "" {
+		lines = append(lines, "--body-data="+curl.Quote(body))
+	}
+
+	lines = append(lines, "--timeout="+seconds(r.Timeout))
+	if r.Insecure {
+		lines = append(lines, "--no-check-certificate")
+	}
+	if !r.FollowRedirects {
+		lines = append(lines, "--max-redirect=0")
+	}
+	lines = append(lines, curl.Quote(r.URL))
+
+	return strings.Join(lines, " \\\n  ")
+}
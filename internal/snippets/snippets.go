@@ -0,0 +1,173 @@
+// Package snippets generates ready-to-run code that sends a request, for a registry of languages and libraries.
+package snippets
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/curl"
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/importing"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Generator writes the code that sends a request in one language and library.
+type Generator func(req Request) string
+
+var (
+	targets    []models.SnippetTarget
+	generators = map[string]Generator{}
+)
+
+func init() {
+	Register(models.SnippetTarget{ID: "curl", Language: "Shell", Library: "curl"}, curlSnippet)
+	Register(models.SnippetTarget{ID: "go", Language: "Go", Library: "net/http"}, goSnippet)
+	Register(models.SnippetTarget{ID: "python", Language: "Python", Library: "requests"}, pythonSnippet)
+	Register(models.SnippetTarget{ID: "fetch", Language: "JavaScript", Library: "fetch"}, fetchSnippet)
+	Register(models.SnippetTarget{ID: "axios", Language: "Node.js", Library: "axios"}, axiosSnippet)
+	Register(models.SnippetTarget{ID: "httpie", Language: "Shell", Library: "HTTPie"}, httpieSnippet)
+	Register(models.SnippetTarget{ID: "powershell", Language: "PowerShell", Library: "Invoke-RestMethod"}, powershellSnippet)
+	Register(models.SnippetTarget{ID: "wget", Language: "Shell", Library: "wget"}, wgetSnippet)
+}
+
+// Register adds a target to the registry. Registering an id twice is a programming error and panics.
+func Register(target models.SnippetTarget, generate Generator) {
+	if _, ok := generators[target.ID]; ok {
+		panic(fmt.Sprintf("snippets: target %q registered twice", target.ID))
+	}
+
+	targets = append(targets, target)
+	generators[target.ID] = generate
+}
+
+// Targets returns the registered targets in the order they were registered.
+func Targets() []models.SnippetTarget {
+	return append([]models.SnippetTarget{}, targets...)
+}
+
+// Generate writes the code that sends a request, whose variables should be resolved already, for a target.
+func Generate(target string, detail models.RequestWithDetail) (string, error) {
+	generate, ok := generators[target]
+	if !ok {
+		return "", errors.Wrap(errors.ErrSnippetTarget, fmt.Errorf("unknown target %q", target))
+	}
+
+	return generate(newRequest(detail)), nil
+}
+
+// Pair is a header or a form field.
+type Pair struct {
+	Name  string
+	Value string
+}
+
+// Request is a request as the executor sends it: the query parameters in the URL, the cookies in a Cookie header
+// and the Content-Type its body format implies among the headers. Form-data bodies are split into Form, the
+// multipart Content-Type is left to the generated code.
+type Request struct {
+	Method          string
+	URL             string
+	Headers         []Pair
+	Body            string
+	Form            []Pair
+	Timeout         int // milliseconds
+	Insecure        bool
+	FollowRedirects bool
+
+	detail models.RequestWithDetail
+}
+
+func newRequest(detail models.RequestWithDetail) Request {
+	r := Request{
+		Method:          strings.ToUpper(detail.Method),
+		URL:             curl.URL(detail),
+		Timeout:         detail.Timeout,
+		Insecure:        !detail.SSLVerification,
+		FollowRedirects: detail.AllowRedirects,
+		detail:          detail,
+	}
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+	if r.Timeout <= 0 {
+		r.Timeout = importing.DEFAULT_TIMEOUT_MS
+	}
+
+	fields := map[string]string{}
+	multipart := detail.BodyFormat == "form-data" && json.Unmarshal([]byte(detail.Body), &fields) == nil
+	if multipart {
+		for name, value := range fields {
+			r.Form = append(r.Form, Pair{Name: name, Value: value})
+		}
+		sort.Slice(r.Form, func(i, j int) bool { return r.Form[i].Name < r.Form[j].Name })
+	} else {
+		r.Body = detail.Body
+	}
+
+	for _, h := range detail.Headers {
+		if h.Key == "" || (multipart && strings.EqualFold(h.Key, "content-type")) {
+			continue
+		}
+		r.Headers = append(r.Headers, Pair{Name: h.Key, Value: h.Value})
+	}
+
+	if _, ok := r.Header("Content-Type"); !ok && r.Body != "" {
+		switch detail.BodyFormat {
+		case "JSON":
+			r.Headers = append(r.Headers, Pair{Name: "Content-Type", Value: "application/json"})
+		case "XML":
+			r.Headers = append(r.Headers, Pair{Name: "Content-Type", Value: "application/xml"})
+		}
+	}
+
+	if len(detail.Cookies) > 0 {
+		cookies := make([]string, 0, len(detail.Cookies))
+		for _, c := range detail.Cookies {
+			cookies = append(cookies, c.Key+"="+c.Value)
+		}
+		r.Headers = append(r.Headers, Pair{Name: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+
+	return r
+}
+
+// Header returns the value of a header, matched case-insensitively.
+func (r Request) Header(name string) (string, bool) {
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+// HeadersWithout returns the headers other than name.
+func (r Request) HeadersWithout(name string) []Pair {
+	out := make([]Pair, 0, len(r.Headers))
+	for _, h := range r.Headers {
+		if !strings.EqualFold(h.Name, name) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// jsonString writes a string as a JSON string literal, which JavaScript and Python read as well.
+func jsonString(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// seconds writes a timeout in milliseconds as seconds, e.g. 30 or 2.5.
+func seconds(ms int) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", float64(ms)/1000), "0"), ".")
+}
+
+func curlSnippet(r Request) string {
+	return curl.Command(r.detail)
+}
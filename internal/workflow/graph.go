@@ -0,0 +1,145 @@
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// graph is a validated workflow with its nodes by index.
+type graph struct {
+	nodes    []models.WorkflowNode
+	index    map[string]int
+	incoming [][]models.WorkflowEdge // edges into each node, in the order they were defined
+	outgoing [][]int                 // nodes each node has edges to
+}
+
+// Validate rejects workflows that could not run: unnamed or duplicate nodes, nodes without a request,
+// edges between unknown nodes, malformed mappings and cycles.
+func Validate(w models.Workflow) error {
+	if _, err := newGraph(w); err != nil {
+		return err
+	}
+
+	for _, n := range w.Nodes {
+		if n.RequestID == 0 {
+			return invalid("node %q has no request", n.Name)
+		}
+	}
+
+	return nil
+}
+
+// newGraph checks the structure of a workflow. Nodes whose request was deleted are allowed, so a saved workflow
+// still runs: they fail when their turn comes and the nodes after them are skipped.
+func newGraph(w models.Workflow) (*graph, error) {
+	if len(w.Nodes) == 0 {
+		return nil, invalid("the workflow has no requests")
+	}
+
+	g := &graph{
+		nodes:    w.Nodes,
+		index:    make(map[string]int, len(w.Nodes)),
+		incoming: make([][]models.WorkflowEdge, len(w.Nodes)),
+		outgoing: make([][]int, len(w.Nodes)),
+	}
+
+	for i, n := range w.Nodes {
+		switch {
+		case strings.TrimSpace(n.Name) == "":
+			return nil, invalid("every node needs a name")
+		case strings.ContainsAny(n.Name, ". \t"):
+			return nil, invalid("node %q: names cannot contain dots or spaces, mappings refer to nodes as name.source", n.Name)
+		}
+
+		if _, ok := g.index[n.Name]; ok {
+			return nil, invalid("two nodes are named %q", n.Name)
+		}
+		g.index[n.Name] = i
+	}
+
+	seen := map[[2]int]bool{}
+	for _, e := range w.Edges {
+		from, ok := g.index[e.From]
+		if !ok {
+			return nil, invalid("edge from unknown node %q", e.From)
+		}
+		to, ok := g.index[e.To]
+		if !ok {
+			return nil, invalid("edge to unknown node %q", e.To)
+		}
+
+		switch {
+		case from == to:
+			return nil, invalid("node %q has an edge to itself", e.From)
+		case seen[[2]int{from, to}]:
+			return nil, invalid("two edges from %q to %q, put their mappings on one", e.From, e.To)
+		}
+		seen[[2]int{from, to}] = true
+
+		for _, m := range e.Mappings {
+			if strings.TrimSpace(m.Source) == "" {
+				return nil, invalid("%s -> %s: a mapping has no source", e.From, e.To)
+			}
+			if _, _, err := parseTarget(m.Target); err != nil {
+				return nil, invalid("%s: %v", Describe(e, m), err)
+			}
+		}
+
+		g.incoming[to] = append(g.incoming[to], e)
+		g.outgoing[from] = append(g.outgoing[from], to)
+	}
+
+	if cycle := g.cycle(); len(cycle) > 0 {
+		return nil, invalid("the workflow loops through %s", strings.Join(cycle, ", "))
+	}
+
+	return g, nil
+}
+
+// cycle returns the names of the nodes that are part of, or only reachable through, a cycle.
+func (g *graph) cycle() []string {
+	remaining := make([]int, len(g.nodes))
+	for i := range g.nodes {
+		remaining[i] = len(g.incoming[i])
+	}
+
+	queue := []int{}
+	for i, n := range remaining {
+		if n == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, next := range g.outgoing[i] {
+			if remaining[next]--; remaining[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	names := []string{}
+	for i, n := range remaining {
+		if n > 0 {
+			names = append(names, g.nodes[i].Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Describe writes a mapping the way it reads best, e.g. "login.token -> createOrder.header.Authorization".
+func Describe(e models.WorkflowEdge, m models.WorkflowMapping) string {
+	return fmt.Sprintf("%s.%s -> %s.%s", e.From, m.Source, e.To, m.Target)
+}
+
+func invalid(format string, args ...any) error {
+	return errors.Wrap(errors.ErrInvalidWorkflow, fmt.Errorf(format, args...))
+}
@@ -0,0 +1,104 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Amir-Zouerami/TAPA/internal/assertions"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+const (
+	targetHeader   string = "header"
+	targetQuery    string = "query"
+	targetVariable string = "variable"
+	targetBody     string = "body"
+)
+
+// parseTarget splits a mapping target such as "header.Authorization" into its kind and name.
+func parseTarget(target string) (string, string, error) {
+	kind, name, ok := strings.Cut(strings.TrimSpace(target), ".")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("target %q should look like header.<name>, query.<name>, variable.<name> or body.<field>", target)
+	}
+
+	switch kind {
+	case targetHeader, targetQuery, targetVariable, targetBody:
+		return kind, name, nil
+	}
+
+	return "", "", fmt.Errorf("unknown target %q, use header, query, variable or body", kind)
+}
+
+// read returns the value a mapping source selects from a node's response, or from the variables its scripts set.
+// Values from JSON bodies keep their JSON type.
+func read(source string, result *models.ExecutionResult, vars *executor.Variables) (any, error) {
+	source = strings.TrimSpace(source)
+	kind, name, _ := strings.Cut(source, ".")
+
+	switch {
+	case source == "status":
+		return result.StatusCode, nil
+	case kind == "header" && name != "":
+		values := http.Header(result.ResponseHeaders).Values(name)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("the response has no %s header", name)
+		}
+		return strings.Join(values, ", "), nil
+	case kind == "variable" && name != "":
+		value, ok := vars.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("variable %q is not set", name)
+		}
+		return value, nil
+	case source == "body":
+		return result.ResponseBody, nil
+	case kind == "body":
+		source = name
+	}
+
+	// Numbers stay as written, so ids beyond float64's precision are carried over unchanged.
+	var doc any
+	decoder := json.NewDecoder(strings.NewReader(result.ResponseBody))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("the response body is not JSON")
+	}
+
+	path := source
+	if strings.HasPrefix(path, "[") {
+		path = "$" + path
+	}
+
+	values, err := assertions.EvaluateJSONPath(doc, path)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(values) == 0:
+		return nil, fmt.Errorf("the response body has nothing at %s", source)
+	case len(values) == 1:
+		return values[0], nil
+	}
+
+	return values, nil
+}
+
+// text renders a value for headers, query parameters and variables: strings as they are, anything else as JSON.
+func text(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,430 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+)
+
+// Listener receives the progress of a workflow run. It is called from the goroutines of the run's nodes.
+type Listener func(event models.WorkflowEvent)
+
+// Store keeps workflow runs after they finished. With a store, run ids are the ids it assigns.
+type Store interface {
+	InsertWorkflowRun(report models.WorkflowRunReport) (int, error)
+	SaveWorkflowRun(report models.WorkflowRunReport) error
+}
+
+// Runner sends the requests of workflows through the executor, each as soon as the nodes it depends on passed.
+type Runner struct {
+	executor *executor.Executor
+	store    Store // nil keeps runs in memory only
+
+	mu     sync.Mutex
+	nextID int
+	runs   map[int]*Run
+}
+
+// Run is a workflow run in progress, or finished, that can be stopped.
+type Run struct {
+	id       int
+	graph    *graph
+	listener Listener
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu     sync.Mutex
+	report models.WorkflowRunReport
+}
+
+// outcome is what a finished node leaves for the nodes after it: its response and the variables its scripts set.
+type outcome struct {
+	result *models.ExecutionResult
+	vars   *executor.Variables
+	passed bool
+}
+
+// Start runs a workflow in the background. Every node gets its own fork of one executor session, so nodes share
+// cookies but only see each other's values through mappings. A node that fails skips every node after it;
+// the other branches go on. environmentID overrides the workflow's environment when set.
+func (r *Runner) Start(ctx context.Context, w models.Workflow, environmentID *int, listener Listener) (*Run, error) {
+	g, err := newGraph(w)
+	if err != nil {
+		return nil, err
+	}
+
+	if environmentID == nil {
+		environmentID = w.EnvironmentID
+	}
+
+	session, err := r.executor.NewSession(environmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if listener == nil {
+		listener = func(models.WorkflowEvent) {}
+	}
+
+	report := models.WorkflowRunReport{
+		WorkflowID:    w.ID,
+		Name:          w.Name,
+		EnvironmentID: session.EnvironmentID,
+		Status:        models.RunStatusRunning,
+		Nodes:         make([]models.WorkflowNodeResult, len(g.nodes)),
+		StartedAt:     time.Now(),
+	}
+	for i, n := range g.nodes {
+		report.Nodes[i] = models.WorkflowNodeResult{
+			Node:      n.Name,
+			RequestID: n.RequestID,
+			Status:    models.WorkflowNodePending,
+			Tests:     []models.TestOutcome{},
+			Inputs:    []models.WorkflowValue{},
+			Outputs:   []models.WorkflowValue{},
+		}
+	}
+
+	if r.store != nil {
+		id, err := r.store.InsertWorkflowRun(report)
+		if err != nil {
+			return nil, err
+		}
+		report.ID = id
+	}
+
+	r.mu.Lock()
+	if r.store == nil {
+		r.nextID++
+		report.ID = r.nextID
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	run := &Run{
+		id:       report.ID,
+		graph:    g,
+		listener: listener,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		report:   report,
+	}
+	r.runs[run.id] = run
+	r.mu.Unlock()
+
+	go r.execute(ctx, run, session)
+
+	return run, nil
+}
+
+// Get returns a workflow run started by this runner.
+func (r *Runner) Get(id int) (*Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[id]
+	return run, ok
+}
+
+// execute starts every node whose incoming edges are all done, as they become done, until none is left.
+func (r *Runner) execute(ctx context.Context, run *Run, session *executor.Session) {
+	defer close(run.done)
+	defer run.cancel()
+
+	run.emit(models.WorkflowEvent{Type: models.WorkflowEventStarted})
+
+	g := run.graph
+	remaining := make([]int, len(g.nodes))
+	failedBefore := make([][]string, len(g.nodes)) // the nodes each node waits on that did not pass
+	outcomes := make([]*outcome, len(g.nodes))
+	finished := make(chan int, len(g.nodes))
+
+	queue := []int{}
+	for i := range g.nodes {
+		remaining[i] = len(g.incoming[i])
+		if remaining[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	release := func(i int) {
+		for _, next := range g.outgoing[i] {
+			if !outcomes[i].passed {
+				failedBefore[next] = append(failedBefore[next], g.nodes[i].Name)
+			}
+			if remaining[next]--; remaining[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	inFlight := 0
+	for {
+		for len(queue) > 0 && ctx.Err() == nil {
+			i := queue[0]
+			queue = queue[1:]
+
+			if len(failedBefore[i]) > 0 {
+				outcomes[i] = &outcome{}
+				run.skip(i, failedBefore[i])
+				release(i)
+				continue
+			}
+
+			inFlight++
+			go func(i int) {
+				outcomes[i] = r.node(ctx, run, session, i, outcomes)
+				finished <- i
+			}(i)
+		}
+
+		if inFlight == 0 {
+			break
+		}
+
+		i := <-finished
+		inFlight--
+		release(i)
+	}
+
+	report := run.finish(ctx.Err() != nil)
+
+	// Once saved, the run is read back from the store; the runner only keeps runs that are running or unsaved.
+	if r.store != nil {
+		if err := r.store.SaveWorkflowRun(report); err != nil {
+			log.Printf("Saving workflow run %d failed: %v", report.ID, err)
+		} else {
+			r.mu.Lock()
+			delete(r.runs, run.id)
+			r.mu.Unlock()
+		}
+	}
+
+	run.emit(models.WorkflowEvent{Type: models.WorkflowEventFinished, Report: &report})
+}
+
+// node maps the values of its incoming edges into the node's request and sends it.
+// The outcomes of the nodes it depends on are complete by the time it is called.
+func (r *Runner) node(ctx context.Context, run *Run, session *executor.Session, i int, outcomes []*outcome) *outcome {
+	g := run.graph
+	fork := session.Fork()
+	overrides := executor.Overrides{Headers: map[string]string{}, QueryParams: map[string]string{}, BodyFields: map[string]any{}}
+
+	inputs := []models.WorkflowValue{}
+	failed := []string{}
+	for _, e := range g.incoming[i] {
+		from := g.index[e.From]
+		source := outcomes[from]
+
+		for _, m := range e.Mappings {
+			value := models.WorkflowValue{From: e.From, Source: m.Source, To: e.To, Target: m.Target}
+
+			v, err := read(m.Source, source.result, source.vars)
+			if err != nil {
+				value.Error = err.Error()
+				failed = append(failed, fmt.Sprintf("%s: %v", Describe(e, m), err))
+			} else {
+				value.Value = text(v)
+
+				kind, name, _ := parseTarget(m.Target)
+				switch kind {
+				case targetHeader:
+					overrides.Headers[name] = value.Value
+				case targetQuery:
+					overrides.QueryParams[name] = value.Value
+				case targetVariable:
+					fork.Variables.Set(name, value.Value)
+				case targetBody:
+					overrides.BodyFields[name] = v
+				}
+			}
+
+			inputs = append(inputs, value)
+			run.output(from, value)
+		}
+	}
+
+	run.begin(i, inputs)
+
+	var result *models.ExecutionResult
+	var err error
+	switch {
+	case g.nodes[i].RequestID == 0:
+		err = errors.Wrap(errors.ErrWorkflowRequestDeleted, fmt.Errorf("node %q", g.nodes[i].Name))
+	case len(failed) > 0:
+		err = errors.Wrap(errors.ErrWorkflowMapping, fmt.Errorf("%s", strings.Join(failed, "; ")))
+	default:
+		result, err = r.executor.ExecuteWith(ctx, fork, g.nodes[i].RequestID, overrides)
+		// Deleting a request leaves the node pointing at it; report it like a node whose request is gone.
+		if errors.Is(err, errors.ErrRequestNotFound) {
+			err = errors.Wrap(errors.ErrWorkflowRequestDeleted, fmt.Errorf("node %q", g.nodes[i].Name))
+		}
+	}
+
+	// A stop while the request was in flight cancels it; the node goes back to pending.
+	if ctx.Err() != nil {
+		run.reset(i)
+		return &outcome{}
+	}
+
+	return &outcome{result: result, vars: fork.Variables, passed: run.complete(i, result, err)}
+}
+
+// ID identifies the run for Stop calls.
+func (run *Run) ID() int {
+	return run.id
+}
+
+// Stop cancels the run, including the requests in flight. Nodes that did not finish stay pending.
+func (run *Run) Stop() {
+	run.cancel()
+}
+
+// Wait blocks until the run has finished and returns its final report.
+func (run *Run) Wait() models.WorkflowRunReport {
+	<-run.done
+	return run.Report()
+}
+
+// Report returns a snapshot of the run's report.
+func (run *Run) Report() models.WorkflowRunReport {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	report := run.report
+	report.Nodes = make([]models.WorkflowNodeResult, len(run.report.Nodes))
+	for i, n := range run.report.Nodes {
+		n.Tests = append([]models.TestOutcome{}, n.Tests...)
+		n.Inputs = append([]models.WorkflowValue{}, n.Inputs...)
+		n.Outputs = append([]models.WorkflowValue{}, n.Outputs...)
+		report.Nodes[i] = n
+	}
+	if report.FinishedAt == nil {
+		report.Duration = int(time.Since(report.StartedAt).Milliseconds())
+	}
+
+	return report
+}
+
+func (run *Run) begin(i int, inputs []models.WorkflowValue) {
+	run.mu.Lock()
+	now := time.Now()
+	node := &run.report.Nodes[i]
+	node.Status = models.WorkflowNodeRunning
+	node.Inputs = inputs
+	node.StartedAt = &now
+	snapshot := *node
+	run.mu.Unlock()
+
+	run.emit(models.WorkflowEvent{Type: models.WorkflowEventNodeStarted, Node: &snapshot})
+}
+
+// complete records the outcome of a node and reports whether it passed: a response arrived, its scripts
+// succeeded and every test passed.
+func (run *Run) complete(i int, result *models.ExecutionResult, err error) bool {
+	run.mu.Lock()
+	node := &run.report.Nodes[i]
+	node.Duration = int(time.Since(*node.StartedAt).Milliseconds())
+
+	if err != nil {
+		node.Error = err.Error()
+	} else {
+		node.HistoryID = result.HistoryID
+		node.Method = result.Method
+		node.URL = result.URL
+		node.StatusCode = result.StatusCode
+		node.ResponseTime = result.ResponseTime
+		node.Error = result.Error
+		node.Tests = result.Tests
+	}
+
+	passed := node.Error == ""
+	for _, test := range node.Tests {
+		if !test.Passed {
+			passed = false
+		}
+	}
+
+	node.Status = models.WorkflowNodeFailed
+	if passed {
+		node.Status = models.WorkflowNodePassed
+	}
+	snapshot := *node
+	run.mu.Unlock()
+
+	run.emit(models.WorkflowEvent{Type: models.WorkflowEventNodeFinished, Node: &snapshot})
+	return passed
+}
+
+func (run *Run) skip(i int, failedBefore []string) {
+	run.mu.Lock()
+	node := &run.report.Nodes[i]
+	node.Status = models.WorkflowNodeSkipped
+	node.Error = fmt.Sprintf("skipped because %s did not pass", strings.Join(failedBefore, ", "))
+	snapshot := *node
+	run.mu.Unlock()
+
+	run.emit(models.WorkflowEvent{Type: models.WorkflowEventNodeFinished, Node: &snapshot})
+}
+
+func (run *Run) reset(i int) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	node := &run.report.Nodes[i]
+	node.Status = models.WorkflowNodePending
+	node.StartedAt = nil
+}
+
+// output adds a value read from a node's response to the node's outputs.
+func (run *Run) output(i int, value models.WorkflowValue) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	run.report.Nodes[i].Outputs = append(run.report.Nodes[i].Outputs, value)
+}
+
+func (run *Run) finish(stopped bool) models.WorkflowRunReport {
+	run.mu.Lock()
+	now := time.Now()
+	run.report.FinishedAt = &now
+	run.report.Duration = int(now.Sub(run.report.StartedAt).Milliseconds())
+	run.report.Status = models.RunStatusCompleted
+	if stopped {
+		run.report.Status = models.RunStatusStopped
+	}
+
+	run.report.Passed, run.report.Failed, run.report.Skipped = 0, 0, 0
+	for _, n := range run.report.Nodes {
+		switch n.Status {
+		case models.WorkflowNodePassed:
+			run.report.Passed++
+		case models.WorkflowNodeFailed:
+			run.report.Failed++
+		case models.WorkflowNodeSkipped:
+			run.report.Skipped++
+		}
+	}
+	run.mu.Unlock()
+
+	return run.Report()
+}
+
+func (run *Run) emit(event models.WorkflowEvent) {
+	event.RunID = run.id
+	run.listener(event)
+}
+
+func NewRunner(executor *executor.Executor, store Store) *Runner {
+	return &Runner{
+		executor: executor,
+		store:    store,
+		runs:     map[int]*Run{},
+	}
+}
@@ -0,0 +1,181 @@
+package workflow_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Amir-Zouerami/TAPA/internal/database"
+	"github.com/Amir-Zouerami/TAPA/internal/errors"
+	"github.com/Amir-Zouerami/TAPA/internal/executor"
+	"github.com/Amir-Zouerami/TAPA/internal/models"
+	"github.com/Amir-Zouerami/TAPA/internal/repository"
+	"github.com/Amir-Zouerami/TAPA/internal/workflow"
+	"github.com/jmoiron/sqlx"
+)
+
+// newTestDB creates an in-memory database with the app's schema, a collection with two GET requests to url
+// and a workflow to record runs of.
+func newTestDB(t *testing.T, url string) *sqlx.DB {
+	t.Helper()
+
+	db, err := database.InitializeDBAt(os.DirFS("../..").(fs.ReadFileFS), ":memory:")
+	if err != nil {
+		t.Fatalf("initializing the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`INSERT INTO collections (id, name, position) VALUES (1, 'Flow', 1)`); err != nil {
+		t.Fatalf("inserting the collection: %v", err)
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO requests (id, collection_id, position, name, method, url, body, timeout)
+		VALUES (1, 1, 1, 'Login', 'GET', ?, '', 30000), (2, 1, 2, 'Orders', 'GET', ?, '', 30000)`, url, url); err != nil {
+		t.Fatalf("inserting the requests: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO workflows (id, name) VALUES (1, 'Checkout')`); err != nil {
+		t.Fatalf("inserting the workflow: %v", err)
+	}
+
+	return db
+}
+
+func TestDeletedRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token":"abc"}`))
+	}))
+	defer server.Close()
+
+	db := newTestDB(t, server.URL)
+	store := repository.NewWorkflowsRepository(db)
+	runner := workflow.NewRunner(executor.NewExecutor(db), store)
+
+	w := models.Workflow{
+		ID:   1,
+		Name: "Checkout",
+		Nodes: []models.WorkflowNode{
+			{Name: "login", RequestID: 1},
+			{Name: "orders", RequestID: 2},
+		},
+		Edges: []models.WorkflowEdge{{From: "login", To: "orders"}},
+	}
+
+	if _, err := db.Exec(`DELETE FROM requests WHERE id = 2`); err != nil {
+		t.Fatalf("deleting the request: %v", err)
+	}
+
+	run, err := runner.Start(context.Background(), w, nil, nil)
+	if err != nil {
+		t.Fatalf("starting the workflow: %v", err)
+	}
+	report := run.Wait()
+
+	if report.Passed != 1 || report.Failed != 1 {
+		t.Errorf("passed = %d, failed = %d; want 1 and 1", report.Passed, report.Failed)
+	}
+
+	orders := report.Nodes[1]
+	if orders.Status != models.WorkflowNodeFailed {
+		t.Errorf("node %s status = %q, want %q", orders.Node, orders.Status, models.WorkflowNodeFailed)
+	}
+	if !strings.Contains(orders.Error, errors.ErrWorkflowRequestDeleted.Error()) {
+		t.Errorf("node %s error = %q, want %q", orders.Node, orders.Error, errors.ErrWorkflowRequestDeleted.Error())
+	}
+
+	if _, ok := runner.Get(report.ID); ok {
+		t.Errorf("finished run %d is still kept by the runner after it was saved", report.ID)
+	}
+
+	saved, err := store.GetWorkflowRun(report.ID)
+	if err != nil {
+		t.Fatalf("loading the saved run: %v", err)
+	}
+	if saved.Failed != 1 || saved.Nodes[1].Error != orders.Error {
+		t.Errorf("saved run = %d failed, node error %q; want 1 and %q", saved.Failed, saved.Nodes[1].Error, orders.Error)
+	}
+}
+
+func TestBodyMapping(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			received <- string(body)
+		}
+		w.Write([]byte(`{"customer":{"id":9007199254740993}}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		body  string
+		want  string // the body the server receives
+		error string // the error of the node, instead
+	}{
+		{
+			name: "numbers keep their digits",
+			body: `{"total":1.50,"customer":{"name":"Ada"}}`,
+			want: `{"customer":{"id":9007199254740993,"name":"Ada"},"total":1.50}`,
+		},
+		{
+			name: "variables in strings",
+			body: `{"note":"{{note}}"}`,
+			want: `{"customer":{"id":9007199254740993},"note":"{{note}}"}`, // unset variables are sent as written
+		},
+		{
+			name:  "variables outside strings",
+			body:  `{"total":{{total}}}`,
+			error: "uses {{variables}} outside JSON strings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, server.URL)
+			if _, err := db.Exec(`UPDATE requests SET method = 'POST', body = ? WHERE id = 2`, tt.body); err != nil {
+				t.Fatalf("updating the request: %v", err)
+			}
+
+			w := models.Workflow{
+				ID:   1,
+				Name: "Checkout",
+				Nodes: []models.WorkflowNode{
+					{Name: "login", RequestID: 1},
+					{Name: "orders", RequestID: 2},
+				},
+				Edges: []models.WorkflowEdge{{
+					From:     "login",
+					To:       "orders",
+					Mappings: []models.WorkflowMapping{{Source: "customer.id", Target: "body.customer.id"}},
+				}},
+			}
+
+			run, err := workflow.NewRunner(executor.NewExecutor(db), nil).Start(context.Background(), w, nil, nil)
+			if err != nil {
+				t.Fatalf("starting the workflow: %v", err)
+			}
+			orders := run.Wait().Nodes[1]
+
+			if tt.error != "" {
+				if !strings.Contains(orders.Error, tt.error) {
+					t.Errorf("node error = %q, want it to contain %q", orders.Error, tt.error)
+				}
+				return
+			}
+
+			if orders.Error != "" {
+				t.Fatalf("node error = %q", orders.Error)
+			}
+			if body := <-received; body != tt.want {
+				t.Errorf("body = %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
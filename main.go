@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/Amir-Zouerami/TAPA/internal/cli"
 	"github.com/Amir-Zouerami/TAPA/internal/config"
 	"github.com/Amir-Zouerami/TAPA/internal/database"
 	"github.com/Amir-Zouerami/TAPA/internal/services"
@@ -19,6 +20,10 @@ var assets embed.FS
 var dbSchema embed.FS
 
 func main() {
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:], dbSchema, os.Stdout, os.Stderr))
+	}
+
 	app := config.NewApp()
 
 	db, err := database.InitializeDB(dbSchema)